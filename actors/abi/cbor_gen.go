@@ -547,6 +547,532 @@ func (t *SealVerifyInfo) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
+var lengthBufAggregateSealVerifyInfo = []byte{133}
+
+func (t *AggregateSealVerifyInfo) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufAggregateSealVerifyInfo); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Number (abi.SectorNumber) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Number)); err != nil {
+		return err
+	}
+
+	// t.Randomness (abi.SealRandomness) (slice)
+	if len(t.Randomness) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.Randomness was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.Randomness))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(t.Randomness[:]); err != nil {
+		return err
+	}
+
+	// t.InteractiveRandomness (abi.InteractiveSealRandomness) (slice)
+	if len(t.InteractiveRandomness) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.InteractiveRandomness was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.InteractiveRandomness))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(t.InteractiveRandomness[:]); err != nil {
+		return err
+	}
+
+	// t.SealedCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.SealedCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.SealedCID: %w", err)
+	}
+
+	// t.UnsealedCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.UnsealedCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.UnsealedCID: %w", err)
+	}
+
+	return nil
+}
+
+func (t *AggregateSealVerifyInfo) UnmarshalCBOR(r io.Reader) error {
+	*t = AggregateSealVerifyInfo{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 5 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Number (abi.SectorNumber) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Number = SectorNumber(extra)
+
+	}
+	// t.Randomness (abi.SealRandomness) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.Randomness: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.Randomness = make([]uint8, extra)
+	}
+
+	if _, err := io.ReadFull(br, t.Randomness[:]); err != nil {
+		return err
+	}
+	// t.InteractiveRandomness (abi.InteractiveSealRandomness) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.InteractiveRandomness: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.InteractiveRandomness = make([]uint8, extra)
+	}
+
+	if _, err := io.ReadFull(br, t.InteractiveRandomness[:]); err != nil {
+		return err
+	}
+	// t.SealedCID (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.SealedCID: %w", err)
+		}
+
+		t.SealedCID = c
+
+	}
+	// t.UnsealedCID (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.UnsealedCID: %w", err)
+		}
+
+		t.UnsealedCID = c
+
+	}
+	return nil
+}
+
+var lengthBufAggregateSealVerifyProofAndInfos = []byte{133}
+
+func (t *AggregateSealVerifyProofAndInfos) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufAggregateSealVerifyProofAndInfos); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Miner (abi.ActorID) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Miner)); err != nil {
+		return err
+	}
+
+	// t.SealProof (abi.RegisteredSealProof) (int64)
+	if t.SealProof >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.SealProof)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.SealProof-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.AggregateProof (abi.RegisteredAggregationProof) (int64)
+	if t.AggregateProof >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.AggregateProof)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.AggregateProof-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.Proof ([]uint8) (slice)
+	if len(t.Proof) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.Proof was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.Proof))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(t.Proof[:]); err != nil {
+		return err
+	}
+
+	// t.Infos ([]abi.AggregateSealVerifyInfo) (slice)
+	if len(t.Infos) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Infos was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Infos))); err != nil {
+		return err
+	}
+	for _, v := range t.Infos {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *AggregateSealVerifyProofAndInfos) UnmarshalCBOR(r io.Reader) error {
+	*t = AggregateSealVerifyProofAndInfos{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 5 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Miner (abi.ActorID) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Miner = ActorID(extra)
+
+	}
+	// t.SealProof (abi.RegisteredSealProof) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.SealProof = RegisteredSealProof(extraI)
+	}
+	// t.AggregateProof (abi.RegisteredAggregationProof) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.AggregateProof = RegisteredAggregationProof(extraI)
+	}
+	// t.Proof ([]uint8) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.Proof: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.Proof = make([]uint8, extra)
+	}
+
+	if _, err := io.ReadFull(br, t.Proof[:]); err != nil {
+		return err
+	}
+	// t.Infos ([]abi.AggregateSealVerifyInfo) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Infos: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Infos = make([]AggregateSealVerifyInfo, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v AggregateSealVerifyInfo
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Infos[i] = v
+	}
+
+	return nil
+}
+
+var lengthBufReplicaUpdateInfo = []byte{133}
+
+func (t *ReplicaUpdateInfo) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufReplicaUpdateInfo); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.UpdateProofType (abi.RegisteredUpdateProof) (int64)
+	if t.UpdateProofType >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.UpdateProofType)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.UpdateProofType-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.OldSealedSectorCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.OldSealedSectorCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.OldSealedSectorCID: %w", err)
+	}
+
+	// t.NewSealedSectorCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.NewSealedSectorCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.NewSealedSectorCID: %w", err)
+	}
+
+	// t.NewUnsealedSectorCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.NewUnsealedSectorCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.NewUnsealedSectorCID: %w", err)
+	}
+
+	// t.Proof ([]uint8) (slice)
+	if len(t.Proof) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.Proof was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.Proof))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(t.Proof[:]); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *ReplicaUpdateInfo) UnmarshalCBOR(r io.Reader) error {
+	*t = ReplicaUpdateInfo{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 5 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.UpdateProofType (abi.RegisteredUpdateProof) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.UpdateProofType = RegisteredUpdateProof(extraI)
+	}
+	// t.OldSealedSectorCID (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.OldSealedSectorCID: %w", err)
+		}
+
+		t.OldSealedSectorCID = c
+
+	}
+	// t.NewSealedSectorCID (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.NewSealedSectorCID: %w", err)
+		}
+
+		t.NewSealedSectorCID = c
+
+	}
+	// t.NewUnsealedSectorCID (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.NewUnsealedSectorCID: %w", err)
+		}
+
+		t.NewUnsealedSectorCID = c
+
+	}
+	// t.Proof ([]uint8) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.Proof: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.Proof = make([]uint8, extra)
+	}
+
+	if _, err := io.ReadFull(br, t.Proof[:]); err != nil {
+		return err
+	}
+	return nil
+}
+
 var lengthBufPoStProof = []byte{130}
 
 func (t *PoStProof) MarshalCBOR(w io.Writer) error {
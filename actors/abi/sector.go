@@ -94,6 +94,16 @@ const (
 	RegisteredPoStProof_StackedDrgWindow64GiBV1   = RegisteredPoStProof(9)
 )
 
+type RegisteredUpdateProof RegisteredProof
+
+const (
+	RegisteredUpdateProof_StackedDrg2KiBV1   = RegisteredUpdateProof(0)
+	RegisteredUpdateProof_StackedDrg8MiBV1   = RegisteredUpdateProof(1)
+	RegisteredUpdateProof_StackedDrg512MiBV1 = RegisteredUpdateProof(2)
+	RegisteredUpdateProof_StackedDrg32GiBV1  = RegisteredUpdateProof(3)
+	RegisteredUpdateProof_StackedDrg64GiBV1  = RegisteredUpdateProof(4)
+)
+
 func (p RegisteredPoStProof) RegisteredSealProof() (RegisteredSealProof, error) {
 	switch p {
 	case RegisteredPoStProof_StackedDrgWinning2KiBV1, RegisteredPoStProof_StackedDrgWindow2KiBV1:
@@ -207,6 +217,25 @@ func (p RegisteredSealProof) RegisteredWindowPoStProof() (RegisteredPoStProof, e
 	}
 }
 
+// RegisteredUpdateProof produces the replica update RegisteredProof corresponding
+// to the receiving RegisteredProof.
+func (p RegisteredSealProof) RegisteredUpdateProof() (RegisteredUpdateProof, error) {
+	switch p {
+	case RegisteredSealProof_StackedDrg64GiBV1:
+		return RegisteredUpdateProof_StackedDrg64GiBV1, nil
+	case RegisteredSealProof_StackedDrg32GiBV1:
+		return RegisteredUpdateProof_StackedDrg32GiBV1, nil
+	case RegisteredSealProof_StackedDrg2KiBV1:
+		return RegisteredUpdateProof_StackedDrg2KiBV1, nil
+	case RegisteredSealProof_StackedDrg8MiBV1:
+		return RegisteredUpdateProof_StackedDrg8MiBV1, nil
+	case RegisteredSealProof_StackedDrg512MiBV1:
+		return RegisteredUpdateProof_StackedDrg512MiBV1, nil
+	default:
+		return 0, errors.Errorf("unsupported mapping from %+v to update RegisteredProof", p)
+	}
+}
+
 // SectorMaximumLifetime is the maximum duration a sector sealed with this proof may exist between activation and expiration
 func (p RegisteredSealProof) SectorMaximumLifetime() ChainEpoch {
 	// For all Stacked DRG sectors, the max is 5 years
@@ -236,6 +265,47 @@ type SealVerifyInfo struct {
 	UnsealedCID cid.Cid `checked:"true"` // CommD
 }
 
+type RegisteredAggregationProof int64
+
+const (
+	RegisteredAggregationProof_SnarkPackV1 = RegisteredAggregationProof(0)
+)
+
+// Information about a single sector in an aggregate seal proof.
+type AggregateSealVerifyInfo struct {
+	Number                SectorNumber
+	Randomness            SealRandomness
+	InteractiveRandomness InteractiveSealRandomness
+
+	// Safe because we get those from the miner actor
+	SealedCID   cid.Cid `checked:"true"` // CommR
+	UnsealedCID cid.Cid `checked:"true"` // CommD
+}
+
+// Information needed to verify an aggregate proof of the seals of many sectors of a single miner.
+type AggregateSealVerifyProofAndInfos struct {
+	Miner          ActorID
+	SealProof      RegisteredSealProof
+	AggregateProof RegisteredAggregationProof
+	Proof          []byte
+	Infos          []AggregateSealVerifyInfo
+}
+
+///
+/// Replica updates
+///
+
+// Information needed to verify a proof that a sector's replica has been updated with new data
+// (encoded to a new sealed CID) without re-sealing.
+type ReplicaUpdateInfo struct {
+	UpdateProofType RegisteredUpdateProof
+	// Safe because we get those from the miner actor
+	OldSealedSectorCID   cid.Cid `checked:"true"` // CommR of the replica being updated
+	NewSealedSectorCID   cid.Cid `checked:"true"` // CommR of the updated replica
+	NewUnsealedSectorCID cid.Cid `checked:"true"` // CommD of the new data
+	Proof                []byte
+}
+
 ///
 /// PoSting
 ///
@@ -6,7 +6,9 @@ import (
 	"sort"
 
 	addr "github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 
@@ -37,6 +39,8 @@ func (a Actor) Exports() []interface{} {
 		7:                         a.OnMinerSectorsTerminate,
 		8:                         a.ComputeDataCommitment,
 		9:                         a.CronTick,
+		10:                        a.CancelDeal,
+		11:                        a.ExtendDeals,
 	}
 }
 
@@ -139,10 +143,21 @@ type PublishStorageDealsParams struct {
 }
 
 type PublishStorageDealsReturn struct {
-	IDs []abi.DealID
+	IDs        []abi.DealID      // IDs of the published deals, in the order of ValidDeals.
+	ValidDeals bitfield.BitField // Indices of the deals in the parameters which were published.
+	Rejections []DealRejection   // Deals in the parameters which were not published, in order of index.
+}
+
+// The reason a deal proposal was not published.
+type DealRejection struct {
+	Index uint64            // Index of the deal in the parameters.
+	Code  exitcode.ExitCode // Exit code with which the deal would have failed alone.
 }
 
 // Publish a new set of storage deals (not yet included in a sector).
+// Deals which are invalid, which the client or provider cannot fund, or which duplicate a pending deal are skipped,
+// and the exit code of each rejection returned (with the reason logged). The message fails only if every deal is rejected, with the exit code
+// and reason of the first rejection.
 func (a Actor) PublishStorageDeals(rt Runtime, params *PublishStorageDealsParams) *PublishStorageDealsReturn {
 
 	// Deal message must have a From field identical to the provider of all the deals.
@@ -170,35 +185,74 @@ func (a Actor) PublishStorageDeals(rt Runtime, params *PublishStorageDealsParams
 		rt.Abortf(exitcode.ErrForbidden, "caller is not provider %v", provider)
 	}
 
-	resolvedAddrs := make(map[addr.Address]addr.Address, len(params.Deals))
 	baselinePower := requestCurrentBaselinePower(rt)
 	networkQAPower := requestCurrentNetworkQAPower(rt)
 
-	var newDealIds []abi.DealID
 	var st State
+	rt.State().Readonly(&st)
+	msm, err := st.mutator(adt.AsStore(rt)).withPendingProposals(ReadOnlyPermission).
+		withEscrowTable(ReadOnlyPermission).withLockedTable(ReadOnlyPermission).build()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
+
+	// Validate each deal, skipping those which cannot be published.
+	var validDeals []ClientDealProposal
+	var rejections []DealRejection
+	var firstRejection error
+	reject := func(di int, err error) {
+		rt.Log(vmr.INFO, "invalid deal %d: %s", di, err)
+		rejections = append(rejections, DealRejection{Index: uint64(di), Code: exitcode.Unwrap(err, exitcode.ErrIllegalArgument)})
+		if firstRejection == nil {
+			firstRejection = xerrors.Errorf("deal %d: %w", di, err)
+		}
+	}
+	validIndices := bitfield.New()
+	proposalCids := make(map[cid.Cid]struct{}, len(params.Deals))
+	lockedByDeals := make(pendingLocks)
+	for di, deal := range params.Deals {
+		pcid, err := validatePublishedDeal(rt, msm, &deal, provider, providerRaw, baselinePower, networkQAPower, proposalCids, lockedByDeals)
+		if err != nil {
+			reject(di, err)
+			continue
+		}
+
+		// Check VerifiedClient allowed cap and deduct PieceSize from cap.
+		// Either the DealSize is within the available DataCap of the VerifiedClient
+		// or this deal is rejected. We do not allow a deal that is partially verified.
+		if deal.Proposal.VerifiedDeal {
+			_, code := rt.Send(
+				builtin.VerifiedRegistryActorAddr,
+				builtin.MethodsVerifiedRegistry.UseBytes,
+				&verifreg.UseBytesParams{
+					Address:  deal.Proposal.Client,
+					DealSize: big.NewIntUnsigned(uint64(deal.Proposal.PieceSize)),
+				},
+				abi.NewTokenAmount(0),
+			)
+			if !code.IsSuccess() {
+				reject(di, code.Wrapf("failed to add verified deal for client %v", deal.Proposal.Client))
+				continue
+			}
+		}
+
+		proposalCids[pcid] = struct{}{}
+		lockedByDeals.add(deal.Proposal.Client, deal.Proposal.ClientBalanceRequirement())
+		lockedByDeals.add(provider, deal.Proposal.ProviderCollateral)
+		validDeals = append(validDeals, deal)
+		validIndices.Set(uint64(di))
+	}
+	if len(validDeals) == 0 {
+		rt.Abortf(exitcode.Unwrap(firstRejection, exitcode.ErrIllegalArgument), "all deal proposals invalid, %s", firstRejection)
+	}
+
+	var newDealIds []abi.DealID
 	rt.State().Transaction(&st, func() {
 		msm, err := st.mutator(adt.AsStore(rt)).withPendingProposals(WritePermission).
 			withDealProposals(WritePermission).withDealsByEpoch(WritePermission).withEscrowTable(WritePermission).
-			withLockedTable(WritePermission).build()
+			withLockedTable(WritePermission).withDealsByParty(WritePermission).build()
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
 
-		// All storage dealProposals will be added in an atomic transaction; this operation will be unrolled if any of them fails.
-		for di, deal := range params.Deals {
-			validateDeal(rt, deal, baselinePower, networkQAPower)
-
-			if deal.Proposal.Provider != provider && deal.Proposal.Provider != providerRaw {
-				rt.Abortf(exitcode.ErrIllegalArgument, "cannot publish deals from different providers at the same time")
-			}
-
-			client, ok := rt.ResolveAddress(deal.Proposal.Client)
-			if !ok {
-				rt.Abortf(exitcode.ErrNotFound, "failed to resolve client address %v", deal.Proposal.Client)
-			}
-			// Normalise provider and client addresses in the proposal stored on chain (after signature verification).
-			deal.Proposal.Provider = provider
-			resolvedAddrs[deal.Proposal.Client] = client
-			deal.Proposal.Client = client
-
+		// All valid dealProposals will be added in an atomic transaction; this operation will be unrolled if any of them fails.
+		for di, deal := range validDeals {
 			err, code := msm.lockClientAndProviderBalances(&deal.Proposal)
 			builtin.RequireNoErr(rt, err, code, "failed to lock balance")
 
@@ -207,18 +261,15 @@ func (a Actor) PublishStorageDeals(rt Runtime, params *PublishStorageDealsParams
 			pcid, err := deal.Proposal.Cid()
 			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "failed to take cid of proposal %d", di)
 
-			has, err := msm.pendingDeals.Get(adt.CidKey(pcid), nil)
-			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check for existence of deal proposal")
-			if has {
-				rt.Abortf(exitcode.ErrIllegalArgument, "cannot publish duplicate deals")
-			}
-
 			err = msm.pendingDeals.Put(adt.CidKey(pcid), &deal.Proposal)
 			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set pending deal")
 
 			err = msm.dealProposals.Set(id, &deal.Proposal)
 			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set deal")
 
+			err = msm.indexDeal(id, &deal.Proposal)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to index deal")
+
 			// We should randomize the first epoch for when the deal will be processed so an attacker isn't able to
 			// schedule too many deals for the same tick.
 			processEpoch, err := genRandNextEpoch(rt.CurrEpoch(), &deal.Proposal, rt.GetRandomnessFromBeacon)
@@ -234,44 +285,102 @@ func (a Actor) PublishStorageDeals(rt Runtime, params *PublishStorageDealsParams
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush state")
 	})
 
-	for _, deal := range params.Deals {
-		// Check VerifiedClient allowed cap and deduct PieceSize from cap.
-		// Either the DealSize is within the available DataCap of the VerifiedClient
-		// or this message will fail. We do not allow a deal that is partially verified.
-		if deal.Proposal.VerifiedDeal {
-			resolvedClient, ok := resolvedAddrs[deal.Proposal.Client]
-			builtin.RequireParam(rt, ok, "could not get resolvedClient client address")
+	return &PublishStorageDealsReturn{IDs: newDealIds, ValidDeals: validIndices, Rejections: rejections}
+}
 
-			_, code := rt.Send(
-				builtin.VerifiedRegistryActorAddr,
-				builtin.MethodsVerifiedRegistry.UseBytes,
-				&verifreg.UseBytesParams{
-					Address:  resolvedClient,
-					DealSize: big.NewIntUnsigned(uint64(deal.Proposal.PieceSize)),
-				},
-				abi.NewTokenAmount(0),
-			)
-			builtin.RequireSuccess(rt, code, "failed to add verified deal for client: %v", deal.Proposal.Client)
-		}
+// Amounts committed to deals validated earlier in a message, but not yet locked.
+type pendingLocks map[addr.Address]abi.TokenAmount
+
+func (p pendingLocks) get(a addr.Address) abi.TokenAmount {
+	if amount, ok := p[a]; ok {
+		return amount
 	}
+	return big.Zero()
+}
 
-	return &PublishStorageDealsReturn{newDealIds}
+func (p pendingLocks) add(a addr.Address, amount abi.TokenAmount) {
+	p[a] = big.Add(p.get(a), amount)
 }
 
-type VerifyDealsForActivationParams struct {
-	DealIDs      []abi.DealID
+// Checks whether a deal may be published by a provider, given the balances already committed to
+// earlier deals in the same message, and the proposal CIDs of those deals.
+// Normalizes the client and provider addresses of the proposal and returns its CID.
+// An error carries the exit code for the rejection. Errors loading state abort.
+func validatePublishedDeal(rt Runtime, msm *marketStateMutation, deal *ClientDealProposal, provider, providerRaw addr.Address,
+	baselinePower, networkQAPower abi.StoragePower, proposalCids map[cid.Cid]struct{}, lockedByDeals pendingLocks) (cid.Cid, error) {
+	if err := validateDeal(rt, *deal, baselinePower, networkQAPower); err != nil {
+		return cid.Undef, err
+	}
+
+	if deal.Proposal.Provider != provider && deal.Proposal.Provider != providerRaw {
+		return cid.Undef, exitcode.ErrIllegalArgument.Wrapf("cannot publish deals from different providers at the same time")
+	}
+
+	client, ok := rt.ResolveAddress(deal.Proposal.Client)
+	if !ok {
+		return cid.Undef, exitcode.ErrNotFound.Wrapf("failed to resolve client address %v", deal.Proposal.Client)
+	}
+	// Normalise provider and client addresses in the proposal stored on chain (after signature verification).
+	deal.Proposal.Provider = provider
+	deal.Proposal.Client = client
+
+	clientAvailable, err := msm.availableBalance(client)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get client balance")
+	clientRequired := big.Add(lockedByDeals.get(client), deal.Proposal.ClientBalanceRequirement())
+	if clientAvailable.LessThan(clientRequired) {
+		return cid.Undef, exitcode.ErrInsufficientFunds.Wrapf("client %s available balance %v insufficient to lock %v",
+			client, clientAvailable, clientRequired)
+	}
+
+	providerAvailable, err := msm.availableBalance(provider)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get provider balance")
+	providerRequired := big.Add(lockedByDeals.get(provider), deal.Proposal.ProviderCollateral)
+	if client == provider {
+		providerRequired = big.Add(providerRequired, deal.Proposal.ClientBalanceRequirement())
+	}
+	if providerAvailable.LessThan(providerRequired) {
+		return cid.Undef, exitcode.ErrInsufficientFunds.Wrapf("provider %s available balance %v insufficient to lock %v",
+			provider, providerAvailable, providerRequired)
+	}
+
+	pcid, err := deal.Proposal.Cid()
+	if err != nil {
+		return cid.Undef, exitcode.ErrIllegalArgument.Wrapf("failed to take cid of proposal: %w", err)
+	}
+	if _, ok := proposalCids[pcid]; ok {
+		return cid.Undef, exitcode.ErrIllegalArgument.Wrapf("cannot publish duplicate deals")
+	}
+	has, err := msm.pendingDeals.Get(adt.CidKey(pcid), nil)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check for existence of deal proposal")
+	if has {
+		return cid.Undef, exitcode.ErrIllegalArgument.Wrapf("cannot publish duplicate deals")
+	}
+	return pcid, nil
+}
+
+type SectorDeals struct {
 	SectorExpiry abi.ChainEpoch
-	SectorStart  abi.ChainEpoch
+	DealIDs      []abi.DealID
 }
 
-type VerifyDealsForActivationReturn struct {
+type VerifyDealsForActivationParams struct {
+	Sectors     []SectorDeals
+	SectorStart abi.ChainEpoch
+}
+
+type SectorWeights struct {
 	DealWeight         abi.DealWeight
 	VerifiedDealWeight abi.DealWeight
 }
 
-// Verify that a given set of storage deals is valid for a sector currently being PreCommitted
-// and return DealWeight of the set of storage deals given.
+type VerifyDealsForActivationReturn struct {
+	Sectors []SectorWeights
+}
+
+// Verify that the given sets of storage deals are valid for sectors currently being PreCommitted
+// and return the DealWeight of each set of storage deals, in the order given.
 // The weight is defined as the sum, over all deals in the set, of the product of deal size and duration.
+// A deal may appear at most once across all the sectors.
 func (A Actor) VerifyDealsForActivation(rt Runtime, params *VerifyDealsForActivationParams) *VerifyDealsForActivationReturn {
 	rt.ValidateImmediateCallerType(builtin.StorageMinerActorCodeID)
 	minerAddr := rt.Message().Caller()
@@ -280,13 +389,26 @@ func (A Actor) VerifyDealsForActivation(rt Runtime, params *VerifyDealsForActiva
 	rt.State().Readonly(&st)
 	store := adt.AsStore(rt)
 
-	dealWeight, verifiedWeight, err := ValidateDealsForActivation(&st, store, params.DealIDs, minerAddr, params.SectorExpiry, params.SectorStart)
-	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to validate dealProposals for activation")
+	seenDealIDs := make(map[abi.DealID]struct{})
+	weights := make([]SectorWeights, len(params.Sectors))
+	for i, sector := range params.Sectors {
+		for _, dealID := range sector.DealIDs {
+			if _, seen := seenDealIDs[dealID]; seen {
+				rt.Abortf(exitcode.ErrIllegalArgument, "deal ID %d present multiple times", dealID)
+			}
+			seenDealIDs[dealID] = struct{}{}
+		}
+
+		dealWeight, verifiedWeight, err := ValidateDealsForActivation(&st, store, sector.DealIDs, minerAddr, sector.SectorExpiry, params.SectorStart)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to validate dealProposals for activation")
 
-	return &VerifyDealsForActivationReturn{
-		DealWeight:         dealWeight,
-		VerifiedDealWeight: verifiedWeight,
+		weights[i] = SectorWeights{
+			DealWeight:         dealWeight,
+			VerifiedDealWeight: verifiedWeight,
+		}
 	}
+
+	return &VerifyDealsForActivationReturn{Sectors: weights}
 }
 
 type ActivateDealsParams struct {
@@ -397,7 +519,7 @@ func (a Actor) OnMinerSectorsTerminate(rt Runtime, params *OnMinerSectorsTermina
 	var st State
 	rt.State().Transaction(&st, func() {
 		msm, err := st.mutator(adt.AsStore(rt)).withDealStates(WritePermission).
-			withDealProposals(ReadOnlyPermission).build()
+			withDealProposals(ReadOnlyPermission).withDealsByParty(WritePermission).build()
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deal state")
 
 		for _, dealID := range params.DealIDs {
@@ -433,6 +555,9 @@ func (a Actor) OnMinerSectorsTerminate(rt Runtime, params *OnMinerSectorsTermina
 
 			err = msm.dealStates.Set(dealID, state)
 			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set deal state %v", dealID)
+
+			err = msm.unindexDeal(dealID, deal)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to unindex terminated deal")
 		}
 
 		err = msm.commitState()
@@ -453,7 +578,7 @@ func (a Actor) CronTick(rt Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 
 		msm, err := st.mutator(adt.AsStore(rt)).withDealStates(WritePermission).
 			withLockedTable(WritePermission).withEscrowTable(WritePermission).withDealsByEpoch(WritePermission).
-			withDealProposals(WritePermission).withPendingProposals(WritePermission).build()
+			withDealProposals(WritePermission).withPendingProposals(WritePermission).withDealsByParty(WritePermission).build()
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
 
 		for i := st.LastCron + 1; i <= rt.CurrEpoch(); i++ {
@@ -488,6 +613,9 @@ func (a Actor) CronTick(rt Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 					pdErr := msm.pendingDeals.Delete(adt.CidKey(dcid))
 					builtin.RequireNoErr(rt, pdErr, exitcode.ErrIllegalState, "failed to delete pending proposal")
 
+					err = msm.unindexDeal(dealID, deal)
+					builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to unindex timed out deal")
+
 					return nil
 				}
 
@@ -506,6 +634,12 @@ func (a Actor) CronTick(rt Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 					amountSlashed = big.Add(amountSlashed, slashAmount)
 					err := deleteDealProposalAndState(dealID, msm.dealStates, msm.dealProposals, true, true)
 					builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete deal proposal and states")
+
+					// Terminated deals were removed from the indexes when they were marked for slashing.
+					if state.SlashEpoch == epochUndefined {
+						err = msm.unindexDeal(dealID, deal)
+						builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to unindex expired deal")
+					}
 				} else {
 					AssertMsg(nextEpoch > rt.CurrEpoch() && slashAmount.IsZero(), "deal should not be slashed and should have a schedule for next cron tick"+
 						" as it has not been removed")
@@ -572,6 +706,206 @@ func (a Actor) CronTick(rt Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 	return nil
 }
 
+type CancelDealParams struct {
+	DealID abi.DealID
+	// Signature of the provider's worker over the DealCancellation, if the provider agrees to the cancellation.
+	ProviderSignature *crypto.Signature
+}
+
+// The message signed by a provider to agree to the cancellation of a deal.
+type DealCancellation struct {
+	DealID   abi.DealID
+	Proposal cid.Cid
+}
+
+// Cancels a published deal before it is activated and before its start epoch, at the request of the deal's client.
+// With the provider's signature the client and provider balances locked for the deal are released in full.
+// Without it the client forfeits its collateral to the provider.
+func (a Actor) CancelDeal(rt Runtime, params *CancelDealParams) *adt.EmptyValue {
+	rt.ValidateImmediateCallerType(builtin.CallerTypesSignable...)
+
+	var st State
+	rt.State().Readonly(&st)
+	msm, err := st.mutator(adt.AsStore(rt)).withDealProposals(ReadOnlyPermission).
+		withDealStates(ReadOnlyPermission).build()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
+
+	deal, err := getDealProposal(msm.dealProposals, params.DealID)
+	builtin.RequireNoErr(rt, err, exitcode.ErrNotFound, "failed to get deal %d", params.DealID)
+	if deal.Client != rt.Message().Caller() {
+		rt.Abortf(exitcode.ErrForbidden, "caller %v is not client %v of deal %d", rt.Message().Caller(), deal.Client, params.DealID)
+	}
+	if rt.CurrEpoch() >= deal.StartEpoch {
+		rt.Abortf(exitcode.ErrForbidden, "deal %d start epoch %d has been reached", params.DealID, deal.StartEpoch)
+	}
+
+	_, activated, err := msm.dealStates.Get(params.DealID)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get state for deal %d", params.DealID)
+	if activated {
+		rt.Abortf(exitcode.ErrForbidden, "deal %d already activated", params.DealID)
+	}
+
+	dcid, err := deal.Cid()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to calculate CID for proposal %d", params.DealID)
+
+	forfeitCollateral := params.ProviderSignature == nil
+	if !forfeitCollateral {
+		_, worker, _ := builtin.RequestMinerControlAddrs(rt, deal.Provider)
+		buf := bytes.Buffer{}
+		err := (&DealCancellation{DealID: params.DealID, Proposal: dcid}).MarshalCBOR(&buf)
+		builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to serialize deal cancellation")
+		err = rt.Syscalls().VerifySignature(*params.ProviderSignature, worker, buf.Bytes())
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "invalid provider signature for deal %d cancellation", params.DealID)
+	}
+
+	rt.State().Transaction(&st, func() {
+		msm, err := st.mutator(adt.AsStore(rt)).withDealProposals(WritePermission).withPendingProposals(WritePermission).
+			withDealsByEpoch(WritePermission).withEscrowTable(WritePermission).withLockedTable(WritePermission).
+			withDealsByParty(WritePermission).build()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
+
+		msm.processDealCancelled(rt, deal, forfeitCollateral)
+
+		err = msm.dealProposals.Delete(uint64(params.DealID))
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete deal proposal")
+
+		err = msm.pendingDeals.Delete(adt.CidKey(dcid))
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete pending proposal")
+
+		err = msm.unindexDeal(params.DealID, deal)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to unindex cancelled deal")
+
+		// The deal's first cron processing was scheduled at a random epoch in its first update interval.
+		found := false
+		for epoch := deal.StartEpoch; epoch < deal.StartEpoch+DealUpdatesInterval && !found; epoch++ {
+			found, err = msm.dealsByEpoch.Remove(epoch, params.DealID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to remove deal op at epoch %d", epoch)
+		}
+		AssertMsg(found, "no deal op scheduled for pending deal %d", params.DealID)
+
+		err = msm.commitState()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush state")
+	})
+
+	if deal.VerifiedDeal {
+		_, code := rt.Send(
+			builtin.VerifiedRegistryActorAddr,
+			builtin.MethodsVerifiedRegistry.RestoreBytes,
+			&verifreg.RestoreBytesParams{
+				Address:  deal.Client,
+				DealSize: big.NewIntUnsigned(uint64(deal.PieceSize)),
+			},
+			abi.NewTokenAmount(0),
+		)
+		builtin.RequireSuccess(rt, code, "failed to restore bytes for cancelled verified deal %d", params.DealID)
+	}
+	return nil
+}
+
+type ExtendDealsParams struct {
+	Sectors []SectorDealExtensions
+}
+
+// Extensions to deals stored in a single sector, which may not extend a deal beyond the sector's expiration.
+type SectorDealExtensions struct {
+	SectorExpiry abi.ChainEpoch
+	Extensions   []ClientDealExtension
+}
+
+type ClientDealExtension struct {
+	Extension       DealExtension
+	ClientSignature crypto.Signature
+}
+
+// The message signed by a client to agree to the extension of a deal.
+type DealExtension struct {
+	DealID      abi.DealID
+	NewEndEpoch abi.ChainEpoch
+}
+
+type ExtendDealsReturn struct {
+	Sectors []SectorWeights
+}
+
+// Extends the end epoch of active deals, at the request of their provider and with the agreement of each deal's client.
+// The client's storage fee for the additional epochs, at the deal's price, is locked from its escrow balance.
+// Returns the weight added to the deals of each sector by the extensions, in the order given.
+func (a Actor) ExtendDeals(rt Runtime, params *ExtendDealsParams) *ExtendDealsReturn {
+	rt.ValidateImmediateCallerType(builtin.StorageMinerActorCodeID)
+	minerAddr := rt.Message().Caller()
+
+	var st State
+	rt.State().Readonly(&st)
+	msm, err := st.mutator(adt.AsStore(rt)).withDealProposals(ReadOnlyPermission).
+		withDealStates(ReadOnlyPermission).build()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
+
+	seenDealIDs := make(map[abi.DealID]struct{})
+	weights := make([]SectorWeights, len(params.Sectors))
+	for i, sector := range params.Sectors {
+		weights[i] = SectorWeights{DealWeight: big.Zero(), VerifiedDealWeight: big.Zero()}
+		for _, ext := range sector.Extensions {
+			dealID := ext.Extension.DealID
+			if _, seen := seenDealIDs[dealID]; seen {
+				rt.Abortf(exitcode.ErrIllegalArgument, "deal ID %d present multiple times", dealID)
+			}
+			seenDealIDs[dealID] = struct{}{}
+
+			deal, err := getDealProposal(msm.dealProposals, dealID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get deal %d", dealID)
+			err = validateDealExtension(rt, msm, deal, &ext, minerAddr, sector.SectorExpiry)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "cannot extend deal %d", dealID)
+
+			addedWeight := big.Mul(big.NewIntUnsigned(uint64(deal.PieceSize)), big.NewInt(int64(ext.Extension.NewEndEpoch-deal.EndEpoch)))
+			if deal.VerifiedDeal {
+				weights[i].VerifiedDealWeight = big.Add(weights[i].VerifiedDealWeight, addedWeight)
+			} else {
+				weights[i].DealWeight = big.Add(weights[i].DealWeight, addedWeight)
+			}
+		}
+	}
+
+	rt.State().Transaction(&st, func() {
+		msm, err := st.mutator(adt.AsStore(rt)).withDealProposals(WritePermission).withDealStates(ReadOnlyPermission).
+			withPendingProposals(WritePermission).withEscrowTable(WritePermission).withLockedTable(WritePermission).build()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load state")
+
+		for _, sector := range params.Sectors {
+			for _, ext := range sector.Extensions {
+				dealID := ext.Extension.DealID
+				deal, err := getDealProposal(msm.dealProposals, dealID)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get deal %d", dealID)
+				oldCid, err := deal.Cid()
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to calculate CID for proposal %d", dealID)
+
+				err, code := msm.lockClientExtensionFee(deal, ext.Extension.NewEndEpoch)
+				builtin.RequireNoErr(rt, err, code, "failed to lock balance for deal %d", dealID)
+
+				deal.EndEpoch = ext.Extension.NewEndEpoch
+				err = msm.dealProposals.Set(dealID, deal)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set deal %d", dealID)
+
+				// A deal which has not yet been processed by cron remains pending under its proposal CID, which has changed.
+				state, _, err := msm.dealStates.Get(dealID)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get state for deal %d", dealID)
+				if state.LastUpdatedEpoch == epochUndefined {
+					newCid, err := deal.Cid()
+					builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to calculate CID for proposal %d", dealID)
+					err = msm.pendingDeals.Delete(adt.CidKey(oldCid))
+					builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete pending proposal")
+					err = msm.pendingDeals.Put(adt.CidKey(newCid), deal)
+					builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set pending proposal")
+				}
+			}
+		}
+
+		err = msm.commitState()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush state")
+	})
+
+	return &ExtendDealsReturn{Sectors: weights}
+}
+
 func genRandNextEpoch(currEpoch abi.ChainEpoch, deal *DealProposal, rbF func(crypto.DomainSeparationTag, abi.ChainEpoch, []byte) abi.Randomness) (abi.ChainEpoch, error) {
 	buf := bytes.Buffer{}
 	if err := deal.MarshalCBOR(&buf); err != nil {
@@ -667,53 +1001,96 @@ func validateDealCanActivate(proposal *DealProposal, minerAddr addr.Address, sec
 	return nil
 }
 
-func validateDeal(rt Runtime, deal ClientDealProposal, baselinePower, networkQAPower abi.StoragePower) {
+func validateDealExtension(rt Runtime, msm *marketStateMutation, deal *DealProposal, ext *ClientDealExtension,
+	minerAddr addr.Address, sectorExpiry abi.ChainEpoch) error {
+	newEndEpoch := ext.Extension.NewEndEpoch
+	if deal.Provider != minerAddr {
+		return exitcode.ErrForbidden.Wrapf("proposal has provider %v, must be %v", deal.Provider, minerAddr)
+	}
+
+	state, found, err := msm.dealStates.Get(ext.Extension.DealID)
+	if err != nil {
+		return xerrors.Errorf("failed to get deal state: %w", err)
+	}
+	if !found {
+		return exitcode.ErrIllegalArgument.Wrapf("deal not activated")
+	}
+	if state.SlashEpoch != epochUndefined {
+		return exitcode.ErrIllegalArgument.Wrapf("deal slashed at %d", state.SlashEpoch)
+	}
+	if rt.CurrEpoch() >= deal.EndEpoch {
+		return exitcode.ErrIllegalArgument.Wrapf("deal expired at %d", deal.EndEpoch)
+	}
+
+	if newEndEpoch <= deal.EndEpoch {
+		return exitcode.ErrIllegalArgument.Wrapf("new end epoch %d not after end epoch %d", newEndEpoch, deal.EndEpoch)
+	}
+	if newEndEpoch > sectorExpiry {
+		return exitcode.ErrIllegalArgument.Wrapf("new end epoch %d exceeds sector expiration %d", newEndEpoch, sectorExpiry)
+	}
+	_, maxDuration := dealDurationBounds(deal.PieceSize)
+	if newEndEpoch-deal.StartEpoch > maxDuration {
+		return exitcode.ErrIllegalArgument.Wrapf("extended duration %d exceeds maximum %d", newEndEpoch-deal.StartEpoch, maxDuration)
+	}
+
+	buf := bytes.Buffer{}
+	if err := ext.Extension.MarshalCBOR(&buf); err != nil {
+		return xerrors.Errorf("failed to marshal extension: %w", err)
+	}
+	if err := rt.Syscalls().VerifySignature(ext.ClientSignature, deal.Client, buf.Bytes()); err != nil {
+		return exitcode.ErrIllegalArgument.Wrapf("invalid client signature: %s", err)
+	}
+	return nil
+}
+
+func validateDeal(rt Runtime, deal ClientDealProposal, baselinePower, networkQAPower abi.StoragePower) error {
 	if err := dealProposalIsInternallyValid(rt, deal); err != nil {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Invalid deal proposal: %s", err)
+		return exitcode.ErrIllegalArgument.Wrapf("Invalid deal proposal: %s", err)
 	}
 
 	proposal := deal.Proposal
 
 	if err := proposal.PieceSize.Validate(); err != nil {
-		rt.Abortf(exitcode.ErrIllegalArgument, "proposal piece size is invalid: %v", err)
+		return exitcode.ErrIllegalArgument.Wrapf("proposal piece size is invalid: %v", err)
 	}
 
 	if !proposal.PieceCID.Defined() {
-		rt.Abortf(exitcode.ErrIllegalArgument, "proposal PieceCID undefined")
+		return exitcode.ErrIllegalArgument.Wrapf("proposal PieceCID undefined")
 	}
 
 	if proposal.PieceCID.Prefix() != PieceCIDPrefix {
-		rt.Abortf(exitcode.ErrIllegalArgument, "proposal PieceCID had wrong prefix")
+		return exitcode.ErrIllegalArgument.Wrapf("proposal PieceCID had wrong prefix")
 	}
 
 	if proposal.EndEpoch <= proposal.StartEpoch {
-		rt.Abortf(exitcode.ErrIllegalArgument, "proposal end before proposal start")
+		return exitcode.ErrIllegalArgument.Wrapf("proposal end before proposal start")
 	}
 
 	if rt.CurrEpoch() > proposal.StartEpoch {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Deal start epoch has already elapsed.")
+		return exitcode.ErrIllegalArgument.Wrapf("Deal start epoch has already elapsed.")
 	}
 
 	minDuration, maxDuration := dealDurationBounds(proposal.PieceSize)
 	if proposal.Duration() < minDuration || proposal.Duration() > maxDuration {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Deal duration out of bounds.")
+		return exitcode.ErrIllegalArgument.Wrapf("Deal duration out of bounds.")
 	}
 
 	minPrice, maxPrice := dealPricePerEpochBounds(proposal.PieceSize, proposal.Duration())
 	if proposal.StoragePricePerEpoch.LessThan(minPrice) || proposal.StoragePricePerEpoch.GreaterThan(maxPrice) {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Storage price out of bounds.")
+		return exitcode.ErrIllegalArgument.Wrapf("Storage price out of bounds.")
 	}
 
 	minProviderCollateral, maxProviderCollateral := DealProviderCollateralBounds(proposal.PieceSize, proposal.VerifiedDeal,
 		networkQAPower, baselinePower, rt.TotalFilCircSupply())
 	if proposal.ProviderCollateral.LessThan(minProviderCollateral) || proposal.ProviderCollateral.GreaterThan(maxProviderCollateral) {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Provider collateral out of bounds.")
+		return exitcode.ErrIllegalArgument.Wrapf("Provider collateral out of bounds.")
 	}
 
 	minClientCollateral, maxClientCollateral := DealClientCollateralBounds(proposal.PieceSize, proposal.Duration())
 	if proposal.ClientCollateral.LessThan(minClientCollateral) || proposal.ClientCollateral.GreaterThan(maxClientCollateral) {
-		rt.Abortf(exitcode.ErrIllegalArgument, "Client collateral out of bounds.")
+		return exitcode.ErrIllegalArgument.Wrapf("Client collateral out of bounds.")
 	}
+	return nil
 }
 
 //
@@ -30,6 +30,31 @@ func (m *marketStateMutation) lockClientAndProviderBalances(proposal *DealPropos
 	return nil, exitcode.Ok
 }
 
+// Locks the client storage fee for the additional epochs of a deal extended to a new end epoch.
+func (m *marketStateMutation) lockClientExtensionFee(proposal *DealProposal, newEndEpoch abi.ChainEpoch) (error, exitcode.ExitCode) {
+	fee := big.Mul(big.NewInt(int64(newEndEpoch-proposal.EndEpoch)), proposal.StoragePricePerEpoch)
+	err, code := m.maybeLockBalance(proposal.Client, fee)
+	if err != nil {
+		return xerrors.Errorf("failed to lock client funds: %w", err), code
+	}
+
+	m.totalClientStorageFee = big.Add(m.totalClientStorageFee, fee)
+	return nil, exitcode.Ok
+}
+
+// The escrow balance of an address which is not locked.
+func (m *marketStateMutation) availableBalance(addr addr.Address) (abi.TokenAmount, error) {
+	escrowBalance, err := m.escrowTable.Get(addr)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("failed to get escrow balance: %w", err)
+	}
+	prevLocked, err := m.lockedTable.Get(addr)
+	if err != nil {
+		return big.Zero(), xerrors.Errorf("failed to get locked balance: %w", err)
+	}
+	return big.Sub(escrowBalance, prevLocked), nil
+}
+
 func (m *marketStateMutation) unlockBalance(addr addr.Address, amount abi.TokenAmount, lockReason BalanceLockingReason) error {
 	Assert(amount.GreaterThanEqual(big.Zero()))
 
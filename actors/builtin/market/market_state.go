@@ -2,7 +2,9 @@ package market
 
 import (
 	"bytes"
+	"sort"
 
+	addr "github.com/filecoin-project/go-address"
 	"github.com/ipfs/go-cid"
 	xerrors "golang.org/x/xerrors"
 
@@ -52,6 +54,10 @@ type State struct {
 	DealOpsByEpoch cid.Cid // SetMultimap, HAMT[epoch]Set
 	LastCron       abi.ChainEpoch
 
+	// Indexes of published deals which have not expired, timed out or been terminated.
+	DealsByClient   cid.Cid // AddrSetMultimap, HAMT[Address]Set
+	DealsByProvider cid.Cid // AddrSetMultimap, HAMT[Address]Set
+
 	// Total Client Collateral that is locked -> unlocked when deal is terminated
 	TotalClientLockedCollateral abi.TokenAmount
 	// Total Provider Collateral that is locked -> unlocked when deal is terminated
@@ -70,6 +76,8 @@ func ConstructState(emptyArrayCid, emptyMapCid, emptyMSetCid cid.Cid) *State {
 		NextID:           abi.DealID(0),
 		DealOpsByEpoch:   emptyMSetCid,
 		LastCron:         abi.ChainEpoch(-1),
+		DealsByClient:    emptyMSetCid,
+		DealsByProvider:  emptyMSetCid,
 
 		TotalClientLockedCollateral:   abi.NewTokenAmount(0),
 		TotalProviderLockedCollateral: abi.NewTokenAmount(0),
@@ -77,6 +85,39 @@ func ConstructState(emptyArrayCid, emptyMapCid, emptyMSetCid cid.Cid) *State {
 	}
 }
 
+// Returns the IDs, in ascending order, of a client's deals which have been published and have not expired,
+// timed out or been terminated. The client must be an ID address.
+func (s *State) ClientDeals(store adt.Store, client addr.Address) ([]abi.DealID, error) {
+	dealsByClient, err := AsAddrSetMultimap(store, s.DealsByClient)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load deals by client: %w", err)
+	}
+	return collectDealIDs(dealsByClient, client)
+}
+
+// Returns the IDs, in ascending order, of a provider's deals which have been published and have not expired,
+// timed out or been terminated. The provider must be an ID address.
+func (s *State) ProviderDeals(store adt.Store, provider addr.Address) ([]abi.DealID, error) {
+	dealsByProvider, err := AsAddrSetMultimap(store, s.DealsByProvider)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load deals by provider: %w", err)
+	}
+	return collectDealIDs(dealsByProvider, provider)
+}
+
+func collectDealIDs(index *AddrSetMultimap, party addr.Address) ([]abi.DealID, error) {
+	var dealIDs []abi.DealID
+	err := index.ForEach(party, func(id abi.DealID) error {
+		dealIDs = append(dealIDs, id)
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to iterate deals for %v: %w", party, err)
+	}
+	sort.Slice(dealIDs, func(i, j int) bool { return dealIDs[i] < dealIDs[j] })
+	return dealIDs, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // Deal state operations
 ////////////////////////////////////////////////////////////////////////////////
@@ -180,6 +221,28 @@ func (m *marketStateMutation) processDealInitTimedOut(rt Runtime, deal *DealProp
 	return amountSlashed
 }
 
+// Cancellation by the client before activation. Unlock balances for both provider and client, except for client
+// collateral forfeited to the provider.
+func (m *marketStateMutation) processDealCancelled(rt Runtime, deal *DealProposal, forfeitCollateral bool) {
+	if err := m.unlockBalance(deal.Client, deal.TotalStorageFee(), ClientStorageFee); err != nil {
+		rt.Abortf(exitcode.ErrIllegalState, "failure unlocking client storage fee: %s", err)
+	}
+	if forfeitCollateral {
+		if err := m.slashBalance(deal.Client, deal.ClientCollateral, ClientCollateral); err != nil {
+			rt.Abortf(exitcode.ErrIllegalState, "failed to slash client collateral: %s", err)
+		}
+		if err := m.escrowTable.Add(deal.Provider, deal.ClientCollateral); err != nil {
+			rt.Abortf(exitcode.ErrIllegalState, "failed to add forfeit collateral to provider escrow: %s", err)
+		}
+	} else if err := m.unlockBalance(deal.Client, deal.ClientCollateral, ClientCollateral); err != nil {
+		rt.Abortf(exitcode.ErrIllegalState, "failure unlocking client collateral: %s", err)
+	}
+
+	if err := m.unlockBalance(deal.Provider, deal.ProviderCollateral, ProviderCollateral); err != nil {
+		rt.Abortf(exitcode.ErrIllegalState, "failed to unlock deal provider balance: %s", err)
+	}
+}
+
 // Normal expiration. Unlock collaterals for both provider and client.
 func (m *marketStateMutation) processDealExpired(rt Runtime, deal *DealProposal, state *DealState) {
 	Assert(state.SectorStartEpoch != epochUndefined)
@@ -194,6 +257,32 @@ func (m *marketStateMutation) processDealExpired(rt Runtime, deal *DealProposal,
 	}
 }
 
+// Adds a deal to the client and provider indexes.
+func (m *marketStateMutation) indexDeal(dealID abi.DealID, deal *DealProposal) error {
+	if err := m.dealsByClient.Put(deal.Client, dealID); err != nil {
+		return xerrors.Errorf("failed to index deal %d by client: %w", dealID, err)
+	}
+	if err := m.dealsByProvider.Put(deal.Provider, dealID); err != nil {
+		return xerrors.Errorf("failed to index deal %d by provider: %w", dealID, err)
+	}
+	return nil
+}
+
+// Removes a deal from the client and provider indexes.
+func (m *marketStateMutation) unindexDeal(dealID abi.DealID, deal *DealProposal) error {
+	if found, err := m.dealsByClient.Remove(deal.Client, dealID); err != nil {
+		return xerrors.Errorf("failed to remove deal %d from client index: %w", dealID, err)
+	} else if !found {
+		return xerrors.Errorf("deal %d not indexed by client %v", dealID, deal.Client)
+	}
+	if found, err := m.dealsByProvider.Remove(deal.Provider, dealID); err != nil {
+		return xerrors.Errorf("failed to remove deal %d from provider index: %w", dealID, err)
+	} else if !found {
+		return xerrors.Errorf("deal %d not indexed by provider %v", dealID, deal.Provider)
+	}
+	return nil
+}
+
 func (m *marketStateMutation) generateStorageDealID() abi.DealID {
 	ret := m.nextDealId
 	m.nextDealId = m.nextDealId + abi.DealID(1)
@@ -264,6 +353,10 @@ type marketStateMutation struct {
 	dpePermit    MarketStateMutationPermission
 	dealsByEpoch *SetMultimap
 
+	partyPermit     MarketStateMutationPermission
+	dealsByClient   *AddrSetMultimap
+	dealsByProvider *AddrSetMultimap
+
 	lockedPermit                  MarketStateMutationPermission
 	lockedTable                   *adt.BalanceTable
 	totalClientLockedCollateral   abi.TokenAmount
@@ -329,6 +422,20 @@ func (m *marketStateMutation) build() (*marketStateMutation, error) {
 		m.dealsByEpoch = dbe
 	}
 
+	if m.partyPermit != Invalid {
+		dbc, err := AsAddrSetMultimap(m.store, m.st.DealsByClient)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load deals by client: %w", err)
+		}
+		m.dealsByClient = dbc
+
+		dbp, err := AsAddrSetMultimap(m.store, m.st.DealsByProvider)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load deals by provider: %w", err)
+		}
+		m.dealsByProvider = dbp
+	}
+
 	m.nextDealId = m.st.NextID
 
 	return m, nil
@@ -364,6 +471,11 @@ func (m *marketStateMutation) withDealsByEpoch(permit MarketStateMutationPermiss
 	return m
 }
 
+func (m *marketStateMutation) withDealsByParty(permit MarketStateMutationPermission) *marketStateMutation {
+	m.partyPermit = permit
+	return m
+}
+
 func (m *marketStateMutation) commitState() error {
 	var err error
 	if m.proposalPermit == WritePermission {
@@ -405,6 +517,15 @@ func (m *marketStateMutation) commitState() error {
 		}
 	}
 
+	if m.partyPermit == WritePermission {
+		if m.st.DealsByClient, err = m.dealsByClient.Root(); err != nil {
+			return xerrors.Errorf("failed to flush deals by client: %w", err)
+		}
+		if m.st.DealsByProvider, err = m.dealsByProvider.Root(); err != nil {
+			return xerrors.Errorf("failed to flush deals by provider: %w", err)
+		}
+	}
+
 	m.st.NextID = m.nextDealId
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/filecoin-project/go-address"
@@ -144,6 +145,8 @@ func TestMarketActor(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 
 		t.Run("adds to non-provider escrow funds", func(t *testing.T) {
@@ -185,6 +188,8 @@ func TestMarketActor(t *testing.T) {
 				rt.Call(actor.AddBalance, &provider)
 			})
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	})
 
@@ -206,6 +211,8 @@ func TestMarketActor(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 
 		t.Run("fails if withdraw from non provider funds is not initiated by the recipient", func(t *testing.T) {
@@ -232,6 +239,8 @@ func TestMarketActor(t *testing.T) {
 			// verify there was no withdrawal
 			rt.GetState(&st)
 			assert.Equal(t, abi.NewTokenAmount(20), actor.getEscrowBalance(rt, client))
+
+			actor.checkState(rt)
 		})
 
 		t.Run("fails if withdraw from provider funds is not initiated by the owner or worker", func(t *testing.T) {
@@ -261,6 +270,8 @@ func TestMarketActor(t *testing.T) {
 			// verify there was no withdrawal
 			rt.GetState(&st)
 			assert.Equal(t, abi.NewTokenAmount(20), actor.getEscrowBalance(rt, provider))
+
+			actor.checkState(rt)
 		})
 
 		t.Run("withdraws from provider escrow funds and sends to owner", func(t *testing.T) {
@@ -277,6 +288,8 @@ func TestMarketActor(t *testing.T) {
 
 			rt.GetState(&st)
 			assert.Equal(t, abi.NewTokenAmount(19), actor.getEscrowBalance(rt, provider))
+
+			actor.checkState(rt)
 		})
 
 		t.Run("withdraws from non-provider escrow funds", func(t *testing.T) {
@@ -291,6 +304,8 @@ func TestMarketActor(t *testing.T) {
 
 			rt.GetState(&st)
 			assert.Equal(t, abi.NewTokenAmount(19), actor.getEscrowBalance(rt, client))
+
+			actor.checkState(rt)
 		})
 
 		t.Run("client withdrawing more than escrow balance limits to available funds", func(t *testing.T) {
@@ -303,6 +318,8 @@ func TestMarketActor(t *testing.T) {
 			actor.withdrawClientBalance(rt, client, withdrawAmount, expectedAmount)
 
 			actor.assertAccountZero(rt, client)
+
+			actor.checkState(rt)
 		})
 
 		t.Run("worker withdrawing more than escrow balance limits to available funds", func(t *testing.T) {
@@ -318,6 +335,8 @@ func TestMarketActor(t *testing.T) {
 			actor.withdrawProviderBalance(rt, withdrawAmount, actualWithdrawn, minerAddrs)
 
 			actor.assertAccountZero(rt, provider)
+
+			actor.checkState(rt)
 		})
 
 		t.Run("balance after withdrawal must ALWAYS be greater than or equal to locked amount", func(t *testing.T) {
@@ -347,6 +366,8 @@ func TestMarketActor(t *testing.T) {
 			// add some more funds to the client & ensure withdrawal is limited by the locked funds
 			actor.addParticipantFunds(rt, client, withDrawableAmt)
 			actor.withdrawClientBalance(rt, client, withDrawAmt, withDrawableAmt)
+
+			actor.checkState(rt)
 		})
 
 		t.Run("worker balance after withdrawal must account for slashed funds", func(t *testing.T) {
@@ -379,6 +400,8 @@ func TestMarketActor(t *testing.T) {
 			actualWithdrawn = abi.NewTokenAmount(25)
 
 			actor.withdrawProviderBalance(rt, withDrawAmt, actualWithdrawn, minerAddrs)
+
+			actor.checkState(rt)
 		})
 	})
 }
@@ -472,6 +495,8 @@ func TestPublishStorageDeals(t *testing.T) {
 		prop := actor.getDealProposal(rt, dealId)
 		require.EqualValues(t, clientResolved, prop.Client)
 		require.EqualValues(t, providerResolved, prop.Provider)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("publish a deal after activating a previous deal which has a start epoch far in the future", func(t *testing.T) {
@@ -493,6 +518,8 @@ func TestPublishStorageDeals(t *testing.T) {
 		rt.SetEpoch(newEpoch)
 		deal2ID := actor.generateAndPublishDeal(rt, client, mAddr, startEpoch+1, endEpoch+1, startEpoch+1)
 		actor.activateDeals(rt, endEpoch+1, provider, newEpoch, deal2ID)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("publish a deal with enough collateral when circulating supply > 0", func(t *testing.T) {
@@ -516,6 +543,8 @@ func TestPublishStorageDeals(t *testing.T) {
 		// publish the deal successfully
 		rt.SetEpoch(publishEpoch)
 		actor.publishDeals(rt, mAddr, publishDealReq{deal: deal})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("publish multiple deals for different clients and ensure balances are correct", func(t *testing.T) {
@@ -608,6 +637,8 @@ func TestPublishStorageDeals(t *testing.T) {
 		require.EqualValues(t, big.Add(providerLocked, provider2Locked), st.TotalProviderLockedCollateral)
 		totalStorageFee = big.Add(totalStorageFee, big.Add(deal6.TotalStorageFee(), deal7.TotalStorageFee()))
 		require.EqualValues(t, totalStorageFee, st.TotalClientStorageFee)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -782,6 +813,8 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 				})
 
 				rt.Verify()
+
+				actor.checkState(rt)
 			})
 		}
 	}
@@ -808,6 +841,8 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 
 		t.Run("fail when provider has some funds but not enough for a deal", func(t *testing.T) {
@@ -829,12 +864,14 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
-	// fail when deals have different providers
+	// skip deals with different providers
 	{
-		t.Run("fail when deals have different providers", func(t *testing.T) {
+		t.Run("skip deals with different providers", func(t *testing.T) {
 			rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 			deal1 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
 			m2 := &minerAddrs{owner, worker, tutil.NewIDAddr(t, 1000)}
@@ -852,11 +889,13 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 
 			actor.expectGetRandom(rt, &deal1, abi.ChainEpoch(100))
 
-			rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
-				rt.Call(actor.PublishStorageDeals, params)
-			})
-
+			ret := rt.Call(actor.PublishStorageDeals, params).(*market.PublishStorageDealsReturn)
 			rt.Verify()
+			assertValidDeals(t, ret, 0)
+			assertRejections(t, ret, market.DealRejection{Index: 1, Code: exitcode.ErrIllegalArgument})
+			rt.ExpectLogsContain("invalid deal 1: cannot publish deals from different providers")
+
+			actor.checkState(rt)
 		})
 
 		//  failures because of incorrect call params
@@ -906,6 +945,8 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -925,6 +966,470 @@ func TestPublishStorageDealsFailures(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		actor.checkState(rt)
+	})
+}
+
+func TestPublishStorageDealsPartialSuccess(t *testing.T) {
+	owner := tutil.NewIDAddr(t, 101)
+	provider := tutil.NewIDAddr(t, 102)
+	worker := tutil.NewIDAddr(t, 103)
+	client := tutil.NewIDAddr(t, 104)
+	mAddrs := &minerAddrs{owner, worker, provider}
+
+	startEpoch := abi.ChainEpoch(10)
+	endEpoch := startEpoch + 200*builtin.EpochsInDay
+
+	expectPublish := func(rt *mock.Runtime, actor *marketActorTestHarness) {
+		rt.SetCaller(worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.CallerTypesSignable...)
+		rt.ExpectSend(provider, builtin.MethodsMiner.ControlAddresses, nil, abi.NewTokenAmount(0), &miner.GetControlAddressesReturn{Worker: worker, Owner: owner}, 0)
+		expectQueryNetworkInfo(rt, actor)
+	}
+
+	t.Run("publishes valid deals and skips invalid ones", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		unfundedClient := tutil.NewIDAddr(t, 105)
+		rt.SetAddressActorType(unfundedClient, builtin.AccountActorCodeID)
+
+		deal0 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
+		deal1 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch+1, endEpoch)
+		deal2 := generateDealProposal(unfundedClient, provider, startEpoch, endEpoch)
+		actor.addProviderFunds(rt, deal2.ProviderCollateral, mAddrs)
+		deal3 := deal0
+		deal4 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch+2, endEpoch)
+		params := mkPublishStorageParams(deal0, deal1, deal2, deal3, deal4)
+
+		expectPublish(rt, actor)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal0), nil)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal1), errors.New("bad signature"))
+		rt.ExpectVerifySignature(crypto.Signature{}, unfundedClient, mustCbor(&deal2), nil)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal3), nil)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal4), nil)
+		actor.expectGetRandom(rt, &deal0, abi.ChainEpoch(100))
+		actor.expectGetRandom(rt, &deal4, abi.ChainEpoch(100))
+
+		ret := rt.Call(actor.PublishStorageDeals, params).(*market.PublishStorageDealsReturn)
+		rt.Verify()
+		assertValidDeals(t, ret, 0, 4)
+		assertRejections(t, ret,
+			market.DealRejection{Index: 1, Code: exitcode.ErrIllegalArgument},
+			market.DealRejection{Index: 2, Code: exitcode.ErrInsufficientFunds},
+			market.DealRejection{Index: 3, Code: exitcode.ErrIllegalArgument},
+		)
+		rt.ExpectLogsContain("invalid deal 1: Invalid deal proposal")
+		rt.ExpectLogsContain("invalid deal 2: client " + unfundedClient.String())
+		rt.ExpectLogsContain("invalid deal 3: cannot publish duplicate deals")
+
+		assert.Equal(t, deal4.StartEpoch, actor.getDealProposal(rt, ret.IDs[1]).StartEpoch)
+		// only the published deals' funds are locked
+		assert.Equal(t, big.Add(deal0.ClientBalanceRequirement(), deal4.ClientBalanceRequirement()), actor.getLockedBalance(rt, client))
+		assert.Equal(t, big.Add(deal0.ProviderCollateral, deal4.ProviderCollateral), actor.getLockedBalance(rt, provider))
+		assert.Equal(t, big.Zero(), actor.getLockedBalance(rt, unfundedClient))
+		actor.checkState(rt)
+	})
+
+	t.Run("client balance is shared between deals", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		deal0 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
+		deal1 := generateDealProposal(client, provider, startEpoch+1, endEpoch)
+		actor.addProviderFunds(rt, deal1.ProviderCollateral, mAddrs)
+		params := mkPublishStorageParams(deal0, deal1)
+
+		expectPublish(rt, actor)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal0), nil)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal1), nil)
+		actor.expectGetRandom(rt, &deal0, abi.ChainEpoch(100))
+
+		ret := rt.Call(actor.PublishStorageDeals, params).(*market.PublishStorageDealsReturn)
+		rt.Verify()
+		assertValidDeals(t, ret, 0)
+		assertRejections(t, ret, market.DealRejection{Index: 1, Code: exitcode.ErrInsufficientFunds})
+		rt.ExpectLogsContain("invalid deal 1: client")
+		actor.checkState(rt)
+	})
+
+	t.Run("skips verified deal when client lacks data cap", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		deal0 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
+		deal0.VerifiedDeal = true
+		deal1 := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch+1, endEpoch)
+		params := mkPublishStorageParams(deal0, deal1)
+
+		expectPublish(rt, actor)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal0), nil)
+		rt.ExpectSend(builtin.VerifiedRegistryActorAddr, builtin.MethodsVerifiedRegistry.UseBytes, &verifreg.UseBytesParams{
+			Address:  client,
+			DealSize: big.NewIntUnsigned(uint64(deal0.PieceSize)),
+		}, abi.NewTokenAmount(0), nil, exitcode.ErrIllegalArgument)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal1), nil)
+		actor.expectGetRandom(rt, &deal1, abi.ChainEpoch(100))
+
+		ret := rt.Call(actor.PublishStorageDeals, params).(*market.PublishStorageDealsReturn)
+		rt.Verify()
+		assertValidDeals(t, ret, 1)
+		assertRejections(t, ret, market.DealRejection{Index: 0, Code: exitcode.ErrIllegalArgument})
+		rt.ExpectLogsContain("invalid deal 0: failed to add verified deal")
+		actor.checkState(rt)
+	})
+
+	t.Run("fails with first rejection when all deals are invalid", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		deal0 := generateDealProposal(client, provider, startEpoch, endEpoch)
+		deal1 := generateDealProposal(client, provider, startEpoch+1, endEpoch)
+		deal1.EndEpoch = deal1.StartEpoch
+		params := mkPublishStorageParams(deal0, deal1)
+
+		expectPublish(rt, actor)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal0), nil)
+		rt.ExpectVerifySignature(crypto.Signature{}, client, mustCbor(&deal1), nil)
+		rt.ExpectAbortContainsMessage(exitcode.ErrInsufficientFunds, "deal 0", func() {
+			rt.Call(actor.PublishStorageDeals, params)
+		})
+		rt.Verify()
+		actor.checkState(rt)
+	})
+}
+
+// Asserts that exactly the deals at the given parameter indices were published.
+func assertValidDeals(t *testing.T, ret *market.PublishStorageDealsReturn, indices ...uint64) {
+	valid, err := ret.ValidDeals.All(uint64(len(indices) + 1))
+	require.NoError(t, err)
+	assert.Equal(t, indices, valid)
+	assert.Len(t, ret.IDs, len(indices))
+}
+
+// Asserts that exactly the given deals were rejected, with the given exit codes.
+func assertRejections(t *testing.T, ret *market.PublishStorageDealsReturn, rejections ...market.DealRejection) {
+	assert.Equal(t, rejections, ret.Rejections)
+}
+
+func TestCancelDeal(t *testing.T) {
+	owner := tutil.NewIDAddr(t, 101)
+	provider := tutil.NewIDAddr(t, 102)
+	worker := tutil.NewIDAddr(t, 103)
+	client := tutil.NewIDAddr(t, 104)
+	mAddrs := &minerAddrs{owner, worker, provider}
+
+	startEpoch := abi.ChainEpoch(50)
+	endEpoch := startEpoch + 200*builtin.EpochsInDay
+	processEpoch := startEpoch + 5
+
+	t.Run("client forfeits collateral to cancel without provider agreement", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+		deal := actor.getDealProposal(rt, dealID)
+		clientEscrow := actor.getEscrowBalance(rt, client)
+		providerEscrow := actor.getEscrowBalance(rt, provider)
+
+		actor.cancelDeal(rt, client, dealID, nil)
+
+		actor.assertDealDeleted(rt, dealID, deal)
+		actor.assertNoDealOps(rt)
+		assert.Equal(t, big.Zero(), actor.getLockedBalance(rt, client))
+		assert.Equal(t, big.Zero(), actor.getLockedBalance(rt, provider))
+		assert.Equal(t, big.Sub(clientEscrow, deal.ClientCollateral), actor.getEscrowBalance(rt, client))
+		assert.Equal(t, big.Add(providerEscrow, deal.ClientCollateral), actor.getEscrowBalance(rt, provider))
+
+		// nothing happens when the deal would have been processed
+		rt.SetEpoch(processEpoch)
+		actor.cronTick(rt)
+		actor.checkState(rt)
+	})
+
+	t.Run("client cancels with provider signature", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+		deal := actor.getDealProposal(rt, dealID)
+		clientEscrow := actor.getEscrowBalance(rt, client)
+		providerEscrow := actor.getEscrowBalance(rt, provider)
+
+		sig := &crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte("provider")}
+		actor.expectProviderCancellation(rt, mAddrs, dealID, deal, sig, nil)
+		actor.cancelDeal(rt, client, dealID, sig)
+
+		actor.assertDealDeleted(rt, dealID, deal)
+		actor.assertNoDealOps(rt)
+		assert.Equal(t, big.Zero(), actor.getLockedBalance(rt, client))
+		assert.Equal(t, big.Zero(), actor.getLockedBalance(rt, provider))
+		assert.Equal(t, clientEscrow, actor.getEscrowBalance(rt, client))
+		assert.Equal(t, providerEscrow, actor.getEscrowBalance(rt, provider))
+		actor.checkState(rt)
+	})
+
+	t.Run("cancelling a verified deal restores the client's data cap", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		deal := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
+		deal.VerifiedDeal = true
+		dealID := actor.publishDeals(rt, mAddrs, publishDealReq{deal: deal, requiredProcessEpoch: processEpoch})[0]
+
+		rt.ExpectSend(builtin.VerifiedRegistryActorAddr, builtin.MethodsVerifiedRegistry.RestoreBytes, &verifreg.RestoreBytesParams{
+			Address:  client,
+			DealSize: big.NewIntUnsigned(uint64(deal.PieceSize)),
+		}, abi.NewTokenAmount(0), nil, exitcode.Ok)
+		actor.cancelDeal(rt, client, dealID, nil)
+
+		actor.assertDealDeleted(rt, dealID, &deal)
+		actor.assertNoDealOps(rt)
+		actor.checkState(rt)
+	})
+
+	t.Run("fails with invalid provider signature", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+		deal := actor.getDealProposal(rt, dealID)
+
+		sig := &crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte("provider")}
+		actor.expectProviderCancellation(rt, mAddrs, dealID, deal, sig, errors.New("bad signature"))
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.cancelDeal(rt, client, dealID, sig)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when caller is not the client", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.cancelDeal(rt, worker, dealID, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when deal is activated", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+		rt.SetEpoch(startEpoch - 1)
+		actor.activateDeals(rt, endEpoch+1, provider, startEpoch-1, dealID)
+
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.cancelDeal(rt, client, dealID, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when deal does not exist", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			actor.cancelDeal(rt, client, 42, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails once deal start epoch is reached", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, processEpoch)
+		rt.SetEpoch(startEpoch)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "start epoch", func() {
+			actor.cancelDeal(rt, client, dealID, nil)
+		})
+		actor.checkState(rt)
+	})
+}
+
+func TestExtendDeals(t *testing.T) {
+	owner := tutil.NewIDAddr(t, 101)
+	provider := tutil.NewIDAddr(t, 102)
+	worker := tutil.NewIDAddr(t, 103)
+	client := tutil.NewIDAddr(t, 104)
+	mAddrs := &minerAddrs{owner, worker, provider}
+
+	startEpoch := abi.ChainEpoch(50)
+	endEpoch := startEpoch + 200*builtin.EpochsInDay
+	newEndEpoch := endEpoch + 100*builtin.EpochsInDay
+	sectorExpiry := newEndEpoch + 400
+
+	t.Run("extends an active deal and locks the additional storage fee", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+		deal := actor.getDealProposal(rt, dealID)
+		addedFee := big.Mul(big.NewInt(int64(newEndEpoch-endEpoch)), deal.StoragePricePerEpoch)
+		actor.addParticipantFunds(rt, client, addedFee)
+		clientLocked := actor.getLockedBalance(rt, client)
+
+		ext := actor.expectDealExtension(rt, client, dealID, newEndEpoch, nil)
+		ret := actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+
+		require.Len(t, ret.Sectors, 1)
+		addedWeight := big.Mul(big.NewIntUnsigned(uint64(deal.PieceSize)), big.NewInt(int64(newEndEpoch-endEpoch)))
+		assert.Equal(t, addedWeight, ret.Sectors[0].DealWeight)
+		assert.Equal(t, big.Zero(), ret.Sectors[0].VerifiedDealWeight)
+		extended := actor.getDealProposal(rt, dealID)
+		assert.Equal(t, newEndEpoch, extended.EndEpoch)
+		assert.Equal(t, big.Add(clientLocked, addedFee), actor.getLockedBalance(rt, client))
+		actor.checkState(rt)
+
+		// the deal is paid until its new end epoch
+		rt.SetEpoch(newEndEpoch + 5)
+		pay, slashed := actor.cronTickAndAssertBalances(rt, client, provider, newEndEpoch+5, dealID)
+		assert.Equal(t, big.Mul(big.NewInt(int64(newEndEpoch-startEpoch)), deal.StoragePricePerEpoch), pay)
+		assert.Equal(t, big.Zero(), slashed)
+		actor.assertDealDeleted(rt, dealID, extended)
+		actor.checkState(rt)
+	})
+
+	t.Run("extension of a verified deal adds verified weight", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		deal := actor.generateDealAndAddFunds(rt, client, mAddrs, startEpoch, endEpoch)
+		deal.VerifiedDeal = true
+		dealID := actor.publishDeals(rt, mAddrs, publishDealReq{deal: deal, requiredProcessEpoch: startEpoch})[0]
+		actor.activateDeals(rt, sectorExpiry, provider, 0, dealID)
+		actor.addParticipantFunds(rt, client, big.Mul(big.NewInt(int64(newEndEpoch-endEpoch)), deal.StoragePricePerEpoch))
+
+		ext := actor.expectDealExtension(rt, client, dealID, newEndEpoch, nil)
+		ret := actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+
+		addedWeight := big.Mul(big.NewIntUnsigned(uint64(deal.PieceSize)), big.NewInt(int64(newEndEpoch-endEpoch)))
+		assert.Equal(t, big.Zero(), ret.Sectors[0].DealWeight)
+		assert.Equal(t, addedWeight, ret.Sectors[0].VerifiedDealWeight)
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when client cannot cover the additional storage fee", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		ext := actor.expectDealExtension(rt, client, dealID, newEndEpoch, nil)
+		rt.ExpectAbort(exitcode.ErrInsufficientFunds, func() {
+			actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails with invalid client signature", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		ext := actor.expectDealExtension(rt, client, dealID, newEndEpoch, errors.New("bad signature"))
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when extended beyond sector expiration", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		ext := market.ClientDealExtension{Extension: market.DealExtension{DealID: dealID, NewEndEpoch: newEndEpoch}}
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: newEndEpoch - 1, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when new end epoch is not later", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		ext := market.ClientDealExtension{Extension: market.DealExtension{DealID: dealID, NewEndEpoch: endEpoch}}
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when deal is not activated", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, startEpoch)
+
+		ext := market.ClientDealExtension{Extension: market.DealExtension{DealID: dealID, NewEndEpoch: newEndEpoch}}
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.extendDeals(rt, provider, market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when caller is not the provider", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealID := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		ext := market.ClientDealExtension{Extension: market.DealExtension{DealID: dealID, NewEndEpoch: newEndEpoch}}
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.extendDeals(rt, tutil.NewIDAddr(t, 501), market.SectorDealExtensions{SectorExpiry: sectorExpiry, Extensions: []market.ClientDealExtension{ext}})
+		})
+		actor.checkState(rt)
+	})
+}
+
+func TestDealIndexes(t *testing.T) {
+	owner := tutil.NewIDAddr(t, 101)
+	provider := tutil.NewIDAddr(t, 102)
+	worker := tutil.NewIDAddr(t, 103)
+	client := tutil.NewIDAddr(t, 104)
+	otherClient := tutil.NewIDAddr(t, 105)
+	mAddrs := &minerAddrs{owner, worker, provider}
+
+	startEpoch := abi.ChainEpoch(50)
+	endEpoch := startEpoch + 200*builtin.EpochsInDay
+	sectorExpiry := endEpoch + 400
+
+	setup := func(t *testing.T) (*mock.Runtime, *marketActorTestHarness) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		rt.SetAddressActorType(otherClient, builtin.AccountActorCodeID)
+		return rt, actor
+	}
+
+	t.Run("published deals are indexed until cancelled", func(t *testing.T) {
+		rt, actor := setup(t)
+		deal1 := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, startEpoch)
+		deal2 := actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch+1, startEpoch)
+		deal3 := actor.generateAndPublishDeal(rt, otherClient, mAddrs, startEpoch, endEpoch, startEpoch)
+
+		assert.Equal(t, []abi.DealID{deal1, deal2}, actor.getClientDeals(rt, client))
+		assert.Equal(t, []abi.DealID{deal3}, actor.getClientDeals(rt, otherClient))
+		assert.Equal(t, []abi.DealID{deal1, deal2, deal3}, actor.getProviderDeals(rt, provider))
+		assert.Empty(t, actor.getProviderDeals(rt, client))
+
+		actor.cancelDeal(rt, client, deal2, nil)
+		assert.Equal(t, []abi.DealID{deal1}, actor.getClientDeals(rt, client))
+		assert.Equal(t, []abi.DealID{deal1, deal3}, actor.getProviderDeals(rt, provider))
+		actor.checkState(rt)
+	})
+
+	t.Run("terminated deals are removed", func(t *testing.T) {
+		rt, actor := setup(t)
+		deal1 := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+		deal2 := actor.publishAndActivateDeal(rt, otherClient, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+
+		rt.SetEpoch(startEpoch + 10)
+		actor.terminateDeals(rt, provider, deal1)
+		assert.Empty(t, actor.getClientDeals(rt, client))
+		assert.Equal(t, []abi.DealID{deal2}, actor.getProviderDeals(rt, provider))
+		actor.checkState(rt)
+
+		// the deal remains unindexed when cron deletes it
+		terminated := actor.getDealProposal(rt, deal1)
+		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, terminated.ProviderCollateral, nil, exitcode.Ok)
+		actor.cronTick(rt)
+		actor.assertDealDeleted(rt, deal1, terminated)
+		assert.Equal(t, []abi.DealID{deal2}, actor.getProviderDeals(rt, provider))
+		actor.checkState(rt)
+	})
+
+	t.Run("expired and timed out deals are removed", func(t *testing.T) {
+		rt, actor := setup(t)
+		expiring := actor.publishAndActivateDeal(rt, client, mAddrs, startEpoch, endEpoch, 0, sectorExpiry, startEpoch)
+		expiringDeal := actor.getDealProposal(rt, expiring)
+		timingOut := actor.generateAndPublishDeal(rt, otherClient, mAddrs, startEpoch, endEpoch, startEpoch)
+		timedOutDeal := actor.getDealProposal(rt, timingOut)
+
+		rt.SetEpoch(startEpoch)
+		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, timedOutDeal.ProviderCollateral, nil, exitcode.Ok)
+		actor.cronTick(rt)
+		assert.Empty(t, actor.getClientDeals(rt, otherClient))
+		assert.Equal(t, []abi.DealID{expiring}, actor.getProviderDeals(rt, provider))
+
+		rt.SetEpoch(endEpoch + market.DealUpdatesInterval)
+		actor.cronTick(rt)
+		actor.assertDealDeleted(rt, expiring, expiringDeal)
+		assert.Empty(t, actor.getClientDeals(rt, client))
+		assert.Empty(t, actor.getProviderDeals(rt, provider))
+		actor.checkState(rt)
 	})
 }
 
@@ -967,6 +1472,8 @@ func TestActivateDeals(t *testing.T) {
 		// provider1 activates deal3
 		actor.activateDeals(rt, sectorExpiry, provider, currentEpoch, dealId3)
 		actor.assertDealsNotActivated(rt, currentEpoch, dealId4)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -998,6 +1505,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1012,6 +1521,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1028,6 +1539,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1045,6 +1558,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1062,6 +1577,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 
 		t.Run("fail when end epoch of deal greater than sector expiry", func(t *testing.T) {
@@ -1075,6 +1592,8 @@ func TestActivateDealFailures(t *testing.T) {
 			})
 
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1106,6 +1625,8 @@ func TestActivateDealFailures(t *testing.T) {
 			_, found, err := states.Get(dealId2)
 			require.NoError(t, err)
 			require.False(t, found)
+
+			actor.checkState(rt)
 		})
 	}
 
@@ -1158,6 +1679,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		// provider2 terminates deal4
 		actor.terminateDeals(rt, provider2, dealId4)
 		actor.assertDealsTerminated(rt, currentEpoch, dealId4)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("ignore deal proposal that does not exist", func(t *testing.T) {
@@ -1171,6 +1694,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		actor.terminateDeals(rt, provider, dealId1, abi.DealID(42))
 		st := actor.getDealState(rt, dealId1)
 		require.EqualValues(t, currentEpoch, st.SlashEpoch)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("terminate valid deals along with expired deals - only valid deals are terminated", func(t *testing.T) {
@@ -1192,6 +1717,7 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		actor.assertDealsTerminated(rt, newEpoch, dealId1, dealId2)
 		actor.assertDeaslNotTerminated(rt, dealId3)
 
+		actor.checkState(rt)
 	})
 
 	t.Run("terminating a deal the second time does not change it's slash epoch", func(t *testing.T) {
@@ -1210,6 +1736,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		actor.terminateDeals(rt, provider, dealId1)
 		st := actor.getDealState(rt, dealId1)
 		require.EqualValues(t, currentEpoch, st.SlashEpoch)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("terminating new deals and an already terminated deal only terminates the new deals", func(t *testing.T) {
@@ -1238,6 +1766,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 
 		st3 := actor.getDealState(rt, dealId3)
 		require.EqualValues(t, newEpoch, st3.SlashEpoch)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("do not terminate deal if end epoch is equal to or less than current epoch", func(t *testing.T) {
@@ -1258,6 +1788,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		rt.SetEpoch(endEpoch + 1)
 		actor.terminateDeals(rt, provider, dealId2)
 		actor.assertDeaslNotTerminated(rt, dealId2)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail when caller is not a StorageMinerActor", func(t *testing.T) {
@@ -1269,6 +1801,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail when caller is not the provider of the deal", func(t *testing.T) {
@@ -1288,6 +1822,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail when deal has been published but not activated", func(t *testing.T) {
@@ -1304,6 +1840,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("termination of all deals should fail when one deal fails", func(t *testing.T) {
@@ -1326,6 +1864,8 @@ func TestOnMinerSectorsTerminate(t *testing.T) {
 
 		// verify deal1 has not been terminated
 		actor.assertDeaslNotTerminated(rt, dealId1)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1372,6 +1912,8 @@ func TestCronTick(t *testing.T) {
 		rt.ExpectAssertionFailure("assertion failed", func() {
 			actor.cronTick(rt)
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("crontick for a deal at it's start epoch results in zero payment and no slashing", func(t *testing.T) {
@@ -1388,6 +1930,8 @@ func TestCronTick(t *testing.T) {
 		// deal proposal and state should NOT be deleted
 		require.NotNil(t, actor.getDealProposal(rt, dealId))
 		require.NotNil(t, actor.getDealState(rt, dealId))
+
+		actor.checkState(rt)
 	})
 
 	t.Run("slash a deal and make payment for another deal in the same epoch", func(t *testing.T) {
@@ -1412,6 +1956,8 @@ func TestCronTick(t *testing.T) {
 		actor.assertDealDeleted(rt, dealId1, d1)
 		s2 := actor.getDealState(rt, dealId2)
 		require.EqualValues(t, current, s2.LastUpdatedEpoch)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("cannot publish the same deal twice BEFORE a cron tick", func(t *testing.T) {
@@ -1439,6 +1985,8 @@ func TestCronTick(t *testing.T) {
 		rt.SetEpoch(d1.StartEpoch)
 		actor.cronTick(rt)
 		actor.publishDeals(rt, mAddrs, publishDealReq{deal: d2})
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1479,6 +2027,8 @@ func TestRandomCronEpochDuringPublish(t *testing.T) {
 		pay, _ = actor.cronTickAndAssertBalances(rt, client, provider, current, dealId)
 		duration = big.Sub(big.NewInt(int64(current)), big.NewInt(int64(processEpoch)))
 		require.EqualValues(t, big.Mul(duration, d.StoragePricePerEpoch), pay)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("deals are scheduled for expiry later than the end epoch", func(t *testing.T) {
@@ -1507,6 +2057,8 @@ func TestRandomCronEpochDuringPublish(t *testing.T) {
 		pay, _ = actor.cronTickAndAssertBalances(rt, client, provider, curr, dealId)
 		require.EqualValues(t, d.StoragePricePerEpoch, pay)
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("deal is processed after it's end epoch -> should expire correctly", func(t *testing.T) {
@@ -1525,6 +2077,8 @@ func TestRandomCronEpochDuringPublish(t *testing.T) {
 		require.EqualValues(t, big.Mul(duration, d.StoragePricePerEpoch), pay)
 
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("activation after deal start epoch but before it is processed fails", func(t *testing.T) {
@@ -1552,6 +2106,8 @@ func TestRandomCronEpochDuringPublish(t *testing.T) {
 		actor.cronTick(rt)
 
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 }
@@ -1679,6 +2235,8 @@ func TestCronTickTimedoutDeals(t *testing.T) {
 		actor.assertAccountZero(rt, provider)
 
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("publishing timed out deal again should work after cron tick as it should no longer be pending", func(t *testing.T) {
@@ -1707,6 +2265,8 @@ func TestCronTickTimedoutDeals(t *testing.T) {
 
 		// now publishing should work
 		actor.generateAndPublishDeal(rt, client, mAddrs, startEpoch, endEpoch, startEpoch)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("timed out and verified deals are slashed, deleted AND sent to the Registry actor", func(t *testing.T) {
@@ -1754,6 +2314,8 @@ func TestCronTickTimedoutDeals(t *testing.T) {
 		actor.assertDealDeleted(rt, dealIds[0], &deal1)
 		actor.assertDealDeleted(rt, dealIds[1], &deal2)
 		actor.assertDealDeleted(rt, dealIds[2], &deal3)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1794,6 +2356,8 @@ func TestCronTickDealExpiry(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("deal expiry -> regular payments till deal expires and then locked funds are unlocked", func(t *testing.T) {
@@ -1843,6 +2407,8 @@ func TestCronTickDealExpiry(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("deal expiry -> payment for a deal if deal is already expired before a cron tick", func(t *testing.T) {
@@ -1865,6 +2431,8 @@ func TestCronTickDealExpiry(t *testing.T) {
 
 		// running cron tick again doesn't do anything
 		actor.cronTickNoChange(rt, client, provider)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("expired deal should unlock the remaining client and provider locked balance after payment and deal should be deleted", func(t *testing.T) {
@@ -1891,6 +2459,8 @@ func TestCronTickDealExpiry(t *testing.T) {
 
 		// deal should be deleted
 		actor.assertDealDeleted(rt, dealId, deal)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("all payments are made for a deal -> deal expires -> client withdraws collateral and client account is removed", func(t *testing.T) {
@@ -1907,6 +2477,8 @@ func TestCronTickDealExpiry(t *testing.T) {
 		// client withdraws collateral -> account should be removed as it now has zero balance
 		actor.withdrawClientBalance(rt, client, deal.ClientCollateral, deal.ClientCollateral)
 		actor.assertAccountZero(rt, client)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -2034,6 +2606,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 						rt.Verify()
 					})
 				}
+
+				actor.checkState(rt)
 			})
 		}
 	}
@@ -2063,6 +2637,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("deal is correctly processed twice in the same crontick and slashed", func(t *testing.T) {
@@ -2092,6 +2668,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	// end-end tests for slashing
@@ -2125,6 +2703,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 		actor.assertDealDeleted(rt, dealId1, d1)
 		actor.assertDealDeleted(rt, dealId2, d2)
 		actor.assertDealDeleted(rt, dealId3, d3)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("regular payments till deal is slashed and then slashing is processed", func(t *testing.T) {
@@ -2181,6 +2761,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 
 	// expired deals should NOT be slashed
@@ -2222,6 +2804,8 @@ func TestCronTickDealSlashing(t *testing.T) {
 
 		// deal should be deleted as it should have expired
 		actor.assertDealDeleted(rt, dealId, d)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -2305,6 +2889,8 @@ func TestComputeDataCommitment(t *testing.T) {
 		require.True(t, ok)
 		require.Equal(t, c, *(*cid.Cid)(val))
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail when deal proposal is absent", func(t *testing.T) {
@@ -2353,6 +2939,8 @@ func TestVerifyDealsForActivation(t *testing.T) {
 		resp := actor.verifyDealsForActivation(rt, provider, sectorStart, sectorExpiry, dealId)
 		require.EqualValues(t, big.Zero(), resp.VerifiedDealWeight)
 		require.EqualValues(t, market.DealWeight(d), resp.DealWeight)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("verify deal and get deal weight for verified deal proposal", func(t *testing.T) {
@@ -2364,6 +2952,8 @@ func TestVerifyDealsForActivation(t *testing.T) {
 		resp := actor.verifyDealsForActivation(rt, provider, sectorStart, sectorExpiry, dealIds...)
 		require.EqualValues(t, market.DealWeight(&deal), resp.VerifiedDealWeight)
 		require.EqualValues(t, big.Zero(), resp.DealWeight)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("verification and weights for verified and unverified deals", func(T *testing.T) {
@@ -2389,11 +2979,51 @@ func TestVerifyDealsForActivation(t *testing.T) {
 		require.EqualValues(t, nvweight, resp.DealWeight)
 	})
 
+	t.Run("verification and weights for a batch of sectors", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+
+		vd := actor.generateDealAndAddFunds(rt, client, mAddrs, start, end)
+		vd.VerifiedDeal = true
+		d1 := actor.generateDealAndAddFunds(rt, client, mAddrs, start, end+1)
+		d2 := actor.generateDealAndAddFunds(rt, client, mAddrs, start, end+2)
+		dealIds := actor.publishDeals(rt, mAddrs, publishDealReq{deal: vd}, publishDealReq{deal: d1}, publishDealReq{deal: d2})
+
+		resp := actor.verifyDealsForActivationBatch(rt, provider, sectorStart,
+			market.SectorDeals{SectorExpiry: sectorExpiry, DealIDs: dealIds[:2]},
+			market.SectorDeals{SectorExpiry: sectorExpiry},
+			market.SectorDeals{SectorExpiry: sectorExpiry, DealIDs: dealIds[2:]},
+		)
+
+		assert.EqualValues(t, market.DealWeight(&vd), resp.Sectors[0].VerifiedDealWeight)
+		assert.EqualValues(t, market.DealWeight(&d1), resp.Sectors[0].DealWeight)
+		assert.EqualValues(t, big.Zero(), resp.Sectors[1].VerifiedDealWeight)
+		assert.EqualValues(t, big.Zero(), resp.Sectors[1].DealWeight)
+		assert.EqualValues(t, big.Zero(), resp.Sectors[2].VerifiedDealWeight)
+		assert.EqualValues(t, market.DealWeight(&d2), resp.Sectors[2].DealWeight)
+
+		actor.checkState(rt)
+	})
+
+	t.Run("fail when the same deal ID is passed for multiple sectors", func(t *testing.T) {
+		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
+		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
+
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{
+			{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId}},
+			{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId}},
+		}, SectorStart: sectorStart}
+		rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
+		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "multiple times", func() {
+			rt.Call(actor.VerifyDealsForActivation, param)
+		})
+	})
+
 	t.Run("fail when caller is not a StorageMinerActor", func(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
 
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{dealId}, SectorStart: sectorStart, SectorExpiry: sectorExpiry}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId}}}, SectorStart: sectorStart}
 		rt.SetCaller(worker, builtin.AccountActorCodeID)
 		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
 		rt.ExpectAbort(exitcode.ErrForbidden, func() {
@@ -2403,7 +3033,7 @@ func TestVerifyDealsForActivation(t *testing.T) {
 
 	t.Run("fail when deal proposal is not found", func(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{1}, SectorStart: sectorStart, SectorExpiry: sectorExpiry}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{1}}}, SectorStart: sectorStart}
 		rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
 		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
 		rt.ExpectAbort(exitcode.ErrNotFound, func() {
@@ -2414,7 +3044,7 @@ func TestVerifyDealsForActivation(t *testing.T) {
 	t.Run("fail when caller is not the provider", func(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{dealId}, SectorStart: sectorStart, SectorExpiry: sectorExpiry}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId}}}, SectorStart: sectorStart}
 
 		provider2 := tutil.NewIDAddr(t, 205)
 		rt.SetCaller(provider2, builtin.StorageMinerActorCodeID)
@@ -2428,7 +3058,7 @@ func TestVerifyDealsForActivation(t *testing.T) {
 	t.Run("fail when sector start epoch is greater than proposal start epoch", func(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{dealId}, SectorStart: start + 1, SectorExpiry: sectorExpiry}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId}}}, SectorStart: start + 1}
 
 		rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
 		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
@@ -2440,7 +3070,7 @@ func TestVerifyDealsForActivation(t *testing.T) {
 	t.Run("fail when deal end epoch is greater than sector expiration", func(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{dealId}, SectorStart: start, SectorExpiry: end - 1}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: end - 1, DealIDs: []abi.DealID{dealId}}}, SectorStart: start}
 
 		rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
 		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
@@ -2453,7 +3083,7 @@ func TestVerifyDealsForActivation(t *testing.T) {
 		rt, actor := basicMarketSetup(t, owner, provider, worker, client)
 		dealId := actor.generateAndPublishDeal(rt, client, mAddrs, start, end, start)
 
-		param := &market.VerifyDealsForActivationParams{DealIDs: []abi.DealID{dealId, dealId}, SectorStart: sectorStart, SectorExpiry: sectorExpiry}
+		param := &market.VerifyDealsForActivationParams{Sectors: []market.SectorDeals{{SectorExpiry: sectorExpiry, DealIDs: []abi.DealID{dealId, dealId}}}, SectorStart: sectorStart}
 		rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
 		rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
 		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "multiple times", func() {
@@ -2478,8 +3108,14 @@ func (h *marketActorTestHarness) constructAndVerify(rt *mock.Runtime) {
 }
 
 func (h *marketActorTestHarness) verifyDealsForActivation(rt *mock.Runtime, provider address.Address,
-	sectorStart, sectorExpiry abi.ChainEpoch, dealIds ...abi.DealID) *market.VerifyDealsForActivationReturn {
-	param := &market.VerifyDealsForActivationParams{DealIDs: dealIds, SectorStart: sectorStart, SectorExpiry: sectorExpiry}
+	sectorStart, sectorExpiry abi.ChainEpoch, dealIds ...abi.DealID) *market.SectorWeights {
+	ret := h.verifyDealsForActivationBatch(rt, provider, sectorStart, market.SectorDeals{SectorExpiry: sectorExpiry, DealIDs: dealIds})
+	return &ret.Sectors[0]
+}
+
+func (h *marketActorTestHarness) verifyDealsForActivationBatch(rt *mock.Runtime, provider address.Address,
+	sectorStart abi.ChainEpoch, sectors ...market.SectorDeals) *market.VerifyDealsForActivationReturn {
+	param := &market.VerifyDealsForActivationParams{Sectors: sectors, SectorStart: sectorStart}
 	rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
 	rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
 
@@ -2489,6 +3125,7 @@ func (h *marketActorTestHarness) verifyDealsForActivation(rt *mock.Runtime, prov
 	val, ok := ret.(*market.VerifyDealsForActivationReturn)
 	require.True(h.t, ok)
 	require.NotNil(h.t, val)
+	require.Len(h.t, val.Sectors, len(sectors))
 	return val
 }
 
@@ -2722,6 +3359,9 @@ func (h *marketActorTestHarness) publishDeals(rt *mock.Runtime, minerAddrs *mine
 	resp, ok := ret.(*market.PublishStorageDealsReturn)
 	require.True(h.t, ok, "unexpected type returned from call to PublishStorageDeals")
 	require.Len(h.t, resp.IDs, len(publishDealReqs))
+	validCount, err := resp.ValidDeals.Count()
+	require.NoError(h.t, err)
+	require.Equal(h.t, uint64(len(publishDealReqs)), validCount)
 
 	// assert state after publishing the deals
 	dealIds := resp.IDs
@@ -2835,6 +3475,24 @@ func (h *marketActorTestHarness) getLockedBalance(rt *mock.Runtime, addr address
 	return bal
 }
 
+func (h *marketActorTestHarness) getClientDeals(rt *mock.Runtime, client address.Address) []abi.DealID {
+	var st market.State
+	rt.GetState(&st)
+
+	dealIDs, err := st.ClientDeals(adt.AsStore(rt), client)
+	require.NoError(h.t, err)
+	return dealIDs
+}
+
+func (h *marketActorTestHarness) getProviderDeals(rt *mock.Runtime, provider address.Address) []abi.DealID {
+	var st market.State
+	rt.GetState(&st)
+
+	dealIDs, err := st.ProviderDeals(adt.AsStore(rt), provider)
+	require.NoError(h.t, err)
+	return dealIDs
+}
+
 func (h *marketActorTestHarness) getDealState(rt *mock.Runtime, dealID abi.DealID) *market.DealState {
 	var st market.State
 	rt.GetState(&st)
@@ -2884,6 +3542,50 @@ func (h *marketActorTestHarness) assertDealDeleted(rt *mock.Runtime, dealId abi.
 	require.False(h.t, found)
 }
 
+func (h *marketActorTestHarness) assertNoDealOps(rt *mock.Runtime) {
+	var st market.State
+	rt.GetState(&st)
+
+	dealOps, err := market.AsSetMultimap(adt.AsStore(rt), st.DealOpsByEpoch)
+	require.NoError(h.t, err)
+	err = dealOps.ForAll(func(epoch abi.ChainEpoch, id abi.DealID) error {
+		h.t.Errorf("unexpected deal op for deal %d at epoch %d", id, epoch)
+		return nil
+	})
+	require.NoError(h.t, err)
+}
+
+func (h *marketActorTestHarness) expectProviderCancellation(rt *mock.Runtime, minerAddrs *minerAddrs, dealID abi.DealID,
+	deal *market.DealProposal, sig *crypto.Signature, result error) {
+	h.expectProviderControlAddresses(rt, minerAddrs.provider, minerAddrs.owner, minerAddrs.worker)
+	dcid, err := deal.Cid()
+	require.NoError(h.t, err)
+	rt.ExpectVerifySignature(*sig, minerAddrs.worker, mustCbor(&market.DealCancellation{DealID: dealID, Proposal: dcid}), result)
+}
+
+func (h *marketActorTestHarness) cancelDeal(rt *mock.Runtime, caller address.Address, dealID abi.DealID, sig *crypto.Signature) {
+	rt.SetCaller(caller, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerType(builtin.CallerTypesSignable...)
+	rt.Call(h.CancelDeal, &market.CancelDealParams{DealID: dealID, ProviderSignature: sig})
+	rt.Verify()
+}
+
+func (h *marketActorTestHarness) expectDealExtension(rt *mock.Runtime, client address.Address, dealID abi.DealID,
+	newEndEpoch abi.ChainEpoch, result error) market.ClientDealExtension {
+	ext := market.DealExtension{DealID: dealID, NewEndEpoch: newEndEpoch}
+	sig := crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte("client")}
+	rt.ExpectVerifySignature(sig, client, mustCbor(&ext), result)
+	return market.ClientDealExtension{Extension: ext, ClientSignature: sig}
+}
+
+func (h *marketActorTestHarness) extendDeals(rt *mock.Runtime, provider address.Address, sectors ...market.SectorDealExtensions) *market.ExtendDealsReturn {
+	rt.SetCaller(provider, builtin.StorageMinerActorCodeID)
+	rt.ExpectValidateCallerType(builtin.StorageMinerActorCodeID)
+	ret := rt.Call(h.ExtendDeals, &market.ExtendDealsParams{Sectors: sectors})
+	rt.Verify()
+	return ret.(*market.ExtendDealsReturn)
+}
+
 func (h *marketActorTestHarness) assertDealsTerminated(rt *mock.Runtime, epoch abi.ChainEpoch, dealIds ...abi.DealID) {
 	for _, d := range dealIds {
 		s := h.getDealState(rt, d)
@@ -3049,3 +3751,10 @@ func expectQueryNetworkInfo(rt *mock.Runtime, h *marketActorTestHarness) {
 		exitcode.Ok,
 	)
 }
+
+func (h *marketActorTestHarness) checkState(rt *mock.Runtime) {
+	var st market.State
+	rt.GetState(&st)
+	_, msgs := market.CheckStateInvariants(&st, rt.AdtStore(), rt.Balance())
+	assert.True(h.t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
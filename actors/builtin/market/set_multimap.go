@@ -3,6 +3,7 @@ package market
 import (
 	"reflect"
 
+	addr "github.com/filecoin-project/go-address"
 	cid "github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	errors "github.com/pkg/errors"
@@ -39,37 +40,45 @@ func (mm *SetMultimap) Root() (cid.Cid, error) {
 }
 
 func (mm *SetMultimap) Put(epoch abi.ChainEpoch, v abi.DealID) error {
-	// Load the hamt under key, or initialize a new empty one if not found.
-	k := adt.UIntKey(uint64(epoch))
-	set, found, err := mm.get(k)
-	if err != nil {
-		return err
-	}
-	if !found {
-		set = adt.MakeEmptySet(mm.store)
-	}
+	return mm.putMany(adt.UIntKey(uint64(epoch)), []abi.DealID{v})
+}
 
-	// Add to the set.
-	if err = set.Put(dealKey(v)); err != nil {
-		return errors.Wrapf(err, "failed to add key to set %v", epoch)
-	}
+func (mm *SetMultimap) PutMany(epoch abi.ChainEpoch, vs []abi.DealID) error {
+	return mm.putMany(adt.UIntKey(uint64(epoch)), vs)
+}
 
-	src, err := set.Root()
-	if err != nil {
-		return xerrors.Errorf("failed to flush set root: %w", err)
-	}
-	// Store the new set root under key.
-	newSetRoot := cbg.CborCid(src)
-	err = mm.mp.Put(k, &newSetRoot)
-	if err != nil {
-		return errors.Wrapf(err, "failed to store set")
+// Removes a value for a key, returning whether it was present.
+func (mm *SetMultimap) Remove(epoch abi.ChainEpoch, v abi.DealID) (bool, error) {
+	return mm.remove(adt.UIntKey(uint64(epoch)), v)
+}
+
+// Removes all values for a key.
+func (mm *SetMultimap) RemoveAll(key abi.ChainEpoch) error {
+	err := mm.mp.Delete(adt.UIntKey(uint64(key)))
+	if err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
+		return xerrors.Errorf("failed to delete set key %v: %w", key, err)
 	}
 	return nil
 }
 
-func (mm *SetMultimap) PutMany(epoch abi.ChainEpoch, vs []abi.DealID) error {
+// Iterates all entries for a key, iteration halts if the function returns an error.
+func (mm *SetMultimap) ForEach(epoch abi.ChainEpoch, fn func(id abi.DealID) error) error {
+	return mm.forEach(adt.UIntKey(uint64(epoch)), fn)
+}
+
+// Iterates all entries for all keys, iteration halts if the function returns an error.
+func (mm *SetMultimap) ForAll(fn func(epoch abi.ChainEpoch, id abi.DealID) error) error {
+	return mm.forAll(func(k string, id abi.DealID) error {
+		epoch, err := adt.ParseUIntKey(k)
+		if err != nil {
+			return err
+		}
+		return fn(abi.ChainEpoch(epoch), id)
+	})
+}
+
+func (mm *SetMultimap) putMany(k adt.Keyer, vs []abi.DealID) error {
 	// Load the hamt under key, or initialize a new empty one if not found.
-	k := adt.UIntKey(uint64(epoch))
 	set, found, err := mm.get(k)
 	if err != nil {
 		return err
@@ -81,35 +90,45 @@ func (mm *SetMultimap) PutMany(epoch abi.ChainEpoch, vs []abi.DealID) error {
 	// Add to the set.
 	for _, v := range vs {
 		if err = set.Put(dealKey(v)); err != nil {
-			return errors.Wrapf(err, "failed to add key to set %v", epoch)
+			return errors.Wrapf(err, "failed to add key to set %v", k)
 		}
 	}
+	return mm.putSet(k, set)
+}
 
-	src, err := set.Root()
-	if err != nil {
-		return xerrors.Errorf("failed to flush set root: %w", err)
+func (mm *SetMultimap) remove(k adt.Keyer, v abi.DealID) (bool, error) {
+	set, found, err := mm.get(k)
+	if err != nil || !found {
+		return false, err
 	}
-	// Store the new set root under key.
-	newSetRoot := cbg.CborCid(src)
-	err = mm.mp.Put(k, &newSetRoot)
-	if err != nil {
-		return errors.Wrapf(err, "failed to store set")
+
+	if has, err := set.Has(dealKey(v)); err != nil || !has {
+		return false, err
+	}
+	if err = set.Delete(dealKey(v)); err != nil {
+		return false, errors.Wrapf(err, "failed to remove key from set %v", k)
 	}
-	return nil
-}
 
-// Removes all values for a key.
-func (mm *SetMultimap) RemoveAll(key abi.ChainEpoch) error {
-	err := mm.mp.Delete(adt.UIntKey(uint64(key)))
-	if err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
-		return xerrors.Errorf("failed to delete set key %v: %w", key, err)
+	// Delete the key rather than storing an empty set.
+	empty := true
+	errStop := xerrors.New("stop")
+	if err = set.ForEach(func(string) error {
+		empty = false
+		return errStop
+	}); err != nil && err != errStop {
+		return false, errors.Wrapf(err, "failed to iterate set %v", k)
 	}
-	return nil
+	if empty {
+		if err = mm.mp.Delete(k); err != nil {
+			return false, xerrors.Errorf("failed to delete set key %v: %w", k, err)
+		}
+		return true, nil
+	}
+	return true, mm.putSet(k, set)
 }
 
-// Iterates all entries for a key, iteration halts if the function returns an error.
-func (mm *SetMultimap) ForEach(epoch abi.ChainEpoch, fn func(id abi.DealID) error) error {
-	set, found, err := mm.get(adt.UIntKey(uint64(epoch)))
+func (mm *SetMultimap) forEach(k adt.Keyer, fn func(id abi.DealID) error) error {
+	set, found, err := mm.get(k)
 	if err != nil {
 		return err
 	}
@@ -125,6 +144,36 @@ func (mm *SetMultimap) ForEach(epoch abi.ChainEpoch, fn func(id abi.DealID) erro
 	return nil
 }
 
+func (mm *SetMultimap) forAll(fn func(k string, id abi.DealID) error) error {
+	var setRoot cbg.CborCid
+	return mm.mp.ForEach(&setRoot, func(k string) error {
+		set, err := adt.AsSet(mm.store, cid.Cid(setRoot))
+		if err != nil {
+			return err
+		}
+		return set.ForEach(func(sk string) error {
+			v, err := parseDealKey(sk)
+			if err != nil {
+				return err
+			}
+			return fn(k, v)
+		})
+	})
+}
+
+// Stores the new root of a set under a key.
+func (mm *SetMultimap) putSet(k adt.Keyer, set *adt.Set) error {
+	src, err := set.Root()
+	if err != nil {
+		return xerrors.Errorf("failed to flush set root: %w", err)
+	}
+	newSetRoot := cbg.CborCid(src)
+	if err = mm.mp.Put(k, &newSetRoot); err != nil {
+		return errors.Wrapf(err, "failed to store set")
+	}
+	return nil
+}
+
 func (mm *SetMultimap) get(key adt.Keyer) (*adt.Set, bool, error) {
 	var setRoot cbg.CborCid
 	found, err := mm.mp.Get(key, &setRoot)
@@ -141,6 +190,55 @@ func (mm *SetMultimap) get(key adt.Keyer) (*adt.Set, bool, error) {
 	return set, found, nil
 }
 
+// A map of sets of deal IDs keyed by address, backed by the same structure as SetMultimap.
+type AddrSetMultimap struct {
+	mm *SetMultimap
+}
+
+// Interprets a store as a HAMT-based map of HAMT-based sets with root `r`, keyed by address.
+func AsAddrSetMultimap(s adt.Store, r cid.Cid) (*AddrSetMultimap, error) {
+	mm, err := AsSetMultimap(s, r)
+	if err != nil {
+		return nil, err
+	}
+	return &AddrSetMultimap{mm}, nil
+}
+
+// Creates a new map backed by an empty HAMT.
+func MakeEmptyAddrSetMultimap(s adt.Store) *AddrSetMultimap {
+	return &AddrSetMultimap{MakeEmptySetMultimap(s)}
+}
+
+// Returns the root cid of the underlying HAMT.
+func (mm *AddrSetMultimap) Root() (cid.Cid, error) {
+	return mm.mm.Root()
+}
+
+func (mm *AddrSetMultimap) Put(a addr.Address, v abi.DealID) error {
+	return mm.mm.putMany(adt.AddrKey(a), []abi.DealID{v})
+}
+
+// Removes a value for a key, returning whether it was present.
+func (mm *AddrSetMultimap) Remove(a addr.Address, v abi.DealID) (bool, error) {
+	return mm.mm.remove(adt.AddrKey(a), v)
+}
+
+// Iterates all entries for a key, iteration halts if the function returns an error.
+func (mm *AddrSetMultimap) ForEach(a addr.Address, fn func(id abi.DealID) error) error {
+	return mm.mm.forEach(adt.AddrKey(a), fn)
+}
+
+// Iterates all entries for all keys, iteration halts if the function returns an error.
+func (mm *AddrSetMultimap) ForAll(fn func(a addr.Address, id abi.DealID) error) error {
+	return mm.mm.forAll(func(k string, id abi.DealID) error {
+		a, err := addr.NewFromBytes([]byte(k))
+		if err != nil {
+			return err
+		}
+		return fn(a, id)
+	})
+}
+
 func dealKey(e abi.DealID) adt.Keyer {
 	return adt.UIntKey(uint64(e))
 }
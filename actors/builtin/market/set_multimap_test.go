@@ -0,0 +1,88 @@
+package market_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/filecoin-project/specs-actors/support/mock"
+	tutil "github.com/filecoin-project/specs-actors/support/testing"
+)
+
+func TestAddrSetMultimap(t *testing.T) {
+	client := tutil.NewIDAddr(t, 100)
+	other := tutil.NewIDAddr(t, 101)
+
+	setup := func() (adt.Store, *market.AddrSetMultimap) {
+		rt := mock.NewBuilder(context.Background(), address.Undef).Build(t)
+		store := adt.AsStore(rt)
+		return store, market.MakeEmptyAddrSetMultimap(store)
+	}
+
+	hasKey := func(store adt.Store, mm *market.AddrSetMultimap, a address.Address) bool {
+		mp, err := adt.AsMap(store, tutil.MustRoot(t, mm))
+		require.NoError(t, err)
+		var setRoot cbg.CborCid
+		found, err := mp.Get(adt.AddrKey(a), &setRoot)
+		require.NoError(t, err)
+		return found
+	}
+
+	collect := func(mm *market.AddrSetMultimap, a address.Address) []abi.DealID {
+		var ids []abi.DealID
+		require.NoError(t, mm.ForEach(a, func(id abi.DealID) error {
+			ids = append(ids, id)
+			return nil
+		}))
+		return ids
+	}
+
+	t.Run("remove returns whether value was present", func(t *testing.T) {
+		_, mm := setup()
+		require.NoError(t, mm.Put(client, 1))
+
+		found, err := mm.Remove(client, 2)
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		found, err = mm.Remove(other, 1)
+		require.NoError(t, err)
+		assert.False(t, found)
+
+		found, err = mm.Remove(client, 1)
+		require.NoError(t, err)
+		assert.True(t, found)
+	})
+
+	t.Run("remove keeps key while set is not empty", func(t *testing.T) {
+		store, mm := setup()
+		require.NoError(t, mm.Put(client, 1))
+		require.NoError(t, mm.Put(client, 2))
+
+		found, err := mm.Remove(client, 1)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.True(t, hasKey(store, mm, client))
+		assert.Equal(t, []abi.DealID{2}, collect(mm, client))
+	})
+
+	t.Run("remove deletes key when set becomes empty", func(t *testing.T) {
+		store, mm := setup()
+		require.NoError(t, mm.Put(client, 1))
+		require.NoError(t, mm.Put(other, 2))
+
+		found, err := mm.Remove(client, 1)
+		require.NoError(t, err)
+		assert.True(t, found)
+		assert.False(t, hasKey(store, mm, client))
+		assert.True(t, hasKey(store, mm, other))
+		assert.Empty(t, collect(mm, client))
+	})
+}
@@ -0,0 +1,291 @@
+package market
+
+import (
+	addr "github.com/filecoin-project/go-address"
+	cid "github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+)
+
+type DealSummary struct {
+	Client           addr.Address
+	Provider         addr.Address
+	StartEpoch       abi.ChainEpoch
+	EndEpoch         abi.ChainEpoch
+	SectorStartEpoch abi.ChainEpoch
+	LastUpdatedEpoch abi.ChainEpoch
+	SlashEpoch       abi.ChainEpoch
+}
+
+type StateSummary struct {
+	Deals            map[abi.DealID]*DealSummary
+	PendingProposals map[cid.Cid]bool
+	EscrowTotal      abi.TokenAmount
+	LockedTotal      abi.TokenAmount
+}
+
+// Checks internal invariants of market state.
+func CheckStateInvariants(st *State, store adt.Store, balance abi.TokenAmount) (*StateSummary, *builtin.MessageAccumulator) {
+	acc := &builtin.MessageAccumulator{}
+
+	acc.Require(
+		st.TotalClientLockedCollateral.GreaterThanEqual(big.Zero()),
+		"negative total client locked collateral: %v", st.TotalClientLockedCollateral)
+	acc.Require(
+		st.TotalProviderLockedCollateral.GreaterThanEqual(big.Zero()),
+		"negative total provider locked collateral: %v", st.TotalProviderLockedCollateral)
+	acc.Require(
+		st.TotalClientStorageFee.GreaterThanEqual(big.Zero()),
+		"negative total client storage fee: %v", st.TotalClientStorageFee)
+
+	//
+	// Proposals
+	//
+
+	proposalCids := make(map[cid.Cid][]abi.DealID)
+	maxDealID := int64(-1)
+	proposalStats := make(map[abi.DealID]*DealSummary)
+	if proposals, err := AsDealProposalArray(store, st.Proposals); err != nil {
+		acc.Addf("error loading proposals: %v", err)
+	} else {
+		var proposal DealProposal
+		err = proposals.ForEach(&proposal, func(dealID int64) error {
+			pcid, err := proposal.Cid()
+			if err != nil {
+				return err
+			}
+
+			// A proposal may be published again once its previous instance is no longer pending.
+			proposalCids[pcid] = append(proposalCids[pcid], abi.DealID(dealID))
+
+			acc.Require(proposal.StartEpoch < proposal.EndEpoch, "deal %d start epoch %d is not before end epoch %d",
+				dealID, proposal.StartEpoch, proposal.EndEpoch)
+			acc.Require(proposal.Client.Protocol() == addr.ID, "deal %d client %v is not an ID address", dealID, proposal.Client)
+			acc.Require(proposal.Provider.Protocol() == addr.ID, "deal %d provider %v is not an ID address", dealID, proposal.Provider)
+
+			if dealID > maxDealID {
+				maxDealID = dealID
+			}
+
+			proposalStats[abi.DealID(dealID)] = &DealSummary{
+				Client:           proposal.Client,
+				Provider:         proposal.Provider,
+				StartEpoch:       proposal.StartEpoch,
+				EndEpoch:         proposal.EndEpoch,
+				SectorStartEpoch: epochUndefined,
+				LastUpdatedEpoch: epochUndefined,
+				SlashEpoch:       epochUndefined,
+			}
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating proposals")
+	}
+
+	// next id should be higher than any existing deal
+	acc.Require(int64(st.NextID) > maxDealID, "next id, %d, is not greater than highest id in proposals, %d", st.NextID, maxDealID)
+
+	//
+	// Deal States
+	//
+
+	if dealStates, err := AsDealStateArray(store, st.States); err != nil {
+		acc.Addf("error loading deal states: %v", err)
+	} else {
+		var dealState DealState
+		err = dealStates.ForEach(&dealState, func(dealID int64) error {
+			acc.Require(
+				dealState.SectorStartEpoch >= 0,
+				"deal %d state start epoch undefined: %v", dealID, dealState)
+			acc.Require(
+				dealState.LastUpdatedEpoch == epochUndefined || dealState.LastUpdatedEpoch >= dealState.SectorStartEpoch,
+				"deal %d state last updated before sector start: %v", dealID, dealState)
+			acc.Require(
+				dealState.SlashEpoch == epochUndefined || dealState.SlashEpoch >= dealState.SectorStartEpoch,
+				"deal %d state slashed before sector start: %v", dealID, dealState)
+
+			stats, found := proposalStats[abi.DealID(dealID)]
+			acc.Require(found, "no deal proposal for deal state %d", dealID)
+			if found {
+				stats.SectorStartEpoch = dealState.SectorStartEpoch
+				stats.LastUpdatedEpoch = dealState.LastUpdatedEpoch
+				stats.SlashEpoch = dealState.SlashEpoch
+			}
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating deal states")
+	}
+
+	//
+	// Pending Proposals
+	//
+
+	pendingProposals := make(map[cid.Cid]bool)
+	if pendingProposalsMap, err := adt.AsMap(store, st.PendingProposals); err != nil {
+		acc.Addf("error loading pending proposals: %v", err)
+	} else {
+		var pendingProposal DealProposal
+		err = pendingProposalsMap.ForEach(&pendingProposal, func(key string) error {
+			proposalCID, err := cid.Parse([]byte(key))
+			if err != nil {
+				return err
+			}
+
+			pcid, err := pendingProposal.Cid()
+			if err != nil {
+				return err
+			}
+			acc.Require(pcid.Equals(proposalCID), "pending proposal key %v does not match proposal cid %v", proposalCID, pcid)
+
+			dealIDs, found := proposalCids[proposalCID]
+			acc.Require(found, "pending proposal %v not found in proposals", proposalCID)
+			if found {
+				// Pending entries are removed on the first cron tick after activation.
+				notUpdated := 0
+				for _, dealID := range dealIDs {
+					if proposalStats[dealID].LastUpdatedEpoch == epochUndefined {
+						notUpdated++
+					}
+				}
+				acc.Require(notUpdated == 1, "pending proposal %v has %d deals not yet updated by cron, expected 1", proposalCID, notUpdated)
+			}
+
+			pendingProposals[proposalCID] = true
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating pending proposals")
+	}
+
+	// Every deal that has not yet been processed by cron since activation must be pending.
+	for pcid, dealIDs := range proposalCids {
+		for _, dealID := range dealIDs {
+			if proposalStats[dealID].LastUpdatedEpoch == epochUndefined {
+				acc.Require(pendingProposals[pcid], "deal %d not yet updated by cron is missing from pending proposals", dealID)
+			}
+		}
+	}
+
+	//
+	// Escrow Table and Locked Table
+	//
+
+	escrowTotal := abi.NewTokenAmount(0)
+	escrowBalances := make(map[addr.Address]abi.TokenAmount)
+	if escrowTable, err := adt.AsBalanceTable(store, st.EscrowTable); err != nil {
+		acc.Addf("error loading escrow table: %v", err)
+	} else {
+		var escrow abi.TokenAmount
+		err = (*adt.Map)(escrowTable).ForEach(&escrow, func(key string) error {
+			escrowAddr, err := addr.NewFromBytes([]byte(key))
+			if err != nil {
+				return err
+			}
+			acc.Require(escrow.GreaterThanEqual(big.Zero()), "escrow balance for %v is negative: %v", escrowAddr, escrow)
+			escrowBalances[escrowAddr] = escrow.Copy()
+			escrowTotal = big.Add(escrowTotal, escrow)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating escrow table")
+	}
+
+	lockedTotal := abi.NewTokenAmount(0)
+	if lockedTable, err := adt.AsBalanceTable(store, st.LockedTable); err != nil {
+		acc.Addf("error loading locked table: %v", err)
+	} else {
+		var locked abi.TokenAmount
+		err = (*adt.Map)(lockedTable).ForEach(&locked, func(key string) error {
+			lockedAddr, err := addr.NewFromBytes([]byte(key))
+			if err != nil {
+				return err
+			}
+			acc.Require(locked.GreaterThanEqual(big.Zero()), "locked balance for %v is negative: %v", lockedAddr, locked)
+
+			escrow, found := escrowBalances[lockedAddr]
+			if !found {
+				escrow = big.Zero()
+			}
+			acc.Require(locked.LessThanEqual(escrow), "locked funds for %s, %s, greater than escrow amount, %s",
+				lockedAddr, locked, escrow)
+
+			lockedTotal = big.Add(lockedTotal, locked)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating locked table")
+	}
+
+	// lockTable total should be sum of client and provider locked plus client storage fee
+	expectedLockTotal := big.Sum(st.TotalProviderLockedCollateral, st.TotalClientLockedCollateral, st.TotalClientStorageFee)
+	acc.Require(lockedTotal.Equals(expectedLockTotal),
+		"locked total, %s, does not sum to provider locked, %s, client locked, %s, and client storage fee, %s",
+		lockedTotal, st.TotalProviderLockedCollateral, st.TotalClientLockedCollateral, st.TotalClientStorageFee)
+
+	// assert escrow <= actor balance
+	// lockTable item <= escrow item and escrowTotal <= balance implies lockTable total <= balance
+	acc.Require(escrowTotal.LessThanEqual(balance), "escrow total, %v, greater than actor balance, %v", escrowTotal, balance)
+
+	//
+	// Deal Ops by Epoch
+	//
+
+	dealOps := make(map[abi.DealID]bool)
+	if dealOpsByEpoch, err := AsSetMultimap(store, st.DealOpsByEpoch); err != nil {
+		acc.Addf("error loading deal ops: %v", err)
+	} else {
+		err = dealOpsByEpoch.ForAll(func(epoch abi.ChainEpoch, dealID abi.DealID) error {
+			acc.Require(epoch >= st.LastCron, "deal op for deal %d scheduled at epoch %d, before last cron %d", dealID, epoch, st.LastCron)
+			acc.Require(!dealOps[dealID], "deal %d has multiple scheduled deal ops", dealID)
+			dealOps[dealID] = true
+
+			_, found := proposalStats[dealID]
+			acc.Require(found, "deal op found for deal id %d with missing proposal at epoch %d", dealID, epoch)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating deal ops")
+	}
+
+	// Every deal is scheduled for cron processing exactly once.
+	for dealID := range proposalStats {
+		acc.Require(dealOps[dealID], "deal %d has no scheduled deal op", dealID)
+	}
+
+	//
+	// Deals by Client and Provider
+	//
+
+	checkDealIndex := func(name string, root cid.Cid, party func(*DealSummary) addr.Address) {
+		indexed := make(map[abi.DealID]bool)
+		if index, err := AsAddrSetMultimap(store, root); err != nil {
+			acc.Addf("error loading deals by %s: %v", name, err)
+		} else {
+			err = index.ForAll(func(a addr.Address, dealID abi.DealID) error {
+				indexed[dealID] = true
+				stats, found := proposalStats[dealID]
+				acc.Require(found, "deal %d indexed by %s %v has no proposal", dealID, name, a)
+				if found {
+					acc.Require(party(stats) == a, "deal %d indexed by %s %v, expected %v", dealID, name, a, party(stats))
+					acc.Require(stats.SlashEpoch == epochUndefined, "terminated deal %d indexed by %s", dealID, name)
+				}
+				return nil
+			})
+			acc.RequireNoError(err, "error iterating deals by %s", name)
+		}
+
+		// Every deal not yet terminated is indexed.
+		for dealID, stats := range proposalStats {
+			if stats.SlashEpoch == epochUndefined {
+				acc.Require(indexed[dealID], "deal %d not indexed by %s", dealID, name)
+			}
+		}
+	}
+	checkDealIndex("client", st.DealsByClient, func(d *DealSummary) addr.Address { return d.Client })
+	checkDealIndex("provider", st.DealsByProvider, func(d *DealSummary) addr.Address { return d.Provider })
+
+	return &StateSummary{
+		Deals:            proposalStats,
+		PendingProposals: pendingProposals,
+		EscrowTotal:      escrowTotal,
+		LockedTotal:      lockedTotal,
+	}, acc
+}
@@ -40,7 +40,10 @@ var MethodsMultisig = struct {
 	RemoveSigner                abi.MethodNum
 	SwapSigner                  abi.MethodNum
 	ChangeNumApprovalsThreshold abi.MethodNum
-}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8}
+	LockBalance                 abi.MethodNum
+	PruneExpiredTransactions    abi.MethodNum
+	SetExecutedHistoryLength    abi.MethodNum
+}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
 
 var MethodsPaych = struct {
 	Constructor        abi.MethodNum
@@ -59,7 +62,9 @@ var MethodsMarket = struct {
 	OnMinerSectorsTerminate  abi.MethodNum
 	ComputeDataCommitment    abi.MethodNum
 	CronTick                 abi.MethodNum
-}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9}
+	CancelDeal               abi.MethodNum
+	ExtendDeals              abi.MethodNum
+}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
 
 var MethodsPower = struct {
 	Constructor              abi.MethodNum
@@ -73,33 +78,43 @@ var MethodsPower = struct {
 }{MethodConstructor, 2, 3, 4, 5, 6, 7, 8}
 
 var MethodsMiner = struct {
-	Constructor              abi.MethodNum
-	ControlAddresses         abi.MethodNum
-	ChangeWorkerAddress      abi.MethodNum
-	ChangePeerID             abi.MethodNum
-	SubmitWindowedPoSt       abi.MethodNum
-	PreCommitSector          abi.MethodNum
-	ProveCommitSector        abi.MethodNum
-	ExtendSectorExpiration   abi.MethodNum
-	TerminateSectors         abi.MethodNum
-	DeclareFaults            abi.MethodNum
-	DeclareFaultsRecovered   abi.MethodNum
-	OnDeferredCronEvent      abi.MethodNum
-	CheckSectorProven        abi.MethodNum
-	AddLockedFund            abi.MethodNum
-	ReportConsensusFault     abi.MethodNum
-	WithdrawBalance          abi.MethodNum
-	ConfirmSectorProofsValid abi.MethodNum
-	ChangeMultiaddrs         abi.MethodNum
-	CompactPartitions        abi.MethodNum
-	CompactSectorNumbers     abi.MethodNum
-}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	Constructor                abi.MethodNum
+	ControlAddresses           abi.MethodNum
+	ChangeWorkerAddress        abi.MethodNum
+	ChangePeerID               abi.MethodNum
+	SubmitWindowedPoSt         abi.MethodNum
+	PreCommitSector            abi.MethodNum
+	ProveCommitSector          abi.MethodNum
+	ExtendSectorExpiration     abi.MethodNum
+	TerminateSectors           abi.MethodNum
+	DeclareFaults              abi.MethodNum
+	DeclareFaultsRecovered     abi.MethodNum
+	OnDeferredCronEvent        abi.MethodNum
+	CheckSectorProven          abi.MethodNum
+	AddLockedFund              abi.MethodNum
+	ReportConsensusFault       abi.MethodNum
+	WithdrawBalance            abi.MethodNum
+	ConfirmSectorProofsValid   abi.MethodNum
+	ChangeMultiaddrs           abi.MethodNum
+	CompactPartitions          abi.MethodNum
+	CompactSectorNumbers       abi.MethodNum
+	ChangeOwnerAddress         abi.MethodNum
+	ProveCommitAggregate       abi.MethodNum
+	PreCommitSectorBatch       abi.MethodNum
+	ChangeBeneficiary          abi.MethodNum
+	DisputeWindowedPoSt        abi.MethodNum
+	ProveReplicaUpdates        abi.MethodNum
+	RepayDebt                  abi.MethodNum
+	ConfirmChangeWorkerAddress abi.MethodNum
+}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28}
 
 var MethodsVerifiedRegistry = struct {
-	Constructor       abi.MethodNum
-	AddVerifier       abi.MethodNum
-	RemoveVerifier    abi.MethodNum
-	AddVerifiedClient abi.MethodNum
-	UseBytes          abi.MethodNum
-	RestoreBytes      abi.MethodNum
-}{MethodConstructor, 2, 3, 4, 5, 6}
+	Constructor                 abi.MethodNum
+	AddVerifier                 abi.MethodNum
+	RemoveVerifier              abi.MethodNum
+	AddVerifiedClient           abi.MethodNum
+	UseBytes                    abi.MethodNum
+	RestoreBytes                abi.MethodNum
+	RemoveVerifiedClientDataCap abi.MethodNum
+	UpdateVerifierAllowance     abi.MethodNum
+}{MethodConstructor, 2, 3, 4, 5, 6, 7, 8}
@@ -8,6 +8,7 @@ import (
 
 	address "github.com/filecoin-project/go-address"
 	abi "github.com/filecoin-project/specs-actors/actors/abi"
+	market "github.com/filecoin-project/specs-actors/actors/builtin/market"
 	cid "github.com/ipfs/go-cid"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	xerrors "golang.org/x/xerrors"
@@ -303,7 +304,7 @@ func (t *State) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufMinerInfo = []byte{138}
+var lengthBufMinerInfo = []byte{142}
 
 func (t *MinerInfo) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -413,6 +414,26 @@ func (t *MinerInfo) MarshalCBOR(w io.Writer) error {
 			return err
 		}
 	}
+
+	// t.PendingOwnerAddress (address.Address) (struct)
+	if err := t.PendingOwnerAddress.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Beneficiary (address.Address) (struct)
+	if err := t.Beneficiary.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.BeneficiaryTerm (miner.BeneficiaryTerm) (struct)
+	if err := t.BeneficiaryTerm.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.PendingBeneficiaryTerm (miner.PendingBeneficiaryChange) (struct)
+	if err := t.PendingBeneficiaryTerm.MarshalCBOR(w); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -430,7 +451,7 @@ func (t *MinerInfo) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 10 {
+	if extra != 14 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -646,6 +667,62 @@ func (t *MinerInfo) UnmarshalCBOR(r io.Reader) error {
 
 		t.ConsensusFaultElapsed = abi.ChainEpoch(extraI)
 	}
+	// t.PendingOwnerAddress (address.Address) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return err
+			}
+			t.PendingOwnerAddress = new(address.Address)
+			if err := t.PendingOwnerAddress.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("unmarshaling t.PendingOwnerAddress pointer: %w", err)
+			}
+		}
+
+	}
+	// t.Beneficiary (address.Address) (struct)
+
+	{
+
+		if err := t.Beneficiary.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Beneficiary: %w", err)
+		}
+
+	}
+	// t.BeneficiaryTerm (miner.BeneficiaryTerm) (struct)
+
+	{
+
+		if err := t.BeneficiaryTerm.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.BeneficiaryTerm: %w", err)
+		}
+
+	}
+	// t.PendingBeneficiaryTerm (miner.PendingBeneficiaryChange) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return err
+			}
+			t.PendingBeneficiaryTerm = new(PendingBeneficiaryChange)
+			if err := t.PendingBeneficiaryTerm.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("unmarshaling t.PendingBeneficiaryTerm pointer: %w", err)
+			}
+		}
+
+	}
 	return nil
 }
 
@@ -729,7 +806,7 @@ func (t *Deadlines) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufDeadline = []byte{135}
+var lengthBufDeadline = []byte{139}
 
 func (t *Deadline) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -780,6 +857,31 @@ func (t *Deadline) MarshalCBOR(w io.Writer) error {
 	if err := t.FaultyPower.MarshalCBOR(w); err != nil {
 		return err
 	}
+
+	// t.OptimisticPoStSubmissions (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.OptimisticPoStSubmissions); err != nil {
+		return xerrors.Errorf("failed to write cid field t.OptimisticPoStSubmissions: %w", err)
+	}
+
+	// t.PartitionsSnapshot (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.PartitionsSnapshot); err != nil {
+		return xerrors.Errorf("failed to write cid field t.PartitionsSnapshot: %w", err)
+	}
+
+	// t.SectorsSnapshot (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.SectorsSnapshot); err != nil {
+		return xerrors.Errorf("failed to write cid field t.SectorsSnapshot: %w", err)
+	}
+
+	// t.OptimisticPoStSubmissionsSnapshot (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.OptimisticPoStSubmissionsSnapshot); err != nil {
+		return xerrors.Errorf("failed to write cid field t.OptimisticPoStSubmissionsSnapshot: %w", err)
+	}
+
 	return nil
 }
 
@@ -797,7 +899,7 @@ func (t *Deadline) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 7 {
+	if extra != 11 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -879,6 +981,54 @@ func (t *Deadline) UnmarshalCBOR(r io.Reader) error {
 			return xerrors.Errorf("unmarshaling t.FaultyPower: %w", err)
 		}
 
+	}
+	// t.OptimisticPoStSubmissions (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.OptimisticPoStSubmissions: %w", err)
+		}
+
+		t.OptimisticPoStSubmissions = c
+
+	}
+	// t.PartitionsSnapshot (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.PartitionsSnapshot: %w", err)
+		}
+
+		t.PartitionsSnapshot = c
+
+	}
+	// t.SectorsSnapshot (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.SectorsSnapshot: %w", err)
+		}
+
+		t.SectorsSnapshot = c
+
+	}
+	// t.OptimisticPoStSubmissionsSnapshot (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.OptimisticPoStSubmissionsSnapshot: %w", err)
+		}
+
+		t.OptimisticPoStSubmissionsSnapshot = c
+
 	}
 	return nil
 }
@@ -2122,37 +2272,44 @@ func (t *WorkerKeyChange) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufVestingFunds = []byte{129}
+var lengthBufBeneficiaryTerm = []byte{131}
 
-func (t *VestingFunds) MarshalCBOR(w io.Writer) error {
+func (t *BeneficiaryTerm) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufVestingFunds); err != nil {
+	if _, err := w.Write(lengthBufBeneficiaryTerm); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.Funds ([]miner.VestingFund) (slice)
-	if len(t.Funds) > cbg.MaxLength {
-		return xerrors.Errorf("Slice value in field t.Funds was too long")
+	// t.Quota (big.Int) (struct)
+	if err := t.Quota.MarshalCBOR(w); err != nil {
+		return err
 	}
 
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Funds))); err != nil {
+	// t.UsedQuota (big.Int) (struct)
+	if err := t.UsedQuota.MarshalCBOR(w); err != nil {
 		return err
 	}
-	for _, v := range t.Funds {
-		if err := v.MarshalCBOR(w); err != nil {
+
+	// t.Expiration (abi.ChainEpoch) (int64)
+	if t.Expiration >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Expiration)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.Expiration-1)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (t *VestingFunds) UnmarshalCBOR(r io.Reader) error {
-	*t = VestingFunds{}
+func (t *BeneficiaryTerm) UnmarshalCBOR(r io.Reader) error {
+	*t = BeneficiaryTerm{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -2165,75 +2322,104 @@ func (t *VestingFunds) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 1 {
+	if extra != 3 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.Funds ([]miner.VestingFund) (slice)
+	// t.Quota (big.Int) (struct)
 
-	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
-	if err != nil {
-		return err
-	}
+	{
 
-	if extra > cbg.MaxLength {
-		return fmt.Errorf("t.Funds: array too large (%d)", extra)
-	}
+		if err := t.Quota.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Quota: %w", err)
+		}
 
-	if maj != cbg.MajArray {
-		return fmt.Errorf("expected cbor array")
 	}
+	// t.UsedQuota (big.Int) (struct)
 
-	if extra > 0 {
-		t.Funds = make([]VestingFund, extra)
-	}
+	{
 
-	for i := 0; i < int(extra); i++ {
+		if err := t.UsedQuota.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.UsedQuota: %w", err)
+		}
 
-		var v VestingFund
-		if err := v.UnmarshalCBOR(br); err != nil {
+	}
+	// t.Expiration (abi.ChainEpoch) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
 			return err
 		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
 
-		t.Funds[i] = v
+		t.Expiration = abi.ChainEpoch(extraI)
 	}
-
 	return nil
 }
 
-var lengthBufVestingFund = []byte{130}
+var lengthBufPendingBeneficiaryChange = []byte{133}
 
-func (t *VestingFund) MarshalCBOR(w io.Writer) error {
+func (t *PendingBeneficiaryChange) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufVestingFund); err != nil {
+	if _, err := w.Write(lengthBufPendingBeneficiaryChange); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.Epoch (abi.ChainEpoch) (int64)
-	if t.Epoch >= 0 {
-		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Epoch)); err != nil {
+	// t.NewBeneficiary (address.Address) (struct)
+	if err := t.NewBeneficiary.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.NewQuota (big.Int) (struct)
+	if err := t.NewQuota.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.NewExpiration (abi.ChainEpoch) (int64)
+	if t.NewExpiration >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.NewExpiration)); err != nil {
 			return err
 		}
 	} else {
-		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.Epoch-1)); err != nil {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.NewExpiration-1)); err != nil {
 			return err
 		}
 	}
 
-	// t.Amount (big.Int) (struct)
-	if err := t.Amount.MarshalCBOR(w); err != nil {
+	// t.ApprovedByBeneficiary (bool) (bool)
+	if err := cbg.WriteBool(w, t.ApprovedByBeneficiary); err != nil {
+		return err
+	}
+
+	// t.ApprovedByNominee (bool) (bool)
+	if err := cbg.WriteBool(w, t.ApprovedByNominee); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *VestingFund) UnmarshalCBOR(r io.Reader) error {
-	*t = VestingFund{}
+func (t *PendingBeneficiaryChange) UnmarshalCBOR(r io.Reader) error {
+	*t = PendingBeneficiaryChange{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -2246,11 +2432,29 @@ func (t *VestingFund) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 2 {
+	if extra != 5 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.Epoch (abi.ChainEpoch) (int64)
+	// t.NewBeneficiary (address.Address) (struct)
+
+	{
+
+		if err := t.NewBeneficiary.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.NewBeneficiary: %w", err)
+		}
+
+	}
+	// t.NewQuota (big.Int) (struct)
+
+	{
+
+		if err := t.NewQuota.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.NewQuota: %w", err)
+		}
+
+	}
+	// t.NewExpiration (abi.ChainEpoch) (int64)
 	{
 		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
 		var extraI int64
@@ -2273,37 +2477,227 @@ func (t *VestingFund) UnmarshalCBOR(r io.Reader) error {
 			return fmt.Errorf("wrong type for int64 field: %d", maj)
 		}
 
-		t.Epoch = abi.ChainEpoch(extraI)
+		t.NewExpiration = abi.ChainEpoch(extraI)
 	}
-	// t.Amount (big.Int) (struct)
+	// t.ApprovedByBeneficiary (bool) (bool)
 
-	{
-
-		if err := t.Amount.UnmarshalCBOR(br); err != nil {
-			return xerrors.Errorf("unmarshaling t.Amount: %w", err)
-		}
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajOther {
+		return fmt.Errorf("booleans must be major type 7")
+	}
+	switch extra {
+	case 20:
+		t.ApprovedByBeneficiary = false
+	case 21:
+		t.ApprovedByBeneficiary = true
+	default:
+		return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
+	}
+	// t.ApprovedByNominee (bool) (bool)
 
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajOther {
+		return fmt.Errorf("booleans must be major type 7")
+	}
+	switch extra {
+	case 20:
+		t.ApprovedByNominee = false
+	case 21:
+		t.ApprovedByNominee = true
+	default:
+		return fmt.Errorf("booleans are either major type 7, value 20 or 21 (got %d)", extra)
 	}
 	return nil
 }
 
-var lengthBufSubmitWindowedPoStParams = []byte{132}
+var lengthBufVestingFunds = []byte{129}
 
-func (t *SubmitWindowedPoStParams) MarshalCBOR(w io.Writer) error {
+func (t *VestingFunds) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufSubmitWindowedPoStParams); err != nil {
+	if _, err := w.Write(lengthBufVestingFunds); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.Deadline (uint64) (uint64)
-
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Deadline)); err != nil {
-		return err
+	// t.Funds ([]miner.VestingFund) (slice)
+	if len(t.Funds) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Funds was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Funds))); err != nil {
+		return err
+	}
+	for _, v := range t.Funds {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *VestingFunds) UnmarshalCBOR(r io.Reader) error {
+	*t = VestingFunds{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Funds ([]miner.VestingFund) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Funds: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Funds = make([]VestingFund, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v VestingFund
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Funds[i] = v
+	}
+
+	return nil
+}
+
+var lengthBufVestingFund = []byte{130}
+
+func (t *VestingFund) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufVestingFund); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Epoch (abi.ChainEpoch) (int64)
+	if t.Epoch >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Epoch)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.Epoch-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.Amount (big.Int) (struct)
+	if err := t.Amount.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *VestingFund) UnmarshalCBOR(r io.Reader) error {
+	*t = VestingFund{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Epoch (abi.ChainEpoch) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.Epoch = abi.ChainEpoch(extraI)
+	}
+	// t.Amount (big.Int) (struct)
+
+	{
+
+		if err := t.Amount.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Amount: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufSubmitWindowedPoStParams = []byte{132}
+
+func (t *SubmitWindowedPoStParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufSubmitWindowedPoStParams); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Deadline (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Deadline)); err != nil {
+		return err
 	}
 
 	// t.Partitions ([]miner.PoStPartition) (slice)
@@ -2863,42 +3257,41 @@ func (t *ProveCommitSectorParams) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufChangeWorkerAddressParams = []byte{130}
+var lengthBufProveCommitAggregateParams = []byte{130}
 
-func (t *ChangeWorkerAddressParams) MarshalCBOR(w io.Writer) error {
+func (t *ProveCommitAggregateParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufChangeWorkerAddressParams); err != nil {
+	if _, err := w.Write(lengthBufProveCommitAggregateParams); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.NewWorker (address.Address) (struct)
-	if err := t.NewWorker.MarshalCBOR(w); err != nil {
+	// t.SectorNumbers (bitfield.BitField) (struct)
+	if err := t.SectorNumbers.MarshalCBOR(w); err != nil {
 		return err
 	}
 
-	// t.NewControlAddrs ([]address.Address) (slice)
-	if len(t.NewControlAddrs) > cbg.MaxLength {
-		return xerrors.Errorf("Slice value in field t.NewControlAddrs was too long")
+	// t.AggregateProof ([]uint8) (slice)
+	if len(t.AggregateProof) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.AggregateProof was too long")
 	}
 
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.NewControlAddrs))); err != nil {
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.AggregateProof))); err != nil {
 		return err
 	}
-	for _, v := range t.NewControlAddrs {
-		if err := v.MarshalCBOR(w); err != nil {
-			return err
-		}
+
+	if _, err := w.Write(t.AggregateProof[:]); err != nil {
+		return err
 	}
 	return nil
 }
 
-func (t *ChangeWorkerAddressParams) UnmarshalCBOR(r io.Reader) error {
-	*t = ChangeWorkerAddressParams{}
+func (t *ProveCommitAggregateParams) UnmarshalCBOR(r io.Reader) error {
+	*t = ProveCommitAggregateParams{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -2915,69 +3308,61 @@ func (t *ChangeWorkerAddressParams) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.NewWorker (address.Address) (struct)
+	// t.SectorNumbers (bitfield.BitField) (struct)
 
 	{
 
-		if err := t.NewWorker.UnmarshalCBOR(br); err != nil {
-			return xerrors.Errorf("unmarshaling t.NewWorker: %w", err)
+		if err := t.SectorNumbers.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.SectorNumbers: %w", err)
 		}
 
 	}
-	// t.NewControlAddrs ([]address.Address) (slice)
+	// t.AggregateProof ([]uint8) (slice)
 
 	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
 	if err != nil {
 		return err
 	}
 
-	if extra > cbg.MaxLength {
-		return fmt.Errorf("t.NewControlAddrs: array too large (%d)", extra)
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.AggregateProof: byte array too large (%d)", extra)
 	}
-
-	if maj != cbg.MajArray {
-		return fmt.Errorf("expected cbor array")
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
 	}
 
 	if extra > 0 {
-		t.NewControlAddrs = make([]address.Address, extra)
+		t.AggregateProof = make([]uint8, extra)
 	}
 
-	for i := 0; i < int(extra); i++ {
-
-		var v address.Address
-		if err := v.UnmarshalCBOR(br); err != nil {
-			return err
-		}
-
-		t.NewControlAddrs[i] = v
+	if _, err := io.ReadFull(br, t.AggregateProof[:]); err != nil {
+		return err
 	}
-
 	return nil
 }
 
-var lengthBufExtendSectorExpirationParams = []byte{129}
+var lengthBufPreCommitSectorBatchParams = []byte{129}
 
-func (t *ExtendSectorExpirationParams) MarshalCBOR(w io.Writer) error {
+func (t *PreCommitSectorBatchParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufExtendSectorExpirationParams); err != nil {
+	if _, err := w.Write(lengthBufPreCommitSectorBatchParams); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.Extensions ([]miner.ExpirationExtension) (slice)
-	if len(t.Extensions) > cbg.MaxLength {
-		return xerrors.Errorf("Slice value in field t.Extensions was too long")
+	// t.Sectors ([]miner.SectorPreCommitInfo) (slice)
+	if len(t.Sectors) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Sectors was too long")
 	}
 
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Extensions))); err != nil {
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Sectors))); err != nil {
 		return err
 	}
-	for _, v := range t.Extensions {
+	for _, v := range t.Sectors {
 		if err := v.MarshalCBOR(w); err != nil {
 			return err
 		}
@@ -2985,8 +3370,8 @@ func (t *ExtendSectorExpirationParams) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *ExtendSectorExpirationParams) UnmarshalCBOR(r io.Reader) error {
-	*t = ExtendSectorExpirationParams{}
+func (t *PreCommitSectorBatchParams) UnmarshalCBOR(r io.Reader) error {
+	*t = PreCommitSectorBatchParams{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -3003,7 +3388,7 @@ func (t *ExtendSectorExpirationParams) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.Extensions ([]miner.ExpirationExtension) (slice)
+	// t.Sectors ([]miner.SectorPreCommitInfo) (slice)
 
 	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
 	if err != nil {
@@ -3011,7 +3396,7 @@ func (t *ExtendSectorExpirationParams) UnmarshalCBOR(r io.Reader) error {
 	}
 
 	if extra > cbg.MaxLength {
-		return fmt.Errorf("t.Extensions: array too large (%d)", extra)
+		return fmt.Errorf("t.Sectors: array too large (%d)", extra)
 	}
 
 	if maj != cbg.MajArray {
@@ -3019,44 +3404,49 @@ func (t *ExtendSectorExpirationParams) UnmarshalCBOR(r io.Reader) error {
 	}
 
 	if extra > 0 {
-		t.Extensions = make([]ExpirationExtension, extra)
+		t.Sectors = make([]SectorPreCommitInfo, extra)
 	}
 
 	for i := 0; i < int(extra); i++ {
 
-		var v ExpirationExtension
+		var v SectorPreCommitInfo
 		if err := v.UnmarshalCBOR(br); err != nil {
 			return err
 		}
 
-		t.Extensions[i] = v
+		t.Sectors[i] = v
 	}
 
 	return nil
 }
 
-var lengthBufDeclareFaultsParams = []byte{129}
+var lengthBufChangeWorkerAddressParams = []byte{130}
 
-func (t *DeclareFaultsParams) MarshalCBOR(w io.Writer) error {
+func (t *ChangeWorkerAddressParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufDeclareFaultsParams); err != nil {
+	if _, err := w.Write(lengthBufChangeWorkerAddressParams); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.Faults ([]miner.FaultDeclaration) (slice)
-	if len(t.Faults) > cbg.MaxLength {
-		return xerrors.Errorf("Slice value in field t.Faults was too long")
+	// t.NewWorker (address.Address) (struct)
+	if err := t.NewWorker.MarshalCBOR(w); err != nil {
+		return err
 	}
 
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Faults))); err != nil {
+	// t.NewControlAddrs ([]address.Address) (slice)
+	if len(t.NewControlAddrs) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.NewControlAddrs was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.NewControlAddrs))); err != nil {
 		return err
 	}
-	for _, v := range t.Faults {
+	for _, v := range t.NewControlAddrs {
 		if err := v.MarshalCBOR(w); err != nil {
 			return err
 		}
@@ -3064,8 +3454,8 @@ func (t *DeclareFaultsParams) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
-func (t *DeclareFaultsParams) UnmarshalCBOR(r io.Reader) error {
-	*t = DeclareFaultsParams{}
+func (t *ChangeWorkerAddressParams) UnmarshalCBOR(r io.Reader) error {
+	*t = ChangeWorkerAddressParams{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -3078,16 +3468,226 @@ func (t *DeclareFaultsParams) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 1 {
+	if extra != 2 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.Faults ([]miner.FaultDeclaration) (slice)
+	// t.NewWorker (address.Address) (struct)
 
-	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
-	if err != nil {
-		return err
-	}
+	{
+
+		if err := t.NewWorker.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.NewWorker: %w", err)
+		}
+
+	}
+	// t.NewControlAddrs ([]address.Address) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.NewControlAddrs: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.NewControlAddrs = make([]address.Address, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v address.Address
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.NewControlAddrs[i] = v
+	}
+
+	return nil
+}
+
+var lengthBufExtendSectorExpirationParams = []byte{130}
+
+func (t *ExtendSectorExpirationParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufExtendSectorExpirationParams); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Extensions ([]miner.ExpirationExtension) (slice)
+	if len(t.Extensions) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Extensions was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Extensions))); err != nil {
+		return err
+	}
+	for _, v := range t.Extensions {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+
+	// t.DealExtensions ([]market.ClientDealExtension) (slice)
+	if len(t.DealExtensions) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.DealExtensions was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.DealExtensions))); err != nil {
+		return err
+	}
+	for _, v := range t.DealExtensions {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ExtendSectorExpirationParams) UnmarshalCBOR(r io.Reader) error {
+	*t = ExtendSectorExpirationParams{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Extensions ([]miner.ExpirationExtension) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Extensions: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Extensions = make([]ExpirationExtension, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v ExpirationExtension
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Extensions[i] = v
+	}
+
+	// t.DealExtensions ([]market.ClientDealExtension) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.DealExtensions: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.DealExtensions = make([]market.ClientDealExtension, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v market.ClientDealExtension
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.DealExtensions[i] = v
+	}
+
+	return nil
+}
+
+var lengthBufDeclareFaultsParams = []byte{129}
+
+func (t *DeclareFaultsParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufDeclareFaultsParams); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Faults ([]miner.FaultDeclaration) (slice)
+	if len(t.Faults) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Faults was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Faults))); err != nil {
+		return err
+	}
+	for _, v := range t.Faults {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *DeclareFaultsParams) UnmarshalCBOR(r io.Reader) error {
+	*t = DeclareFaultsParams{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Faults ([]miner.FaultDeclaration) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
 
 	if extra > cbg.MaxLength {
 		return fmt.Errorf("t.Faults: array too large (%d)", extra)
@@ -3661,34 +4261,44 @@ func (t *CompactSectorNumbersParams) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufCronEventPayload = []byte{129}
+var lengthBufChangeBeneficiaryParams = []byte{131}
 
-func (t *CronEventPayload) MarshalCBOR(w io.Writer) error {
+func (t *ChangeBeneficiaryParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufCronEventPayload); err != nil {
+	if _, err := w.Write(lengthBufChangeBeneficiaryParams); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
-	// t.EventType (miner.CronEventType) (int64)
-	if t.EventType >= 0 {
-		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.EventType)); err != nil {
+	// t.NewBeneficiary (address.Address) (struct)
+	if err := t.NewBeneficiary.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.NewQuota (big.Int) (struct)
+	if err := t.NewQuota.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.NewExpiration (abi.ChainEpoch) (int64)
+	if t.NewExpiration >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.NewExpiration)); err != nil {
 			return err
 		}
 	} else {
-		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.EventType-1)); err != nil {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.NewExpiration-1)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (t *CronEventPayload) UnmarshalCBOR(r io.Reader) error {
-	*t = CronEventPayload{}
+func (t *ChangeBeneficiaryParams) UnmarshalCBOR(r io.Reader) error {
+	*t = ChangeBeneficiaryParams{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -3701,11 +4311,29 @@ func (t *CronEventPayload) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 1 {
+	if extra != 3 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
-	// t.EventType (miner.CronEventType) (int64)
+	// t.NewBeneficiary (address.Address) (struct)
+
+	{
+
+		if err := t.NewBeneficiary.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.NewBeneficiary: %w", err)
+		}
+
+	}
+	// t.NewQuota (big.Int) (struct)
+
+	{
+
+		if err := t.NewQuota.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.NewQuota: %w", err)
+		}
+
+	}
+	// t.NewExpiration (abi.ChainEpoch) (int64)
 	{
 		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
 		var extraI int64
@@ -3728,19 +4356,19 @@ func (t *CronEventPayload) UnmarshalCBOR(r io.Reader) error {
 			return fmt.Errorf("wrong type for int64 field: %d", maj)
 		}
 
-		t.EventType = CronEventType(extraI)
+		t.NewExpiration = abi.ChainEpoch(extraI)
 	}
 	return nil
 }
 
-var lengthBufFaultDeclaration = []byte{131}
+var lengthBufDisputeWindowedPoStParams = []byte{130}
 
-func (t *FaultDeclaration) MarshalCBOR(w io.Writer) error {
+func (t *DisputeWindowedPoStParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufFaultDeclaration); err != nil {
+	if _, err := w.Write(lengthBufDisputeWindowedPoStParams); err != nil {
 		return err
 	}
 
@@ -3752,21 +4380,17 @@ func (t *FaultDeclaration) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.Partition (uint64) (uint64)
+	// t.PoStIndex (uint64) (uint64)
 
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Partition)); err != nil {
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.PoStIndex)); err != nil {
 		return err
 	}
 
-	// t.Sectors (bitfield.BitField) (struct)
-	if err := t.Sectors.MarshalCBOR(w); err != nil {
-		return err
-	}
 	return nil
 }
 
-func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
-	*t = FaultDeclaration{}
+func (t *DisputeWindowedPoStParams) UnmarshalCBOR(r io.Reader) error {
+	*t = DisputeWindowedPoStParams{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -3779,7 +4403,7 @@ func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 3 {
+	if extra != 2 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -3797,7 +4421,7 @@ func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
 		t.Deadline = uint64(extra)
 
 	}
-	// t.Partition (uint64) (uint64)
+	// t.PoStIndex (uint64) (uint64)
 
 	{
 
@@ -3808,10 +4432,394 @@ func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
 		if maj != cbg.MajUnsignedInt {
 			return fmt.Errorf("wrong type for uint64 field")
 		}
-		t.Partition = uint64(extra)
+		t.PoStIndex = uint64(extra)
 
 	}
-	// t.Sectors (bitfield.BitField) (struct)
+	return nil
+}
+
+var lengthBufProveReplicaUpdatesParams = []byte{129}
+
+func (t *ProveReplicaUpdatesParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufProveReplicaUpdatesParams); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Updates ([]miner.ReplicaUpdate) (slice)
+	if len(t.Updates) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Updates was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Updates))); err != nil {
+		return err
+	}
+	for _, v := range t.Updates {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ProveReplicaUpdatesParams) UnmarshalCBOR(r io.Reader) error {
+	*t = ProveReplicaUpdatesParams{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Updates ([]miner.ReplicaUpdate) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Updates: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Updates = make([]ReplicaUpdate, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v ReplicaUpdate
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Updates[i] = v
+	}
+
+	return nil
+}
+
+var lengthBufRepayDebtReturn = []byte{130}
+
+func (t *RepayDebtReturn) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRepayDebtReturn); err != nil {
+		return err
+	}
+
+	// t.FromVesting (big.Int) (struct)
+	if err := t.FromVesting.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.FromBalance (big.Int) (struct)
+	if err := t.FromBalance.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RepayDebtReturn) UnmarshalCBOR(r io.Reader) error {
+	*t = RepayDebtReturn{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.FromVesting (big.Int) (struct)
+
+	{
+
+		if err := t.FromVesting.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.FromVesting: %w", err)
+		}
+
+	}
+	// t.FromBalance (big.Int) (struct)
+
+	{
+
+		if err := t.FromBalance.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.FromBalance: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufCronEventPayload = []byte{129}
+
+func (t *CronEventPayload) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufCronEventPayload); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.EventType (miner.CronEventType) (int64)
+	if t.EventType >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.EventType)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.EventType-1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *CronEventPayload) UnmarshalCBOR(r io.Reader) error {
+	*t = CronEventPayload{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.EventType (miner.CronEventType) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.EventType = CronEventType(extraI)
+	}
+	return nil
+}
+
+var lengthBufFaultDeclaration = []byte{131}
+
+func (t *FaultDeclaration) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufFaultDeclaration); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Deadline (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Deadline)); err != nil {
+		return err
+	}
+
+	// t.Partition (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Partition)); err != nil {
+		return err
+	}
+
+	// t.Sectors (bitfield.BitField) (struct)
+	if err := t.Sectors.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
+	*t = FaultDeclaration{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Deadline (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Deadline = uint64(extra)
+
+	}
+	// t.Partition (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Partition = uint64(extra)
+
+	}
+	// t.Sectors (bitfield.BitField) (struct)
+
+	{
+
+		if err := t.Sectors.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Sectors: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufRecoveryDeclaration = []byte{131}
+
+func (t *RecoveryDeclaration) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRecoveryDeclaration); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Deadline (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Deadline)); err != nil {
+		return err
+	}
+
+	// t.Partition (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Partition)); err != nil {
+		return err
+	}
+
+	// t.Sectors (bitfield.BitField) (struct)
+	if err := t.Sectors.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RecoveryDeclaration) UnmarshalCBOR(r io.Reader) error {
+	*t = RecoveryDeclaration{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Deadline (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Deadline = uint64(extra)
+
+	}
+	// t.Partition (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.Partition = uint64(extra)
+
+	}
+	// t.Sectors (bitfield.BitField) (struct)
 
 	{
 
@@ -3823,19 +4831,25 @@ func (t *FaultDeclaration) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufRecoveryDeclaration = []byte{131}
+var lengthBufReplicaUpdate = []byte{135}
 
-func (t *RecoveryDeclaration) MarshalCBOR(w io.Writer) error {
+func (t *ReplicaUpdate) MarshalCBOR(w io.Writer) error {
 	if t == nil {
 		_, err := w.Write(cbg.CborNull)
 		return err
 	}
-	if _, err := w.Write(lengthBufRecoveryDeclaration); err != nil {
+	if _, err := w.Write(lengthBufReplicaUpdate); err != nil {
 		return err
 	}
 
 	scratch := make([]byte, 9)
 
+	// t.SectorID (abi.SectorNumber) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.SectorID)); err != nil {
+		return err
+	}
+
 	// t.Deadline (uint64) (uint64)
 
 	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Deadline)); err != nil {
@@ -3848,15 +4862,54 @@ func (t *RecoveryDeclaration) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
-	// t.Sectors (bitfield.BitField) (struct)
-	if err := t.Sectors.MarshalCBOR(w); err != nil {
+	// t.NewSealedSectorCID (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.NewSealedSectorCID); err != nil {
+		return xerrors.Errorf("failed to write cid field t.NewSealedSectorCID: %w", err)
+	}
+
+	// t.Deals ([]abi.DealID) (slice)
+	if len(t.Deals) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Deals was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Deals))); err != nil {
+		return err
+	}
+	for _, v := range t.Deals {
+		if err := cbg.CborWriteHeader(w, cbg.MajUnsignedInt, uint64(v)); err != nil {
+			return err
+		}
+	}
+
+	// t.UpdateProofType (abi.RegisteredUpdateProof) (int64)
+	if t.UpdateProofType >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.UpdateProofType)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.UpdateProofType-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.ReplicaProof ([]uint8) (slice)
+	if len(t.ReplicaProof) > cbg.ByteArrayMaxLen {
+		return xerrors.Errorf("Byte array in field t.ReplicaProof was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajByteString, uint64(len(t.ReplicaProof))); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(t.ReplicaProof[:]); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *RecoveryDeclaration) UnmarshalCBOR(r io.Reader) error {
-	*t = RecoveryDeclaration{}
+func (t *ReplicaUpdate) UnmarshalCBOR(r io.Reader) error {
+	*t = ReplicaUpdate{}
 
 	br := cbg.GetPeeker(r)
 	scratch := make([]byte, 8)
@@ -3869,10 +4922,24 @@ func (t *RecoveryDeclaration) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 3 {
+	if extra != 7 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
+	// t.SectorID (abi.SectorNumber) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.SectorID = abi.SectorNumber(extra)
+
+	}
 	// t.Deadline (uint64) (uint64)
 
 	{
@@ -3901,14 +4968,96 @@ func (t *RecoveryDeclaration) UnmarshalCBOR(r io.Reader) error {
 		t.Partition = uint64(extra)
 
 	}
-	// t.Sectors (bitfield.BitField) (struct)
+	// t.NewSealedSectorCID (cid.Cid) (struct)
 
 	{
 
-		if err := t.Sectors.UnmarshalCBOR(br); err != nil {
-			return xerrors.Errorf("unmarshaling t.Sectors: %w", err)
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.NewSealedSectorCID: %w", err)
+		}
+
+		t.NewSealedSectorCID = c
+
+	}
+	// t.Deals ([]abi.DealID) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Deals: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Deals = make([]abi.DealID, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		maj, val, err := cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return xerrors.Errorf("failed to read uint64 for t.Deals slice: %w", err)
+		}
+
+		if maj != cbg.MajUnsignedInt {
+			return xerrors.Errorf("value read for array t.Deals was not a uint, instead got %d", maj)
+		}
+
+		t.Deals[i] = abi.DealID(val)
+	}
+
+	// t.UpdateProofType (abi.RegisteredUpdateProof) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
 		}
 
+		t.UpdateProofType = abi.RegisteredUpdateProof(extraI)
+	}
+	// t.ReplicaProof ([]uint8) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("t.ReplicaProof: byte array too large (%d)", extra)
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+
+	if extra > 0 {
+		t.ReplicaProof = make([]uint8, extra)
+	}
+
+	if _, err := io.ReadFull(br, t.ReplicaProof[:]); err != nil {
+		return err
 	}
 	return nil
 }
@@ -4198,3 +5347,96 @@ func (t *PoStPartition) UnmarshalCBOR(r io.Reader) error {
 	}
 	return nil
 }
+
+var lengthBufWindowedPoSt = []byte{130}
+
+func (t *WindowedPoSt) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufWindowedPoSt); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Partitions (bitfield.BitField) (struct)
+	if err := t.Partitions.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Proofs ([]abi.PoStProof) (slice)
+	if len(t.Proofs) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Proofs was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Proofs))); err != nil {
+		return err
+	}
+	for _, v := range t.Proofs {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *WindowedPoSt) UnmarshalCBOR(r io.Reader) error {
+	*t = WindowedPoSt{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Partitions (bitfield.BitField) (struct)
+
+	{
+
+		if err := t.Partitions.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Partitions: %w", err)
+		}
+
+	}
+	// t.Proofs ([]abi.PoStProof) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Proofs: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Proofs = make([]abi.PoStProof, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v abi.PoStProof
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Proofs[i] = v
+	}
+
+	return nil
+}
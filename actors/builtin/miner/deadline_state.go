@@ -54,6 +54,23 @@ type Deadline struct {
 
 	// Memoized sum of faulty power in partitions.
 	FaultyPower PowerPair
+
+	// Proofs accepted without verification during the current challenge window, in order of submission.
+	OptimisticPoStSubmissions cid.Cid // AMT[]WindowedPoSt
+
+	// Snapshots of the partitions, sectors and optimistically accepted proofs as of the close of this
+	// deadline's last challenge window. The proofs may be disputed against these during the dispute window.
+	PartitionsSnapshot                cid.Cid // AMT[PartitionNumber]Partition
+	SectorsSnapshot                   cid.Cid // Array, AMT[SectorNumber]SectorOnChainInfo (sparse)
+	OptimisticPoStSubmissionsSnapshot cid.Cid // AMT[]WindowedPoSt
+}
+
+// A Window PoSt accepted without verification, retained so that it may be disputed.
+type WindowedPoSt struct {
+	// Partitions newly proven by the submission.
+	Partitions bitfield.BitField
+	// Proofs as submitted.
+	Proofs []abi.PoStProof
 }
 
 //
@@ -119,6 +136,11 @@ func ConstructDeadline(emptyArrayCid cid.Cid) *Deadline {
 		LiveSectors:       0,
 		TotalSectors:      0,
 		FaultyPower:       NewPowerPairZero(),
+
+		OptimisticPoStSubmissions:         emptyArrayCid,
+		PartitionsSnapshot:                emptyArrayCid,
+		SectorsSnapshot:                   emptyArrayCid,
+		OptimisticPoStSubmissionsSnapshot: emptyArrayCid,
 	}
 }
 
@@ -855,6 +877,8 @@ type PoStResult struct {
 	PowerDelta PowerPair
 	// Powers used for calculating penalties.
 	NewFaultyPower, RetractedRecoveryPower, RecoveredPower PowerPair
+	// Partitions is a bitfield of the partitions newly proven.
+	Partitions bitfield.BitField
 	// Sectors is a bitfield of all sectors in the proven partitions.
 	Sectors bitfield.BitField
 	// IgnoredSectors is a subset of Sectors that should be ignored.
@@ -886,6 +910,7 @@ func (dl *Deadline) RecordProvenSectors(
 		return nil, err
 	}
 
+	provenPartitions := bitfield.New()
 	allSectors := make([]bitfield.BitField, 0, len(postPartitions))
 	allIgnored := make([]bitfield.BitField, 0, len(postPartitions))
 	newFaultyPowerTotal := NewPowerPairZero()
@@ -949,6 +974,7 @@ func (dl *Deadline) RecordProvenSectors(
 
 		// Record the post.
 		dl.PostSubmissions.Set(post.Index)
+		provenPartitions.Set(post.Index)
 
 		// At this point, the partition faults represents the expected faults for the proof, with new skipped
 		// faults and recoveries taken into account.
@@ -981,6 +1007,7 @@ func (dl *Deadline) RecordProvenSectors(
 	}
 
 	return &PoStResult{
+		Partitions:             provenPartitions,
 		Sectors:                allSectorNos,
 		IgnoredSectors:         allIgnoredSectorNos,
 		PowerDelta:             powerDelta,
@@ -990,6 +1017,127 @@ func (dl *Deadline) RecordProvenSectors(
 	}, nil
 }
 
+// RecordPoStProofs records a proof accepted without verification, so that it may be disputed after the
+// challenge window closes.
+func (dl *Deadline) RecordPoStProofs(store adt.Store, partitions bitfield.BitField, proofs []abi.PoStProof) error {
+	proofArr, err := adt.AsArray(store, dl.OptimisticPoStSubmissions)
+	if err != nil {
+		return xc.ErrIllegalState.Wrapf("failed to load proofs: %w", err)
+	}
+	err = proofArr.AppendContinuous(&WindowedPoSt{
+		Partitions: partitions,
+		Proofs:     proofs,
+	})
+	if err != nil {
+		return xc.ErrIllegalState.Wrapf("failed to store proof: %w", err)
+	}
+	dl.OptimisticPoStSubmissions, err = proofArr.Root()
+	if err != nil {
+		return xc.ErrIllegalState.Wrapf("failed to save proofs: %w", err)
+	}
+	return nil
+}
+
+// TakePoStProofs removes and returns a proof from the snapshot of optimistically accepted proofs, so that it
+// may be disputed at most once.
+func (dl *Deadline) TakePoStProofs(store adt.Store, idx uint64) (*WindowedPoSt, error) {
+	proofArr, err := adt.AsArray(store, dl.OptimisticPoStSubmissionsSnapshot)
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to load proofs: %w", err)
+	}
+	var post WindowedPoSt
+	found, err := proofArr.Get(idx, &post)
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to load proof %d: %w", idx, err)
+	} else if !found {
+		return nil, xc.ErrNotFound.Wrapf("no proof %d to dispute", idx)
+	}
+	if err = proofArr.Delete(idx); err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to delete proof %d: %w", idx, err)
+	}
+	dl.OptimisticPoStSubmissionsSnapshot, err = proofArr.Root()
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to save proofs: %w", err)
+	}
+	return &post, nil
+}
+
+// Snapshot records the deadline's partitions, the miner's sectors and the proofs accepted without
+// verification at the close of a challenge window, and begins a new collection of proofs.
+func (dl *Deadline) Snapshot(store adt.Store, sectors cid.Cid) error {
+	emptyArray, err := adt.MakeEmptyArray(store).Root()
+	if err != nil {
+		return xerrors.Errorf("failed to construct empty proofs array: %w", err)
+	}
+	dl.PartitionsSnapshot = dl.Partitions
+	dl.SectorsSnapshot = sectors
+	dl.OptimisticPoStSubmissionsSnapshot = dl.OptimisticPoStSubmissions
+	dl.OptimisticPoStSubmissions = emptyArray
+	return nil
+}
+
+type DisputeInfo struct {
+	// All sectors in the disputed partitions, and the subset ignored by the proof, as of the snapshot.
+	AllSectorNos, IgnoredSectorNos bitfield.BitField
+	// Sectors to be marked faulty if the dispute succeeds, by partition.
+	DisputedSectors PartitionSectorMap
+	// Power of the sectors active in the disputed partitions, as of the snapshot.
+	DisputedPower PowerPair
+}
+
+// LoadPartitionsForDispute loads the sectors proven by a disputed proof from the partitions snapshot.
+func (dl *Deadline) LoadPartitionsForDispute(store adt.Store, partitions bitfield.BitField) (*DisputeInfo, error) {
+	partitionsSnapshot, err := adt.AsArray(store, dl.PartitionsSnapshot)
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to load partitions snapshot: %w", err)
+	}
+
+	var allSectors, allIgnored []bitfield.BitField
+	disputedSectors := make(PartitionSectorMap)
+	disputedPower := NewPowerPairZero()
+	err = partitions.ForEach(func(partIdx uint64) error {
+		var partition Partition
+		found, err := partitionsSnapshot.Get(partIdx, &partition)
+		if err != nil {
+			return xc.ErrIllegalState.Wrapf("failed to load partition %d: %w", partIdx, err)
+		} else if !found {
+			return xc.ErrIllegalState.Wrapf("no partition %d in snapshot", partIdx)
+		}
+
+		allSectors = append(allSectors, partition.Sectors)
+		allIgnored = append(allIgnored, partition.Faults, partition.Terminated)
+
+		active, err := partition.ActiveSectors()
+		if err != nil {
+			return xc.ErrIllegalState.Wrapf("failed to compute active sectors of partition %d: %w", partIdx, err)
+		}
+		if err = disputedSectors.Add(partIdx, active); err != nil {
+			return xc.ErrIllegalState.Wrapf("failed to record disputed sectors of partition %d: %w", partIdx, err)
+		}
+		disputedPower = disputedPower.Add(partition.ActivePower())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allSectorNos, err := bitfield.MultiMerge(allSectors...)
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to merge sector bitfields: %w", err)
+	}
+	allIgnoredSectorNos, err := bitfield.MultiMerge(allIgnored...)
+	if err != nil {
+		return nil, xc.ErrIllegalState.Wrapf("failed to merge ignored sector bitfields: %w", err)
+	}
+
+	return &DisputeInfo{
+		AllSectorNos:     allSectorNos,
+		IgnoredSectorNos: allIgnoredSectorNos,
+		DisputedSectors:  disputedSectors,
+		DisputedPower:    disputedPower,
+	}, nil
+}
+
 // RescheduleSectorExpirations reschedules the expirations of the given sectors
 // to the target epoch, skipping any sectors it can't find.
 //
@@ -157,3 +157,17 @@ func deadlineIsMutable(provingPeriodStart abi.ChainEpoch, dlIdx uint64, currentE
 	// that deadline opens.
 	return currentEpoch < dlInfo.Open-WPoStChallengeWindow
 }
+
+// Returns the most recently closed occurrence of the deadline at the given index, and whether the current epoch
+// is within its dispute window, during which proofs accepted without verification may be disputed.
+func deadlineAvailableForOptimisticPoStDispute(provingPeriodStart abi.ChainEpoch, dlIdx uint64, currentEpoch abi.ChainEpoch) (*DeadlineInfo, bool) {
+	if provingPeriodStart > currentEpoch {
+		// No deadline has closed yet.
+		return nil, false
+	}
+	dlInfo := NewDeadlineInfo(provingPeriodStart, dlIdx, currentEpoch)
+	if !dlInfo.HasElapsed() {
+		dlInfo = NewDeadlineInfo(provingPeriodStart-WPoStProvingPeriod, dlIdx, currentEpoch)
+	}
+	return dlInfo, dlInfo.HasElapsed() && currentEpoch < dlInfo.Close+WPoStDisputeWindow
+}
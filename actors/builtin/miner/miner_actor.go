@@ -31,6 +31,8 @@ type Runtime = vmr.Runtime
 type CronEventType int64
 
 const (
+	// Deprecated: pending worker key changes are committed with ConfirmChangeWorkerAddress.
+	// Events enrolled before then are still handled.
 	CronEventWorkerKeyChange CronEventType = iota
 	CronEventProvingDeadline
 	CronEventProcessEarlyTerminations
@@ -70,6 +72,14 @@ func (a Actor) Exports() []interface{} {
 		18:                        a.ChangeMultiaddrs,
 		19:                        a.CompactPartitions,
 		20:                        a.CompactSectorNumbers,
+		21:                        a.ChangeOwnerAddress,
+		22:                        a.ProveCommitAggregate,
+		23:                        a.PreCommitSectorBatch,
+		24:                        a.ChangeBeneficiary,
+		25:                        a.DisputeWindowedPoSt,
+		26:                        a.ProveReplicaUpdates,
+		27:                        a.RepayDebt,
+		28:                        a.ConfirmChangeWorkerAddress,
 	}
 }
 
@@ -173,12 +183,13 @@ type ChangeWorkerAddressParams struct {
 
 // ChangeWorkerAddress will ALWAYS overwrite the existing control addresses with the control addresses passed in the params.
 // If a nil addresses slice is passed, the control addresses will be cleared.
-// A worker change will be scheduled if the worker passed in the params is different from the existing worker.
+// A worker change will be scheduled if the worker passed in the params is different from the existing worker,
+// and must be confirmed with ConfirmChangeWorkerAddress once it becomes effective.
+// Requesting the change already pending leaves its effective epoch unchanged, while requesting a different worker
+// supersedes it. If the worker passed in the params is the existing worker, any pending change is cancelled.
 func (a Actor) ChangeWorkerAddress(rt Runtime, params *ChangeWorkerAddressParams) *adt.EmptyValue {
 	checkControlAddresses(rt, params.NewControlAddrs)
 
-	var effectiveEpoch abi.ChainEpoch
-
 	newWorker := resolveWorkerAddress(rt, params.NewWorker)
 
 	var controlAddrs []addr.Address
@@ -188,7 +199,6 @@ func (a Actor) ChangeWorkerAddress(rt Runtime, params *ChangeWorkerAddressParams
 	}
 
 	var st State
-	isWorkerChange := false
 	rt.State().Transaction(&st, func() {
 		info := getMinerInfo(rt, &st)
 
@@ -202,14 +212,14 @@ func (a Actor) ChangeWorkerAddress(rt Runtime, params *ChangeWorkerAddressParams
 
 		{
 			// save newWorker addr key change request
-			// This may replace another pending key change.
-			if newWorker != info.Worker {
-				isWorkerChange = true
-				effectiveEpoch = rt.CurrEpoch() + WorkerKeyChangeDelay
-
+			if newWorker == info.Worker {
+				// Cancel any pending key change.
+				info.PendingWorkerKey = nil
+			} else if info.PendingWorkerKey == nil || info.PendingWorkerKey.NewWorker != newWorker {
+				// This may replace another pending key change.
 				info.PendingWorkerKey = &WorkerKeyChange{
 					NewWorker:   newWorker,
-					EffectiveAt: effectiveEpoch,
+					EffectiveAt: rt.CurrEpoch() + WorkerKeyChangeDelay,
 				}
 			}
 		}
@@ -218,15 +228,164 @@ func (a Actor) ChangeWorkerAddress(rt Runtime, params *ChangeWorkerAddressParams
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "could not save miner info")
 	})
 
-	// we only need to enroll the cron event for newWorker key change as we change the control
-	// addresses immediately
-	if isWorkerChange {
-		cronPayload := CronEventPayload{
-			EventType: CronEventWorkerKeyChange,
+	return nil
+}
+
+// Commits a pending worker key change once its effective epoch has been reached.
+// Only the owner may confirm the change.
+func (a Actor) ConfirmChangeWorkerAddress(rt Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
+	var st State
+	rt.State().Transaction(&st, func() {
+		info := getMinerInfo(rt, &st)
+		rt.ValidateImmediateCallerIs(info.Owner)
+
+		if info.PendingWorkerKey == nil {
+			rt.Abortf(exitcode.ErrIllegalState, "no pending worker key change")
 		}
-		enrollCronEvent(rt, effectiveEpoch, &cronPayload)
+		if info.PendingWorkerKey.EffectiveAt > rt.CurrEpoch() {
+			rt.Abortf(exitcode.ErrForbidden, "too early to confirm worker key change, effective at %d, now %d",
+				info.PendingWorkerKey.EffectiveAt, rt.CurrEpoch())
+		}
+
+		info.Worker = info.PendingWorkerKey.NewWorker
+		info.PendingWorkerKey = nil
+		err := st.SaveInfo(adt.AsStore(rt), info)
+		builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to save miner info")
+	})
+	return nil
+}
+
+// Proposes or confirms a change of owner address.
+// If invoked by the current owner, proposes a new owner address for confirmation. If the proposed address is the
+// current owner address, revokes any existing proposal.
+// If invoked by the previously proposed address, with the same proposal, changes the current owner address to be
+// that proposed address.
+func (a Actor) ChangeOwnerAddress(rt Runtime, newAddress *addr.Address) *adt.EmptyValue {
+	if newAddress.Empty() {
+		rt.Abortf(exitcode.ErrIllegalArgument, "empty address")
 	}
+	if newAddress.Protocol() != addr.ID {
+		rt.Abortf(exitcode.ErrIllegalArgument, "owner address must be an ID address")
+	}
+
+	var st State
+	rt.State().Transaction(&st, func() {
+		info := getMinerInfo(rt, &st)
+		if rt.Message().Caller() == info.Owner || info.PendingOwnerAddress == nil {
+			// Propose new address.
+			rt.ValidateImmediateCallerIs(info.Owner)
+			info.PendingOwnerAddress = newAddress
+		} else {
+			// Confirm the proposal.
+			// This validates that the operator can in fact use the proposed new address to sign messages.
+			rt.ValidateImmediateCallerIs(*info.PendingOwnerAddress)
+			if *newAddress != *info.PendingOwnerAddress {
+				rt.Abortf(exitcode.ErrIllegalArgument, "expected confirmation of %v, got %v",
+					info.PendingOwnerAddress, newAddress)
+			}
+			// A beneficiary that was the previous owner follows the owner, and a beneficiary change proposed
+			// by the previous owner is dropped.
+			if info.Beneficiary == info.Owner {
+				info.Beneficiary = *info.PendingOwnerAddress
+			}
+			info.PendingBeneficiaryTerm = nil
+			info.Owner = *info.PendingOwnerAddress
+			if info.Beneficiary == info.Owner {
+				info.BeneficiaryTerm = BeneficiaryTerm{Quota: big.Zero(), UsedQuota: big.Zero(), Expiration: 0}
+			}
+		}
+
+		// Clear any resulting no-op change.
+		if info.PendingOwnerAddress != nil && *info.PendingOwnerAddress == info.Owner {
+			info.PendingOwnerAddress = nil
+		}
+
+		err := st.SaveInfo(adt.AsStore(rt), info)
+		builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to save miner info")
+	})
+	return nil
+}
+
+type ChangeBeneficiaryParams struct {
+	NewBeneficiary addr.Address
+	NewQuota       abi.TokenAmount
+	NewExpiration  abi.ChainEpoch
+}
+
+// Proposes or approves a change of the beneficiary to which withdrawn balance is paid.
+// If invoked by the owner, proposes a new beneficiary with a quota and expiration, replacing any existing proposal.
+// A beneficiary other than the owner must have a positive quota and an expiration not in the past, while a proposal
+// to return the beneficiary to the owner must have zero quota and expiration.
+// If invoked by the current or the nominated beneficiary, with the same parameters as the pending proposal,
+// approves the proposal on that party's behalf.
+// The current beneficiary's approval is not required if it is the owner or its term is spent or expired, nor the
+// nominee's if it is the owner. Once approved by both, the new beneficiary takes effect with a fresh term.
+func (a Actor) ChangeBeneficiary(rt Runtime, params *ChangeBeneficiaryParams) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	caller := rt.Message().Caller()
+	newBeneficiary := resolveControlAddress(rt, params.NewBeneficiary)
+
+	var st State
+	rt.State().Transaction(&st, func() {
+		info := getMinerInfo(rt, &st)
+		if caller == info.Owner {
+			// Propose a new beneficiary.
+			if newBeneficiary == info.Owner {
+				if !params.NewQuota.IsZero() || params.NewExpiration != 0 {
+					rt.Abortf(exitcode.ErrIllegalArgument, "owner as beneficiary must have zero quota and expiration")
+				}
+			} else {
+				if params.NewQuota.LessThanEqual(big.Zero()) {
+					rt.Abortf(exitcode.ErrIllegalArgument, "beneficiary quota %v must be positive", params.NewQuota)
+				}
+				if params.NewExpiration < rt.CurrEpoch() {
+					rt.Abortf(exitcode.ErrIllegalArgument, "beneficiary expiration %d is before current epoch %d",
+						params.NewExpiration, rt.CurrEpoch())
+				}
+			}
+
+			info.PendingBeneficiaryTerm = &PendingBeneficiaryChange{
+				NewBeneficiary:        newBeneficiary,
+				NewQuota:              params.NewQuota,
+				NewExpiration:         params.NewExpiration,
+				ApprovedByBeneficiary: info.Beneficiary == info.Owner || info.BeneficiaryTerm.Available(rt.CurrEpoch()).LessThanEqual(big.Zero()),
+				ApprovedByNominee:     newBeneficiary == info.Owner,
+			}
+		} else {
+			// Approve the pending proposal.
+			pending := info.PendingBeneficiaryTerm
+			if pending == nil {
+				rt.Abortf(exitcode.ErrForbidden, "no pending beneficiary change to approve")
+			}
+			if caller != info.Beneficiary && caller != pending.NewBeneficiary {
+				rt.Abortf(exitcode.ErrForbidden, "caller %v is not the owner, beneficiary or nominated beneficiary", caller)
+			}
+			if newBeneficiary != pending.NewBeneficiary || !params.NewQuota.Equals(pending.NewQuota) ||
+				params.NewExpiration != pending.NewExpiration {
+				rt.Abortf(exitcode.ErrIllegalArgument, "approval does not match pending beneficiary change to %v",
+					pending.NewBeneficiary)
+			}
+			if caller == info.Beneficiary {
+				pending.ApprovedByBeneficiary = true
+			}
+			if caller == pending.NewBeneficiary {
+				pending.ApprovedByNominee = true
+			}
+		}
 
+		if pending := info.PendingBeneficiaryTerm; pending.ApprovedByBeneficiary && pending.ApprovedByNominee {
+			info.Beneficiary = pending.NewBeneficiary
+			info.BeneficiaryTerm = BeneficiaryTerm{
+				Quota:      pending.NewQuota,
+				UsedQuota:  big.Zero(),
+				Expiration: pending.NewExpiration,
+			}
+			info.PendingBeneficiaryTerm = nil
+		}
+
+		err := st.SaveInfo(adt.AsStore(rt), info)
+		builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to save miner info")
+	})
 	return nil
 }
 
@@ -373,7 +532,8 @@ func (a Actor) SubmitWindowedPoSt(rt Runtime, params *SubmitWindowedPoStParams)
 		// proven/skipped.
 		//
 		// NOTE: This function does not actually check the proofs but does assume that they'll be
-		// successfully validated. The actual proof verification is done below in verifyWindowedPost.
+		// successfully validated. The actual proof verification is done below in verifyWindowedPost,
+		// or later by a dispute of a proof accepted optimistically.
 		//
 		// If proof verification fails, the this deadline MUST NOT be saved and this function should
 		// be aborted.
@@ -396,11 +556,18 @@ func (a Actor) SubmitWindowedPoSt(rt Runtime, params *SubmitWindowedPoStParams)
 				// The miner _was_ supposed to prove something, but didn't.
 				rt.Abortf(exitcode.ErrIllegalArgument, "no proofs submitted in window PoSt for %d sectors", len(sectorInfos))
 			}
-			// Verify the proof.
-			// A failed verification doesn't immediately cause a penalty; the miner can try again.
-			//
-			// This function aborts on failure.
-			verifyWindowedPost(rt, currDeadline.Challenge, sectorInfos, params.Proofs)
+			if postResult.RecoveredPower.IsZero() {
+				// Accept the proof optimistically, recording it so that it may be disputed during the
+				// dispute window after the challenge window closes.
+				err = deadline.RecordPoStProofs(store, postResult.Partitions, params.Proofs)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to record proof for dispute")
+			} else {
+				// Verify a proof that recovers power immediately.
+				// A failed verification doesn't immediately cause a penalty; the miner can try again.
+				if err := verifyWindowedPost(rt, currDeadline.Challenge, sectorInfos, params.Proofs); err != nil {
+					rt.Abortf(exitcode.ErrIllegalArgument, "%s", err)
+				}
+			}
 		}
 
 		// Penalize new skipped faults and retracted recoveries as undeclared faults.
@@ -455,6 +622,110 @@ func (a Actor) SubmitWindowedPoSt(rt Runtime, params *SubmitWindowedPoStParams)
 	return nil
 }
 
+type DisputeWindowedPoStParams struct {
+	Deadline  uint64
+	PoStIndex uint64 // Index of the proof among those accepted without verification at the deadline.
+}
+
+// Disputes a Window PoSt accepted without verification, verifying it against the snapshot of the deadline
+// taken when its challenge window closed. May be invoked by any account during the dispute window following
+// the close of the deadline.
+// If the proof is invalid, the sectors it claimed to prove are marked faulty and the miner is penalized, with part
+// of the penalty paid to the disputer. A dispute of a valid proof fails. Each proof may be disputed only once.
+func (a Actor) DisputeWindowedPoSt(rt Runtime, params *DisputeWindowedPoStParams) *adt.EmptyValue {
+	rt.ValidateImmediateCallerType(builtin.CallerTypesSignable...)
+	reporter := rt.Message().Caller()
+
+	if params.Deadline >= WPoStPeriodDeadlines {
+		rt.Abortf(exitcode.ErrIllegalArgument, "invalid deadline %d of %d", params.Deadline, WPoStPeriodDeadlines)
+	}
+	currEpoch := rt.CurrEpoch()
+
+	// Get the total power/reward. We need these to compute penalties.
+	rewardStats := requestCurrentEpochBlockReward(rt)
+	pwrTotal := requestCurrentTotalPower(rt)
+
+	powerDelta := NewPowerPairZero()
+	pledgeDelta := big.Zero()
+	burnAmount := big.Zero()
+	rewardAmount := big.Zero()
+	var st State
+	rt.State().Transaction(&st, func() {
+		dlInfo, ok := deadlineAvailableForOptimisticPoStDispute(st.ProvingPeriodStart, params.Deadline, currEpoch)
+		if !ok {
+			rt.Abortf(exitcode.ErrForbidden, "can only dispute window posts within %d epochs of the deadline closing",
+				WPoStDisputeWindow)
+		}
+
+		info := getMinerInfo(rt, &st)
+		store := adt.AsStore(rt)
+
+		deadlines, err := st.LoadDeadlines(store)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deadlines")
+
+		deadline, err := deadlines.LoadDeadline(store, params.Deadline)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deadline %d", params.Deadline)
+
+		post, err := deadline.TakePoStProofs(store, params.PoStIndex)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load proof %d for dispute", params.PoStIndex)
+
+		dispute, err := deadline.LoadPartitionsForDispute(store, post.Partitions)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load partitions for dispute")
+
+		// Verify the proof against the sectors as they were when it was accepted.
+		sectorsSnapshot, err := LoadSectors(store, deadline.SectorsSnapshot)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors snapshot")
+		sectorInfos, err := sectorsSnapshot.LoadForProof(dispute.AllSectorNos, dispute.IgnoredSectorNos)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load disputed sector info")
+		if err := verifyWindowedPost(rt, dlInfo.Challenge, sectorInfos, post.Proofs); err == nil {
+			rt.Abortf(exitcode.ErrIllegalArgument, "failed to dispute valid post")
+		}
+
+		// The proof is invalid, so the sectors it claimed to prove are faulty.
+		sectors, err := LoadSectors(store, st.Sectors)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors")
+		faultExpiration := dlInfo.Last() + FaultMaxAge
+		powerDelta, err = deadline.DeclareFaults(store, sectors, info.SectorSize, dlInfo.QuantSpec(), faultExpiration, dispute.DisputedSectors)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to fault disputed sectors")
+
+		err = deadlines.UpdateDeadline(store, params.Deadline, deadline)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update deadline %d", params.Deadline)
+
+		err = st.SaveDeadlines(store, deadlines)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save deadlines")
+
+		// Penalize the power the proof claimed, and reward the disputer from the penalty.
+		penaltyTarget := PledgePenaltyForInvalidWindowPoSt(
+			rewardStats.ThisEpochRewardSmoothed, pwrTotal.QualityAdjPowerSmoothed, dispute.DisputedPower.QA,
+		)
+		err = st.ApplyPenalty(penaltyTarget)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to apply penalty")
+
+		penaltyFromVesting, penaltyFromBalance, err := st.RepayPartialDebtInPriorityOrder(store, currEpoch, rt.CurrentBalance())
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to pay penalty")
+		burnAmount = big.Add(penaltyFromVesting, penaltyFromBalance)
+		pledgeDelta = penaltyFromVesting.Neg()
+
+		// clamp reward at funds burnt
+		rewardAmount = big.Min(burnAmount, BaseRewardForDisputedWindowPoSt)
+		burnAmount = big.Sub(burnAmount, rewardAmount)
+	})
+
+	requestUpdatePower(rt, powerDelta)
+	if rewardAmount.GreaterThan(big.Zero()) {
+		_, code := rt.Send(reporter, builtin.MethodSend, nil, rewardAmount)
+		if !code.IsSuccess() {
+			rt.Log(vmr.ERROR, "failed to send reward")
+		}
+	}
+	burnFunds(rt, burnAmount)
+	notifyPledgeChanged(rt, pledgeDelta)
+
+	rt.State().Readonly(&st)
+	st.AssertBalanceInvariants(rt.CurrentBalance())
+	return nil
+}
+
 ///////////////////////
 // Sector Commitment //
 ///////////////////////
@@ -462,47 +733,93 @@ func (a Actor) SubmitWindowedPoSt(rt Runtime, params *SubmitWindowedPoStParams)
 // Proposals must be posted on chain via sma.PublishStorageDeals before PreCommitSector.
 // Optimization: PreCommitSector could contain a list of deals that are not published yet.
 func (a Actor) PreCommitSector(rt Runtime, params *SectorPreCommitInfo) *adt.EmptyValue {
-	if _, ok := SupportedProofTypes[params.SealProof]; !ok {
-		rt.Abortf(exitcode.ErrIllegalArgument, "unsupported seal proof type: %s", params.SealProof)
-	}
-	if params.SectorNumber > abi.MaxSectorNumber {
-		rt.Abortf(exitcode.ErrIllegalArgument, "sector number %d out of range 0..(2^63-1)", params.SectorNumber)
-	}
-	if !params.SealedCID.Defined() {
-		rt.Abortf(exitcode.ErrIllegalArgument, "sealed CID undefined")
-	}
-	if params.SealedCID.Prefix() != SealedCIDPrefix {
-		rt.Abortf(exitcode.ErrIllegalArgument, "sealed CID had wrong prefix")
-	}
-	if params.SealRandEpoch >= rt.CurrEpoch() {
-		rt.Abortf(exitcode.ErrIllegalArgument, "seal challenge epoch %v must be before now %v", params.SealRandEpoch, rt.CurrEpoch())
+	preCommitSectorBatch(rt, []*SectorPreCommitInfo{params})
+	return nil
+}
+
+type PreCommitSectorBatchParams struct {
+	Sectors []SectorPreCommitInfo
+}
+
+// Pledges the miner to seal and commit some new sectors.
+// The caller specifies sector numbers, sealed sector data CIDs, seal randomness epoch, expiration, and the IDs
+// of any storage deals contained in the sectors.
+// Deal weights for all sectors are requested from the storage market actor in a single call.
+// If any sector in the batch is invalid, the whole batch fails.
+func (a Actor) PreCommitSectorBatch(rt Runtime, params *PreCommitSectorBatchParams) *adt.EmptyValue {
+	if len(params.Sectors) == 0 {
+		rt.Abortf(exitcode.ErrIllegalArgument, "batch empty")
+	} else if len(params.Sectors) > PreCommitSectorBatchMaxSize {
+		rt.Abortf(exitcode.ErrIllegalArgument, "batch of %d too large, max %d", len(params.Sectors), PreCommitSectorBatchMaxSize)
 	}
 
-	challengeEarliest := rt.CurrEpoch() - MaxPreCommitRandomnessLookback
-	if params.SealRandEpoch < challengeEarliest {
-		rt.Abortf(exitcode.ErrIllegalArgument, "seal challenge epoch %v too old, must be after %v", params.SealRandEpoch, challengeEarliest)
+	sectors := make([]*SectorPreCommitInfo, len(params.Sectors))
+	for i := range params.Sectors {
+		sectors[i] = &params.Sectors[i]
 	}
+	preCommitSectorBatch(rt, sectors)
+	return nil
+}
 
-	// Require sector lifetime meets minimum by assuming activation happens at last epoch permitted for seal proof.
-	// This could make sector maximum lifetime validation more lenient if the maximum sector limit isn't hit first.
-	maxActivation := rt.CurrEpoch() + MaxProveCommitDuration[params.SealProof]
-	validateExpiration(rt, maxActivation, params.Expiration, params.SealProof)
+func preCommitSectorBatch(rt Runtime, sectors []*SectorPreCommitInfo) {
+	currEpoch := rt.CurrEpoch()
+	challengeEarliest := currEpoch - MaxPreCommitRandomnessLookback
+	sectorNumbers := make(map[abi.SectorNumber]struct{}, len(sectors))
+	sectorDeals := make([]market.SectorDeals, len(sectors))
+	for i, params := range sectors {
+		if _, ok := SupportedProofTypes[params.SealProof]; !ok {
+			rt.Abortf(exitcode.ErrIllegalArgument, "unsupported seal proof type: %s", params.SealProof)
+		}
+		if params.SectorNumber > abi.MaxSectorNumber {
+			rt.Abortf(exitcode.ErrIllegalArgument, "sector number %d out of range 0..(2^63-1)", params.SectorNumber)
+		}
+		if _, dup := sectorNumbers[params.SectorNumber]; dup {
+			rt.Abortf(exitcode.ErrIllegalArgument, "duplicate sector number %d", params.SectorNumber)
+		}
+		sectorNumbers[params.SectorNumber] = struct{}{}
+		if !params.SealedCID.Defined() {
+			rt.Abortf(exitcode.ErrIllegalArgument, "sealed CID undefined")
+		}
+		if params.SealedCID.Prefix() != SealedCIDPrefix {
+			rt.Abortf(exitcode.ErrIllegalArgument, "sealed CID had wrong prefix")
+		}
+		if params.SealRandEpoch >= currEpoch {
+			rt.Abortf(exitcode.ErrIllegalArgument, "seal challenge epoch %v must be before now %v", params.SealRandEpoch, currEpoch)
+		}
+		if params.SealRandEpoch < challengeEarliest {
+			rt.Abortf(exitcode.ErrIllegalArgument, "seal challenge epoch %v too old, must be after %v", params.SealRandEpoch, challengeEarliest)
+		}
 
-	if params.ReplaceCapacity && len(params.DealIDs) == 0 {
-		rt.Abortf(exitcode.ErrIllegalArgument, "cannot replace sector without committing deals")
-	}
-	if params.ReplaceSectorDeadline >= WPoStPeriodDeadlines {
-		rt.Abortf(exitcode.ErrIllegalArgument, "invalid deadline %d", params.ReplaceSectorDeadline)
-	}
-	if params.ReplaceSectorNumber > abi.MaxSectorNumber {
-		rt.Abortf(exitcode.ErrIllegalArgument, "invalid sector number %d", params.ReplaceSectorNumber)
+		// Require sector lifetime meets minimum by assuming activation happens at last epoch permitted for seal proof.
+		// This could make sector maximum lifetime validation more lenient if the maximum sector limit isn't hit first.
+		maxActivation := currEpoch + MaxProveCommitDuration[params.SealProof]
+		validateExpiration(rt, maxActivation, params.Expiration, params.SealProof)
+
+		if params.ReplaceCapacity && len(params.DealIDs) == 0 {
+			rt.Abortf(exitcode.ErrIllegalArgument, "cannot replace sector without committing deals")
+		}
+		if params.ReplaceSectorDeadline >= WPoStPeriodDeadlines {
+			rt.Abortf(exitcode.ErrIllegalArgument, "invalid deadline %d", params.ReplaceSectorDeadline)
+		}
+		if params.ReplaceSectorNumber > abi.MaxSectorNumber {
+			rt.Abortf(exitcode.ErrIllegalArgument, "invalid sector number %d", params.ReplaceSectorNumber)
+		}
+
+		sectorDeals[i] = market.SectorDeals{
+			SectorExpiry: params.Expiration,
+			DealIDs:      params.DealIDs,
+		}
 	}
 
 	// gather information from other actors
 
 	rewardStats := requestCurrentEpochBlockReward(rt)
 	pwrTotal := requestCurrentTotalPower(rt)
-	dealWeight := requestDealWeight(rt, params.DealIDs, rt.CurrEpoch(), params.Expiration)
+	dealWeights := requestDealWeights(rt, sectorDeals, currEpoch)
+	if len(dealWeights.Sectors) != len(sectors) {
+		rt.Abortf(exitcode.ErrIllegalState, "deal weight request returned %d records, expected %d",
+			len(dealWeights.Sectors), len(sectors))
+	}
 
 	store := adt.AsStore(rt)
 	var st State
@@ -510,7 +827,7 @@ func (a Actor) PreCommitSector(rt Runtime, params *SectorPreCommitInfo) *adt.Emp
 	newlyVested := big.Zero()
 	feeToBurn := abi.NewTokenAmount(0)
 	rt.State().Transaction(&st, func() {
-		newlyVested, err = st.UnlockVestedFunds(store, rt.CurrEpoch())
+		newlyVested, err = st.UnlockVestedFunds(store, currEpoch)
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to vest funds")
 		// available balance already accounts for fee debt so it is correct to call
 		// this before RepayDebts. We would have to
@@ -521,71 +838,77 @@ func (a Actor) PreCommitSector(rt Runtime, params *SectorPreCommitInfo) *adt.Emp
 		info := getMinerInfo(rt, &st)
 		rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
 
-		if ConsensusFaultActive(info, rt.CurrEpoch()) {
+		if ConsensusFaultActive(info, currEpoch) {
 			rt.Abortf(exitcode.ErrForbidden, "precommit not allowed during active consensus fault")
 		}
 
-		if params.SealProof != info.SealProofType {
-			rt.Abortf(exitcode.ErrIllegalArgument, "sector seal proof %v must match miner seal proof type %d", params.SealProof, info.SealProofType)
-		}
+		totalDepositRequired := big.Zero()
+		for i, params := range sectors {
+			dealWeight := dealWeights.Sectors[i]
 
-		maxDealLimit := dealPerSectorLimit(info.SectorSize)
-		if uint64(len(params.DealIDs)) > maxDealLimit {
-			rt.Abortf(exitcode.ErrIllegalArgument, "too many deals for sector %d > %d", len(params.DealIDs), maxDealLimit)
-		}
+			if params.SealProof != info.SealProofType {
+				rt.Abortf(exitcode.ErrIllegalArgument, "sector seal proof %v must match miner seal proof type %d", params.SealProof, info.SealProofType)
+			}
 
-		err = st.AllocateSectorNumber(store, params.SectorNumber)
-		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to allocate sector id %d", params.SectorNumber)
+			maxDealLimit := dealPerSectorLimit(info.SectorSize)
+			if uint64(len(params.DealIDs)) > maxDealLimit {
+				rt.Abortf(exitcode.ErrIllegalArgument, "too many deals for sector %d > %d", len(params.DealIDs), maxDealLimit)
+			}
 
-		// The following two checks shouldn't be necessary, but it can't
-		// hurt to double-check (unless it's really just too
-		// expensive?).
-		_, preCommitFound, err := st.GetPrecommittedSector(store, params.SectorNumber)
-		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check pre-commit %v", params.SectorNumber)
-		if preCommitFound {
-			rt.Abortf(exitcode.ErrIllegalState, "sector %v already pre-committed", params.SectorNumber)
-		}
+			err = st.AllocateSectorNumber(store, params.SectorNumber)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to allocate sector id %d", params.SectorNumber)
 
-		sectorFound, err := st.HasSectorNo(store, params.SectorNumber)
-		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check sector %v", params.SectorNumber)
-		if sectorFound {
-			rt.Abortf(exitcode.ErrIllegalState, "sector %v already committed", params.SectorNumber)
-		}
+			// The following two checks shouldn't be necessary, but it can't
+			// hurt to double-check (unless it's really just too
+			// expensive?).
+			_, preCommitFound, err := st.GetPrecommittedSector(store, params.SectorNumber)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check pre-commit %v", params.SectorNumber)
+			if preCommitFound {
+				rt.Abortf(exitcode.ErrIllegalState, "sector %v already pre-committed", params.SectorNumber)
+			}
 
-		if params.ReplaceCapacity {
-			validateReplaceSector(rt, &st, store, params)
-		}
+			sectorFound, err := st.HasSectorNo(store, params.SectorNumber)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to check sector %v", params.SectorNumber)
+			if sectorFound {
+				rt.Abortf(exitcode.ErrIllegalState, "sector %v already committed", params.SectorNumber)
+			}
 
-		duration := params.Expiration - rt.CurrEpoch()
-		sectorWeight := QAPowerForWeight(info.SectorSize, duration, dealWeight.DealWeight, dealWeight.VerifiedDealWeight)
-		depositReq := PreCommitDepositForPower(rewardStats.ThisEpochRewardSmoothed, pwrTotal.QualityAdjPowerSmoothed, sectorWeight)
-		if availableBalance.LessThan(depositReq) {
-			rt.Abortf(exitcode.ErrInsufficientFunds, "insufficient funds for pre-commit deposit: %v", depositReq)
-		}
+			if params.ReplaceCapacity {
+				validateReplaceSector(rt, &st, store, params)
+			}
 
-		st.AddPreCommitDeposit(depositReq)
+			duration := params.Expiration - currEpoch
+			sectorWeight := QAPowerForWeight(info.SectorSize, duration, dealWeight.DealWeight, dealWeight.VerifiedDealWeight)
+			depositReq := PreCommitDepositForPower(rewardStats.ThisEpochRewardSmoothed, pwrTotal.QualityAdjPowerSmoothed, sectorWeight)
+			totalDepositRequired = big.Add(totalDepositRequired, depositReq)
+
+			if err := st.PutPrecommittedSector(store, &SectorPreCommitOnChainInfo{
+				Info:               *params,
+				PreCommitDeposit:   depositReq,
+				PreCommitEpoch:     currEpoch,
+				DealWeight:         dealWeight.DealWeight,
+				VerifiedDealWeight: dealWeight.VerifiedDealWeight,
+			}); err != nil {
+				rt.Abortf(exitcode.ErrIllegalState, "failed to write pre-committed sector %v: %v", params.SectorNumber, err)
+			}
+			// add precommit expiry to the queue
+			msd, ok := MaxProveCommitDuration[params.SealProof]
+			if !ok {
+				rt.Abortf(exitcode.ErrIllegalArgument, "no max seal duration set for proof type: %d", params.SealProof)
+			}
+			// The +1 here is critical for the batch verification of proofs. Without it, if a proof arrived exactly on the
+			// due epoch, ProveCommitSector would accept it, then the expiry event would remove it, and then
+			// ConfirmSectorProofsValid would fail to find it.
+			expiryBound := currEpoch + msd + 1
 
-		if err := st.PutPrecommittedSector(store, &SectorPreCommitOnChainInfo{
-			Info:               *params,
-			PreCommitDeposit:   depositReq,
-			PreCommitEpoch:     rt.CurrEpoch(),
-			DealWeight:         dealWeight.DealWeight,
-			VerifiedDealWeight: dealWeight.VerifiedDealWeight,
-		}); err != nil {
-			rt.Abortf(exitcode.ErrIllegalState, "failed to write pre-committed sector %v: %v", params.SectorNumber, err)
+			err = st.AddPreCommitExpiry(store, expiryBound, params.SectorNumber)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to add pre-commit expiry to queue")
 		}
-		// add precommit expiry to the queue
-		msd, ok := MaxProveCommitDuration[params.SealProof]
-		if !ok {
-			rt.Abortf(exitcode.ErrIllegalArgument, "no max seal duration set for proof type: %d", params.SealProof)
-		}
-		// The +1 here is critical for the batch verification of proofs. Without it, if a proof arrived exactly on the
-		// due epoch, ProveCommitSector would accept it, then the expiry event would remove it, and then
-		// ConfirmSectorProofsValid would fail to find it.
-		expiryBound := rt.CurrEpoch() + msd + 1
 
-		err = st.AddPreCommitExpiry(store, expiryBound, params.SectorNumber)
-		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to add pre-commit expiry to queue")
+		if availableBalance.LessThan(totalDepositRequired) {
+			rt.Abortf(exitcode.ErrInsufficientFunds, "insufficient funds for pre-commit deposit: %v", totalDepositRequired)
+		}
+		st.AddPreCommitDeposit(totalDepositRequired)
 	})
 
 	burnFunds(rt, feeToBurn)
@@ -593,8 +916,6 @@ func (a Actor) PreCommitSector(rt Runtime, params *SectorPreCommitInfo) *adt.Emp
 	st.AssertBalanceInvariants(rt.CurrentBalance())
 
 	notifyPledgeChanged(rt, newlyVested.Neg())
-
-	return nil
 }
 
 type ProveCommitSectorParams struct {
@@ -670,6 +991,21 @@ func (a Actor) ConfirmSectorProofsValid(rt Runtime, params *builtin.ConfirmSecto
 		)
 	}
 
+	var st State
+	rt.State().Readonly(&st)
+	store := adt.AsStore(rt)
+
+	// This skips missing pre-commits.
+	precommittedSectors, err := st.FindPrecommittedSectors(store, params.Sectors...)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pre-committed sectors")
+
+	confirmSectorProofsValid(rt, precommittedSectors)
+	return nil
+}
+
+// Activates pre-committed sectors whose seal proofs have been verified.
+// Sectors whose deals fail to activate are dropped, but at least one sector must be activated.
+func confirmSectorProofsValid(rt Runtime, precommittedSectors []*SectorPreCommitOnChainInfo) {
 	// get network stats from other actors
 	rewardStats := requestCurrentEpochBlockReward(rt)
 	pwrTotal := requestCurrentTotalPower(rt)
@@ -696,10 +1032,6 @@ func (a Actor) ConfirmSectorProofsValid(rt Runtime, params *builtin.ConfirmSecto
 	// Activate storage deals.
 	//
 
-	// This skips missing pre-commits.
-	precommittedSectors, err := st.FindPrecommittedSectors(store, params.Sectors...)
-	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pre-committed sectors")
-
 	// Committed-capacity sectors licensed for early removal by new sectors being proven.
 	replaceSectors := make(DeadlineSectorMap)
 	// Pre-commits for new sectors.
@@ -829,7 +1161,99 @@ func (a Actor) ConfirmSectorProofsValid(rt Runtime, params *builtin.ConfirmSecto
 	// Request power and pledge update for activated sector.
 	requestUpdatePower(rt, newPower)
 	notifyPledgeChanged(rt, big.Sub(totalPledge, newlyVested))
+}
+
+type ProveCommitAggregateParams struct {
+	SectorNumbers  bitfield.BitField
+	AggregateProof []byte
+}
+
+// Checks state of the corresponding sector pre-commitments and verifies a single aggregate proof of all their seals.
+// The sectors are activated immediately, rather than being scheduled for verification in bulk by the power actor.
+func (a Actor) ProveCommitAggregate(rt Runtime, params *ProveCommitAggregateParams) *adt.EmptyValue {
+	aggSectorsCount, err := params.SectorNumbers.Count()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "failed to count aggregated sectors")
+	if aggSectorsCount > MaxAggregatedSectors {
+		rt.Abortf(exitcode.ErrIllegalArgument, "too many sectors addressed, addressed %d want <= %d", aggSectorsCount, MaxAggregatedSectors)
+	} else if aggSectorsCount < MinAggregatedSectors {
+		rt.Abortf(exitcode.ErrIllegalArgument, "too few sectors addressed, addressed %d want >= %d", aggSectorsCount, MinAggregatedSectors)
+	}
+
+	if len(params.AggregateProof) > MaxAggregateProofSize {
+		rt.Abortf(exitcode.ErrIllegalArgument, "sector prove-commit proof of size %d exceeds max size of %d",
+			len(params.AggregateProof), MaxAggregateProofSize)
+	}
+
+	store := adt.AsStore(rt)
+	var st State
+	rt.State().Readonly(&st)
+	info := getMinerInfo(rt, &st)
+	rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
+
+	sectorNos, err := params.SectorNumbers.All(MaxAggregatedSectors)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "failed to expand aggregated sector numbers")
 
+	precommits := make([]*SectorPreCommitOnChainInfo, 0, len(sectorNos))
+	for _, sectorNo := range sectorNos {
+		if sectorNo > abi.MaxSectorNumber {
+			rt.Abortf(exitcode.ErrIllegalArgument, "sector number %d greater than maximum", sectorNo)
+		}
+		precommit, found, err := st.GetPrecommittedSector(store, abi.SectorNumber(sectorNo))
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pre-committed sector %v", sectorNo)
+		if !found {
+			rt.Abortf(exitcode.ErrNotFound, "no pre-committed sector %v", sectorNo)
+		}
+		precommits = append(precommits, precommit)
+	}
+
+	minerActorID, err := addr.IDFromAddress(rt.Message().Receiver())
+	AssertNoError(err) // Runtime always provides ID-addresses
+
+	sealProof := precommits[0].Info.SealProof
+	svInfos := make([]abi.AggregateSealVerifyInfo, 0, len(precommits))
+	for _, precommit := range precommits {
+		sectorNo := precommit.Info.SectorNumber
+		if precommit.Info.SealProof != sealProof {
+			rt.Abortf(exitcode.ErrIllegalArgument, "aggregate contains mismatched seal proofs %d and %d",
+				sealProof, precommit.Info.SealProof)
+		}
+
+		msd, ok := MaxProveCommitDuration[precommit.Info.SealProof]
+		if !ok {
+			rt.Abortf(exitcode.ErrIllegalState, "no max seal duration for proof type: %d", precommit.Info.SealProof)
+		}
+		proveCommitDue := precommit.PreCommitEpoch + msd
+		if rt.CurrEpoch() > proveCommitDue {
+			rt.Abortf(exitcode.ErrIllegalArgument, "commitment proof for %d too late at %d, due %d", sectorNo, rt.CurrEpoch(), proveCommitDue)
+		}
+
+		interactiveEpoch := precommit.PreCommitEpoch + PreCommitChallengeDelay
+		if rt.CurrEpoch() <= interactiveEpoch {
+			rt.Abortf(exitcode.ErrForbidden, "too early to prove sector %d", sectorNo)
+		}
+
+		commD := requestUnsealedSectorCID(rt, precommit.Info.SealProof, precommit.Info.DealIDs)
+		sealRand, interactiveRand := getSealRandomness(rt, precommit.Info.SealRandEpoch, interactiveEpoch)
+
+		svInfos = append(svInfos, abi.AggregateSealVerifyInfo{
+			Number:                sectorNo,
+			Randomness:            sealRand,
+			InteractiveRandomness: interactiveRand,
+			SealedCID:             precommit.Info.SealedCID,
+			UnsealedCID:           commD,
+		})
+	}
+
+	err = rt.Syscalls().VerifyAggregateSeals(abi.AggregateSealVerifyProofAndInfos{
+		Miner:          abi.ActorID(minerActorID),
+		SealProof:      sealProof,
+		AggregateProof: abi.RegisteredAggregationProof_SnarkPackV1,
+		Proof:          params.AggregateProof,
+		Infos:          svInfos,
+	})
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "aggregate seal verify failed")
+
+	confirmSectorProofsValid(rt, precommits)
 	return nil
 }
 
@@ -863,6 +1287,8 @@ func (a Actor) CheckSectorProven(rt Runtime, params *CheckSectorProvenParams) *a
 
 type ExtendSectorExpirationParams struct {
 	Extensions []ExpirationExtension
+	// Extensions of deals in the extended sectors, each agreed by the deal's client.
+	DealExtensions []market.ClientDealExtension
 }
 
 type ExpirationExtension struct {
@@ -874,6 +1300,7 @@ type ExpirationExtension struct {
 
 // Changes the expiration epoch for a sector to a new, later one.
 // The sector must not be terminated or faulty.
+// Deals in the extended sectors may be extended up to the sectors' new expiration, adding to the sectors' deal weights.
 // The sector's power is recomputed for the new expiration.
 func (a Actor) ExtendSectorExpiration(rt Runtime, params *ExtendSectorExpirationParams) *adt.EmptyValue {
 	if uint64(len(params.Extensions)) > AddressedPartitionsMax {
@@ -905,16 +1332,21 @@ func (a Actor) ExtendSectorExpiration(rt Runtime, params *ExtendSectorExpiration
 	}
 
 	currEpoch := rt.CurrEpoch()
-
-	powerDelta := NewPowerPairZero()
-	pledgeDelta := big.Zero()
 	store := adt.AsStore(rt)
+
 	var st State
-	rt.State().Transaction(&st, func() {
-		info := getMinerInfo(rt, &st)
+	rt.State().Readonly(&st)
+	info := getMinerInfo(rt, &st)
+	rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
 
-		rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
+	addedWeights := map[abi.SectorNumber]market.SectorWeights{}
+	if len(params.DealExtensions) > 0 {
+		addedWeights = requestDealExtensions(rt, &st, params)
+	}
 
+	powerDelta := NewPowerPairZero()
+	pledgeDelta := big.Zero()
+	rt.State().Transaction(&st, func() {
 		deadlines, err := st.LoadDeadlines(adt.AsStore(rt))
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deadlines")
 
@@ -974,6 +1406,10 @@ func (a Actor) ExtendSectorExpiration(rt Runtime, params *ExtendSectorExpiration
 
 					newSector := *sector
 					newSector.Expiration = decl.NewExpiration
+					if weights, ok := addedWeights[sector.SectorNumber]; ok {
+						newSector.DealWeight = big.Add(newSector.DealWeight, weights.DealWeight)
+						newSector.VerifiedDealWeight = big.Add(newSector.VerifiedDealWeight, weights.VerifiedDealWeight)
+					}
 
 					newSectors[i] = &newSector
 				}
@@ -991,6 +1427,10 @@ func (a Actor) ExtendSectorExpiration(rt Runtime, params *ExtendSectorExpiration
 
 				err = partitions.Set(decl.Partition, &partition)
 				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save partition", key)
+
+				// Record the new partition expiration epoch so that the deadline processes it.
+				err = deadline.AddExpirationPartitions(store, decl.NewExpiration, []uint64{decl.Partition}, quant)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to add expiration partitions %v", key)
 			}
 
 			deadline.Partitions, err = partitions.Root()
@@ -1014,6 +1454,251 @@ func (a Actor) ExtendSectorExpiration(rt Runtime, params *ExtendSectorExpiration
 	return nil
 }
 
+type ReplicaUpdate struct {
+	SectorID           abi.SectorNumber
+	Deadline           uint64
+	Partition          uint64
+	NewSealedSectorCID cid.Cid `checked:"true"` // CommR
+	Deals              []abi.DealID
+	UpdateProofType    abi.RegisteredUpdateProof
+	ReplicaProof       []byte
+}
+
+type ProveReplicaUpdatesParams struct {
+	Updates []ReplicaUpdate
+}
+
+// Upgrades committed-capacity sectors in place to hold deals, without re-sealing them.
+// Each update supplies a proof that the sector's replica was updated to encode the deals' data under a new sealed CID.
+// The deals are activated, and the sector's deal weights, power and initial pledge are recomputed from the
+// current epoch, as for a newly committed sector with the same number and expiration.
+// All updates must succeed, or the method aborts.
+func (a Actor) ProveReplicaUpdates(rt Runtime, params *ProveReplicaUpdatesParams) *adt.EmptyValue {
+	if len(params.Updates) == 0 {
+		rt.Abortf(exitcode.ErrIllegalArgument, "no replica updates")
+	} else if len(params.Updates) > ProveReplicaUpdatesMaxSize {
+		rt.Abortf(exitcode.ErrIllegalArgument, "too many replica updates %d, max %d", len(params.Updates), ProveReplicaUpdatesMaxSize)
+	}
+
+	currEpoch := rt.CurrEpoch()
+	store := adt.AsStore(rt)
+
+	var st State
+	rt.State().Readonly(&st)
+	info := getMinerInfo(rt, &st)
+	rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
+
+	sectors, err := LoadSectors(store, st.Sectors)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors array")
+
+	seen := make(map[abi.SectorNumber]struct{}, len(params.Updates))
+	oldSectors := make([]*SectorOnChainInfo, len(params.Updates))
+	sectorDeals := make([]market.SectorDeals, len(params.Updates))
+	for i, update := range params.Updates {
+		if _, ok := seen[update.SectorID]; ok {
+			rt.Abortf(exitcode.ErrIllegalArgument, "sector %d updated more than once", update.SectorID)
+		}
+		seen[update.SectorID] = struct{}{}
+
+		if len(update.Deals) == 0 {
+			rt.Abortf(exitcode.ErrIllegalArgument, "update of sector %d has no deals", update.SectorID)
+		}
+		if len(update.ReplicaProof) > MaxReplicaUpdateProofSize {
+			rt.Abortf(exitcode.ErrIllegalArgument, "update proof for sector %d size %d exceeds max %d",
+				update.SectorID, len(update.ReplicaProof), MaxReplicaUpdateProofSize)
+		}
+		if !update.NewSealedSectorCID.Defined() {
+			rt.Abortf(exitcode.ErrIllegalArgument, "new sealed CID undefined for sector %d", update.SectorID)
+		}
+		if update.NewSealedSectorCID.Prefix() != SealedCIDPrefix {
+			rt.Abortf(exitcode.ErrIllegalArgument, "new sealed CID for sector %d had wrong prefix", update.SectorID)
+		}
+		if update.Deadline >= WPoStPeriodDeadlines {
+			rt.Abortf(exitcode.ErrIllegalArgument, "deadline %d not in range 0..%d", update.Deadline, WPoStPeriodDeadlines)
+		}
+		// The sealed CID challenged at the deadline's next window must not change within the window preceding it.
+		if !deadlineIsMutable(st.ProvingPeriodStart, update.Deadline, currEpoch) {
+			rt.Abortf(exitcode.ErrForbidden, "cannot update sector %d in immutable deadline %d", update.SectorID, update.Deadline)
+		}
+
+		sector, found, err := sectors.Get(update.SectorID)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sector %d", update.SectorID)
+		if !found {
+			rt.Abortf(exitcode.ErrNotFound, "no such sector %d to update", update.SectorID)
+		}
+		if len(sector.DealIDs) > 0 {
+			rt.Abortf(exitcode.ErrIllegalArgument, "cannot update sector %d which has deals", update.SectorID)
+		}
+		updateProof, err := sector.SealProof.RegisteredUpdateProof()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to determine update proof for sector %d", update.SectorID)
+		if update.UpdateProofType != updateProof {
+			rt.Abortf(exitcode.ErrIllegalArgument, "update proof type %d for sector %d does not match %d",
+				update.UpdateProofType, update.SectorID, updateProof)
+		}
+
+		err = st.CheckSectorHealth(store, update.Deadline, update.Partition, update.SectorID)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update sector %d", update.SectorID)
+
+		oldSectors[i] = sector
+		sectorDeals[i] = market.SectorDeals{
+			SectorExpiry: sector.Expiration,
+			DealIDs:      update.Deals,
+		}
+	}
+
+	// The deals' weights are computed from the current epoch, at which the updated sector is activated.
+	dealWeights := requestDealWeights(rt, sectorDeals, currEpoch)
+	if len(dealWeights.Sectors) != len(params.Updates) {
+		rt.Abortf(exitcode.ErrIllegalState, "deal weight request returned %d records, expected %d",
+			len(dealWeights.Sectors), len(params.Updates))
+	}
+
+	for i, update := range params.Updates {
+		oldSector := oldSectors[i]
+		commD := requestUnsealedSectorCID(rt, oldSector.SealProof, update.Deals)
+		err = rt.Syscalls().VerifyReplicaUpdate(abi.ReplicaUpdateInfo{
+			UpdateProofType:      update.UpdateProofType,
+			OldSealedSectorCID:   oldSector.SealedCID,
+			NewSealedSectorCID:   update.NewSealedSectorCID,
+			NewUnsealedSectorCID: commD,
+			Proof:                update.ReplicaProof,
+		})
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "failed to verify replica update of sector %d", update.SectorID)
+
+		_, code := rt.Send(
+			builtin.StorageMarketActorAddr,
+			builtin.MethodsMarket.ActivateDeals,
+			&market.ActivateDealsParams{
+				DealIDs:      update.Deals,
+				SectorExpiry: oldSector.Expiration,
+			},
+			abi.NewTokenAmount(0),
+		)
+		builtin.RequireSuccess(rt, code, "failed to activate deals for sector %d", update.SectorID)
+	}
+
+	// get network stats from other actors
+	rewardStats := requestCurrentEpochBlockReward(rt)
+	pwrTotal := requestCurrentTotalPower(rt)
+	circulatingSupply := rt.TotalFilCircSupply()
+
+	// Group updated sectors by deadline and partition, and compute their new infos.
+	updatedSectors := make(DeadlineSectorMap)
+	newSectorsByNumber := make(map[abi.SectorNumber]*SectorOnChainInfo, len(params.Updates))
+	for i, update := range params.Updates {
+		oldSector := oldSectors[i]
+		weights := dealWeights.Sectors[i]
+		duration := oldSector.Expiration - currEpoch
+
+		pwr := QAPowerForWeight(info.SectorSize, duration, weights.DealWeight, weights.VerifiedDealWeight)
+		dayReward := ExpectedRewardForPower(rewardStats.ThisEpochRewardSmoothed, pwrTotal.QualityAdjPowerSmoothed, pwr, builtin.EpochsInDay)
+		storagePledge := ExpectedRewardForPower(rewardStats.ThisEpochRewardSmoothed, pwrTotal.QualityAdjPowerSmoothed, pwr, InitialPledgeProjectionPeriod)
+		initialPledge := InitialPledgeForPower(pwr, rewardStats.ThisEpochBaselinePower, rewardStats.ThisEpochRewardSmoothed,
+			pwrTotal.QualityAdjPowerSmoothed, circulatingSupply)
+
+		// Lower-bound the pledge by that of the sector being updated, and record the old sector's age
+		// and reward rate for termination fee calculations, as for a replaced committed-capacity sector.
+		newSector := *oldSector
+		newSector.SealedCID = update.NewSealedSectorCID
+		newSector.DealIDs = update.Deals
+		newSector.Activation = currEpoch
+		newSector.DealWeight = weights.DealWeight
+		newSector.VerifiedDealWeight = weights.VerifiedDealWeight
+		newSector.InitialPledge = big.Max(initialPledge, oldSector.InitialPledge)
+		newSector.ExpectedDayReward = dayReward
+		newSector.ExpectedStoragePledge = storagePledge
+		newSector.ReplacedSectorAge = maxEpoch(0, currEpoch-oldSector.Activation)
+		newSector.ReplacedDayReward = oldSector.ExpectedDayReward
+
+		newSectorsByNumber[update.SectorID] = &newSector
+		err = updatedSectors.AddValues(update.Deadline, update.Partition, uint64(update.SectorID))
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "failed to record sector %d for update", update.SectorID)
+	}
+
+	powerDelta := NewPowerPairZero()
+	pledgeDelta := big.Zero()
+	newlyVested := big.Zero()
+	rt.State().Transaction(&st, func() {
+		deadlines, err := st.LoadDeadlines(store)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deadlines")
+
+		sectors, err := LoadSectors(store, st.Sectors)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors array")
+
+		err = updatedSectors.ForEach(func(dlIdx uint64, pm PartitionSectorMap) error {
+			deadline, err := deadlines.LoadDeadline(store, dlIdx)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load deadline %d", dlIdx)
+
+			partitions, err := deadline.PartitionsArray(store)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load partitions for deadline %d", dlIdx)
+
+			quant := st.QuantSpecForDeadline(dlIdx)
+
+			err = pm.ForEach(func(partIdx uint64, sectorNos bitfield.BitField) error {
+				key := PartitionKey{dlIdx, partIdx}
+				var partition Partition
+				found, err := partitions.Get(partIdx, &partition)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load partition %v", key)
+				if !found {
+					rt.Abortf(exitcode.ErrNotFound, "no such partition %v", key)
+				}
+
+				oldSectors, err := sectors.Load(sectorNos)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors in partition %v", key)
+				newSectors := make([]*SectorOnChainInfo, len(oldSectors))
+				for i, sector := range oldSectors {
+					newSectors[i] = newSectorsByNumber[sector.SectorNumber]
+				}
+
+				err = sectors.Store(newSectors...)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update sectors %v", sectorNos)
+
+				// The sectors' expirations are unchanged, so the deadline's expiration partitions need no update.
+				partitionPowerDelta, partitionPledgeDelta, err := partition.ReplaceSectors(store, oldSectors, newSectors, info.SectorSize, quant)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to replace sectors at %v", key)
+
+				powerDelta = powerDelta.Add(partitionPowerDelta)
+				pledgeDelta = big.Add(pledgeDelta, partitionPledgeDelta)
+
+				err = partitions.Set(partIdx, &partition)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save partition %v", key)
+				return nil
+			})
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update partitions for deadline %d", dlIdx)
+
+			deadline.Partitions, err = partitions.Root()
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save partitions for deadline %d", dlIdx)
+
+			err = deadlines.UpdateDeadline(store, dlIdx, deadline)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save deadline %d", dlIdx)
+			return nil
+		})
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update deadlines")
+
+		st.Sectors, err = sectors.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save sectors")
+
+		err = st.SaveDeadlines(store, deadlines)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to save deadlines")
+
+		newlyVested, err = st.UnlockVestedFunds(store, currEpoch)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to vest funds")
+
+		unlockedBalance := st.GetUnlockedBalance(rt.CurrentBalance())
+		if unlockedBalance.LessThan(pledgeDelta) {
+			rt.Abortf(exitcode.ErrInsufficientFunds, "insufficient funds for updated sectors' initial pledge requirement %s, available: %s",
+				pledgeDelta, unlockedBalance)
+		}
+
+		st.AddInitialPledge(pledgeDelta)
+		st.AssertBalanceInvariants(rt.CurrentBalance())
+	})
+
+	requestUpdatePower(rt, powerDelta)
+	notifyPledgeChanged(rt, big.Sub(pledgeDelta, newlyVested))
+	return nil
+}
+
 type TerminateSectorsParams struct {
 	Terminations []TerminationDeclaration
 }
@@ -1308,6 +1993,10 @@ func (a Actor) CompactPartitions(rt Runtime, params *CompactPartitionsParams) *a
 			rt.Abortf(exitcode.ErrForbidden,
 				"cannot compact deadline %d during its challenge window or the prior challenge window", params.Deadline)
 		}
+		// Disputes address partitions by their index in the deadline's snapshot.
+		if _, disputable := deadlineAvailableForOptimisticPoStDispute(st.ProvingPeriodStart, params.Deadline, rt.CurrEpoch()); disputable {
+			rt.Abortf(exitcode.ErrForbidden, "cannot compact deadline %d during its dispute window", params.Deadline)
+		}
 
 		submissionPartitionLimit := loadPartitionsSectorsMax(info.WindowPoStPartitionSectors)
 		if partitionCount > submissionPartitionLimit {
@@ -1496,12 +2185,17 @@ func (a Actor) WithdrawBalance(rt Runtime, params *WithdrawBalanceParams) *adt.E
 	newlyVested := big.Zero()
 	feeToBurn := big.Zero()
 	availableBalance := big.Zero()
+	amountWithdrawn := big.Zero()
 	rt.State().Transaction(&st, func() {
 		var err error
 		info = getMinerInfo(rt, &st)
-		// Only the owner is allowed to withdraw the balance as it belongs to/is controlled by the owner
-		// and not the worker.
-		rt.ValidateImmediateCallerIs(info.Owner)
+		// Only the owner or beneficiary is allowed to withdraw the balance as it belongs to/is controlled by the
+		// owner and not the worker.
+		if info.Beneficiary != info.Owner {
+			rt.ValidateImmediateCallerIs(info.Owner, info.Beneficiary)
+		} else {
+			rt.ValidateImmediateCallerIs(info.Owner)
+		}
 
 		// Ensure we don't have any pending terminations.
 		if count, err := st.EarlyTerminations.Count(); err != nil {
@@ -1526,14 +2220,24 @@ func (a Actor) WithdrawBalance(rt Runtime, params *WithdrawBalanceParams) *adt.E
 		// Verify unlocked funds cover both InitialPledgeRequirement and FeeDebt
 		// and repay fee debt now.
 		feeToBurn = RepayDebtsOrAbort(rt, &st)
+
+		amountWithdrawn = big.Min(availableBalance, params.AmountRequested)
+		// A beneficiary other than the owner is limited to the remainder of its quota.
+		if info.Beneficiary != info.Owner {
+			amountWithdrawn = big.Min(amountWithdrawn, info.BeneficiaryTerm.Available(rt.CurrEpoch()))
+			if amountWithdrawn.GreaterThan(big.Zero()) {
+				info.BeneficiaryTerm.UsedQuota = big.Add(info.BeneficiaryTerm.UsedQuota, amountWithdrawn)
+				err = st.SaveInfo(adt.AsStore(rt), info)
+				builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to save miner info")
+			}
+		}
 	})
 
-	amountWithdrawn := big.Min(availableBalance, params.AmountRequested)
 	Assert(amountWithdrawn.GreaterThanEqual(big.Zero()))
 	Assert(amountWithdrawn.LessThanEqual(availableBalance))
 
 	if amountWithdrawn.GreaterThan(abi.NewTokenAmount(0)) {
-		_, code := rt.Send(info.Owner, builtin.MethodSend, nil, amountWithdrawn)
+		_, code := rt.Send(info.Beneficiary, builtin.MethodSend, nil, amountWithdrawn)
 		builtin.RequireSuccess(rt, code, "failed to withdraw balance")
 	}
 
@@ -1546,6 +2250,43 @@ func (a Actor) WithdrawBalance(rt Runtime, params *WithdrawBalanceParams) *adt.E
 	return nil
 }
 
+type RepayDebtReturn struct {
+	// Amount of debt repaid from vesting funds.
+	FromVesting abi.TokenAmount
+	// Amount of debt repaid from the unlocked balance.
+	FromBalance abi.TokenAmount
+}
+
+// Repays as much of the miner's fee debt as possible, first from the unlocked balance and then from vesting funds.
+// The repaid amount is burnt. Any funds sent with the message are added to the balance before repayment.
+func (a Actor) RepayDebt(rt Runtime, _ *adt.EmptyValue) *RepayDebtReturn {
+	var st State
+	newlyVested := big.Zero()
+	fromVesting := big.Zero()
+	fromBalance := big.Zero()
+	rt.State().Transaction(&st, func() {
+		var err error
+		info := getMinerInfo(rt, &st)
+		rt.ValidateImmediateCallerIs(append(info.ControlAddresses, info.Owner, info.Worker)...)
+
+		// Unlock vested funds so they can be used to repay the debt.
+		newlyVested, err = st.UnlockVestedFunds(adt.AsStore(rt), rt.CurrEpoch())
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to vest funds")
+
+		fromVesting, fromBalance, err = st.RepayPartialDebtFromBalanceFirst(adt.AsStore(rt), rt.CurrEpoch(), rt.CurrentBalance())
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to repay debt")
+	})
+
+	burnFunds(rt, big.Add(fromVesting, fromBalance))
+	notifyPledgeChanged(rt, big.Add(newlyVested, fromVesting).Neg())
+
+	st.AssertBalanceInvariants(rt.CurrentBalance())
+	return &RepayDebtReturn{
+		FromVesting: fromVesting,
+		FromBalance: fromBalance,
+	}
+}
+
 //////////
 // Cron //
 //////////
@@ -1869,7 +2610,8 @@ func havePendingEarlyTerminations(rt Runtime, st *State) bool {
 	return !noEarlyTerminations
 }
 
-func verifyWindowedPost(rt Runtime, challengeEpoch abi.ChainEpoch, sectors []*SectorOnChainInfo, proofs []abi.PoStProof) {
+// Verifies a Window PoSt, returning an error if the proof is invalid.
+func verifyWindowedPost(rt Runtime, challengeEpoch abi.ChainEpoch, sectors []*SectorOnChainInfo, proofs []abi.PoStProof) error {
 	minerActorID, err := addr.IDFromAddress(rt.Message().Receiver())
 	AssertNoError(err) // Runtime always provides ID-addresses
 
@@ -1899,8 +2641,9 @@ func verifyWindowedPost(rt Runtime, challengeEpoch abi.ChainEpoch, sectors []*Se
 
 	// Verify the PoSt Proof
 	if err = rt.Syscalls().VerifyPoSt(pvInfo); err != nil {
-		rt.Abortf(exitcode.ErrIllegalArgument, "invalid PoSt %+v: %s", pvInfo, err)
+		return xerrors.Errorf("invalid PoSt %+v: %w", pvInfo, err)
 	}
+	return nil
 }
 
 // SealVerifyParams is the structure of information that must be sent with a
@@ -1927,13 +2670,7 @@ func getVerifyInfo(rt Runtime, params *SealVerifyStuff) *abi.SealVerifyInfo {
 	minerActorID, err := addr.IDFromAddress(rt.Message().Receiver())
 	AssertNoError(err) // Runtime always provides ID-addresses
 
-	buf := new(bytes.Buffer)
-	receiver := rt.Message().Receiver()
-	err = receiver.MarshalCBOR(buf)
-	AssertNoError(err)
-
-	svInfoRandomness := rt.GetRandomnessFromTickets(crypto.DomainSeparationTag_SealRandomness, params.SealRandEpoch, buf.Bytes())
-	svInfoInteractiveRandomness := rt.GetRandomnessFromBeacon(crypto.DomainSeparationTag_InteractiveSealChallengeSeed, params.InteractiveEpoch, buf.Bytes())
+	svInfoRandomness, svInfoInteractiveRandomness := getSealRandomness(rt, params.SealRandEpoch, params.InteractiveEpoch)
 
 	return &abi.SealVerifyInfo{
 		SealProof: params.RegisteredSealProof,
@@ -1942,14 +2679,26 @@ func getVerifyInfo(rt Runtime, params *SealVerifyStuff) *abi.SealVerifyInfo {
 			Number: params.SectorNumber,
 		},
 		DealIDs:               params.DealIDs,
-		InteractiveRandomness: abi.InteractiveSealRandomness(svInfoInteractiveRandomness),
+		InteractiveRandomness: svInfoInteractiveRandomness,
 		Proof:                 params.Proof,
-		Randomness:            abi.SealRandomness(svInfoRandomness),
+		Randomness:            svInfoRandomness,
 		SealedCID:             params.SealedCID,
 		UnsealedCID:           commD,
 	}
 }
 
+// Draws the seal and interactive seal challenge randomness for a sector of this miner.
+func getSealRandomness(rt Runtime, sealRandEpoch, interactiveEpoch abi.ChainEpoch) (abi.SealRandomness, abi.InteractiveSealRandomness) {
+	buf := new(bytes.Buffer)
+	receiver := rt.Message().Receiver()
+	err := receiver.MarshalCBOR(buf)
+	AssertNoError(err)
+
+	sealRand := rt.GetRandomnessFromTickets(crypto.DomainSeparationTag_SealRandomness, sealRandEpoch, buf.Bytes())
+	interactiveRand := rt.GetRandomnessFromBeacon(crypto.DomainSeparationTag_InteractiveSealChallengeSeed, interactiveEpoch, buf.Bytes())
+	return abi.SealRandomness(sealRand), abi.InteractiveSealRandomness(interactiveRand)
+}
+
 // Requests the storage market actor compute the unsealed sector CID from a sector's deals.
 func requestUnsealedSectorCID(rt Runtime, proofType abi.RegisteredSealProof, dealIDs []abi.DealID) cid.Cid {
 	ret, code := rt.Send(
@@ -1967,22 +2716,80 @@ func requestUnsealedSectorCID(rt Runtime, proofType abi.RegisteredSealProof, dea
 	return cid.Cid(unsealedCID)
 }
 
-func requestDealWeight(rt Runtime, dealIDs []abi.DealID, sectorStart, sectorExpiry abi.ChainEpoch) market.VerifyDealsForActivationReturn {
+// Requests the storage market actor verify deals and compute the deal weights of a batch of sectors.
+func requestDealWeights(rt Runtime, sectors []market.SectorDeals, sectorStart abi.ChainEpoch) market.VerifyDealsForActivationReturn {
 	var dealWeights market.VerifyDealsForActivationReturn
 	ret, code := rt.Send(
 		builtin.StorageMarketActorAddr,
 		builtin.MethodsMarket.VerifyDealsForActivation,
 		&market.VerifyDealsForActivationParams{
-			DealIDs:      dealIDs,
-			SectorStart:  sectorStart,
-			SectorExpiry: sectorExpiry,
+			Sectors:     sectors,
+			SectorStart: sectorStart,
 		},
 		abi.NewTokenAmount(0),
 	)
 	builtin.RequireSuccess(rt, code, "failed to verify deals and get deal weight")
 	AssertNoError(ret.Into(&dealWeights))
 	return dealWeights
+}
+
+// Requests the storage market actor extend deals in sectors being extended, grouping the deals by the sector
+// holding them. Returns the deal weight added to each sector.
+func requestDealExtensions(rt Runtime, st *State, params *ExtendSectorExpirationParams) map[abi.SectorNumber]market.SectorWeights {
+	sectors, err := LoadSectors(adt.AsStore(rt), st.Sectors)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors array")
+
+	// Find the sector holding each deal, and its new expiration.
+	sectorForDeal := map[abi.DealID]abi.SectorNumber{}
+	newExpirations := map[abi.SectorNumber]abi.ChainEpoch{}
+	for _, decl := range params.Extensions {
+		declSectors, err := sectors.Load(decl.Sectors)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load sectors %v", decl.Sectors)
+		for _, sector := range declSectors {
+			newExpirations[sector.SectorNumber] = decl.NewExpiration
+			for _, dealID := range sector.DealIDs {
+				sectorForDeal[dealID] = sector.SectorNumber
+			}
+		}
+	}
+
+	var sectorNumbers []abi.SectorNumber
+	extensionsBySector := map[abi.SectorNumber][]market.ClientDealExtension{}
+	for _, ext := range params.DealExtensions {
+		sectorNo, ok := sectorForDeal[ext.Extension.DealID]
+		if !ok {
+			rt.Abortf(exitcode.ErrIllegalArgument, "deal %d not in an extended sector", ext.Extension.DealID)
+		}
+		if _, ok := extensionsBySector[sectorNo]; !ok {
+			sectorNumbers = append(sectorNumbers, sectorNo)
+		}
+		extensionsBySector[sectorNo] = append(extensionsBySector[sectorNo], ext)
+	}
+
+	sectorExtensions := make([]market.SectorDealExtensions, len(sectorNumbers))
+	for i, sectorNo := range sectorNumbers {
+		sectorExtensions[i] = market.SectorDealExtensions{
+			SectorExpiry: newExpirations[sectorNo],
+			Extensions:   extensionsBySector[sectorNo],
+		}
+	}
 
+	var extended market.ExtendDealsReturn
+	ret, code := rt.Send(
+		builtin.StorageMarketActorAddr,
+		builtin.MethodsMarket.ExtendDeals,
+		&market.ExtendDealsParams{Sectors: sectorExtensions},
+		abi.NewTokenAmount(0),
+	)
+	builtin.RequireSuccess(rt, code, "failed to extend deals")
+	AssertNoError(ret.Into(&extended))
+	AssertMsg(len(extended.Sectors) == len(sectorNumbers), "expected %d sector weights, got %d", len(sectorNumbers), len(extended.Sectors))
+
+	addedWeights := make(map[abi.SectorNumber]market.SectorWeights, len(sectorNumbers))
+	for i, sectorNo := range sectorNumbers {
+		addedWeights[sectorNo] = extended.Sectors[i]
+	}
+	return addedWeights
 }
 
 func commitWorkerKeyChange(rt Runtime) *adt.EmptyValue {
@@ -112,6 +112,20 @@ type MinerInfo struct {
 	// The next epoch this miner is eligible for certain permissioned actor methods
 	// and winning block elections as a result of being reported for a consensus fault.
 	ConsensusFaultElapsed abi.ChainEpoch
+
+	// A proposed new owner account for this miner.
+	// Must be confirmed by a message from the pending address itself.
+	PendingOwnerAddress *addr.Address
+
+	// Account that receives the miner's withdrawn balance.
+	// This is the owner unless changed by agreement of the owner and beneficiary.
+	Beneficiary addr.Address // Must be an ID-address.
+
+	// The amount the beneficiary may withdraw, and until when.
+	BeneficiaryTerm BeneficiaryTerm
+
+	// A proposed change of beneficiary, awaiting approval by the current and new beneficiaries.
+	PendingBeneficiaryTerm *PendingBeneficiaryChange
 }
 
 type WorkerKeyChange struct {
@@ -119,6 +133,36 @@ type WorkerKeyChange struct {
 	EffectiveAt abi.ChainEpoch
 }
 
+type BeneficiaryTerm struct {
+	// The total amount the beneficiary may withdraw.
+	Quota abi.TokenAmount
+	// The amount the beneficiary has withdrawn so far.
+	UsedQuota abi.TokenAmount
+	// The epoch after which the beneficiary may no longer withdraw.
+	Expiration abi.ChainEpoch
+}
+
+// The amount the beneficiary may still withdraw at an epoch.
+func (t *BeneficiaryTerm) Available(currEpoch abi.ChainEpoch) abi.TokenAmount {
+	if t.IsExpired(currEpoch) {
+		return big.Zero()
+	}
+	return big.Max(big.Sub(t.Quota, t.UsedQuota), big.Zero())
+}
+
+// Whether the term has lapsed at an epoch.
+func (t *BeneficiaryTerm) IsExpired(currEpoch abi.ChainEpoch) bool {
+	return currEpoch > t.Expiration
+}
+
+type PendingBeneficiaryChange struct {
+	NewBeneficiary        addr.Address // Must be an ID address
+	NewQuota              abi.TokenAmount
+	NewExpiration         abi.ChainEpoch
+	ApprovedByBeneficiary bool
+	ApprovedByNominee     bool
+}
+
 // Information provided by a miner when pre-committing a sector.
 type SectorPreCommitInfo struct {
 	SealProof       abi.RegisteredSealProof
@@ -208,6 +252,10 @@ func ConstructMinerInfo(owner addr.Address, worker addr.Address, controlAddrs []
 		SectorSize:                 sectorSize,
 		WindowPoStPartitionSectors: partitionSectors,
 		ConsensusFaultElapsed:      abi.ChainEpoch(-1),
+		PendingOwnerAddress:        nil,
+		Beneficiary:                owner,
+		BeneficiaryTerm:            BeneficiaryTerm{Quota: big.Zero(), UsedQuota: big.Zero(), Expiration: 0},
+		PendingBeneficiaryTerm:     nil,
 	}, nil
 }
 
@@ -791,6 +839,25 @@ func (st *State) RepayPartialDebtInPriorityOrder(store adt.Store, currEpoch abi.
 
 }
 
+// Draws from unlocked funds and then the vesting table to repay up to the fee debt.
+// Returns the amount unlocked from the vesting table and the amount taken from
+// current balance. Any fee debt exceeding the total amount available for repayment remains.
+func (st *State) RepayPartialDebtFromBalanceFirst(store adt.Store, currEpoch abi.ChainEpoch, currBalance abi.TokenAmount) (fromVesting abi.TokenAmount, fromBalance abi.TokenAmount, err error) {
+	fromBalance = big.Min(st.GetUnlockedBalance(currBalance), st.FeeDebt)
+	st.FeeDebt = big.Sub(st.FeeDebt, fromBalance)
+
+	fromVesting, err = st.UnlockUnvestedFunds(store, currEpoch, st.FeeDebt)
+	if err != nil {
+		return abi.NewTokenAmount(0), abi.NewTokenAmount(0), err
+	}
+
+	// We should never unlock more than the debt we need to repay
+	Assert(fromVesting.LessThanEqual(st.FeeDebt))
+	st.FeeDebt = big.Sub(st.FeeDebt, fromVesting)
+
+	return fromVesting, fromBalance, nil
+}
+
 // Repays the full miner actor fee debt.  Returns the amount that must be
 // burnt and an error if there are not sufficient funds to cover repayment.
 // Miner state repays from unlocked funds, potentially violating IP requirements
@@ -1039,8 +1106,20 @@ func (st *State) AdvanceDeadline(store adt.Store, currEpoch abi.ChainEpoch) (*Ad
 		return nil, xerrors.Errorf("failed to load deadline %d: %w", dlInfo.Index, err)
 	}
 
-	// No live sectors in this deadline, nothing to do.
+	// Snapshot the deadline as the challenge window closes, so that proofs accepted without verification
+	// may be disputed against the state they claimed to prove.
+	if err = deadline.Snapshot(store, st.Sectors); err != nil {
+		return nil, xerrors.Errorf("failed to snapshot deadline %d: %w", dlInfo.Index, err)
+	}
+
+	// No live sectors in this deadline, nothing else to do.
 	if deadline.LiveSectors == 0 {
+		if err = deadlines.UpdateDeadline(store, dlInfo.Index, deadline); err != nil {
+			return nil, xerrors.Errorf("failed to update deadline %d: %w", dlInfo.Index, err)
+		}
+		if err = st.SaveDeadlines(store, deadlines); err != nil {
+			return nil, xerrors.Errorf("failed to save deadlines: %w", err)
+		}
 		return &AdvanceDeadlineResult{
 			pledgeDelta,
 			powerDelta,
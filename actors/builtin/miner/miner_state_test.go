@@ -792,6 +792,40 @@ func TestRepayDebtInPriorityOrder(t *testing.T) {
 	assert.Equal(t, expectedDebt, harness.s.FeeDebt)
 }
 
+func TestRepayDebtFromBalanceFirst(t *testing.T) {
+	harness := constructStateHarness(t, abi.ChainEpoch(0))
+	vspec := &miner.VestSpec{
+		InitialDelay: 0,
+		VestPeriod:   5,
+		StepDuration: 1,
+		Quantization: 1,
+	}
+	harness.addLockedFunds(abi.ChainEpoch(100), abi.NewTokenAmount(100), vspec)
+
+	// unlocked balance is 300 of the 400 held
+	currentBalance := abi.NewTokenAmount(400)
+	err := harness.s.ApplyPenalty(abi.NewTokenAmount(340))
+	require.NoError(t, err)
+
+	fromVesting, fromBalance, err := harness.s.RepayPartialDebtFromBalanceFirst(harness.store, abi.ChainEpoch(100), currentBalance)
+	require.NoError(t, err)
+	assert.Equal(t, abi.NewTokenAmount(300), fromBalance)
+	assert.Equal(t, abi.NewTokenAmount(40), fromVesting)
+	assert.True(t, harness.s.FeeDebt.IsZero())
+	assert.Equal(t, abi.NewTokenAmount(60), harness.s.LockedFunds)
+
+	// debt exceeding the balance and vesting funds remains
+	currentBalance = abi.NewTokenAmount(60)
+	err = harness.s.ApplyPenalty(abi.NewTokenAmount(100))
+	require.NoError(t, err)
+
+	fromVesting, fromBalance, err = harness.s.RepayPartialDebtFromBalanceFirst(harness.store, abi.ChainEpoch(100), currentBalance)
+	require.NoError(t, err)
+	assert.True(t, fromBalance.IsZero())
+	assert.Equal(t, abi.NewTokenAmount(60), fromVesting)
+	assert.Equal(t, abi.NewTokenAmount(40), harness.s.FeeDebt)
+}
+
 func TestMinerEligibleForElection(t *testing.T) {
 	tenFIL := big.Mul(big.NewInt(1e18), big.NewInt(10))
 	thisEpochReward := tenFIL
@@ -996,6 +1030,8 @@ func constructStateHarness(t *testing.T, periodBoundary abi.ChainEpoch) *stateHa
 		SealProofType:              testSealProofType,
 		SectorSize:                 sectorSize,
 		WindowPoStPartitionSectors: partitionSectors,
+		Beneficiary:                owner,
+		BeneficiaryTerm:            miner.BeneficiaryTerm{Quota: big.Zero(), UsedQuota: big.Zero()},
 	}
 	infoCid, err := store.Put(context.Background(), &info)
 	require.NoError(t, err)
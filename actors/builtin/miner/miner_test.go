@@ -6,6 +6,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"testing"
 
 	addr "github.com/filecoin-project/go-address"
@@ -474,6 +475,8 @@ func TestCommitments(t *testing.T) {
 		assert.Equal(t, expectedInitialPledge, entry.OnTimePledge)
 		assert.Equal(t, sectorPower, entry.ActivePower)
 		assert.Equal(t, miner.NewPowerPairZero(), entry.FaultyPower)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("insufficient funds for pre-commit", func(t *testing.T) {
@@ -514,6 +517,8 @@ func TestCommitments(t *testing.T) {
 		actor.preCommitSector(rt, actor.makePreCommit(101, challengeEpoch, expiration, nil))
 		st = getState(rt)
 		assert.Equal(t, big.Zero(), st.FeeDebt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("invalid pre-commit rejected", func(t *testing.T) {
@@ -756,6 +761,8 @@ func TestCommitments(t *testing.T) {
 		// Old sector gone from pledge requirement and deposit
 		assert.Equal(t, st.InitialPledge, newSector.InitialPledge)
 		assert.Equal(t, st.LockedFunds, big.Mul(big.NewInt(4), faultPenalty)) // from manual fund addition above - 1 fault penalty
+
+		actor.checkState(rt)
 	})
 
 	t.Run("invalid committed capacity upgrade rejected", func(t *testing.T) {
@@ -1013,6 +1020,8 @@ func TestCommitments(t *testing.T) {
 		// Old sector gone from pledge
 		assert.Equal(t, st.InitialPledge, big.Add(newSector1.InitialPledge, newSector2.InitialPledge))
 		assert.Equal(t, st.LockedFunds, big.Mul(big.NewInt(4), faultPenalty)) // from manual fund addition above - 1 fault penalty
+
+		actor.checkState(rt)
 	})
 
 	t.Run("invalid proof rejected", func(t *testing.T) {
@@ -1119,6 +1128,8 @@ func TestCommitments(t *testing.T) {
 		rt.SetEpoch(precommit.Expiration - miner.MinSectorExpiration + 1)
 		actor.confirmSectorProofsValid(rt, proveCommitConf{}, precommit)
 		rt.ExpectLogsContain("less than minimum. ignoring")
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fails with too many deals", func(t *testing.T) {
@@ -1169,6 +1180,123 @@ func TestCommitments(t *testing.T) {
 	})
 }
 
+func TestPreCommitBatch(t *testing.T) {
+	periodOffset := abi.ChainEpoch(100)
+	precommitEpoch := periodOffset + 1
+
+	setup := func(t *testing.T, balance abi.TokenAmount) (*actorHarness, *mock.Runtime, abi.ChainEpoch) {
+		actor := newHarness(t, periodOffset)
+		rt := builderForHarness(actor).
+			WithBalance(balance, big.Zero()).
+			Build(t)
+		rt.SetEpoch(precommitEpoch)
+		actor.constructAndVerify(rt)
+		expiration := actor.deadline(rt).PeriodEnd() + defaultSectorExpiration*miner.WPoStProvingPeriod
+		return actor, rt, expiration
+	}
+
+	// Deposit for a sector pre-committed at precommitEpoch, given the deal weights mocked by the harness.
+	expectedDeposit := func(actor *actorHarness, expiration abi.ChainEpoch) abi.TokenAmount {
+		weight := big.NewInt(int64(actor.sectorSize / 2))
+		qaPower := miner.QAPowerForWeight(actor.sectorSize, expiration-precommitEpoch, weight, weight)
+		return miner.PreCommitDepositForPower(actor.epochRewardSmooth, actor.epochQAPowerSmooth, qaPower)
+	}
+
+	t.Run("pre-commits a batch of sectors", func(t *testing.T) {
+		actor, rt, expiration := setup(t, bigBalance)
+
+		var sectors []*miner.SectorPreCommitInfo
+		for i := 0; i < 4; i++ {
+			sectors = append(sectors, actor.makePreCommit(100+abi.SectorNumber(i), precommitEpoch-1, expiration, []abi.DealID{abi.DealID(i)}))
+		}
+		onChain := actor.preCommitSectorBatch(rt, sectors...)
+
+		deposit := expectedDeposit(actor, expiration)
+		for i, pc := range onChain {
+			assert.Equal(t, *sectors[i], pc.Info)
+			assert.Equal(t, precommitEpoch, pc.PreCommitEpoch)
+			assert.Equal(t, deposit, pc.PreCommitDeposit)
+		}
+
+		st := getState(rt)
+		assert.Equal(t, big.Mul(deposit, big.NewInt(4)), st.PreCommitDeposits)
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects empty and oversize batches", func(t *testing.T) {
+		actor, rt, expiration := setup(t, bigBalance)
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "batch empty", func() {
+			rt.Call(actor.a.PreCommitSectorBatch, &miner.PreCommitSectorBatchParams{})
+		})
+
+		params := miner.PreCommitSectorBatchParams{}
+		for i := 0; i <= miner.PreCommitSectorBatchMaxSize; i++ {
+			params.Sectors = append(params.Sectors, *actor.makePreCommit(abi.SectorNumber(i), precommitEpoch-1, expiration, nil))
+		}
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "too large", func() {
+			rt.Call(actor.a.PreCommitSectorBatch, &params)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects duplicate sector numbers", func(t *testing.T) {
+		actor, rt, expiration := setup(t, bigBalance)
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+
+		params := miner.PreCommitSectorBatchParams{Sectors: []miner.SectorPreCommitInfo{
+			*actor.makePreCommit(100, precommitEpoch-1, expiration, nil),
+			*actor.makePreCommit(101, precommitEpoch-1, expiration, nil),
+			*actor.makePreCommit(100, precommitEpoch-1, expiration, nil),
+		}}
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "duplicate sector number 100", func() {
+			rt.Call(actor.a.PreCommitSectorBatch, &params)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails atomically on an invalid sector", func(t *testing.T) {
+		actor, rt, expiration := setup(t, bigBalance)
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+
+		bad := actor.makePreCommit(101, precommitEpoch, expiration, nil) // randomness epoch must be in the past
+		params := miner.PreCommitSectorBatchParams{Sectors: []miner.SectorPreCommitInfo{
+			*actor.makePreCommit(100, precommitEpoch-1, expiration, nil),
+			*bad,
+		}}
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "must be before now", func() {
+			rt.Call(actor.a.PreCommitSectorBatch, &params)
+		})
+
+		st := getState(rt)
+		_, found, err := st.GetPrecommittedSector(rt.AdtStore(), 100)
+		require.NoError(t, err)
+		assert.False(t, found)
+		actor.checkState(rt)
+	})
+
+	t.Run("insufficient funds for batch deposit", func(t *testing.T) {
+		actor, rt, expiration := setup(t, bigBalance)
+		deposit := expectedDeposit(actor, expiration)
+
+		// Enough for one sector's deposit but not two.
+		rt.SetBalance(big.Add(deposit, big.Div(deposit, big.NewInt(2))))
+		sectors := []*miner.SectorPreCommitInfo{
+			actor.makePreCommit(100, precommitEpoch-1, expiration, nil),
+			actor.makePreCommit(101, precommitEpoch-1, expiration, nil),
+		}
+		rt.ExpectAbortContainsMessage(exitcode.ErrInsufficientFunds, "insufficient funds for pre-commit deposit", func() {
+			actor.preCommitSectorBatch(rt, sectors...)
+		})
+		rt.Reset()
+
+		st := getState(rt)
+		assert.True(t, st.PreCommitDeposits.IsZero())
+		actor.checkState(rt)
+	})
+}
+
 func TestWindowPost(t *testing.T) {
 	periodOffset := abi.ChainEpoch(100)
 	actor := newHarness(t, periodOffset)
@@ -1210,6 +1338,8 @@ func TestWindowPost(t *testing.T) {
 
 		// Advance to end-of-deadline cron to verify no penalties.
 		advanceDeadline(rt, actor, &cronConfig{})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("test duplicate proof ignored", func(t *testing.T) {
@@ -1263,6 +1393,8 @@ func TestWindowPost(t *testing.T) {
 
 		// Advance to end-of-deadline cron to verify no penalties.
 		advanceDeadline(rt, actor, &cronConfig{})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("successful recoveries recover power", func(t *testing.T) {
@@ -1324,6 +1456,8 @@ func TestWindowPost(t *testing.T) {
 
 		expectedBalance := big.Sub(initialLocked, recoveryFee)
 		assert.Equal(t, expectedBalance, actor.getLockedFunds(rt))
+
+		actor.checkState(rt)
 	})
 
 	t.Run("skipped faults are penalized and adjust power", func(t *testing.T) {
@@ -1392,6 +1526,8 @@ func TestWindowPost(t *testing.T) {
 
 		// expect ongoing fault from both sectors
 		advanceDeadline(rt, actor, &cronConfig{ongoingFaultsPenalty: actor.declaredFaultPenalty(infos)})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("skipped all sectors in a deadline may be skipped", func(t *testing.T) {
@@ -1436,6 +1572,8 @@ func TestWindowPost(t *testing.T) {
 
 		// expect declared fee to be charged during cron
 		advanceDeadline(rt, actor, &cronConfig{ongoingFaultsPenalty: declaredFee})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("skipped recoveries are penalized and do not recover power", func(t *testing.T) {
@@ -1488,6 +1626,7 @@ func TestWindowPost(t *testing.T) {
 		// sector will be charged ongoing fee at proving period cron
 		advanceDeadline(rt, actor, &cronConfig{ongoingFaultsPenalty: ongoingFee})
 
+		actor.checkState(rt)
 	})
 
 	t.Run("skipping a fault from the wrong partition is an error", func(t *testing.T) {
@@ -1529,6 +1668,138 @@ func TestWindowPost(t *testing.T) {
 	})
 }
 
+func TestDisputeWindowPoSt(t *testing.T) {
+	periodOffset := abi.ChainEpoch(100)
+	actor := newHarness(t, periodOffset)
+	actor.setProofType(abi.RegisteredSealProof_StackedDrg2KiBV1)
+	precommitEpoch := abi.ChainEpoch(1)
+	builder := builderForHarness(actor).
+		WithEpoch(precommitEpoch).
+		WithBalance(bigBalance, big.Zero())
+
+	// Proves a new sector at its deadline and advances past the deadline's close, returning the sector and
+	// the deadline it was proven at.
+	setup := func(t *testing.T) (*mock.Runtime, *miner.SectorOnChainInfo, *miner.DeadlineInfo) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		sector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, nil)[0]
+		pwr := miner.PowerForSector(actor.sectorSize, sector)
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), sector.SectorNumber)
+		require.NoError(t, err)
+
+		dlinfo := actor.deadline(rt)
+		for dlinfo.Index != dlIdx {
+			dlinfo = advanceDeadline(rt, actor, &cronConfig{})
+		}
+
+		partitions := []miner.PoStPartition{{Index: pIdx, Skipped: bitfield.New()}}
+		actor.submitWindowPoSt(rt, dlinfo, partitions, []*miner.SectorOnChainInfo{sector}, &poStConfig{
+			expectedPowerDelta: pwr,
+			expectedPenalty:    big.Zero(),
+		})
+		advanceDeadline(rt, actor, &cronConfig{})
+		return rt, sector, dlinfo
+	}
+
+	t.Run("proof is accepted without verification and snapshotted at deadline close", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		sector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, nil)[0]
+		pwr := miner.PowerForSector(actor.sectorSize, sector)
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), sector.SectorNumber)
+		require.NoError(t, err)
+
+		dlinfo := actor.deadline(rt)
+		for dlinfo.Index != dlIdx {
+			dlinfo = advanceDeadline(rt, actor, &cronConfig{})
+		}
+
+		partitions := []miner.PoStPartition{{Index: pIdx, Skipped: bitfield.New()}}
+		actor.submitWindowPoSt(rt, dlinfo, partitions, []*miner.SectorOnChainInfo{sector}, &poStConfig{
+			expectedPowerDelta: pwr,
+			expectedPenalty:    big.Zero(),
+		})
+		deadline := actor.getDeadline(rt, dlIdx)
+		assert.Equal(t, []uint64{pIdx}, actor.getOptimisticProofPartitions(rt, deadline.OptimisticPoStSubmissions))
+
+		advanceDeadline(rt, actor, &cronConfig{})
+		deadline = actor.getDeadline(rt, dlIdx)
+		assert.Empty(t, actor.getOptimisticProofPartitions(rt, deadline.OptimisticPoStSubmissions))
+		assert.Equal(t, []uint64{pIdx}, actor.getOptimisticProofPartitions(rt, deadline.OptimisticPoStSubmissionsSnapshot))
+		actor.checkState(rt)
+	})
+
+	t.Run("invalid proof is disputed", func(t *testing.T) {
+		rt, sector, dlinfo := setup(t)
+		pwr := miner.PowerForSector(actor.sectorSize, sector)
+
+		penalty := miner.PledgePenaltyForInvalidWindowPoSt(actor.epochRewardSmooth, actor.epochQAPowerSmooth, pwr.QA)
+		actor.disputeWindowPoSt(rt, dlinfo, 0, []*miner.SectorOnChainInfo{sector}, &poStDisputeResult{
+			expectedPowerDelta:  pwr.Neg(),
+			expectedReward:      miner.BaseRewardForDisputedWindowPoSt,
+			expectedPenalty:     big.Sub(penalty, miner.BaseRewardForDisputedWindowPoSt),
+			expectedPledgeDelta: big.Zero(),
+		})
+
+		// The sector is now faulty.
+		st := getState(rt)
+		_, pIdx, err := st.FindSector(rt.AdtStore(), sector.SectorNumber)
+		require.NoError(t, err)
+		partition := actor.getPartition(rt, actor.getDeadline(rt, dlinfo.Index), pIdx)
+		assertBitfieldEquals(t, partition.Faults, uint64(sector.SectorNumber))
+
+		// The proof can't be disputed again.
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			actor.disputeWindowPoSt(rt, dlinfo, 0, []*miner.SectorOnChainInfo{sector}, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("valid proof cannot be disputed", func(t *testing.T) {
+		rt, sector, dlinfo := setup(t)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "failed to dispute valid post", func() {
+			actor.disputeWindowPoSt(rt, dlinfo, 0, []*miner.SectorOnChainInfo{sector}, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("dispute must address an existing proof and deadline", func(t *testing.T) {
+		rt, sector, dlinfo := setup(t)
+
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			actor.disputeWindowPoSt(rt, dlinfo, 1, []*miner.SectorOnChainInfo{sector}, nil)
+		})
+
+		params := miner.DisputeWindowedPoStParams{Deadline: miner.WPoStPeriodDeadlines, PoStIndex: 0}
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.CallerTypesSignable...)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.a.DisputeWindowedPoSt, &params)
+		})
+	})
+
+	t.Run("dispute is only possible during the dispute window", func(t *testing.T) {
+		rt, sector, dlinfo := setup(t)
+
+		rt.SetEpoch(dlinfo.Close + miner.WPoStDisputeWindow)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.disputeWindowPoSt(rt, dlinfo, 0, []*miner.SectorOnChainInfo{sector}, nil)
+		})
+
+		// A deadline that has not closed in the current proving period can't be disputed either.
+		rt.SetEpoch(dlinfo.Close)
+		nextDl := miner.NewDeadlineInfo(dlinfo.PeriodStart, dlinfo.Index+1, rt.Epoch())
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.disputeWindowPoSt(rt, nextDl, 0, []*miner.SectorOnChainInfo{sector}, nil)
+		})
+	})
+}
+
 func TestProveCommit(t *testing.T) {
 	periodOffset := abi.ChainEpoch(100)
 	actor := newHarness(t, periodOffset)
@@ -1565,6 +1836,8 @@ func TestProveCommit(t *testing.T) {
 		// succeeds when pledge deposits satisfy initial pledge requirement
 		rt.SetBalance(bal)
 		actor.proveCommitSectorAndConfirm(rt, precommit, precommitEpoch, makeProveCommit(actor.nextSectorNo), proveCommitConf{})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("drop invalid prove commit while processing valid one", func(t *testing.T) {
@@ -1596,6 +1869,186 @@ func TestProveCommit(t *testing.T) {
 			},
 		}
 		actor.confirmSectorProofsValid(rt, conf, precommitA, precommitB)
+
+		actor.checkState(rt)
+	})
+}
+
+func TestProveCommitAggregate(t *testing.T) {
+	periodOffset := abi.ChainEpoch(100)
+	actor := newHarness(t, periodOffset)
+	builder := builderForHarness(actor).
+		WithBalance(bigBalance, big.Zero())
+
+	// Pre-commits n sectors in the same epoch and advances to an epoch at which they may be proven.
+	precommitSectors := func(rt *mock.Runtime, n int) ([]*miner.SectorPreCommitInfo, abi.ChainEpoch) {
+		expiration := defaultSectorExpiration*miner.WPoStProvingPeriod + periodOffset - 1
+		precommitEpoch := rt.Epoch() + 1
+		rt.SetEpoch(precommitEpoch)
+
+		var precommits []*miner.SectorPreCommitInfo
+		for i := 0; i < n; i++ {
+			precommit := actor.makePreCommit(actor.nextSectorNo, precommitEpoch-1, expiration, nil)
+			actor.preCommitSector(rt, precommit)
+			precommits = append(precommits, precommit)
+			actor.nextSectorNo++
+		}
+		rt.SetEpoch(precommitEpoch + miner.PreCommitChallengeDelay + 1)
+		return precommits, precommitEpoch
+	}
+
+	sectorNumbers := func(precommits []*miner.SectorPreCommitInfo) bitfield.BitField {
+		bf := bitfield.New()
+		for _, pc := range precommits {
+			bf.Set(uint64(pc.SectorNumber))
+		}
+		return bf
+	}
+
+	t.Run("prove commit aggregate activates sectors", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, precommitEpoch := precommitSectors(rt, miner.MinAggregatedSectors+1)
+
+		actor.proveCommitAggregateSector(rt, proveCommitConf{}, precommits, precommitEpoch, []byte("aggregate"))
+
+		st := getState(rt)
+		for _, pc := range precommits {
+			sector := actor.getSector(rt, pc.SectorNumber)
+			assert.Equal(t, rt.Epoch(), sector.Activation)
+			assert.Equal(t, pc.SealedCID, sector.SealedCID)
+
+			_, found, err := st.GetPrecommittedSector(rt.AdtStore(), pc.SectorNumber)
+			require.NoError(t, err)
+			assert.False(t, found)
+		}
+		assert.True(t, st.PreCommitDeposits.IsZero())
+		assert.True(t, st.InitialPledge.GreaterThan(big.Zero()))
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects too few or too many sectors", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, _ := precommitSectors(rt, miner.MinAggregatedSectors-1)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "too few sectors", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  sectorNumbers(precommits),
+				AggregateProof: []byte("aggregate"),
+			})
+		})
+
+		tooMany := bitfield.New()
+		for i := uint64(0); i <= miner.MaxAggregatedSectors; i++ {
+			tooMany.Set(i)
+		}
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "too many sectors", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  tooMany,
+				AggregateProof: []byte("aggregate"),
+			})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects oversize proof", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, _ := precommitSectors(rt, miner.MinAggregatedSectors)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "exceeds max size", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  sectorNumbers(precommits),
+				AggregateProof: make([]byte, miner.MaxAggregateProofSize+1),
+			})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects missing pre-commit", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, _ := precommitSectors(rt, miner.MinAggregatedSectors)
+
+		sectorNos := sectorNumbers(precommits)
+		sectorNos.Set(uint64(actor.nextSectorNo))
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, actor.controlAddrs...), actor.owner, actor.worker)...)
+		rt.ExpectAbortContainsMessage(exitcode.ErrNotFound, "no pre-committed sector", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  sectorNos,
+				AggregateProof: []byte("aggregate"),
+			})
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects prove commit before challenge delay", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, precommitEpoch := precommitSectors(rt, miner.MinAggregatedSectors)
+		rt.SetEpoch(precommitEpoch + miner.PreCommitChallengeDelay)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, actor.controlAddrs...), actor.owner, actor.worker)...)
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "too early to prove sector", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  sectorNumbers(precommits),
+				AggregateProof: []byte("aggregate"),
+			})
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("aborts when aggregate proof fails to verify", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		precommits, precommitEpoch := precommitSectors(rt, miner.MinAggregatedSectors)
+
+		commd := cbg.CborCid(tutil.MakeCID("commd", &market.PieceCIDPrefix))
+		var buf bytes.Buffer
+		receiver := rt.Receiver()
+		require.NoError(t, receiver.MarshalCBOR(&buf))
+		var infos []abi.AggregateSealVerifyInfo
+		for _, pc := range precommits {
+			rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.ComputeDataCommitment,
+				&market.ComputeDataCommitmentParams{SectorType: pc.SealProof}, big.Zero(), &commd, exitcode.Ok)
+			rt.ExpectGetRandomnessTickets(crypto.DomainSeparationTag_SealRandomness, pc.SealRandEpoch, buf.Bytes(), abi.Randomness("seal"))
+			rt.ExpectGetRandomnessBeacon(crypto.DomainSeparationTag_InteractiveSealChallengeSeed, precommitEpoch+miner.PreCommitChallengeDelay, buf.Bytes(), abi.Randomness("interactive"))
+			infos = append(infos, abi.AggregateSealVerifyInfo{
+				Number:                pc.SectorNumber,
+				Randomness:            abi.SealRandomness("seal"),
+				InteractiveRandomness: abi.InteractiveSealRandomness("interactive"),
+				SealedCID:             pc.SealedCID,
+				UnsealedCID:           cid.Cid(commd),
+			})
+		}
+		actorId, err := addr.IDFromAddress(actor.receiver)
+		require.NoError(t, err)
+		rt.ExpectAggregateVerifySeals(abi.AggregateSealVerifyProofAndInfos{
+			Miner:          abi.ActorID(actorId),
+			SealProof:      actor.sealProofType,
+			AggregateProof: abi.RegisteredAggregationProof_SnarkPackV1,
+			Proof:          []byte("bad"),
+			Infos:          infos,
+		}, fmt.Errorf("invalid aggregate"))
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, actor.controlAddrs...), actor.owner, actor.worker)...)
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "aggregate seal verify failed", func() {
+			rt.Call(actor.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+				SectorNumbers:  sectorNumbers(precommits),
+				AggregateProof: []byte("bad"),
+			})
+		})
+		rt.Verify()
+		actor.checkState(rt)
 	})
 }
 
@@ -1629,6 +2082,8 @@ func TestDeadlineCron(t *testing.T) {
 		})
 		st = getState(rt)
 		assert.Equal(t, periodOffset+miner.WPoStProvingPeriod, st.ProvingPeriodStart)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("sector expires", func(t *testing.T) {
@@ -1667,6 +2122,8 @@ func TestDeadlineCron(t *testing.T) {
 			expiredSectorsPledgeDelta: initialPledge.Neg(),
 			detectedFaultsPenalty:     expectedFee,
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("sector expires and repays fee debt", func(t *testing.T) {
@@ -1713,6 +2170,8 @@ func TestDeadlineCron(t *testing.T) {
 			detectedFaultsPenalty:     expectedFee,
 			repaidFeeDebt:             initialPledge, // We repay unlocked IP as fees
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("detects and penalizes faults", func(t *testing.T) {
@@ -1785,6 +2244,8 @@ func TestDeadlineCron(t *testing.T) {
 		deadline = actor.getDeadline(rt, dlIdx)
 		assert.True(t, totalPower.Equals(deadline.FaultyPower))
 		checkDeadlineInvariants(t, rt.AdtStore(), deadline, st.QuantSpecForDeadline(dlIdx), actor.sectorSize, uint64(4), allSectors)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("test cron run late", func(t *testing.T) {
@@ -1830,6 +2291,8 @@ func TestDeadlineCron(t *testing.T) {
 			detectedFaultsPenalty:    undetectedPenalty,
 			detectedFaultsPowerDelta: &powerDeltaClaim,
 		})
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1878,6 +2341,8 @@ func TestDeclareFaults(t *testing.T) {
 		advanceDeadline(rt, actor, &cronConfig{
 			ongoingFaultsPenalty: ongoingPenalty,
 		})
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1908,6 +2373,8 @@ func TestDeclareRecoveries(t *testing.T) {
 		p, err := dl.LoadPartition(rt.AdtStore(), pIdx)
 		require.NoError(t, err)
 		assert.Equal(t, p.Faults, p.Recoveries)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("recovery must pay back fee debt", func(t *testing.T) {
@@ -1959,6 +2426,8 @@ func TestDeclareRecoveries(t *testing.T) {
 		assert.Equal(t, p.Faults, p.Recoveries)
 		st = getState(rt)
 		assert.Equal(t, big.Zero(), st.FeeDebt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("recovery fails during active consensus fault", func(t *testing.T) {
@@ -2141,21 +2610,119 @@ func TestExtendSectorExpiration(t *testing.T) {
 		empty, err = expirationSet.IsEmpty()
 		require.NoError(t, err)
 		assert.False(t, empty)
+
+		actor.checkState(rt)
 	})
 
-	t.Run("updates many sectors", func(t *testing.T) {
+	t.Run("schedules deadline to process partition at new expiration", func(t *testing.T) {
 		rt := builder.Build(t)
-		actor.constructAndVerify(rt)
-
-		const sectorCount = 4000
-
-		// commit a bunch of sectors to ensure that we get multiple partitions.
-		sectorInfos := actor.commitAndProveSectors(rt, sectorCount, defaultSectorExpiration, nil)
-		advanceAndSubmitPoSts(rt, actor, sectorInfos...)
-
-		newExpiration := sectorInfos[0].Expiration + 42*miner.WPoStProvingPeriod
+		oldSector := commitSector(t, rt)
+		advanceAndSubmitPoSts(rt, actor, oldSector)
 
-		var params miner.ExtendSectorExpirationParams
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), oldSector.SectorNumber)
+		require.NoError(t, err)
+		quant := st.QuantSpecForDeadline(dlIdx)
+
+		newExpiration := oldSector.Expiration + 42*miner.WPoStProvingPeriod
+		params := &miner.ExtendSectorExpirationParams{
+			Extensions: []miner.ExpirationExtension{{
+				Deadline:      dlIdx,
+				Partition:     pIdx,
+				Sectors:       bf(uint64(oldSector.SectorNumber)),
+				NewExpiration: newExpiration,
+			}},
+		}
+		actor.extendSectors(rt, params)
+
+		// The deadline only visits partitions recorded in its expiration queue, so without an entry
+		// at the new expiration epoch the extended sector would never expire.
+		deadline := actor.getDeadline(rt, dlIdx)
+		dQueue := actor.collectDeadlineExpirations(rt, deadline)
+		assert.Equal(t, []uint64{pIdx}, dQueue[quant.QuantizeUp(newExpiration)])
+
+		actor.checkState(rt)
+	})
+
+	t.Run("extends deals in extended sectors", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		oldSector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, [][]abi.DealID{{10, 11}})[0]
+		advanceAndSubmitPoSts(rt, actor, oldSector)
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), oldSector.SectorNumber)
+		require.NoError(t, err)
+
+		newExpiration := oldSector.Expiration + 42*miner.WPoStProvingPeriod
+		dealExtensions := []market.ClientDealExtension{
+			{Extension: market.DealExtension{DealID: 10, NewEndEpoch: newExpiration}},
+			{Extension: market.DealExtension{DealID: 11, NewEndEpoch: newExpiration - 100}},
+		}
+		params := &miner.ExtendSectorExpirationParams{
+			Extensions: []miner.ExpirationExtension{{
+				Deadline:      dlIdx,
+				Partition:     pIdx,
+				Sectors:       bf(uint64(oldSector.SectorNumber)),
+				NewExpiration: newExpiration,
+			}},
+			DealExtensions: dealExtensions,
+		}
+		added := market.SectorWeights{DealWeight: big.NewInt(1 << 20), VerifiedDealWeight: big.NewInt(1 << 21)}
+
+		actor.extendSectorsAndDeals(rt, params,
+			&market.ExtendDealsParams{Sectors: []market.SectorDealExtensions{{SectorExpiry: newExpiration, Extensions: dealExtensions}}},
+			&market.ExtendDealsReturn{Sectors: []market.SectorWeights{added}},
+			map[abi.SectorNumber]market.SectorWeights{oldSector.SectorNumber: added},
+		)
+
+		newSector := actor.getSector(rt, oldSector.SectorNumber)
+		assert.Equal(t, newExpiration, newSector.Expiration)
+		assert.Equal(t, big.Add(oldSector.DealWeight, added.DealWeight), newSector.DealWeight)
+		assert.Equal(t, big.Add(oldSector.VerifiedDealWeight, added.VerifiedDealWeight), newSector.VerifiedDealWeight)
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects extension of deal not in an extended sector", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		oldSector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, [][]abi.DealID{{10}})[0]
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), oldSector.SectorNumber)
+		require.NoError(t, err)
+
+		newExpiration := oldSector.Expiration + 42*miner.WPoStProvingPeriod
+		params := &miner.ExtendSectorExpirationParams{
+			Extensions: []miner.ExpirationExtension{{
+				Deadline:      dlIdx,
+				Partition:     pIdx,
+				Sectors:       bf(uint64(oldSector.SectorNumber)),
+				NewExpiration: newExpiration,
+			}},
+			DealExtensions: []market.ClientDealExtension{
+				{Extension: market.DealExtension{DealID: 12, NewEndEpoch: newExpiration}},
+			},
+		}
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "deal 12 not in an extended sector", func() {
+			actor.extendSectors(rt, params)
+		})
+	})
+
+	t.Run("updates many sectors", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		const sectorCount = 4000
+
+		// commit a bunch of sectors to ensure that we get multiple partitions.
+		sectorInfos := actor.commitAndProveSectors(rt, sectorCount, defaultSectorExpiration, nil)
+		advanceAndSubmitPoSts(rt, actor, sectorInfos...)
+
+		newExpiration := sectorInfos[0].Expiration + 42*miner.WPoStProvingPeriod
+
+		var params miner.ExtendSectorExpirationParams
 
 		// extend all odd-numbered sectors.
 		{
@@ -2228,6 +2795,8 @@ func TestExtendSectorExpiration(t *testing.T) {
 			}))
 			assert.EqualValues(t, sectorCount/2, extendedTotal)
 		}
+
+		actor.checkState(rt)
 	})
 
 	t.Run("supports extensions off deadline boundary", func(t *testing.T) {
@@ -2281,6 +2850,165 @@ func TestExtendSectorExpiration(t *testing.T) {
 			expiredSectorsPowerDelta:  &pwr,
 			expiredSectorsPledgeDelta: newSector.InitialPledge.Neg(),
 		})
+
+		actor.checkState(rt)
+	})
+}
+
+func TestProveReplicaUpdates(t *testing.T) {
+	periodOffset := abi.ChainEpoch(100)
+	actor := newHarness(t, periodOffset)
+	precommitEpoch := abi.ChainEpoch(1)
+	builder := builderForHarness(actor).
+		WithEpoch(precommitEpoch).
+		WithBalance(bigBalance, big.Zero())
+
+	// Commits and proves a CC sector, returning it with a replica update that adds a deal to it.
+	setup := func(t *testing.T, rt *mock.Runtime) (*miner.SectorOnChainInfo, miner.ReplicaUpdate) {
+		actor.constructAndVerify(rt)
+		sector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, nil)[0]
+		advanceAndSubmitPoSts(rt, actor, sector)
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), sector.SectorNumber)
+		require.NoError(t, err)
+		updateProof, err := sector.SealProof.RegisteredUpdateProof()
+		require.NoError(t, err)
+
+		return sector, miner.ReplicaUpdate{
+			SectorID:           sector.SectorNumber,
+			Deadline:           dlIdx,
+			Partition:          pIdx,
+			NewSealedSectorCID: tutil.MakeCID("updated commr", &miner.SealedCIDPrefix),
+			Deals:              []abi.DealID{1},
+			UpdateProofType:    updateProof,
+			ReplicaProof:       []byte{},
+		}
+	}
+
+	verifiedWeight := func(rt *mock.Runtime, sector *miner.SectorOnChainInfo) market.SectorWeights {
+		return market.SectorWeights{
+			DealWeight:         big.Zero(),
+			VerifiedDealWeight: big.Mul(big.NewIntUnsigned(uint64(actor.sectorSize)), big.NewInt(int64(sector.Expiration-rt.Epoch()))),
+		}
+	}
+
+	t.Run("updates CC sector with deals in place", func(t *testing.T) {
+		rt := builder.Build(t)
+		oldSector, update := setup(t, rt)
+		weights := verifiedWeight(rt, oldSector)
+
+		actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{weights}, nil)
+
+		newSector := actor.getSector(rt, oldSector.SectorNumber)
+		assert.Equal(t, oldSector.SectorNumber, newSector.SectorNumber)
+		assert.Equal(t, update.NewSealedSectorCID, newSector.SealedCID)
+		assert.Equal(t, update.Deals, newSector.DealIDs)
+		assert.Equal(t, oldSector.Expiration, newSector.Expiration)
+		assert.Equal(t, rt.Epoch(), newSector.Activation)
+		assert.Equal(t, weights.DealWeight, newSector.DealWeight)
+		assert.Equal(t, weights.VerifiedDealWeight, newSector.VerifiedDealWeight)
+		assert.Equal(t, rt.Epoch()-oldSector.Activation, newSector.ReplacedSectorAge)
+		assert.Equal(t, oldSector.ExpectedDayReward, newSector.ReplacedDayReward)
+		assert.True(t, newSector.InitialPledge.GreaterThan(oldSector.InitialPledge))
+
+		// the partition's power reflects the new deal weight
+		_, partition := actor.getDeadlineAndPartition(rt, update.Deadline, update.Partition)
+		assert.Equal(t, miner.PowerForSectors(actor.sectorSize, []*miner.SectorOnChainInfo{newSector}), partition.LivePower)
+
+		st := getState(rt)
+		assert.Equal(t, newSector.InitialPledge, st.InitialPledge)
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects sector with deals", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		sector := actor.commitAndProveSectors(rt, 1, defaultSectorExpiration, [][]abi.DealID{{10}})[0]
+		advanceAndSubmitPoSts(rt, actor, sector)
+
+		st := getState(rt)
+		dlIdx, pIdx, err := st.FindSector(rt.AdtStore(), sector.SectorNumber)
+		require.NoError(t, err)
+		update := miner.ReplicaUpdate{
+			SectorID:           sector.SectorNumber,
+			Deadline:           dlIdx,
+			Partition:          pIdx,
+			NewSealedSectorCID: tutil.MakeCID("updated commr", &miner.SealedCIDPrefix),
+			Deals:              []abi.DealID{1},
+			UpdateProofType:    abi.RegisteredUpdateProof_StackedDrg32GiBV1,
+		}
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "which has deals", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{verifiedWeight(rt, sector)}, nil)
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects update without deals", func(t *testing.T) {
+		rt := builder.Build(t)
+		sector, update := setup(t, rt)
+		update.Deals = nil
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "has no deals", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{verifiedWeight(rt, sector)}, nil)
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects mismatched update proof type", func(t *testing.T) {
+		rt := builder.Build(t)
+		sector, update := setup(t, rt)
+		update.UpdateProofType = abi.RegisteredUpdateProof_StackedDrg64GiBV1
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "does not match", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{verifiedWeight(rt, sector)}, nil)
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects update in immutable deadline", func(t *testing.T) {
+		rt := builder.Build(t)
+		sector, update := setup(t, rt)
+
+		st := getState(rt)
+		dlinfo := miner.NewDeadlineInfo(st.ProvingPeriodStart, update.Deadline, rt.Epoch()).NextNotElapsed()
+		rt.SetEpoch(dlinfo.Open - miner.WPoStChallengeWindow)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "immutable deadline", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{verifiedWeight(rt, sector)}, nil)
+		})
+		rt.Reset()
+	})
+
+	t.Run("rejects faulty sector", func(t *testing.T) {
+		rt := builder.Build(t)
+		sector, update := setup(t, rt)
+		actor.declareFaults(rt, sector)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "is faulty", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{verifiedWeight(rt, sector)}, nil)
+		})
+		rt.Reset()
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects invalid update proof", func(t *testing.T) {
+		rt := builder.Build(t)
+		sector, update := setup(t, rt)
+		weights := verifiedWeight(rt, sector)
+
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "failed to verify replica update", func() {
+			actor.proveReplicaUpdates(rt, []miner.ReplicaUpdate{update}, []market.SectorWeights{weights}, fmt.Errorf("invalid proof"))
+		})
+		rt.Reset()
+
+		// the sector is unchanged
+		assert.Equal(t, sector, actor.getSector(rt, sector.SectorNumber))
+		actor.checkState(rt)
 	})
 }
 
@@ -2333,6 +3061,8 @@ func TestTerminateSectors(t *testing.T) {
 			// expect pledge requirement to have been decremented
 			assert.Equal(t, big.Zero(), st.InitialPledge)
 		}
+
+		actor.checkState(rt)
 	})
 
 	t.Run("charges correct fee for young termination of committed capacity upgrade", func(t *testing.T) {
@@ -2388,6 +3118,8 @@ func TestTerminateSectors(t *testing.T) {
 
 		sectors := bf(uint64(newSector.SectorNumber))
 		actor.terminateSectors(rt, sectors, expectedFee)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -2403,6 +3135,8 @@ func TestWithdrawBalance(t *testing.T) {
 
 		// withdraw 1% of balance
 		actor.withdrawFunds(rt, onePercentBigBalance, onePercentBigBalance, big.Zero())
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fails if miner can't repay fee debt", func(t *testing.T) {
@@ -2429,6 +3163,115 @@ func TestWithdrawBalance(t *testing.T) {
 		requested := rt.Balance()
 		expectedWithdraw := big.Sub(requested, feeDebt)
 		actor.withdrawFunds(rt, requested, expectedWithdraw, feeDebt)
+
+		actor.checkState(rt)
+	})
+}
+
+func TestRepayDebt(t *testing.T) {
+	periodOffset := abi.ChainEpoch(100)
+	actor := newHarness(t, periodOffset)
+	builder := builderForHarness(actor).
+		WithBalance(bigBalance, big.Zero())
+
+	t.Run("repays debt from balance before vesting funds", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		vesting := abi.NewTokenAmount(600_000)
+		actor.addLockedFunds(rt, vesting)
+		rt.SetBalance(big.Add(rt.Balance(), vesting))
+
+		// the whole unlocked balance and half the vesting funds are needed
+		fromBalance := bigBalance
+		fromVesting := abi.NewTokenAmount(300_000)
+		st := getState(rt)
+		st.FeeDebt = big.Add(fromBalance, fromVesting)
+		rt.ReplaceState(st)
+
+		actor.repayDebt(rt, actor.worker, fromVesting, fromBalance)
+
+		st = getState(rt)
+		assert.True(t, st.IsDebtFree())
+		assert.Equal(t, big.Sub(vesting, fromVesting), st.LockedFunds)
+		actor.checkState(rt)
+	})
+
+	t.Run("leaves vesting funds when balance covers debt", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		vesting := abi.NewTokenAmount(600_000)
+		actor.addLockedFunds(rt, vesting)
+		rt.SetBalance(big.Add(rt.Balance(), vesting))
+
+		st := getState(rt)
+		st.FeeDebt = onePercentBigBalance
+		rt.ReplaceState(st)
+
+		actor.repayDebt(rt, actor.worker, big.Zero(), onePercentBigBalance)
+
+		st = getState(rt)
+		assert.True(t, st.IsDebtFree())
+		assert.Equal(t, vesting, st.LockedFunds)
+		actor.checkState(rt)
+	})
+
+	t.Run("repays debt with funds sent with the message", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		rt.SetBalance(big.Zero())
+
+		st := getState(rt)
+		st.FeeDebt = onePercentBigBalance
+		rt.ReplaceState(st)
+
+		rt.SetReceived(onePercentBigBalance)
+		rt.SetBalance(onePercentBigBalance)
+		actor.repayDebt(rt, actor.owner, big.Zero(), onePercentBigBalance)
+
+		st = getState(rt)
+		assert.True(t, st.IsDebtFree())
+		actor.checkState(rt)
+	})
+
+	t.Run("repays as much as possible", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		st := getState(rt)
+		remaining := abi.NewTokenAmount(1e18)
+		st.FeeDebt = big.Add(rt.Balance(), remaining)
+		rt.ReplaceState(st)
+
+		actor.repayDebt(rt, actor.owner, big.Zero(), rt.Balance())
+
+		st = getState(rt)
+		assert.Equal(t, remaining, st.FeeDebt)
+		assert.False(t, st.IsDebtFree())
+		actor.checkState(rt)
+	})
+
+	t.Run("repays nothing without debt", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		actor.repayDebt(rt, actor.controlAddrs[0], big.Zero(), big.Zero())
+		actor.checkState(rt)
+	})
+
+	t.Run("rejects caller other than owner, worker or control address", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		st := getState(rt)
+		st.FeeDebt = onePercentBigBalance
+		rt.ReplaceState(st)
+
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.repayDebt(rt, tutil.NewIDAddr(t, 1234), big.Zero(), onePercentBigBalance)
+		})
+		rt.Reset()
 	})
 }
 
@@ -2505,6 +3348,8 @@ func TestCompactPartitions(t *testing.T) {
 		actor.terminateSectors(rt, sectors, expectedFee)
 
 		// compacting partition will remove sector1 but retain sector 2, 3 and 4.
+		// The deadline can't be compacted until its dispute window has elapsed.
+		rt.SetEpoch(rt.Epoch() + miner.WPoStDisputeWindow)
 		partId := uint64(0)
 		deadlineId := uint64(0)
 		partitions := bitfield.NewFromSet([]uint64{partId})
@@ -2516,6 +3361,8 @@ func TestCompactPartitions(t *testing.T) {
 		assertSectorExists(rt.AdtStore(), st, sector4, partId, deadlineId)
 
 		assertSectorNotFound(rt.AdtStore(), st, sector1)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail to compact partitions with faults", func(T *testing.T) {
@@ -2530,6 +3377,7 @@ func TestCompactPartitions(t *testing.T) {
 		// fault sector1
 		actor.declareFaults(rt, info[0])
 
+		rt.SetEpoch(rt.Epoch() + miner.WPoStDisputeWindow)
 		partId := uint64(0)
 		deadlineId := uint64(0)
 		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "failed to remove partitions from deadline 0: while removing partitions: cannot remove partition 0: has faults", func() {
@@ -2546,6 +3394,7 @@ func TestCompactPartitions(t *testing.T) {
 		// create 2 sectors in partition 0
 		actor.commitAndProveSectors(rt, 2, defaultSectorExpiration, [][]abi.DealID{{10}, {20}})
 
+		rt.SetEpoch(rt.Epoch() + miner.WPoStDisputeWindow)
 		partId := uint64(0)
 		deadlineId := uint64(0)
 		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalArgument, "failed to remove partitions from deadline 0: while removing partitions: cannot remove partition 0: has unproven sectors", func() {
@@ -2669,20 +3518,19 @@ func TestChangeWorkerAddress(t *testing.T) {
 
 		// no change if current epoch is less than effective epoch
 		rt.SetEpoch(effectiveEpoch - 1)
-		rt.SetCaller(builtin.StoragePowerActorAddr, builtin.StoragePowerActorCodeID)
-		rt.ExpectValidateCallerAddr(builtin.StoragePowerActorAddr)
-		rt.Call(actor.a.OnDeferredCronEvent, &miner.CronEventPayload{
-			EventType: miner.CronEventWorkerKeyChange,
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "too early", func() {
+			actor.confirmWorkerAddrChange(rt, newWorker)
 		})
-		rt.Verify()
+		rt.Reset()
 		st := getState(rt)
 		info, err := st.GetInfo(adt.AsStore(rt))
 		require.NoError(t, err)
 		require.NotNil(t, info.PendingWorkerKey)
 		require.EqualValues(t, actor.worker, info.Worker)
 
-		// set current epoch to effective epoch and ask to change the address
-		actor.cronWorkerAddrChange(rt, effectiveEpoch, newWorker)
+		// set current epoch to effective epoch and confirm the change
+		rt.SetEpoch(effectiveEpoch)
+		actor.confirmWorkerAddrChange(rt, newWorker)
 
 		// assert control addresses are unchanged
 		st = getState(rt)
@@ -2731,8 +3579,9 @@ func TestChangeWorkerAddress(t *testing.T) {
 		effectiveEpoch := currentEpoch + miner.WorkerKeyChangeDelay
 		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, []addr.Address{c1, c2})
 
-		// set current epoch to effective epoch and ask to change the address
-		actor.cronWorkerAddrChange(rt, effectiveEpoch, newWorker)
+		// set current epoch to effective epoch and confirm the change
+		rt.SetEpoch(effectiveEpoch)
+		actor.confirmWorkerAddrChange(rt, newWorker)
 
 		// assert both worker and control addresses have changed
 		st := getState(rt)
@@ -2743,6 +3592,105 @@ func TestChangeWorkerAddress(t *testing.T) {
 		require.Equal(t, newWorker, info.Worker)
 	})
 
+	t.Run("repeating the pending change does not restart the delay", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+
+		rt.SetEpoch(abi.ChainEpoch(5))
+		effectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, actor.controlAddrs)
+
+		rt.SetEpoch(effectiveEpoch - 1)
+		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, actor.controlAddrs)
+
+		rt.SetEpoch(effectiveEpoch)
+		actor.confirmWorkerAddrChange(rt, newWorker)
+	})
+
+	t.Run("changing to a different worker supersedes the pending change", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		firstWorker := tutil.NewIDAddr(t, 999)
+		secondWorker := tutil.NewIDAddr(t, 1001)
+
+		rt.SetEpoch(abi.ChainEpoch(5))
+		firstEffectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, firstWorker, firstEffectiveEpoch, actor.controlAddrs)
+
+		rt.SetEpoch(abi.ChainEpoch(10))
+		secondEffectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, secondWorker, secondEffectiveEpoch, actor.controlAddrs)
+
+		// the delay restarts for the new worker
+		rt.SetEpoch(firstEffectiveEpoch)
+		rt.ExpectAbortContainsMessage(exitcode.ErrForbidden, "too early", func() {
+			actor.confirmWorkerAddrChange(rt, secondWorker)
+		})
+		rt.Reset()
+
+		rt.SetEpoch(secondEffectiveEpoch)
+		actor.confirmWorkerAddrChange(rt, secondWorker)
+	})
+
+	t.Run("changing to the current worker cancels the pending change", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+
+		rt.SetEpoch(abi.ChainEpoch(5))
+		effectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, actor.controlAddrs)
+		actor.changeWorkerAddress(rt, actor.worker, abi.ChainEpoch(-1), actor.controlAddrs)
+
+		rt.SetEpoch(effectiveEpoch)
+		rt.ExpectAbortContainsMessage(exitcode.ErrIllegalState, "no pending worker key change", func() {
+			actor.confirmWorkerAddrChange(rt, newWorker)
+		})
+		rt.Reset()
+		require.Equal(t, actor.worker, actor.getInfo(rt).Worker)
+	})
+
+	t.Run("fails to confirm when caller is not the owner", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+
+		rt.SetEpoch(abi.ChainEpoch(5))
+		effectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, actor.controlAddrs)
+
+		rt.SetEpoch(effectiveEpoch)
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(actor.owner)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.a.ConfirmChangeWorkerAddress, nil)
+		})
+		rt.Verify()
+	})
+
+	t.Run("previously enrolled cron event commits the change", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+
+		rt.SetEpoch(abi.ChainEpoch(5))
+		effectiveEpoch := rt.Epoch() + miner.WorkerKeyChangeDelay
+		actor.changeWorkerAddress(rt, newWorker, effectiveEpoch, actor.controlAddrs)
+
+		rt.SetEpoch(effectiveEpoch)
+		rt.SetCaller(builtin.StoragePowerActorAddr, builtin.StoragePowerActorCodeID)
+		rt.ExpectValidateCallerAddr(builtin.StoragePowerActorAddr)
+		rt.Call(actor.a.OnDeferredCronEvent, &miner.CronEventPayload{
+			EventType: miner.CronEventWorkerKeyChange,
+		})
+		rt.Verify()
+
+		info := actor.getInfo(rt)
+		require.Nil(t, info.PendingWorkerKey)
+		require.Equal(t, newWorker, info.Worker)
+	})
+
 	t.Run("successfully clear all control addresses", func(t *testing.T) {
 		rt, actor := setupFunc()
 		actor.constructAndVerify(rt)
@@ -2824,38 +3772,363 @@ func TestChangeWorkerAddress(t *testing.T) {
 		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
 			rt.Call(actor.a.ChangeWorkerAddress, param)
 		})
-		rt.Verify()
+		rt.Verify()
+	})
+
+	t.Run("fails if new worker is not an account actor", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+		rt.SetAddressActorType(newWorker, builtin.StorageMinerActorCodeID)
+
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		param := &miner.ChangeWorkerAddressParams{NewWorker: newWorker}
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.a.ChangeWorkerAddress, param)
+		})
+		rt.Verify()
+	})
+
+	t.Run("fails when caller is not the owner", func(t *testing.T) {
+		rt, actor := setupFunc()
+		actor.constructAndVerify(rt)
+		newWorker := tutil.NewIDAddr(t, 999)
+		rt.SetAddressActorType(newWorker, builtin.AccountActorCodeID)
+
+		rt.ExpectValidateCallerAddr(actor.owner)
+		rt.ExpectSend(newWorker, builtin.MethodsAccount.PubkeyAddress, nil, big.Zero(), &actor.key, exitcode.Ok)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		param := &miner.ChangeWorkerAddressParams{NewWorker: newWorker}
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.a.ChangeWorkerAddress, param)
+		})
+		rt.Verify()
+	})
+}
+
+func TestChangeOwnerAddress(t *testing.T) {
+	actor := newHarness(t, 0)
+	builder := builderForHarness(actor).
+		WithBalance(bigBalance, big.Zero())
+	newAddr := tutil.NewIDAddr(t, 1001)
+	otherAddr := tutil.NewIDAddr(t, 1002)
+
+	t.Run("successful change", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Owner)
+		assert.Equal(t, newAddr, *info.PendingOwnerAddress)
+
+		rt.SetCaller(newAddr, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		info = actor.getInfo(rt)
+		assert.Equal(t, newAddr, info.Owner)
+		assert.Nil(t, info.PendingOwnerAddress)
+	})
+
+	t.Run("proposed must be valid", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		nominees := []addr.Address{
+			addr.Undef,
+			tutil.NewSECP256K1Addr(t, "asd"),
+			tutil.NewBLSAddr(t, 1234),
+			tutil.NewActorAddr(t, "asd"),
+		}
+		for _, a := range nominees {
+			rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+			rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+				actor.changeOwnerAddress(rt, a)
+			})
+		}
+	})
+
+	t.Run("withdraw proposal", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		// Revert it
+		actor.changeOwnerAddress(rt, actor.owner)
+
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Owner)
+		assert.Nil(t, info.PendingOwnerAddress)
+
+		// New address cannot confirm.
+		rt.SetCaller(newAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, newAddr)
+		})
+	})
+
+	t.Run("only owner can propose", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, newAddr)
+		})
+		rt.SetCaller(otherAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, newAddr)
+		})
+	})
+
+	t.Run("only owner can change proposal", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		// Make a proposal
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+		rt.SetCaller(otherAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+
+		// Owner can change it
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, otherAddr)
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Owner)
+		assert.Equal(t, otherAddr, *info.PendingOwnerAddress)
+	})
+
+	t.Run("only nominee can confirm", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		// Make a proposal
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		// Owner re-proposing same address doesn't confirm it.
+		actor.changeOwnerAddress(rt, newAddr)
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Owner)
+		assert.Equal(t, newAddr, *info.PendingOwnerAddress) // Still staged
+
+		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+		rt.SetCaller(otherAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+
+		// Can't confirm with the wrong address
+		rt.SetCaller(newAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+
+		// Confirms
+		actor.changeOwnerAddress(rt, newAddr)
+		info = actor.getInfo(rt)
+		assert.Equal(t, newAddr, info.Owner)
+		assert.Nil(t, info.PendingOwnerAddress)
+	})
+
+	t.Run("new owner can withdraw and control miner", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+		rt.SetCaller(newAddr, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, newAddr)
+
+		// Old owner can no longer propose changes.
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeOwnerAddress(rt, otherAddr)
+		})
+
+		actor.owner = newAddr
+		actor.withdrawFunds(rt, onePercentBigBalance, onePercentBigBalance, big.Zero())
+	})
+}
+
+func TestChangeBeneficiary(t *testing.T) {
+	actor := newHarness(t, 0)
+	beneficiary := tutil.NewIDAddr(t, 1001)
+	nominee := tutil.NewIDAddr(t, 1002)
+	otherAddr := tutil.NewIDAddr(t, 1003)
+	builder := builderForHarness(actor).
+		WithBalance(bigBalance, big.Zero()).
+		WithActorType(beneficiary, builtin.AccountActorCodeID).
+		WithActorType(nominee, builtin.AccountActorCodeID).
+		WithActorType(otherAddr, builtin.AccountActorCodeID)
+	quota := onePercentBigBalance
+	expiration := abi.ChainEpoch(1000)
+
+	// Builds a miner whose beneficiary is already changed from the owner.
+	setup := func(t *testing.T) *mock.Runtime {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		actor.changeBeneficiary(rt, actor.owner, beneficiary, quota, expiration)
+		actor.changeBeneficiary(rt, beneficiary, beneficiary, quota, expiration)
+		return rt
+	}
+
+	t.Run("beneficiary is initially the owner", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Beneficiary)
+		assert.Nil(t, info.PendingBeneficiaryTerm)
+		actor.checkState(rt)
+	})
+
+	t.Run("nominee approves change from owner", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		actor.changeBeneficiary(rt, actor.owner, beneficiary, quota, expiration)
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Beneficiary)
+		require.NotNil(t, info.PendingBeneficiaryTerm)
+		assert.True(t, info.PendingBeneficiaryTerm.ApprovedByBeneficiary)
+		assert.False(t, info.PendingBeneficiaryTerm.ApprovedByNominee)
+
+		actor.changeBeneficiary(rt, beneficiary, beneficiary, quota, expiration)
+		info = actor.getInfo(rt)
+		assert.Equal(t, beneficiary, info.Beneficiary)
+		assert.Equal(t, quota, info.BeneficiaryTerm.Quota)
+		assert.Equal(t, big.Zero(), info.BeneficiaryTerm.UsedQuota)
+		assert.Equal(t, expiration, info.BeneficiaryTerm.Expiration)
+		assert.Nil(t, info.PendingBeneficiaryTerm)
+		actor.checkState(rt)
+	})
+
+	t.Run("change requires approval of current beneficiary with remaining quota", func(t *testing.T) {
+		rt := setup(t)
+
+		actor.changeBeneficiary(rt, actor.owner, nominee, quota, expiration)
+		actor.changeBeneficiary(rt, nominee, nominee, quota, expiration)
+		info := actor.getInfo(rt)
+		assert.Equal(t, beneficiary, info.Beneficiary)
+		assert.True(t, info.PendingBeneficiaryTerm.ApprovedByNominee)
+		assert.False(t, info.PendingBeneficiaryTerm.ApprovedByBeneficiary)
+
+		actor.changeBeneficiary(rt, beneficiary, nominee, quota, expiration)
+		info = actor.getInfo(rt)
+		assert.Equal(t, nominee, info.Beneficiary)
+		assert.Nil(t, info.PendingBeneficiaryTerm)
+		actor.checkState(rt)
+	})
+
+	t.Run("expired beneficiary is returned to owner without approval", func(t *testing.T) {
+		rt := setup(t)
+
+		rt.SetEpoch(expiration + 1)
+		actor.changeBeneficiary(rt, actor.owner, actor.owner, big.Zero(), 0)
+		info := actor.getInfo(rt)
+		assert.Equal(t, actor.owner, info.Beneficiary)
+		assert.Equal(t, big.Zero(), info.BeneficiaryTerm.Quota)
+		assert.Nil(t, info.PendingBeneficiaryTerm)
+		actor.checkState(rt)
+	})
+
+	t.Run("proposal must be valid", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+		rt.SetEpoch(100)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, actor.owner, beneficiary, big.Zero(), expiration)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, actor.owner, beneficiary, quota, 99)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, actor.owner, actor.owner, quota, 0)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, actor.owner, tutil.NewIDAddr(t, 2000), quota, expiration)
+		})
+	})
+
+	t.Run("only parties to a matching proposal can approve", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt)
+
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeBeneficiary(rt, beneficiary, beneficiary, quota, expiration)
+		})
+
+		actor.changeBeneficiary(rt, actor.owner, beneficiary, quota, expiration)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.changeBeneficiary(rt, otherAddr, beneficiary, quota, expiration)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, beneficiary, beneficiary, big.Add(quota, big.NewInt(1)), expiration)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.changeBeneficiary(rt, beneficiary, beneficiary, quota, expiration+1)
+		})
+		assert.Equal(t, actor.owner, actor.getInfo(rt).Beneficiary)
+	})
+
+	t.Run("beneficiary withdrawal is limited by quota", func(t *testing.T) {
+		rt := setup(t)
+
+		actor.withdrawFundsAs(rt, beneficiary, big.Mul(quota, big.NewInt(2)), quota, big.Zero())
+		info := actor.getInfo(rt)
+		assert.Equal(t, quota, info.BeneficiaryTerm.UsedQuota)
+
+		// The owner may also initiate withdrawal, but funds go only to the beneficiary.
+		actor.withdrawFundsAs(rt, actor.owner, quota, big.Zero(), big.Zero())
+
+		rt.SetCaller(otherAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(actor.owner, beneficiary)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.a.WithdrawBalance, &miner.WithdrawBalanceParams{AmountRequested: quota})
+		})
+		actor.checkState(rt)
 	})
 
-	t.Run("fails if new worker is not an account actor", func(t *testing.T) {
-		rt, actor := setupFunc()
-		actor.constructAndVerify(rt)
-		newWorker := tutil.NewIDAddr(t, 999)
-		rt.SetAddressActorType(newWorker, builtin.StorageMinerActorCodeID)
+	t.Run("beneficiary cannot withdraw after expiration", func(t *testing.T) {
+		rt := setup(t)
 
-		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
-		param := &miner.ChangeWorkerAddressParams{NewWorker: newWorker}
-		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
-			rt.Call(actor.a.ChangeWorkerAddress, param)
-		})
-		rt.Verify()
+		rt.SetEpoch(expiration + 1)
+		actor.withdrawFundsAs(rt, beneficiary, quota, big.Zero(), big.Zero())
+		assert.Equal(t, big.Zero(), actor.getInfo(rt).BeneficiaryTerm.UsedQuota)
+		actor.checkState(rt)
 	})
 
-	t.Run("fails when caller is not the owner", func(t *testing.T) {
-		rt, actor := setupFunc()
+	t.Run("owner beneficiary follows change of owner", func(t *testing.T) {
+		rt := builder.Build(t)
 		actor.constructAndVerify(rt)
-		newWorker := tutil.NewIDAddr(t, 999)
-		rt.SetAddressActorType(newWorker, builtin.AccountActorCodeID)
 
-		rt.ExpectValidateCallerAddr(actor.owner)
-		rt.ExpectSend(newWorker, builtin.MethodsAccount.PubkeyAddress, nil, big.Zero(), &actor.key, exitcode.Ok)
+		rt.SetCaller(actor.owner, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, nominee)
+		rt.SetCaller(nominee, builtin.AccountActorCodeID)
+		actor.changeOwnerAddress(rt, nominee)
 
-		rt.SetCaller(actor.worker, builtin.AccountActorCodeID)
-		param := &miner.ChangeWorkerAddressParams{NewWorker: newWorker}
-		rt.ExpectAbort(exitcode.ErrForbidden, func() {
-			rt.Call(actor.a.ChangeWorkerAddress, param)
-		})
-		rt.Verify()
+		info := actor.getInfo(rt)
+		assert.Equal(t, nominee, info.Owner)
+		assert.Equal(t, nominee, info.Beneficiary)
+		actor.checkState(rt)
 	})
 }
 
@@ -2936,6 +4209,8 @@ func TestAddLockedFund(t *testing.T) {
 		}
 
 		assert.Equal(t, amt, st.LockedFunds)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("funds vest when under collateralized", func(t *testing.T) {
@@ -2963,6 +4238,8 @@ func TestAddLockedFund(t *testing.T) {
 		assert.False(t, st.IsDebtFree())
 		// all funds locked in vesting table
 		assert.Equal(t, amt, st.LockedFunds)
+
+		actor.checkState(rt)
 	})
 
 }
@@ -3196,6 +4473,12 @@ func (h *actorHarness) getInfo(rt *mock.Runtime) *miner.MinerInfo {
 	return info
 }
 
+func (h *actorHarness) checkState(rt *mock.Runtime) {
+	st := getState(rt)
+	_, msgs := miner.CheckStateInvariants(st, rt.AdtStore(), rt.Balance())
+	assert.True(h.t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
+
 func (h *actorHarness) getDeadlines(rt *mock.Runtime) *miner.Deadlines {
 	st := getState(rt)
 	deadlines, err := st.LoadDeadlines(rt.AdtStore())
@@ -3291,22 +4574,6 @@ func (h *actorHarness) changeWorkerAddress(rt *mock.Runtime, newWorker addr.Addr
 	param.NewWorker = newWorker
 	rt.ExpectSend(newWorker, builtin.MethodsAccount.PubkeyAddress, nil, big.Zero(), &h.key, exitcode.Ok)
 
-	if newWorker != h.worker {
-		cronPayload := miner.CronEventPayload{
-			EventType: miner.CronEventWorkerKeyChange,
-		}
-		payload := new(bytes.Buffer)
-		err := cronPayload.MarshalCBOR(payload)
-		require.NoError(h.t, err)
-		cronEvt := &power.EnrollCronEventParams{
-			EventEpoch: effectiveEpoch,
-			Payload:    payload.Bytes(),
-		}
-
-		rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.EnrollCronEvent,
-			cronEvt, big.Zero(), nil, exitcode.Ok)
-	}
-
 	rt.ExpectValidateCallerAddr(h.owner)
 	rt.SetCaller(h.owner, builtin.AccountActorCodeID)
 	rt.Call(h.a.ChangeWorkerAddress, param)
@@ -3319,6 +4586,8 @@ func (h *actorHarness) changeWorkerAddress(rt *mock.Runtime, newWorker addr.Addr
 	if newWorker != h.worker {
 		require.EqualValues(h.t, effectiveEpoch, info.PendingWorkerKey.EffectiveAt)
 		require.EqualValues(h.t, newWorker, info.PendingWorkerKey.NewWorker)
+	} else {
+		require.Nil(h.t, info.PendingWorkerKey)
 	}
 
 	var controlAddrs []addr.Address
@@ -3331,6 +4600,28 @@ func (h *actorHarness) changeWorkerAddress(rt *mock.Runtime, newWorker addr.Addr
 
 }
 
+func (h *actorHarness) changeOwnerAddress(rt *mock.Runtime, newAddr addr.Address) {
+	info := h.getInfo(rt)
+	if rt.Caller() == info.Owner || info.PendingOwnerAddress == nil {
+		rt.ExpectValidateCallerAddr(info.Owner)
+	} else {
+		rt.ExpectValidateCallerAddr(*info.PendingOwnerAddress)
+	}
+	rt.Call(h.a.ChangeOwnerAddress, &newAddr)
+	rt.Verify()
+}
+
+func (h *actorHarness) changeBeneficiary(rt *mock.Runtime, caller, newBeneficiary addr.Address, quota abi.TokenAmount, expiration abi.ChainEpoch) {
+	rt.SetCaller(caller, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerAny()
+	rt.Call(h.a.ChangeBeneficiary, &miner.ChangeBeneficiaryParams{
+		NewBeneficiary: newBeneficiary,
+		NewQuota:       quota,
+		NewExpiration:  expiration,
+	})
+	rt.Verify()
+}
+
 func (h *actorHarness) checkSectorProven(rt *mock.Runtime, sectorNum abi.SectorNumber) {
 	param := &miner.CheckSectorProvenParams{sectorNum}
 
@@ -3342,7 +4633,7 @@ func (h *actorHarness) checkSectorProven(rt *mock.Runtime, sectorNum abi.SectorN
 
 func (h *actorHarness) changeMultiAddrs(rt *mock.Runtime, newAddrs []abi.Multiaddrs) {
 	param := &miner.ChangeMultiaddrsParams{newAddrs}
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
 
 	rt.Call(h.a.ChangeMultiaddrs, param)
@@ -3357,7 +4648,7 @@ func (h *actorHarness) changeMultiAddrs(rt *mock.Runtime, newAddrs []abi.Multiad
 
 func (h *actorHarness) changePeerID(rt *mock.Runtime, newPID abi.PeerID) {
 	param := &miner.ChangePeerIDParams{NewID: newPID}
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
 
 	rt.Call(h.a.ChangePeerID, param)
@@ -3368,13 +4659,10 @@ func (h *actorHarness) changePeerID(rt *mock.Runtime, newPID abi.PeerID) {
 	require.EqualValues(h.t, newPID, info.PeerId)
 }
 
-func (h *actorHarness) cronWorkerAddrChange(rt *mock.Runtime, effectiveEpoch abi.ChainEpoch, newWorker addr.Address) {
-	rt.SetEpoch(effectiveEpoch)
-	rt.SetCaller(builtin.StoragePowerActorAddr, builtin.StoragePowerActorCodeID)
-	rt.ExpectValidateCallerAddr(builtin.StoragePowerActorAddr)
-	rt.Call(h.a.OnDeferredCronEvent, &miner.CronEventPayload{
-		EventType: miner.CronEventWorkerKeyChange,
-	})
+func (h *actorHarness) confirmWorkerAddrChange(rt *mock.Runtime, newWorker addr.Address) {
+	rt.SetCaller(h.owner, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerAddr(h.owner)
+	rt.Call(h.a.ConfirmChangeWorkerAddress, nil)
 	rt.Verify()
 
 	st := getState(rt)
@@ -3393,26 +4681,51 @@ func (h *actorHarness) controlAddresses(rt *mock.Runtime) (owner, worker addr.Ad
 }
 
 func (h *actorHarness) preCommitSector(rt *mock.Runtime, params *miner.SectorPreCommitInfo) *miner.SectorPreCommitOnChainInfo {
+	h.expectPreCommitSectors(rt, params)
+	rt.Call(h.a.PreCommitSector, params)
+	rt.Verify()
+	return h.getPreCommit(rt, params.SectorNumber)
+}
 
+func (h *actorHarness) preCommitSectorBatch(rt *mock.Runtime, sectors ...*miner.SectorPreCommitInfo) []*miner.SectorPreCommitOnChainInfo {
+	params := miner.PreCommitSectorBatchParams{}
+	for _, sector := range sectors {
+		params.Sectors = append(params.Sectors, *sector)
+	}
+	h.expectPreCommitSectors(rt, sectors...)
+	rt.Call(h.a.PreCommitSectorBatch, &params)
+	rt.Verify()
+
+	var onChain []*miner.SectorPreCommitOnChainInfo
+	for _, sector := range sectors {
+		onChain = append(onChain, h.getPreCommit(rt, sector.SectorNumber))
+	}
+	return onChain
+}
+
+// Sets up expectations for a successful pre-commitment of some sectors in a single message.
+func (h *actorHarness) expectPreCommitSectors(rt *mock.Runtime, sectors ...*miner.SectorPreCommitInfo) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	{
 		expectQueryNetworkInfo(rt, h)
 	}
 	{
-		sectorSize, err := params.SealProof.SectorSize()
-		require.NoError(h.t, err)
-
-		vdParams := market.VerifyDealsForActivationParams{
-			DealIDs:      params.DealIDs,
-			SectorStart:  rt.Epoch(),
-			SectorExpiry: params.Expiration,
-		}
+		vdParams := market.VerifyDealsForActivationParams{SectorStart: rt.Epoch()}
+		vdReturn := market.VerifyDealsForActivationReturn{}
+		for _, sector := range sectors {
+			sectorSize, err := sector.SealProof.SectorSize()
+			require.NoError(h.t, err)
 
-		vdReturn := market.VerifyDealsForActivationReturn{
-			DealWeight:         big.NewInt(int64(sectorSize / 2)),
-			VerifiedDealWeight: big.NewInt(int64(sectorSize / 2)),
+			vdParams.Sectors = append(vdParams.Sectors, market.SectorDeals{
+				SectorExpiry: sector.Expiration,
+				DealIDs:      sector.DealIDs,
+			})
+			vdReturn.Sectors = append(vdReturn.Sectors, market.SectorWeights{
+				DealWeight:         big.NewInt(int64(sectorSize / 2)),
+				VerifiedDealWeight: big.NewInt(int64(sectorSize / 2)),
+			})
 		}
 		rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.VerifyDealsForActivation, &vdParams, big.Zero(), &vdReturn, exitcode.Ok)
 	}
@@ -3420,10 +4733,6 @@ func (h *actorHarness) preCommitSector(rt *mock.Runtime, params *miner.SectorPre
 	if st.FeeDebt.GreaterThan(big.Zero()) {
 		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, st.FeeDebt, nil, exitcode.Ok)
 	}
-
-	rt.Call(h.a.PreCommitSector, params)
-	rt.Verify()
-	return h.getPreCommit(rt, params.SectorNumber)
 }
 
 // Options for proveCommitSector behaviour.
@@ -3480,14 +4789,26 @@ func (h *actorHarness) proveCommitSector(rt *mock.Runtime, precommit *miner.Sect
 }
 
 func (h *actorHarness) confirmSectorProofsValid(rt *mock.Runtime, conf proveCommitConf, precommits ...*miner.SectorPreCommitInfo) {
+	h.expectConfirmSectorProofsValid(rt, conf, precommits...)
+
+	var allSectorNumbers []abi.SectorNumber
+	for _, precommit := range precommits {
+		allSectorNumbers = append(allSectorNumbers, precommit.SectorNumber)
+	}
+
+	rt.SetCaller(builtin.StoragePowerActorAddr, builtin.StoragePowerActorCodeID)
+	rt.ExpectValidateCallerAddr(builtin.StoragePowerActorAddr)
+	rt.Call(h.a.ConfirmSectorProofsValid, &builtin.ConfirmSectorProofsParams{Sectors: allSectorNumbers})
+	rt.Verify()
+}
+
+// Sets up expectations for the activation of pre-committed sectors whose proofs have been verified.
+func (h *actorHarness) expectConfirmSectorProofsValid(rt *mock.Runtime, conf proveCommitConf, precommits ...*miner.SectorPreCommitInfo) {
 	// expect calls to get network stats
 	expectQueryNetworkInfo(rt, h)
 
-	// Prepare for and receive call to ConfirmSectorProofsValid.
 	var validPrecommits []*miner.SectorPreCommitInfo
-	var allSectorNumbers []abi.SectorNumber
 	for _, precommit := range precommits {
-		allSectorNumbers = append(allSectorNumbers, precommit.SectorNumber)
 
 		vdParams := market.ActivateDealsParams{
 			DealIDs:      precommit.DealIDs,
@@ -3535,10 +4856,59 @@ func (h *actorHarness) confirmSectorProofsValid(rt *mock.Runtime, conf proveComm
 			rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.UpdatePledgeTotal, &expectPledge, big.Zero(), nil, exitcode.Ok)
 		}
 	}
+}
 
-	rt.SetCaller(builtin.StoragePowerActorAddr, builtin.StoragePowerActorCodeID)
-	rt.ExpectValidateCallerAddr(builtin.StoragePowerActorAddr)
-	rt.Call(h.a.ConfirmSectorProofsValid, &builtin.ConfirmSectorProofsParams{Sectors: allSectorNumbers})
+func (h *actorHarness) proveCommitAggregateSector(rt *mock.Runtime, conf proveCommitConf, precommits []*miner.SectorPreCommitInfo,
+	precommitEpoch abi.ChainEpoch, proof []byte) {
+	commd := cbg.CborCid(tutil.MakeCID("commd", &market.PieceCIDPrefix))
+	sealRand := abi.SealRandomness([]byte{1, 2, 3, 4})
+	sealIntRand := abi.InteractiveSealRandomness([]byte{5, 6, 7, 8})
+	interactiveEpoch := precommitEpoch + miner.PreCommitChallengeDelay
+
+	var buf bytes.Buffer
+	receiver := rt.Receiver()
+	err := receiver.MarshalCBOR(&buf)
+	require.NoError(h.t, err)
+
+	sectorNos := bitfield.New()
+	var infos []abi.AggregateSealVerifyInfo
+	for _, precommit := range precommits {
+		sectorNos.Set(uint64(precommit.SectorNumber))
+
+		cdcParams := market.ComputeDataCommitmentParams{
+			DealIDs:    precommit.DealIDs,
+			SectorType: precommit.SealProof,
+		}
+		rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.ComputeDataCommitment, &cdcParams, big.Zero(), &commd, exitcode.Ok)
+		rt.ExpectGetRandomnessTickets(crypto.DomainSeparationTag_SealRandomness, precommit.SealRandEpoch, buf.Bytes(), abi.Randomness(sealRand))
+		rt.ExpectGetRandomnessBeacon(crypto.DomainSeparationTag_InteractiveSealChallengeSeed, interactiveEpoch, buf.Bytes(), abi.Randomness(sealIntRand))
+
+		infos = append(infos, abi.AggregateSealVerifyInfo{
+			Number:                precommit.SectorNumber,
+			Randomness:            sealRand,
+			InteractiveRandomness: sealIntRand,
+			SealedCID:             precommit.SealedCID,
+			UnsealedCID:           cid.Cid(commd),
+		})
+	}
+
+	actorId, err := addr.IDFromAddress(h.receiver)
+	require.NoError(h.t, err)
+	rt.ExpectAggregateVerifySeals(abi.AggregateSealVerifyProofAndInfos{
+		Miner:          abi.ActorID(actorId),
+		SealProof:      h.sealProofType,
+		AggregateProof: abi.RegisteredAggregationProof_SnarkPackV1,
+		Proof:          proof,
+		Infos:          infos,
+	}, nil)
+	h.expectConfirmSectorProofsValid(rt, conf, precommits...)
+
+	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
+	rt.Call(h.a.ProveCommitAggregate, &miner.ProveCommitAggregateParams{
+		SectorNumbers:  sectorNos,
+		AggregateProof: proof,
+	})
 	rt.Verify()
 }
 
@@ -3586,7 +4956,7 @@ func (h *actorHarness) commitAndProveSectors(rt *mock.Runtime, n int, lifetimePe
 
 func (h *actorHarness) compactSectorNumbers(rt *mock.Runtime, bf bitfield.BitField) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	rt.Call(h.a.CompactSectorNumbers, &miner.CompactSectorNumbersParams{
 		MaskSectorNumbers: bf,
@@ -3632,15 +5002,15 @@ func (h *actorHarness) submitWindowPoSt(rt *mock.Runtime, deadline *miner.Deadli
 	commitRand := abi.Randomness("chaincommitment")
 	rt.ExpectGetRandomnessTickets(crypto.DomainSeparationTag_PoStChainCommit, deadline.Challenge, nil, commitRand)
 
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	expectQueryNetworkInfo(rt, h)
 
 	proofs := makePoStProofs(h.postProofType)
-	challengeRand := abi.SealRandomness([]byte{10, 11, 12, 13})
 
 	// only sectors that are not skipped and not existing non-recovered faults will be verified
 	allIgnored := bf()
+	recovering := false
 	dln := h.getDeadline(rt, deadline.Index)
 	for _, p := range partitions {
 		partition := h.getPartition(rt, dln, p.Index)
@@ -3648,54 +5018,17 @@ func (h *actorHarness) submitWindowPoSt(rt *mock.Runtime, deadline *miner.Deadli
 		require.NoError(h.t, err)
 		allIgnored, err = bitfield.MultiMerge(allIgnored, expectedFaults, p.Skipped)
 		require.NoError(h.t, err)
-	}
-
-	// find the first non-faulty, non-skipped sector in poSt to replace all faulty sectors.
-	var goodInfo *miner.SectorOnChainInfo
-	for _, ci := range infos {
-		contains, err := allIgnored.IsSet(uint64(ci.SectorNumber))
-		require.NoError(h.t, err)
-		if !contains {
-			goodInfo = ci
-			break
-		}
-	}
 
-	// goodInfo == nil indicates all the sectors have been skipped and should PoSt verification should not occur
-	if goodInfo != nil {
-		var buf bytes.Buffer
-		receiver := rt.Receiver()
-		err := receiver.MarshalCBOR(&buf)
+		recovered, err := bitfield.SubtractBitField(partition.Recoveries, p.Skipped)
 		require.NoError(h.t, err)
-
-		rt.ExpectGetRandomnessBeacon(crypto.DomainSeparationTag_WindowedPoStChallengeSeed, deadline.Challenge, buf.Bytes(), abi.Randomness(challengeRand))
-
-		actorId, err := addr.IDFromAddress(h.receiver)
+		noRecoveries, err := recovered.IsEmpty()
 		require.NoError(h.t, err)
+		recovering = recovering || !noRecoveries
+	}
 
-		// if not all sectors are skipped
-		proofInfos := make([]abi.SectorInfo, len(infos))
-		for i, ci := range infos {
-			si := ci
-			contains, err := allIgnored.IsSet(uint64(ci.SectorNumber))
-			require.NoError(h.t, err)
-			if contains {
-				si = goodInfo
-			}
-			proofInfos[i] = abi.SectorInfo{
-				SealProof:    si.SealProof,
-				SectorNumber: si.SectorNumber,
-				SealedCID:    si.SealedCID,
-			}
-		}
-
-		vi := abi.WindowPoStVerifyInfo{
-			Randomness:        abi.PoStRandomness(challengeRand),
-			Proofs:            proofs,
-			ChallengedSectors: proofInfos,
-			Prover:            abi.ActorID(actorId),
-		}
-		rt.ExpectVerifyPoSt(vi, nil)
+	// Proofs are accepted without verification unless they recover power.
+	if recovering {
+		h.expectVerifyWindowPoSt(rt, deadline.Challenge, infos, allIgnored, proofs, nil)
 	}
 	if poStCfg != nil {
 		// expect power update
@@ -3728,9 +5061,129 @@ func (h *actorHarness) submitWindowPoSt(rt *mock.Runtime, deadline *miner.Deadli
 	rt.Verify()
 }
 
+// Expects verification of a Window PoSt for the given sectors, with ignored sectors substituted by a good one.
+// No verification is expected if all sectors are ignored.
+func (h *actorHarness) expectVerifyWindowPoSt(rt *mock.Runtime, challenge abi.ChainEpoch, infos []*miner.SectorOnChainInfo,
+	ignored bitfield.BitField, proofs []abi.PoStProof, verifyErr error) {
+	challengeRand := abi.SealRandomness([]byte{10, 11, 12, 13})
+
+	// find the first non-faulty, non-skipped sector in poSt to replace all faulty sectors.
+	var goodInfo *miner.SectorOnChainInfo
+	for _, ci := range infos {
+		contains, err := ignored.IsSet(uint64(ci.SectorNumber))
+		require.NoError(h.t, err)
+		if !contains {
+			goodInfo = ci
+			break
+		}
+	}
+
+	// goodInfo == nil indicates all the sectors have been skipped and should PoSt verification should not occur
+	if goodInfo == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	receiver := rt.Receiver()
+	err := receiver.MarshalCBOR(&buf)
+	require.NoError(h.t, err)
+
+	rt.ExpectGetRandomnessBeacon(crypto.DomainSeparationTag_WindowedPoStChallengeSeed, challenge, buf.Bytes(), abi.Randomness(challengeRand))
+
+	actorId, err := addr.IDFromAddress(h.receiver)
+	require.NoError(h.t, err)
+
+	proofInfos := make([]abi.SectorInfo, len(infos))
+	for i, ci := range infos {
+		si := ci
+		contains, err := ignored.IsSet(uint64(ci.SectorNumber))
+		require.NoError(h.t, err)
+		if contains {
+			si = goodInfo
+		}
+		proofInfos[i] = abi.SectorInfo{
+			SealProof:    si.SealProof,
+			SectorNumber: si.SectorNumber,
+			SealedCID:    si.SealedCID,
+		}
+	}
+
+	vi := abi.WindowPoStVerifyInfo{
+		Randomness:        abi.PoStRandomness(challengeRand),
+		Proofs:            proofs,
+		ChallengedSectors: proofInfos,
+		Prover:            abi.ActorID(actorId),
+	}
+	rt.ExpectVerifyPoSt(vi, verifyErr)
+}
+
+type poStDisputeResult struct {
+	expectedPowerDelta  miner.PowerPair
+	expectedPledgeDelta abi.TokenAmount
+	expectedPenalty     abi.TokenAmount
+	expectedReward      abi.TokenAmount
+}
+
+// Disputes a proof optimistically accepted at a deadline. The proof is expected to fail verification if
+// dispute is non-nil, and to be valid otherwise.
+func (h *actorHarness) disputeWindowPoSt(rt *mock.Runtime, deadline *miner.DeadlineInfo, proofIndex uint64, infos []*miner.SectorOnChainInfo, dispute *poStDisputeResult) {
+	disputer := tutil.NewIDAddr(h.t, 1000)
+	rt.SetCaller(disputer, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerType(builtin.CallerTypesSignable...)
+
+	expectQueryNetworkInfo(rt, h)
+	proofs := makePoStProofs(h.postProofType)
+	if dispute != nil {
+		h.expectVerifyWindowPoSt(rt, deadline.Challenge, infos, bf(), proofs, fmt.Errorf("invalid post"))
+		if !dispute.expectedPowerDelta.IsZero() {
+			claim := &power.UpdateClaimedPowerParams{
+				RawByteDelta:         dispute.expectedPowerDelta.Raw,
+				QualityAdjustedDelta: dispute.expectedPowerDelta.QA,
+			}
+			rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.UpdateClaimedPower, claim, abi.NewTokenAmount(0),
+				nil, exitcode.Ok)
+		}
+		if dispute.expectedReward.GreaterThan(big.Zero()) {
+			rt.ExpectSend(disputer, builtin.MethodSend, nil, dispute.expectedReward, nil, exitcode.Ok)
+		}
+		if dispute.expectedPenalty.GreaterThan(big.Zero()) {
+			rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, dispute.expectedPenalty, nil, exitcode.Ok)
+		}
+		if !dispute.expectedPledgeDelta.IsZero() {
+			rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.UpdatePledgeTotal, &dispute.expectedPledgeDelta,
+				abi.NewTokenAmount(0), nil, exitcode.Ok)
+		}
+	} else {
+		h.expectVerifyWindowPoSt(rt, deadline.Challenge, infos, bf(), proofs, nil)
+	}
+
+	params := miner.DisputeWindowedPoStParams{
+		Deadline:  deadline.Index,
+		PoStIndex: proofIndex,
+	}
+	rt.Call(h.a.DisputeWindowedPoSt, &params)
+	rt.Verify()
+}
+
+// Returns the partitions proven by each proof in an array of proofs accepted without verification.
+func (h *actorHarness) getOptimisticProofPartitions(rt *mock.Runtime, root cid.Cid) []uint64 {
+	proofs, err := adt.AsArray(rt.AdtStore(), root)
+	require.NoError(h.t, err)
+	var partitions []uint64
+	var post miner.WindowedPoSt
+	err = proofs.ForEach(&post, func(_ int64) error {
+		return post.Partitions.ForEach(func(pIdx uint64) error {
+			partitions = append(partitions, pIdx)
+			return nil
+		})
+	})
+	require.NoError(h.t, err)
+	return partitions
+}
+
 func (h *actorHarness) declareFaults(rt *mock.Runtime, faultSectorInfos ...*miner.SectorOnChainInfo) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	ss, err := faultSectorInfos[0].SealProof.SectorSize()
 	require.NoError(h.t, err)
@@ -3761,7 +5214,7 @@ func (h *actorHarness) declareFaults(rt *mock.Runtime, faultSectorInfos ...*mine
 
 func (h *actorHarness) declareRecoveries(rt *mock.Runtime, deadlineIdx uint64, partitionIdx uint64, recoverySectors bitfield.BitField, expectedDebtRepaid abi.TokenAmount) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	if expectedDebtRepaid.GreaterThan(big.Zero()) {
 		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, expectedDebtRepaid, nil, exitcode.Ok)
@@ -3779,8 +5232,19 @@ func (h *actorHarness) declareRecoveries(rt *mock.Runtime, deadlineIdx uint64, p
 }
 
 func (h *actorHarness) extendSectors(rt *mock.Runtime, params *miner.ExtendSectorExpirationParams) {
+	h.extendSectorsAndDeals(rt, params, nil, nil, nil)
+}
+
+// Extends sectors, expecting the market actor to extend their deals with the given params and return,
+// adding the given weights to the extended sectors.
+func (h *actorHarness) extendSectorsAndDeals(rt *mock.Runtime, params *miner.ExtendSectorExpirationParams,
+	dealParams *market.ExtendDealsParams, dealRet *market.ExtendDealsReturn, addedWeights map[abi.SectorNumber]market.SectorWeights) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
+
+	if dealParams != nil {
+		rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.ExtendDeals, dealParams, big.Zero(), dealRet, exitcode.Ok)
+	}
 
 	qaDelta := big.Zero()
 	for _, extension := range params.Extensions {
@@ -3788,6 +5252,10 @@ func (h *actorHarness) extendSectors(rt *mock.Runtime, params *miner.ExtendSecto
 			sector := h.getSector(rt, abi.SectorNumber(sno))
 			newSector := *sector
 			newSector.Expiration = extension.NewExpiration
+			if weights, ok := addedWeights[sector.SectorNumber]; ok {
+				newSector.DealWeight = big.Add(newSector.DealWeight, weights.DealWeight)
+				newSector.VerifiedDealWeight = big.Add(newSector.VerifiedDealWeight, weights.VerifiedDealWeight)
+			}
 			qaDelta = big.Sum(qaDelta,
 				miner.QAPowerForSector(h.sectorSize, &newSector),
 				miner.QAPowerForSector(h.sectorSize, sector).Neg(),
@@ -3812,9 +5280,89 @@ func (h *actorHarness) extendSectors(rt *mock.Runtime, params *miner.ExtendSecto
 	rt.Verify()
 }
 
+// Proves replica updates of CC sectors, expecting the market actor to return the given deal weights.
+// If verifyErr is non-nil, the first update's proof fails verification.
+func (h *actorHarness) proveReplicaUpdates(rt *mock.Runtime, updates []miner.ReplicaUpdate, weights []market.SectorWeights, verifyErr error) {
+	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
+
+	oldSectors := make([]*miner.SectorOnChainInfo, len(updates))
+	vdParams := market.VerifyDealsForActivationParams{SectorStart: rt.Epoch()}
+	for i, update := range updates {
+		oldSectors[i] = h.getSector(rt, update.SectorID)
+		vdParams.Sectors = append(vdParams.Sectors, market.SectorDeals{
+			SectorExpiry: oldSectors[i].Expiration,
+			DealIDs:      update.Deals,
+		})
+	}
+	vdReturn := market.VerifyDealsForActivationReturn{Sectors: weights}
+	rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.VerifyDealsForActivation, &vdParams, big.Zero(), &vdReturn, exitcode.Ok)
+
+	for i, update := range updates {
+		commd := cbg.CborCid(tutil.MakeCID(fmt.Sprintf("commd-%d", update.SectorID), &market.PieceCIDPrefix))
+		cdcParams := market.ComputeDataCommitmentParams{
+			DealIDs:    update.Deals,
+			SectorType: oldSectors[i].SealProof,
+		}
+		rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.ComputeDataCommitment, &cdcParams, big.Zero(), &commd, exitcode.Ok)
+		rt.ExpectVerifyReplicaUpdate(abi.ReplicaUpdateInfo{
+			UpdateProofType:      update.UpdateProofType,
+			OldSealedSectorCID:   oldSectors[i].SealedCID,
+			NewSealedSectorCID:   update.NewSealedSectorCID,
+			NewUnsealedSectorCID: cid.Cid(commd),
+			Proof:                update.ReplicaProof,
+		}, verifyErr)
+		if verifyErr != nil {
+			break
+		}
+
+		adParams := market.ActivateDealsParams{
+			DealIDs:      update.Deals,
+			SectorExpiry: oldSectors[i].Expiration,
+		}
+		rt.ExpectSend(builtin.StorageMarketActorAddr, builtin.MethodsMarket.ActivateDeals, &adParams, big.Zero(), nil, exitcode.Ok)
+	}
+
+	if verifyErr == nil {
+		expectQueryNetworkInfo(rt, h)
+
+		qaDelta := big.Zero()
+		pledgeDelta := big.Zero()
+		for i, oldSector := range oldSectors {
+			duration := oldSector.Expiration - rt.Epoch()
+			qaPower := miner.QAPowerForWeight(h.sectorSize, duration, weights[i].DealWeight, weights[i].VerifiedDealWeight)
+			pledge := miner.InitialPledgeForPower(qaPower, h.baselinePower, h.epochRewardSmooth,
+				h.epochQAPowerSmooth, rt.TotalFilCircSupply())
+			pledge = big.Max(pledge, oldSector.InitialPledge)
+
+			qaDelta = big.Sum(qaDelta, qaPower, miner.QAPowerForSector(h.sectorSize, oldSector).Neg())
+			pledgeDelta = big.Sum(pledgeDelta, pledge, oldSector.InitialPledge.Neg())
+		}
+
+		if !qaDelta.IsZero() {
+			rt.ExpectSend(builtin.StoragePowerActorAddr,
+				builtin.MethodsPower.UpdateClaimedPower,
+				&power.UpdateClaimedPowerParams{
+					RawByteDelta:         big.Zero(),
+					QualityAdjustedDelta: qaDelta,
+				},
+				abi.NewTokenAmount(0),
+				nil,
+				exitcode.Ok,
+			)
+		}
+		if !pledgeDelta.IsZero() {
+			rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.UpdatePledgeTotal, &pledgeDelta, big.Zero(), nil, exitcode.Ok)
+		}
+	}
+
+	rt.Call(h.a.ProveReplicaUpdates, &miner.ProveReplicaUpdatesParams{Updates: updates})
+	rt.Verify()
+}
+
 func (h *actorHarness) terminateSectors(rt *mock.Runtime, sectors bitfield.BitField, expectedFee abi.TokenAmount) {
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	dealIDs := []abi.DealID{}
 	sectorInfos := []*miner.SectorOnChainInfo{}
@@ -4024,10 +5572,21 @@ func (h *actorHarness) onDeadlineCron(rt *mock.Runtime, config *cronConfig) {
 }
 
 func (h *actorHarness) withdrawFunds(rt *mock.Runtime, amountRequested, amountWithdrawn, expectedDebtRepaid abi.TokenAmount) {
-	rt.SetCaller(h.owner, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(h.owner)
+	h.withdrawFundsAs(rt, h.owner, amountRequested, amountWithdrawn, expectedDebtRepaid)
+}
+
+func (h *actorHarness) withdrawFundsAs(rt *mock.Runtime, caller addr.Address, amountRequested, amountWithdrawn, expectedDebtRepaid abi.TokenAmount) {
+	info := h.getInfo(rt)
+	rt.SetCaller(caller, builtin.AccountActorCodeID)
+	if info.Beneficiary != info.Owner {
+		rt.ExpectValidateCallerAddr(info.Owner, info.Beneficiary)
+	} else {
+		rt.ExpectValidateCallerAddr(info.Owner)
+	}
 
-	rt.ExpectSend(h.owner, builtin.MethodSend, nil, amountWithdrawn, nil, exitcode.Ok)
+	if amountWithdrawn.GreaterThan(big.Zero()) {
+		rt.ExpectSend(info.Beneficiary, builtin.MethodSend, nil, amountWithdrawn, nil, exitcode.Ok)
+	}
 	if expectedDebtRepaid.GreaterThan(big.Zero()) {
 		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, expectedDebtRepaid, nil, exitcode.Ok)
 	}
@@ -4038,10 +5597,29 @@ func (h *actorHarness) withdrawFunds(rt *mock.Runtime, amountRequested, amountWi
 	rt.Verify()
 }
 
+func (h *actorHarness) repayDebt(rt *mock.Runtime, caller addr.Address, expectedFromVesting, expectedFromBalance abi.TokenAmount) {
+	rt.SetCaller(caller, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
+
+	repaid := big.Add(expectedFromVesting, expectedFromBalance)
+	if repaid.GreaterThan(big.Zero()) {
+		rt.ExpectSend(builtin.BurntFundsActorAddr, builtin.MethodSend, nil, repaid, nil, exitcode.Ok)
+	}
+	if !expectedFromVesting.IsZero() {
+		pledgeDelta := expectedFromVesting.Neg()
+		rt.ExpectSend(builtin.StoragePowerActorAddr, builtin.MethodsPower.UpdatePledgeTotal, &pledgeDelta, big.Zero(), nil, exitcode.Ok)
+	}
+
+	ret := rt.Call(h.a.RepayDebt, nil).(*miner.RepayDebtReturn)
+	rt.Verify()
+	assert.Equal(h.t, expectedFromVesting, ret.FromVesting)
+	assert.Equal(h.t, expectedFromBalance, ret.FromBalance)
+}
+
 func (h *actorHarness) compactPartitions(rt *mock.Runtime, deadline uint64, partitions bitfield.BitField) {
 	param := miner.CompactPartitionsParams{deadline, partitions}
 
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
 
 	rt.Call(h.a.CompactPartitions, &param)
@@ -4078,7 +5656,7 @@ func (h *actorHarness) setPeerID(rt *mock.Runtime, newID abi.PeerID) {
 	params := miner.ChangePeerIDParams{NewID: newID}
 
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	ret := rt.Call(h.a.ChangePeerID, &params)
 	assert.Nil(h.t, ret)
@@ -4096,7 +5674,7 @@ func (h *actorHarness) setMultiaddrs(rt *mock.Runtime, newMultiaddrs ...abi.Mult
 	params := miner.ChangeMultiaddrsParams{NewMultiaddrs: newMultiaddrs}
 
 	rt.SetCaller(h.worker, builtin.AccountActorCodeID)
-	rt.ExpectValidateCallerAddr(append(h.controlAddrs, h.owner, h.worker)...)
+	rt.ExpectValidateCallerAddr(append(append([]addr.Address{}, h.controlAddrs...), h.owner, h.worker)...)
 
 	ret := rt.Call(h.a.ChangeMultiaddrs, &params)
 	assert.Nil(h.t, ret)
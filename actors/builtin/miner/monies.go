@@ -26,6 +26,12 @@ var DeclaredFaultProjectionPeriod = abi.ChainEpoch((builtin.EpochsInDay * Declar
 // SP = BR(t, UndeclaredFaultProjectionPeriod)
 var UndeclaredFaultProjectionPeriod = abi.ChainEpoch(5) * builtin.EpochsInDay
 
+// Fixed penalty for a Window PoSt shown to be invalid by a dispute, in addition to the undeclared fault penalty.
+var BasePenaltyForDisputedWindowPoSt = big.Mul(big.NewInt(20), abi.TokenPrecision)
+
+// Reward paid, out of the penalty, to the party disputing an invalid Window PoSt.
+var BaseRewardForDisputedWindowPoSt = big.Mul(big.NewInt(4), abi.TokenPrecision)
+
 // Maximum number of days of BR a terminated sector can be penalized
 const TerminationLifetimeCap = abi.ChainEpoch(70)
 
@@ -59,6 +65,15 @@ func PledgePenaltyForUndeclaredFault(rewardEstimate, networkQAPowerEstimate *smo
 	return ExpectedRewardForPower(rewardEstimate, networkQAPowerEstimate, qaSectorPower, UndeclaredFaultProjectionPeriod)
 }
 
+// This is the penalty for a Window PoSt found invalid by a dispute, for the power it claimed to prove.
+// The power is penalized as an undeclared fault, plus a fixed amount to cover the disputer's reward.
+func PledgePenaltyForInvalidWindowPoSt(rewardEstimate, networkQAPowerEstimate *smoothing.FilterEstimate, qaSectorPower abi.StoragePower) abi.TokenAmount {
+	return big.Add(
+		PledgePenaltyForUndeclaredFault(rewardEstimate, networkQAPowerEstimate, qaSectorPower),
+		BasePenaltyForDisputedWindowPoSt,
+	)
+}
+
 // Penalty to locked pledge collateral for the termination of a sector before scheduled expiry.
 // SectorAge is the time between the sector's activation and termination.
 // replacedDayReward and replacedSectorAge are the day reward and age of the replaced sector in a capacity upgrade.
@@ -18,6 +18,11 @@ var WPoStProvingPeriod = abi.ChainEpoch(builtin.EpochsInDay) // 24 hours
 // The duration of a deadline's challenge window, the period before a deadline when the challenge is available.
 var WPoStChallengeWindow = abi.ChainEpoch(30 * 60 / builtin.EpochDurationSeconds) // 30 minutes (48 per day)
 
+// The period after a deadline's challenge window closes during which Window PoSt proofs accepted without
+// verification may be disputed. This is two finality periods, so that a dispute may be submitted after the
+// proofs it targets have become final.
+var WPoStDisputeWindow = 2 * ChainFinality // 1800 epochs (15 hours)
+
 // The number of non-overlapping PoSt deadlines in each proving period.
 const WPoStPeriodDeadlines = uint64(48)
 
@@ -35,6 +40,10 @@ func init() {
 	if abi.ChainEpoch(WPoStPeriodDeadlines)*WPoStChallengeWindow != WPoStProvingPeriod {
 		panic(fmt.Sprintf("incompatible proving period %d and challenge window %d", WPoStProvingPeriod, WPoStChallengeWindow))
 	}
+	// Check that a deadline's snapshot is not replaced before its dispute window ends.
+	if WPoStDisputeWindow >= WPoStProvingPeriod {
+		panic(fmt.Sprintf("dispute window %d must be shorter than proving period %d", WPoStDisputeWindow, WPoStProvingPeriod))
+	}
 }
 
 // The maximum number of sectors that a miner can have simultaneously active.
@@ -65,6 +74,23 @@ const (
 // Maximum bytes in a single prove-commit proof.
 const MaxProveCommitSize = 1024
 
+// Maximum bytes in an aggregate prove-commit proof.
+const MaxAggregateProofSize = 81960
+
+// Minimum and maximum number of sectors that may be proven in a single aggregate prove-commit.
+// Fewer sectors are cheaper to prove individually.
+const MinAggregatedSectors = 4
+const MaxAggregatedSectors = 819
+
+// Maximum number of sectors that may be pre-committed in a single batch.
+const PreCommitSectorBatchMaxSize = 256
+
+// Maximum number of sectors that may be updated in a single ProveReplicaUpdates invocation.
+const ProveReplicaUpdatesMaxSize = PreCommitSectorBatchMaxSize
+
+// Maximum bytes in a single replica update proof.
+const MaxReplicaUpdateProofSize = 4096
+
 // Maximum number of control addresses
 const MaxControlAddresses = 10
 
@@ -0,0 +1,615 @@
+package miner
+
+import (
+	addr "github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+)
+
+type DealSummary struct {
+	SectorStart      abi.ChainEpoch
+	SectorExpiration abi.ChainEpoch
+}
+
+type StateSummary struct {
+	LivePower     PowerPair
+	ActivePower   PowerPair
+	FaultyPower   PowerPair
+	Deals         map[abi.DealID]DealSummary
+	SealProofType abi.RegisteredSealProof
+}
+
+// Checks internal invariants of miner state.
+func CheckStateInvariants(st *State, store adt.Store, balance abi.TokenAmount) (*StateSummary, *builtin.MessageAccumulator) {
+	acc := &builtin.MessageAccumulator{}
+	sectorSize := abi.SectorSize(0)
+	minerSummary := &StateSummary{
+		LivePower:     NewPowerPairZero(),
+		ActivePower:   NewPowerPairZero(),
+		FaultyPower:   NewPowerPairZero(),
+		Deals:         map[abi.DealID]DealSummary{},
+		SealProofType: 0,
+	}
+
+	// Load data from linked structures.
+	if info, err := st.GetInfo(store); err != nil {
+		acc.Addf("error loading miner info: %v", err)
+		// Stop here, it's too hard to make other useful checks.
+		return minerSummary, acc
+	} else {
+		minerSummary.SealProofType = info.SealProofType
+		sectorSize = info.SectorSize
+		CheckMinerInfo(info, acc)
+	}
+
+	CheckMinerBalances(st, store, balance, acc)
+
+	var allocatedSectors bitfield.BitField
+	allocatedSectorsMap := map[uint64]bool{}
+	if err := store.Get(store.Context(), st.AllocatedSectors, &allocatedSectors); err != nil {
+		acc.Addf("error loading allocated sector bitfield: %v", err)
+	} else if err = allocatedSectors.ForEach(func(sno uint64) error {
+		allocatedSectorsMap[sno] = true
+		return nil
+	}); err != nil {
+		acc.Addf("error iterating allocated sector bitfield: %v", err)
+	}
+
+	CheckPreCommits(st, store, allocatedSectorsMap, acc)
+
+	allSectors := map[abi.SectorNumber]*SectorOnChainInfo{}
+	if sectorsArr, err := adt.AsArray(store, st.Sectors); err != nil {
+		acc.Addf("error loading sectors: %v", err)
+	} else {
+		var sector SectorOnChainInfo
+		err = sectorsArr.ForEach(&sector, func(sno int64) error {
+			cpy := sector
+			allSectors[abi.SectorNumber(sno)] = &cpy
+			acc.Require(allocatedSectorsMap[uint64(sno)], "on chain sector's sector number has not been allocated %d", sno)
+
+			for _, dealID := range sector.DealIDs {
+				minerSummary.Deals[dealID] = DealSummary{
+					SectorStart:      sector.Activation,
+					SectorExpiration: sector.Expiration,
+				}
+			}
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating sectors")
+	}
+
+	// Check deadlines
+	acc.Require(st.CurrentDeadline < WPoStPeriodDeadlines,
+		"current deadline index is greater than deadlines per period(%d): %d", WPoStPeriodDeadlines, st.CurrentDeadline)
+
+	deadlines, err := st.LoadDeadlines(store)
+	if err != nil {
+		acc.Addf("error loading deadlines: %v", err)
+		return minerSummary, acc
+	}
+
+	heldPledge := big.Zero()
+	seenSectors := make(map[abi.SectorNumber]bool)
+	err = deadlines.ForEach(store, func(dlIdx uint64, dl *Deadline) error {
+		acc := acc.WithPrefix("deadline %d: ", dlIdx) // Shadow
+		quant := st.QuantSpecForDeadline(dlIdx)
+		dlSummary := CheckDeadlineStateInvariants(dl, store, quant, sectorSize, allSectors, acc)
+
+		minerSummary.LivePower = minerSummary.LivePower.Add(dlSummary.LivePower)
+		minerSummary.ActivePower = minerSummary.ActivePower.Add(dlSummary.ActivePower)
+		minerSummary.FaultyPower = minerSummary.FaultyPower.Add(dlSummary.FaultyPower)
+		heldPledge = big.Sum(heldPledge, dlSummary.LivePledge, dlSummary.EarlyTerminationPledge)
+
+		for sno := range dlSummary.AllSectors {
+			acc.Require(!seenSectors[sno], "sector %d in multiple deadlines", sno)
+			seenSectors[sno] = true
+		}
+
+		if noEarlyTerminations, err := dlSummary.EarlyTerminatedPartitions.IsEmpty(); err != nil {
+			acc.Addf("error checking early terminations: %v", err)
+		} else if !noEarlyTerminations {
+			found, err := st.EarlyTerminations.IsSet(dlIdx)
+			acc.RequireNoError(err, "error checking state early terminations")
+			acc.Require(found, "deadline has early terminations but is not recorded in state")
+		}
+		return nil
+	})
+	acc.RequireNoError(err, "error iterating deadlines")
+
+	for sno := range allSectors {
+		acc.Require(seenSectors[sno], "sector %d not found in any deadline", sno)
+	}
+
+	// Pledge is held for live sectors and for sectors terminated early whose fees have not yet been processed.
+	acc.Require(st.InitialPledge.Equals(heldPledge), "initial pledge %v does not match pledge of live and early terminated sectors %v",
+		st.InitialPledge, heldPledge)
+
+	return minerSummary, acc
+}
+
+type DeadlineStateSummary struct {
+	AllSectors                map[abi.SectorNumber]bool
+	LiveSectors               map[abi.SectorNumber]bool
+	FaultySectors             map[abi.SectorNumber]bool
+	RecoveringSectors         map[abi.SectorNumber]bool
+	UnprovenSectors           map[abi.SectorNumber]bool
+	TerminatedSectors         map[abi.SectorNumber]bool
+	LivePower                 PowerPair
+	ActivePower               PowerPair
+	FaultyPower               PowerPair
+	LivePledge                abi.TokenAmount
+	EarlyTerminationPledge    abi.TokenAmount
+	EarlyTerminatedPartitions bitfield.BitField
+}
+
+func CheckDeadlineStateInvariants(deadline *Deadline, store adt.Store, quant QuantSpec, ssize abi.SectorSize,
+	sectors map[abi.SectorNumber]*SectorOnChainInfo, acc *builtin.MessageAccumulator) *DeadlineStateSummary {
+	summary := &DeadlineStateSummary{
+		AllSectors:                map[abi.SectorNumber]bool{},
+		LiveSectors:               map[abi.SectorNumber]bool{},
+		FaultySectors:             map[abi.SectorNumber]bool{},
+		RecoveringSectors:         map[abi.SectorNumber]bool{},
+		UnprovenSectors:           map[abi.SectorNumber]bool{},
+		TerminatedSectors:         map[abi.SectorNumber]bool{},
+		LivePower:                 NewPowerPairZero(),
+		ActivePower:               NewPowerPairZero(),
+		FaultyPower:               NewPowerPairZero(),
+		LivePledge:                big.Zero(),
+		EarlyTerminationPledge:    big.Zero(),
+		EarlyTerminatedPartitions: bitfield.New(),
+	}
+
+	// Load linked structures.
+	partitions, err := deadline.PartitionsArray(store)
+	if err != nil {
+		acc.Addf("error loading partitions: %v", err)
+		// Hard to do any useful checks.
+		return summary
+	}
+
+	partitionCount := uint64(0)
+	partitionsWithExpirations := map[abi.ChainEpoch][]uint64{}
+	var partition Partition
+	err = partitions.ForEach(&partition, func(idx int64) error {
+		pIdx := uint64(idx)
+		// Check sequential partitions.
+		acc.Require(pIdx == partitionCount, "Non-sequential partitions, expected index %d, found %d", partitionCount, pIdx)
+		partitionCount++
+
+		acc := acc.WithPrefix("partition %d: ", pIdx) // Shadow
+		pSummary := CheckPartitionStateInvariants(&partition, store, quant, ssize, sectors, acc)
+
+		for sno := range pSummary.AllSectors {
+			acc.Require(!summary.AllSectors[sno], "sector %d in multiple partitions", sno)
+			summary.AllSectors[sno] = true
+		}
+		mergeSectorSet(summary.LiveSectors, pSummary.LiveSectors)
+		mergeSectorSet(summary.FaultySectors, pSummary.FaultySectors)
+		mergeSectorSet(summary.RecoveringSectors, pSummary.RecoveringSectors)
+		mergeSectorSet(summary.UnprovenSectors, pSummary.UnprovenSectors)
+		mergeSectorSet(summary.TerminatedSectors, pSummary.TerminatedSectors)
+
+		summary.LivePower = summary.LivePower.Add(pSummary.LivePower)
+		summary.ActivePower = summary.ActivePower.Add(pSummary.ActivePower)
+		summary.FaultyPower = summary.FaultyPower.Add(pSummary.FaultyPower)
+		summary.LivePledge = big.Add(summary.LivePledge, pSummary.LivePledge)
+		summary.EarlyTerminationPledge = big.Add(summary.EarlyTerminationPledge, pSummary.EarlyTerminationPledge)
+
+		for _, e := range pSummary.ExpirationEpochs {
+			partitionsWithExpirations[e] = append(partitionsWithExpirations[e], pIdx)
+		}
+		if pSummary.EarlyTerminationCount > 0 {
+			summary.EarlyTerminatedPartitions.Set(pIdx)
+		}
+		return nil
+	})
+	acc.RequireNoError(err, "error iterating partitions")
+
+	// Check invariants on partitions proven.
+	if lastProof, err := deadline.PostSubmissions.Last(); err != nil {
+		if err != bitfield.ErrNoBitsSet {
+			acc.Addf("error determining the last partition proven: %v", err)
+		}
+	} else {
+		acc.Require(partitionCount >= (lastProof+1), "expected at least %d partitions, found %d", lastProof+1, partitionCount)
+		acc.Require(deadline.LiveSectors > 0, "expected at least one live sector when partitions have been proven")
+	}
+
+	// Check that proofs accepted without verification cover only partitions proven in the current window.
+	if proofs, err := adt.AsArray(store, deadline.OptimisticPoStSubmissions); err != nil {
+		acc.Addf("error loading optimistic proofs: %v", err)
+	} else {
+		var post WindowedPoSt
+		err = proofs.ForEach(&post, func(idx int64) error {
+			contains, err := abi.BitFieldContainsAll(deadline.PostSubmissions, post.Partitions)
+			if err != nil {
+				return err
+			}
+			acc.Require(contains, "optimistic proof %d covers partitions not proven", idx)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating optimistic proofs")
+	}
+
+	// Check partitions that have early terminations.
+	if contains, err := abi.BitFieldContainsAll(summary.EarlyTerminatedPartitions, deadline.EarlyTerminations); err != nil {
+		acc.Addf("error checking deadline early terminations: %v", err)
+	} else {
+		acc.Require(contains, "deadline early terminations %v not a subset of partitions with early terminations", deadline.EarlyTerminations)
+	}
+	if contains, err := abi.BitFieldContainsAll(deadline.EarlyTerminations, summary.EarlyTerminatedPartitions); err != nil {
+		acc.Addf("error checking deadline early terminations: %v", err)
+	} else {
+		acc.Require(contains, "partitions with early terminations not recorded in deadline early terminations %v", deadline.EarlyTerminations)
+	}
+
+	// Check live and total sector counts.
+	acc.Require(deadline.LiveSectors == uint64(len(summary.LiveSectors)),
+		"deadline live sectors %d != partitions count %d", deadline.LiveSectors, len(summary.LiveSectors))
+	acc.Require(deadline.TotalSectors == uint64(len(summary.AllSectors)),
+		"deadline total sectors %d != partitions count %d", deadline.TotalSectors, len(summary.AllSectors))
+
+	// Check memoized faulty power.
+	acc.Require(deadline.FaultyPower.Equals(summary.FaultyPower), "deadline faulty power %v != partitions total %v",
+		deadline.FaultyPower, summary.FaultyPower)
+
+	// Check that every partition with an expiration is recorded in the deadline expiration queue at that epoch.
+	if expirationEpochs, err := LoadBitfieldQueue(store, deadline.ExpirationsEpochs, quant); err != nil {
+		acc.Addf("error loading expiration queue: %v", err)
+	} else {
+		queued := map[abi.ChainEpoch]map[uint64]bool{}
+		err = expirationEpochs.ForEach(func(epoch abi.ChainEpoch, bf bitfield.BitField) error {
+			acc.Require(quant.QuantizeUp(epoch) == epoch, "expiration queue key %d is not quantized", epoch)
+			queued[epoch] = map[uint64]bool{}
+			return bf.ForEach(func(pIdx uint64) error {
+				acc.Require(pIdx < partitionCount, "expiration queue at epoch %d references missing partition %d", epoch, pIdx)
+				queued[epoch][pIdx] = true
+				return nil
+			})
+		})
+		acc.RequireNoError(err, "error iterating deadline expiration queue")
+
+		for epoch, pIdxs := range partitionsWithExpirations {
+			for _, pIdx := range pIdxs {
+				acc.Require(queued[epoch][pIdx], "partition %d has expirations at epoch %d not recorded in deadline queue", pIdx, epoch)
+			}
+		}
+	}
+
+	return summary
+}
+
+type PartitionStateSummary struct {
+	AllSectors             map[abi.SectorNumber]bool
+	LiveSectors            map[abi.SectorNumber]bool
+	FaultySectors          map[abi.SectorNumber]bool
+	RecoveringSectors      map[abi.SectorNumber]bool
+	UnprovenSectors        map[abi.SectorNumber]bool
+	TerminatedSectors      map[abi.SectorNumber]bool
+	LivePower              PowerPair
+	ActivePower            PowerPair
+	FaultyPower            PowerPair
+	RecoveringPower        PowerPair
+	LivePledge             abi.TokenAmount
+	EarlyTerminationPledge abi.TokenAmount
+	ExpirationEpochs       []abi.ChainEpoch // Epochs at which some sector is scheduled to expire.
+	EarlyTerminationCount  int
+}
+
+func CheckPartitionStateInvariants(
+	partition *Partition,
+	store adt.Store,
+	quant QuantSpec,
+	sectorSize abi.SectorSize,
+	sectors map[abi.SectorNumber]*SectorOnChainInfo,
+	acc *builtin.MessageAccumulator,
+) *PartitionStateSummary {
+	summary := &PartitionStateSummary{
+		AllSectors:             loadSectorSet(partition.Sectors, acc, "sectors"),
+		FaultySectors:          loadSectorSet(partition.Faults, acc, "faults"),
+		RecoveringSectors:      loadSectorSet(partition.Recoveries, acc, "recoveries"),
+		UnprovenSectors:        loadSectorSet(partition.Unproven, acc, "unproven"),
+		TerminatedSectors:      loadSectorSet(partition.Terminated, acc, "terminated"),
+		LiveSectors:            map[abi.SectorNumber]bool{},
+		LivePower:              partition.LivePower,
+		ActivePower:            partition.ActivePower(),
+		FaultyPower:            partition.FaultyPower,
+		RecoveringPower:        partition.RecoveringPower,
+		LivePledge:             big.Zero(),
+		EarlyTerminationPledge: big.Zero(),
+	}
+	for sno := range summary.AllSectors {
+		if !summary.TerminatedSectors[sno] {
+			summary.LiveSectors[sno] = true
+		}
+	}
+
+	// Validate bitfield subset and disjointness relations.
+	requireSubset(acc, summary.FaultySectors, summary.LiveSectors, "faults", "live sectors")
+	requireSubset(acc, summary.RecoveringSectors, summary.FaultySectors, "recoveries", "faults")
+	requireSubset(acc, summary.UnprovenSectors, summary.LiveSectors, "unproven", "live sectors")
+	requireSubset(acc, summary.TerminatedSectors, summary.AllSectors, "terminated", "sectors")
+	for sno := range summary.UnprovenSectors {
+		acc.Require(!summary.FaultySectors[sno], "unproven sector %d is also faulty", sno)
+	}
+
+	// Validate power against sector infos.
+	liveInfos := selectSectorInfos(summary.LiveSectors, sectors, acc, "live")
+	faultyInfos := selectSectorInfos(summary.FaultySectors, sectors, acc, "faulty")
+	recoveringInfos := selectSectorInfos(summary.RecoveringSectors, sectors, acc, "recovering")
+	unprovenInfos := selectSectorInfos(summary.UnprovenSectors, sectors, acc, "unproven")
+
+	livePower := PowerForSectors(sectorSize, liveInfos)
+	acc.Require(partition.LivePower.Equals(livePower), "live power was %v, expected %v", partition.LivePower, livePower)
+	faultyPower := PowerForSectors(sectorSize, faultyInfos)
+	acc.Require(partition.FaultyPower.Equals(faultyPower), "faulty power was %v, expected %v", partition.FaultyPower, faultyPower)
+	recoveringPower := PowerForSectors(sectorSize, recoveringInfos)
+	acc.Require(partition.RecoveringPower.Equals(recoveringPower), "recovering power was %v, expected %v", partition.RecoveringPower, recoveringPower)
+	unprovenPower := PowerForSectors(sectorSize, unprovenInfos)
+	acc.Require(partition.UnprovenPower.Equals(unprovenPower), "unproven power was %v, expected %v", partition.UnprovenPower, unprovenPower)
+
+	for _, sector := range liveInfos {
+		summary.LivePledge = big.Add(summary.LivePledge, sector.InitialPledge)
+	}
+
+	// Validate the expiration queue.
+	if expQ, err := LoadExpirationQueue(store, partition.ExpirationsEpochs, quant); err != nil {
+		acc.Addf("error loading expiration queue: %v", err)
+	} else {
+		summary.ExpirationEpochs = checkExpirationQueue(expQ, summary, sectors, sectorSize, acc)
+	}
+
+	// Validate the early termination queue.
+	if earlyQ, err := LoadBitfieldQueue(store, partition.EarlyTerminated, NoQuantization); err != nil {
+		acc.Addf("error loading early termination queue: %v", err)
+	} else {
+		err = earlyQ.ForEach(func(epoch abi.ChainEpoch, bf bitfield.BitField) error {
+			return bf.ForEach(func(i uint64) error {
+				sno := abi.SectorNumber(i)
+				acc.Require(summary.TerminatedSectors[sno], "early terminated sector %d at epoch %d is not terminated", sno, epoch)
+				if sector, found := sectors[sno]; found {
+					summary.EarlyTerminationPledge = big.Add(summary.EarlyTerminationPledge, sector.InitialPledge)
+				} else {
+					acc.Addf("early terminated sector %d not found in sectors", sno)
+				}
+				summary.EarlyTerminationCount++
+				return nil
+			})
+		})
+		acc.RequireNoError(err, "error iterating early termination queue")
+	}
+
+	return summary
+}
+
+// Checks that the expiration queue contains exactly the live sectors, each once, with power and pledge
+// matching the sector infos. Returns the queue's epochs.
+func checkExpirationQueue(expQ ExpirationQueue, partition *PartitionStateSummary, sectors map[abi.SectorNumber]*SectorOnChainInfo,
+	sectorSize abi.SectorSize, acc *builtin.MessageAccumulator) []abi.ChainEpoch {
+	var epochs []abi.ChainEpoch
+	seenSectors := make(map[abi.SectorNumber]bool)
+	queuedActive := NewPowerPairZero()
+	queuedFaulty := NewPowerPairZero()
+
+	var exp ExpirationSet
+	err := expQ.ForEach(&exp, func(e int64) error {
+		epoch := abi.ChainEpoch(e)
+		acc := acc.WithPrefix("expiration epoch %d: ", epoch) // Shadow
+		acc.Require(expQ.quant.QuantizeUp(epoch) == epoch, "expiration queue key %d is not quantized", epoch)
+		epochs = append(epochs, epoch)
+
+		onTimePledge := big.Zero()
+		onTime := loadSectorSet(exp.OnTimeSectors, acc, "on-time sectors")
+		for sno := range onTime {
+			acc.Require(!seenSectors[sno], "sector %d in expiration queue twice", sno)
+			seenSectors[sno] = true
+			acc.Require(partition.LiveSectors[sno], "on-time expiring sector %d is not live", sno)
+
+			// Note: sectors replaced by a committed capacity upgrade are rescheduled to expire on-time before
+			// their recorded expiration, so the queue epoch is not compared with the sector's expiration.
+			if sector, found := sectors[sno]; found {
+				onTimePledge = big.Add(onTimePledge, sector.InitialPledge)
+			} else {
+				acc.Addf("on-time expiring sector %d not found in sectors", sno)
+			}
+		}
+
+		early := loadSectorSet(exp.EarlySectors, acc, "early sectors")
+		for sno := range early {
+			acc.Require(!seenSectors[sno], "sector %d in expiration queue twice", sno)
+			seenSectors[sno] = true
+			acc.Require(partition.FaultySectors[sno], "early expiring sector %d is not faulty", sno)
+		}
+
+		acc.Require(exp.OnTimePledge.Equals(onTimePledge), "on-time pledge %v does not match sector pledge %v", exp.OnTimePledge, onTimePledge)
+		queuedActive = queuedActive.Add(exp.ActivePower)
+		queuedFaulty = queuedFaulty.Add(exp.FaultyPower)
+		return nil
+	})
+	acc.RequireNoError(err, "error iterating expiration queue")
+
+	for sno := range partition.LiveSectors {
+		acc.Require(seenSectors[sno], "live sector %d missing from expiration queue", sno)
+	}
+
+	queuedLive := queuedActive.Add(queuedFaulty)
+	acc.Require(queuedLive.Equals(partition.LivePower), "expiration queue power %v does not match live power %v",
+		queuedLive, partition.LivePower)
+	acc.Require(queuedFaulty.Equals(partition.FaultyPower), "expiration queue faulty power %v does not match faulty power %v",
+		queuedFaulty, partition.FaultyPower)
+
+	return epochs
+}
+
+func CheckMinerInfo(info *MinerInfo, acc *builtin.MessageAccumulator) {
+	acc.Require(info.Owner.Protocol() == addr.ID, "owner address %v is not an ID address", info.Owner)
+	acc.Require(info.Worker.Protocol() == addr.ID, "worker address %v is not an ID address", info.Worker)
+	for _, a := range info.ControlAddresses {
+		acc.Require(a.Protocol() == addr.ID, "control address %v is not an ID address", a)
+	}
+
+	if info.PendingWorkerKey != nil {
+		acc.Require(info.PendingWorkerKey.NewWorker.Protocol() == addr.ID,
+			"pending worker address %v is not an ID address", info.PendingWorkerKey.NewWorker)
+		acc.Require(info.PendingWorkerKey.NewWorker != info.Worker,
+			"pending worker key %v is same as existing worker %v", info.PendingWorkerKey.NewWorker, info.Worker)
+	}
+
+	if info.PendingOwnerAddress != nil {
+		acc.Require(info.PendingOwnerAddress.Protocol() == addr.ID,
+			"pending owner address %v is not an ID address", info.PendingOwnerAddress)
+		acc.Require(*info.PendingOwnerAddress != info.Owner,
+			"pending owner address %v is same as existing owner %v", info.PendingOwnerAddress, info.Owner)
+	}
+
+	acc.Require(info.Beneficiary.Protocol() == addr.ID, "beneficiary address %v is not an ID address", info.Beneficiary)
+	term := info.BeneficiaryTerm
+	if info.Beneficiary == info.Owner {
+		acc.Require(term.Quota.IsZero() && term.UsedQuota.IsZero() && term.Expiration == 0,
+			"owner as beneficiary has non-zero term %v", term)
+	} else {
+		acc.Require(term.Quota.GreaterThan(big.Zero()), "beneficiary quota %v is not positive", term.Quota)
+		acc.Require(term.UsedQuota.GreaterThanEqual(big.Zero()), "beneficiary used quota %v is negative", term.UsedQuota)
+		acc.Require(term.UsedQuota.LessThanEqual(term.Quota), "beneficiary used quota %v exceeds quota %v",
+			term.UsedQuota, term.Quota)
+	}
+
+	if info.PendingBeneficiaryTerm != nil {
+		acc.Require(info.PendingBeneficiaryTerm.NewBeneficiary.Protocol() == addr.ID,
+			"pending beneficiary address %v is not an ID address", info.PendingBeneficiaryTerm.NewBeneficiary)
+		acc.Require(!(info.PendingBeneficiaryTerm.ApprovedByBeneficiary && info.PendingBeneficiaryTerm.ApprovedByNominee),
+			"pending beneficiary change to %v is fully approved", info.PendingBeneficiaryTerm.NewBeneficiary)
+	}
+
+	sectorSize, err := info.SealProofType.SectorSize()
+	if err != nil {
+		acc.Addf("miner has unrecognized seal proof type %d", info.SealProofType)
+		return
+	}
+	acc.Require(sectorSize == info.SectorSize,
+		"sector size %d is wrong for seal proof type %d: %d", info.SectorSize, info.SealProofType, sectorSize)
+
+	partitionSectors, err := info.SealProofType.WindowPoStPartitionSectors()
+	acc.RequireNoError(err, "failed to determine partition sectors for seal proof type %d", info.SealProofType)
+	acc.Require(partitionSectors == info.WindowPoStPartitionSectors,
+		"miner partition sectors %d does not match partition sectors %d for seal proof type %d",
+		info.WindowPoStPartitionSectors, partitionSectors, info.SealProofType)
+}
+
+func CheckMinerBalances(st *State, store adt.Store, balance abi.TokenAmount, acc *builtin.MessageAccumulator) {
+	acc.Require(balance.GreaterThanEqual(big.Zero()), "miner actor balance is less than zero: %v", balance)
+	acc.Require(st.LockedFunds.GreaterThanEqual(big.Zero()), "miner locked funds is less than zero: %v", st.LockedFunds)
+	acc.Require(st.PreCommitDeposits.GreaterThanEqual(big.Zero()), "miner precommit deposit is less than zero: %v", st.PreCommitDeposits)
+	acc.Require(st.InitialPledge.GreaterThanEqual(big.Zero()), "miner initial pledge is less than zero: %v", st.InitialPledge)
+	acc.Require(st.FeeDebt.GreaterThanEqual(big.Zero()), "miner fee debt is less than zero: %v", st.FeeDebt)
+
+	acc.Require(big.Subtract(balance, st.LockedFunds, st.PreCommitDeposits, st.InitialPledge).GreaterThanEqual(big.Zero()),
+		"miner balance (%v) is less than sum of locked funds (%v), precommit deposit (%v), and initial pledge (%v)",
+		balance, st.LockedFunds, st.PreCommitDeposits, st.InitialPledge)
+
+	// locked funds must be sum of vesting table and vesting table payments must be quantized
+	vestingSum := big.Zero()
+	if funds, err := st.LoadVestingFunds(store); err != nil {
+		acc.Addf("error loading vesting funds: %v", err)
+	} else {
+		prevEpoch := abi.ChainEpoch(-1)
+		for _, fund := range funds.Funds {
+			acc.Require(fund.Epoch > prevEpoch, "vesting table epochs are not strictly increasing: %d after %d", fund.Epoch, prevEpoch)
+			acc.Require(fund.Amount.GreaterThan(big.Zero()), "vesting table entry at epoch %d has non-positive amount %v", fund.Epoch, fund.Amount)
+			prevEpoch = fund.Epoch
+			vestingSum = big.Add(vestingSum, fund.Amount)
+		}
+	}
+
+	acc.Require(st.LockedFunds.Equals(vestingSum),
+		"locked funds %d is not sum of vesting table entries %d", st.LockedFunds, vestingSum)
+}
+
+func CheckPreCommits(st *State, store adt.Store, allocatedSectors map[uint64]bool, acc *builtin.MessageAccumulator) {
+	quant := st.QuantSpecEveryDeadline()
+
+	// invert pre-commit expiry queue into a lookup by sector number
+	expireEpochs := make(map[uint64]abi.ChainEpoch)
+	if expiryQ, err := LoadBitfieldQueue(store, st.PreCommittedSectorsExpiry, quant); err != nil {
+		acc.Addf("error loading pre-commit expiry queue: %v", err)
+	} else {
+		err = expiryQ.ForEach(func(epoch abi.ChainEpoch, bf bitfield.BitField) error {
+			quantized := quant.QuantizeUp(epoch)
+			acc.Require(quantized == epoch, "precommit expiration %d is not quantized", epoch)
+			return bf.ForEach(func(secNum uint64) error {
+				expireEpochs[secNum] = epoch
+				return nil
+			})
+		})
+		acc.RequireNoError(err, "error iterating pre-commit expiry queue")
+	}
+
+	precommitTotal := big.Zero()
+	if precommitted, err := adt.AsMap(store, st.PreCommittedSectors); err != nil {
+		acc.Addf("error loading precommitted sectors: %v", err)
+	} else {
+		var precommit SectorPreCommitOnChainInfo
+		err = precommitted.ForEach(&precommit, func(key string) error {
+			secNum, err := adt.ParseUIntKey(key)
+			if err != nil {
+				acc.Addf("error parsing pre-commit key as uint: %v", err)
+				return nil
+			}
+
+			acc.Require(allocatedSectors[secNum], "pre-committed sector number has not been allocated %d", secNum)
+
+			_, found := expireEpochs[secNum]
+			acc.Require(found, "no expiry epoch for pre-commit at %d", precommit.PreCommitEpoch)
+
+			precommitTotal = big.Add(precommitTotal, precommit.PreCommitDeposit)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating pre-committed sectors")
+	}
+
+	acc.Require(st.PreCommitDeposits.Equals(precommitTotal),
+		"sum of precommit deposits %v does not equal recorded precommit deposit %v", precommitTotal, st.PreCommitDeposits)
+}
+
+//
+// Helpers
+//
+
+func loadSectorSet(bf bitfield.BitField, acc *builtin.MessageAccumulator, name string) map[abi.SectorNumber]bool {
+	set := map[abi.SectorNumber]bool{}
+	err := bf.ForEach(func(sno uint64) error {
+		set[abi.SectorNumber(sno)] = true
+		return nil
+	})
+	acc.RequireNoError(err, "error iterating %s", name)
+	return set
+}
+
+func mergeSectorSet(into, from map[abi.SectorNumber]bool) {
+	for sno := range from {
+		into[sno] = true
+	}
+}
+
+func requireSubset(acc *builtin.MessageAccumulator, subset, superset map[abi.SectorNumber]bool, subName, superName string) {
+	for sno := range subset {
+		acc.Require(superset[sno], "%s contains sector %d not in %s", subName, sno, superName)
+	}
+}
+
+func selectSectorInfos(sectorNos map[abi.SectorNumber]bool, sectors map[abi.SectorNumber]*SectorOnChainInfo,
+	acc *builtin.MessageAccumulator, name string) []*SectorOnChainInfo {
+	infos := make([]*SectorOnChainInfo, 0, len(sectorNos))
+	for sno := range sectorNos {
+		if sector, found := sectors[sno]; found {
+			infos = append(infos, sector)
+		} else {
+			acc.Addf("%s sector %d not found in sectors", name, sno)
+		}
+	}
+	return infos
+}
@@ -7,6 +7,7 @@ import (
 
 	addr "github.com/filecoin-project/go-address"
 	abi "github.com/filecoin-project/specs-actors/actors/abi"
+	big "github.com/filecoin-project/specs-actors/actors/abi/big"
 	builtin "github.com/filecoin-project/specs-actors/actors/builtin"
 	vmr "github.com/filecoin-project/specs-actors/actors/runtime"
 	exitcode "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
@@ -31,6 +32,23 @@ type Transaction struct {
 
 	// This address at index 0 is the transaction proposer, order of this slice must be preserved.
 	Approved []addr.Address
+
+	// The last epoch at which the transaction may be approved, or zero if the transaction does not expire.
+	// An expired transaction may be pruned by anyone.
+	Expiration abi.ChainEpoch
+}
+
+// Returns whether the transaction has expired, and may no longer be approved.
+func (t *Transaction) IsExpired(currEpoch abi.ChainEpoch) bool {
+	return t.Expiration != 0 && currEpoch > t.Expiration
+}
+
+// The outcome of a transaction that has been executed and removed from the pending transactions.
+type ExecutedTransaction struct {
+	TxnID        TxnID
+	ProposalHash []byte
+	ExitCode     exitcode.ExitCode
+	Epoch        abi.ChainEpoch
 }
 
 // Data for a BLAKE2B-256 to be attached to methods referencing proposals via TXIDs.
@@ -39,6 +57,8 @@ type Transaction struct {
 //
 // Requester - The requesting multisig wallet member.
 // All other fields - From the "Transaction" struct.
+//
+// This is the hash data of a transaction without expiration, unchanged from before expiration was introduced.
 type ProposalHashData struct {
 	Requester addr.Address
 	To        addr.Address
@@ -47,6 +67,16 @@ type ProposalHashData struct {
 	Params    []byte
 }
 
+// Hash data for a transaction with an expiration, which the hash must also commit to.
+type ExpiringProposalHashData struct {
+	Requester  addr.Address
+	To         addr.Address
+	Value      abi.TokenAmount
+	Method     abi.MethodNum
+	Params     []byte
+	Expiration abi.ChainEpoch
+}
+
 type Actor struct{}
 
 func (a Actor) Exports() []interface{} {
@@ -59,6 +89,9 @@ func (a Actor) Exports() []interface{} {
 		6:                         a.RemoveSigner,
 		7:                         a.SwapSigner,
 		8:                         a.ChangeNumApprovalsThreshold,
+		9:                         a.LockBalance,
+		10:                        a.PruneExpiredTransactions,
+		11:                        a.SetExecutedHistoryLength,
 	}
 }
 
@@ -69,6 +102,8 @@ type ConstructorParams struct {
 	NumApprovalsThreshold uint64
 	UnlockDuration        abi.ChainEpoch
 	StartEpoch            abi.ChainEpoch
+	// The number of executed transactions to retain in the history, or zero to retain none.
+	ExecutedHistoryLength uint64
 }
 
 func (a Actor) Constructor(rt vmr.Runtime, params *ConstructorParams) *adt.EmptyValue {
@@ -105,15 +140,24 @@ func (a Actor) Constructor(rt vmr.Runtime, params *ConstructorParams) *adt.Empty
 		rt.Abortf(exitcode.ErrIllegalArgument, "negative unlock duration disallowed")
 	}
 
+	if params.ExecutedHistoryLength > MaxExecutedHistoryLength {
+		rt.Abortf(exitcode.ErrIllegalArgument, "executed history length %d exceeds maximum %d", params.ExecutedHistoryLength, MaxExecutedHistoryLength)
+	}
+
 	pending, err := adt.MakeEmptyMap(adt.AsStore(rt)).Root()
 	if err != nil {
 		rt.Abortf(exitcode.ErrIllegalState, "failed to create empty map: %v", err)
 	}
 
+	executed, err := adt.MakeEmptyArray(adt.AsStore(rt)).Root()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to create empty array")
+
 	var st State
 	st.Signers = resolvedSigners
 	st.NumApprovalsThreshold = params.NumApprovalsThreshold
 	st.PendingTxns = pending
+	st.ExecutedHistoryLength = params.ExecutedHistoryLength
+	st.ExecutedTxns = executed
 	st.InitialBalance = abi.NewTokenAmount(0)
 	if params.UnlockDuration != 0 {
 		st.InitialBalance = rt.Message().ValueReceived()
@@ -130,6 +174,8 @@ type ProposeParams struct {
 	Value  abi.TokenAmount
 	Method abi.MethodNum
 	Params []byte
+	// Optional last epoch at which the transaction may be approved, or zero for no expiration.
+	Expiration abi.ChainEpoch
 }
 
 type ProposeReturn struct {
@@ -150,6 +196,9 @@ func (a Actor) Propose(rt vmr.Runtime, params *ProposeParams) *ProposeReturn {
 	if params.Value.Sign() < 0 {
 		rt.Abortf(exitcode.ErrIllegalArgument, "proposed value must be non-negative, was %v", params.Value)
 	}
+	if params.Expiration < 0 || (params.Expiration != 0 && params.Expiration < rt.CurrEpoch()) {
+		rt.Abortf(exitcode.ErrIllegalArgument, "proposal expiration %d must not precede current epoch %d", params.Expiration, rt.CurrEpoch())
+	}
 
 	var txnID TxnID
 	var st State
@@ -162,14 +211,21 @@ func (a Actor) Propose(rt vmr.Runtime, params *ProposeParams) *ProposeReturn {
 		ptx, err := adt.AsMap(adt.AsStore(rt), st.PendingTxns)
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pending transactions")
 
+		proposed, err := countProposedTransactions(ptx, proposer)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to count pending transactions")
+		if proposed >= MaxPendingTxnsPerSigner {
+			rt.Abortf(exitcode.ErrForbidden, "%s already has %d pending transactions, cancel or prune some before proposing more", proposer, proposed)
+		}
+
 		txnID = st.NextTxnID
 		st.NextTxnID += 1
 		txn = &Transaction{
-			To:       params.To,
-			Value:    params.Value,
-			Method:   params.Method,
-			Params:   params.Params,
-			Approved: []addr.Address{},
+			To:         params.To,
+			Value:      params.Value,
+			Method:     params.Method,
+			Params:     params.Params,
+			Approved:   []addr.Address{},
+			Expiration: params.Expiration,
 		}
 
 		if err := ptx.Put(txnID, txn); err != nil {
@@ -224,6 +280,9 @@ func (a Actor) Approve(rt vmr.Runtime, params *TxnIDParams) *ApproveReturn {
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pending transactions")
 
 		txn = getTransaction(rt, ptx, params.ID, params.ProposalHash, true)
+		if txn.IsExpired(rt.CurrEpoch()) {
+			rt.Abortf(exitcode.ErrForbidden, "transaction %d expired at epoch %d", params.ID, txn.Expiration)
+		}
 	})
 
 	// if the transaction already has enough approvers, execute it without "processing" this approval.
@@ -411,6 +470,89 @@ func (a Actor) ChangeNumApprovalsThreshold(rt vmr.Runtime, params *ChangeNumAppr
 	return nil
 }
 
+type LockBalanceParams struct {
+	StartEpoch     abi.ChainEpoch
+	UnlockDuration abi.ChainEpoch
+	Amount         abi.TokenAmount
+}
+
+// Locks an amount of the wallet's balance, to be unlocked linearly over a duration from a start epoch.
+// A new schedule may be set only if no previous schedule is still unlocking funds.
+func (a Actor) LockBalance(rt vmr.Runtime, params *LockBalanceParams) *adt.EmptyValue {
+	// Can only be called by the multisig wallet itself.
+	rt.ValidateImmediateCallerIs(rt.Message().Receiver())
+
+	if params.UnlockDuration <= 0 {
+		rt.Abortf(exitcode.ErrIllegalArgument, "unlock duration must be positive, was %d", params.UnlockDuration)
+	}
+	if params.Amount.LessThan(big.Zero()) {
+		rt.Abortf(exitcode.ErrIllegalArgument, "amount to lock must not be negative, was %v", params.Amount)
+	}
+
+	var st State
+	rt.State().Transaction(&st, func() {
+		if st.isUnlocking(rt.CurrEpoch()) {
+			rt.Abortf(exitcode.ErrForbidden, "balance already locked until epoch %d", st.StartEpoch+st.UnlockDuration)
+		}
+
+		st.setLocked(params.StartEpoch, params.UnlockDuration, params.Amount)
+	})
+	return nil
+}
+
+type PruneExpiredTransactionsParams struct {
+	TxnIDs []TxnID
+}
+
+// Deletes expired transactions from the pending transactions.
+// Any actor may prune expired transactions.
+func (a Actor) PruneExpiredTransactions(rt vmr.Runtime, params *PruneExpiredTransactionsParams) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+
+	var st State
+	rt.State().Transaction(&st, func() {
+		ptx, err := adt.AsMap(adt.AsStore(rt), st.PendingTxns)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load pending transactions")
+
+		for _, txnID := range params.TxnIDs {
+			txn, err := getPendingTransaction(ptx, txnID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get transaction %d for pruning", txnID)
+			if !txn.IsExpired(rt.CurrEpoch()) {
+				rt.Abortf(exitcode.ErrForbidden, "transaction %d has not expired", txnID)
+			}
+
+			err = ptx.Delete(txnID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete expired transaction %d", txnID)
+		}
+
+		st.PendingTxns, err = ptx.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush pending transactions")
+	})
+	return nil
+}
+
+type SetExecutedHistoryLengthParams struct {
+	Length uint64
+}
+
+// Sets the number of executed transactions to retain in the history, or zero to retain none.
+// The most recent entries are retained if the history is shortened.
+func (a Actor) SetExecutedHistoryLength(rt vmr.Runtime, params *SetExecutedHistoryLengthParams) *adt.EmptyValue {
+	// Can only be called by the multisig wallet itself.
+	rt.ValidateImmediateCallerIs(rt.Message().Receiver())
+
+	if params.Length > MaxExecutedHistoryLength {
+		rt.Abortf(exitcode.ErrIllegalArgument, "executed history length %d exceeds maximum %d", params.Length, MaxExecutedHistoryLength)
+	}
+
+	var st State
+	rt.State().Transaction(&st, func() {
+		err := st.setExecutedHistoryLength(adt.AsStore(rt), params.Length)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to set executed history length")
+	})
+	return nil
+}
+
 func (a Actor) approveTransaction(rt vmr.Runtime, txnID TxnID, txn *Transaction) (bool, []byte, exitcode.ExitCode) {
 	caller := rt.Message().Caller()
 
@@ -498,6 +640,19 @@ func executeTransactionIfApproved(rt vmr.Runtime, st State, txnID TxnID, txn *Tr
 
 			st.PendingTxns, err = ptx.Root()
 			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush pending transactions")
+
+			if st.ExecutedHistoryLength > 0 {
+				proposalHash, err := ComputeProposalHash(txn, rt.Syscalls().HashBlake2b)
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to compute proposal hash for %v", txnID)
+
+				err = st.recordExecuted(adt.AsStore(rt), &ExecutedTransaction{
+					TxnID:        txnID,
+					ProposalHash: proposalHash,
+					ExitCode:     code,
+					Epoch:        rt.CurrEpoch(),
+				})
+				builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to record executed transaction %v", txnID)
+			}
 		})
 	}
 
@@ -519,20 +674,33 @@ func isSigner(address addr.Address, signers []addr.Address) bool {
 // Computes a digest of a proposed transaction. This digest is used to confirm identity of the transaction
 // associated with an ID, which might change under chain re-orgs.
 func ComputeProposalHash(txn *Transaction, hash func([]byte) [32]byte) ([]byte, error) {
-	hashData := ProposalHashData{
-		Requester: txn.Approved[0],
-		To:        txn.To,
-		Value:     txn.Value,
-		Method:    txn.Method,
-		Params:    txn.Params,
+	var hashData vmr.CBORMarshaler
+	if txn.Expiration == 0 {
+		// Transactions without expiration hash as they did before expiration was introduced.
+		hashData = &ProposalHashData{
+			Requester: txn.Approved[0],
+			To:        txn.To,
+			Value:     txn.Value,
+			Method:    txn.Method,
+			Params:    txn.Params,
+		}
+	} else {
+		hashData = &ExpiringProposalHashData{
+			Requester:  txn.Approved[0],
+			To:         txn.To,
+			Value:      txn.Value,
+			Method:     txn.Method,
+			Params:     txn.Params,
+			Expiration: txn.Expiration,
+		}
 	}
 
-	data, err := hashData.Serialize()
-	if err != nil {
+	buf := new(bytes.Buffer)
+	if err := hashData.MarshalCBOR(buf); err != nil {
 		return nil, fmt.Errorf("failed to construct multisig approval hash: %w", err)
 	}
 
-	hashResult := hash(data)
+	hashResult := hash(buf.Bytes())
 	return hashResult[:], nil
 }
 
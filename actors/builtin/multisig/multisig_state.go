@@ -11,6 +11,12 @@ import (
 	adt "github.com/filecoin-project/specs-actors/actors/util/adt"
 )
 
+// The maximum number of pending transactions that any one signer may have proposed.
+const MaxPendingTxnsPerSigner = 32
+
+// The maximum number of executed transactions that a wallet may retain in its history.
+const MaxExecutedHistoryLength = 256
+
 type State struct {
 	// Signers may be either public-key or actor ID-addresses. The ID address is canonical, but doesn't exist
 	// for a public key that has not yet received a message on chain.
@@ -28,17 +34,40 @@ type State struct {
 	UnlockDuration abi.ChainEpoch
 
 	PendingTxns cid.Cid
+
+	// The most recently executed transactions, retained so that their outcomes may be queried.
+	// The history is a ring buffer of ExecutedHistoryLength entries, the nth executed transaction being stored
+	// at index n mod ExecutedHistoryLength.
+	ExecutedHistoryLength uint64
+	ExecutedTxns          cid.Cid // AMT[ExecutedTransaction]
+	NumExecutedTxns       uint64  // The number of transactions recorded since the history length was last set.
 }
 
 func (st *State) AmountLocked(elapsedEpoch abi.ChainEpoch) abi.TokenAmount {
 	if elapsedEpoch >= st.UnlockDuration {
 		return abi.NewTokenAmount(0)
 	}
+	if elapsedEpoch <= 0 {
+		// The schedule has not yet started to unlock.
+		return st.InitialBalance
+	}
 
 	unitLocked := big.Div(st.InitialBalance, big.NewInt(int64(st.UnlockDuration)))
 	return big.Mul(unitLocked, big.Sub(big.NewInt(int64(st.UnlockDuration)), big.NewInt(int64(elapsedEpoch))))
 }
 
+// Sets a linear unlock schedule for an amount, replacing any previous schedule.
+func (st *State) setLocked(startEpoch abi.ChainEpoch, unlockDuration abi.ChainEpoch, amount abi.TokenAmount) {
+	st.StartEpoch = startEpoch
+	st.UnlockDuration = unlockDuration
+	st.InitialBalance = amount
+}
+
+// Returns whether the unlock schedule has not yet unlocked all its funds at an epoch.
+func (st *State) isUnlocking(currEpoch abi.ChainEpoch) bool {
+	return st.UnlockDuration != 0 && currEpoch < st.StartEpoch+st.UnlockDuration
+}
+
 // return nil if MultiSig maintains required locked balance after spending the amount, else return an error.
 func (st *State) assertAvailable(currBalance abi.TokenAmount, amountToSpend abi.TokenAmount, currEpoch abi.ChainEpoch) error {
 	if amountToSpend.LessThan(big.Zero()) {
@@ -68,3 +97,99 @@ func getPendingTransaction(ptx *adt.Map, txnID TxnID) (Transaction, error) {
 	}
 	return out, nil
 }
+
+// Counts the pending transactions proposed by an address.
+func countProposedTransactions(ptx *adt.Map, proposer address.Address) (int, error) {
+	count := 0
+	var txn Transaction
+	err := ptx.ForEach(&txn, func(_ string) error {
+		if len(txn.Approved) > 0 && txn.Approved[0] == proposer {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Returns the executed transactions retained in the history, from oldest to most recent.
+func (st *State) ExecutedTransactions(store adt.Store) ([]ExecutedTransaction, error) {
+	if st.ExecutedHistoryLength == 0 {
+		return nil, nil
+	}
+	executed, err := adt.AsArray(store, st.ExecutedTxns)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load executed transactions: %w", err)
+	}
+
+	first := uint64(0)
+	if st.NumExecutedTxns > st.ExecutedHistoryLength {
+		first = st.NumExecutedTxns - st.ExecutedHistoryLength
+	}
+	out := make([]ExecutedTransaction, 0, st.NumExecutedTxns-first)
+	for n := first; n < st.NumExecutedTxns; n++ {
+		var txn ExecutedTransaction
+		found, err := executed.Get(n%st.ExecutedHistoryLength, &txn)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to load executed transaction %d: %w", n, err)
+		}
+		if !found {
+			return nil, xerrors.Errorf("executed transaction %d missing from history", n)
+		}
+		out = append(out, txn)
+	}
+	return out, nil
+}
+
+// Returns the outcome of an executed transaction, if it is retained in the history.
+func (st *State) GetExecutedTransaction(store adt.Store, txnID TxnID) (*ExecutedTransaction, bool, error) {
+	executed, err := st.ExecutedTransactions(store)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range executed {
+		if executed[i].TxnID == txnID {
+			return &executed[i], true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// Changes the length of the executed transaction history, retaining as many of the most recent entries as fit.
+func (st *State) setExecutedHistoryLength(store adt.Store, length uint64) error {
+	retained, err := st.ExecutedTransactions(store)
+	if err != nil {
+		return err
+	}
+	if uint64(len(retained)) > length {
+		retained = retained[uint64(len(retained))-length:]
+	}
+
+	executed := adt.MakeEmptyArray(store)
+	for i := range retained {
+		if err := executed.Set(uint64(i), &retained[i]); err != nil {
+			return xerrors.Errorf("failed to retain executed transaction %d: %w", retained[i].TxnID, err)
+		}
+	}
+	if st.ExecutedTxns, err = executed.Root(); err != nil {
+		return xerrors.Errorf("failed to flush executed transactions: %w", err)
+	}
+	st.ExecutedHistoryLength = length
+	st.NumExecutedTxns = uint64(len(retained))
+	return nil
+}
+
+// Appends an executed transaction to the history, replacing the oldest entry if the history is full.
+func (st *State) recordExecuted(store adt.Store, txn *ExecutedTransaction) error {
+	executed, err := adt.AsArray(store, st.ExecutedTxns)
+	if err != nil {
+		return xerrors.Errorf("failed to load executed transactions: %w", err)
+	}
+	if err := executed.Set(st.NumExecutedTxns%st.ExecutedHistoryLength, txn); err != nil {
+		return xerrors.Errorf("failed to record executed transaction %d: %w", txn.TxnID, err)
+	}
+	if st.ExecutedTxns, err = executed.Root(); err != nil {
+		return xerrors.Errorf("failed to flush executed transactions: %w", err)
+	}
+	st.NumExecutedTxns++
+	return nil
+}
@@ -3,6 +3,8 @@ package multisig_test
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	addr "github.com/filecoin-project/go-address"
@@ -240,6 +242,8 @@ func TestVesting(t *testing.T) {
 			Approved: []addr.Address{anne},
 		})
 		actor.approveOK(rt, 0, proposalHashData, nil)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("partial vesting propose to send half the actor balance when the epoch is hald the unlock duration", func(t *testing.T) {
@@ -268,6 +272,8 @@ func TestVesting(t *testing.T) {
 		})
 
 		actor.approveOK(rt, 0, proposalHashData, nil)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("propose and autoapprove transaction above locked amount fails", func(t *testing.T) {
@@ -291,6 +297,8 @@ func TestVesting(t *testing.T) {
 		rt.ExpectSend(darlene, builtin.MethodSend, fakeParams, abi.NewTokenAmount(10), nil, 0)
 		actor.proposeOK(rt, darlene, abi.NewTokenAmount(10), builtin.MethodSend, fakeParams, nil)
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail to vest more than locked amount", func(t *testing.T) {
@@ -319,6 +327,8 @@ func TestVesting(t *testing.T) {
 			_ = actor.approve(rt, 0, proposalHashData, nil)
 		})
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 }
@@ -356,6 +366,8 @@ func TestPropose(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("propose with threshold met", func(t *testing.T) {
@@ -374,6 +386,8 @@ func TestPropose(t *testing.T) {
 		// the transaction has been sent and cleaned up
 		actor.assertTransactions(rt)
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("propose with threshold and non-empty return value", func(t *testing.T) {
@@ -401,6 +415,7 @@ func TestPropose(t *testing.T) {
 		actor.assertTransactions(rt)
 		rt.Verify()
 
+		actor.checkState(rt)
 	})
 
 	t.Run("fail propose with threshold met and insufficient balance", func(t *testing.T) {
@@ -417,6 +432,8 @@ func TestPropose(t *testing.T) {
 
 		// proposal failed since it should have but failed to immediately execute.
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail propose from non-signer", func(t *testing.T) {
@@ -437,6 +454,8 @@ func TestPropose(t *testing.T) {
 
 		// the transaction is not persisted
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -496,6 +515,8 @@ func TestApprove(t *testing.T) {
 
 		// Transaction should be removed from actor state after send
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("approve with non-empty return value", func(t *testing.T) {
@@ -533,6 +554,8 @@ func TestApprove(t *testing.T) {
 
 		// the transaction has been sent and cleaned up
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("approval works if enough funds have been unlocked for the transaction", func(t *testing.T) {
@@ -565,6 +588,8 @@ func TestApprove(t *testing.T) {
 
 		// as the (current epoch - startepoch) = 20 is  equal to unlock duration, all initial funds must have been vested and available to spend
 		actor.approveOK(rt, txnID, proposalHash, nil)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail approval if current balance is less than the transaction value", func(t *testing.T) {
@@ -581,6 +606,8 @@ func TestApprove(t *testing.T) {
 			actor.proposeOK(rt, chuck, sendValue, fakeMethod, fakeParams, nil)
 		})
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail approval if enough unlocked balance not available", func(t *testing.T) {
@@ -617,6 +644,8 @@ func TestApprove(t *testing.T) {
 				actor.approveOK(rt, txnID, proposalHash, nil)
 			})
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail approval with bad proposal hash", func(t *testing.T) {
@@ -652,6 +681,8 @@ func TestApprove(t *testing.T) {
 			})
 			_ = actor.approve(rt, txnID, proposalHashData, nil)
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("accept approval with no proposal hash", func(t *testing.T) {
@@ -681,6 +712,8 @@ func TestApprove(t *testing.T) {
 
 		// Transaction should be removed from actor state after send
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 	t.Run("fail approve transaction more than once", func(t *testing.T) {
 		const numApprovals = uint64(2)
@@ -715,6 +748,8 @@ func TestApprove(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail approve transaction that does not exist", func(t *testing.T) {
@@ -751,6 +786,8 @@ func TestApprove(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail to approve transaction by non-signer", func(t *testing.T) {
@@ -787,6 +824,8 @@ func TestApprove(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("proposed transaction is approved by proposer if number of approvers has already crossed threshold", func(t *testing.T) {
@@ -816,6 +855,8 @@ func TestApprove(t *testing.T) {
 
 		// Transaction should be removed from actor state after send
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("approve transaction if number of approvers has already crossed threshold even if we attempt a duplicate approval", func(t *testing.T) {
@@ -852,6 +893,8 @@ func TestApprove(t *testing.T) {
 
 		// Transaction should be removed from actor state after send
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("approve transaction if number of approvers has already crossed threshold and ensure non-signatory cannot approve a transaction", func(t *testing.T) {
@@ -892,6 +935,8 @@ func TestApprove(t *testing.T) {
 
 		// Transaction should be removed from actor state after send
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -944,6 +989,8 @@ func TestCancel(t *testing.T) {
 
 		// Transaction should be removed from actor state after cancel
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail cancel with bad proposal hash", func(t *testing.T) {
@@ -1007,6 +1054,8 @@ func TestCancel(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail to cancel transaction when not signer", func(t *testing.T) {
@@ -1043,6 +1092,8 @@ func TestCancel(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fail to cancel a transaction that does not exist", func(t *testing.T) {
@@ -1079,6 +1130,8 @@ func TestCancel(t *testing.T) {
 			Params:   fakeParams,
 			Approved: []addr.Address{anne},
 		})
+
+		actor.checkState(rt)
 	})
 
 	t.Run("transaction can ONLY be cancelled by a proposer who is still the signer", func(t *testing.T) {
@@ -1137,6 +1190,8 @@ func TestCancel(t *testing.T) {
 		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
 		actor.cancel(rt, txnID, proposalHash)
 		actor.assertTransactions(rt)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -1261,6 +1316,8 @@ func TestAddSigner(t *testing.T) {
 				assert.Equal(t, tc.expectApprovals, st.NumApprovalsThreshold)
 			}
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 }
@@ -1431,6 +1488,8 @@ func TestRemoveSigner(t *testing.T) {
 				assert.Equal(t, tc.expectApprovals, st.NumApprovalsThreshold)
 			}
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 }
@@ -1556,6 +1615,8 @@ func TestSwapSigners(t *testing.T) {
 				assert.Equal(t, tc.expect, st.Signers)
 			}
 			rt.Verify()
+
+			actor.checkState(rt)
 		})
 	}
 }
@@ -1628,8 +1689,535 @@ func TestChangeThreshold(t *testing.T) {
 				assert.Equal(t, tc.setThreshold, st.NumApprovalsThreshold)
 			}
 			rt.Verify()
+
+			actor.checkState(rt)
+		})
+	}
+}
+
+func TestExpiringTransactions(t *testing.T) {
+	actor := msActorHarness{multisig.Actor{}, t}
+
+	receiver := tutil.NewIDAddr(t, 100)
+	anne := tutil.NewIDAddr(t, 101)
+	bob := tutil.NewIDAddr(t, 102)
+	chuck := tutil.NewIDAddr(t, 103)
+
+	const noUnlockDuration = int64(0)
+	const fakeMethod = abi.MethodNum(42)
+	const expiration = abi.ChainEpoch(100)
+	var sendValue = abi.NewTokenAmount(10)
+	var fakeParams = runtime.CBORBytes([]byte{1, 2, 3, 4})
+
+	builder := mock.NewBuilder(context.Background(), receiver).
+		WithCaller(builtin.InitActorAddr, builtin.InitActorCodeID).
+		WithEpoch(10).
+		WithBalance(sendValue, big.Zero()).
+		WithHasher(blake2b.Sum256)
+
+	expiringTxn := multisig.Transaction{
+		To:         chuck,
+		Value:      sendValue,
+		Method:     fakeMethod,
+		Params:     fakeParams,
+		Approved:   []addr.Address{anne},
+		Expiration: expiration,
+	}
+
+	setup := func(t *testing.T) *mock.Runtime {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 2, noUnlockDuration, 0, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		actor.proposeExpiring(rt, chuck, sendValue, fakeMethod, fakeParams, expiration)
+		actor.assertTransactions(rt, expiringTxn)
+		return rt
+	}
+
+	t.Run("approve at expiration epoch", func(t *testing.T) {
+		rt := setup(t)
+
+		rt.SetEpoch(expiration)
+		rt.SetCaller(bob, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectSend(chuck, fakeMethod, fakeParams, sendValue, nil, exitcode.Ok)
+		actor.approveOK(rt, 0, makeProposalHash(t, &expiringTxn), nil)
+
+		actor.assertTransactions(rt)
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to approve after expiration", func(t *testing.T) {
+		rt := setup(t)
+
+		rt.SetEpoch(expiration + 1)
+		rt.SetCaller(bob, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.approve(rt, 0, makeProposalHash(t, &expiringTxn), nil)
+		})
+
+		actor.assertTransactions(rt, expiringTxn)
+		actor.checkState(rt)
+	})
+
+	t.Run("proposal hash commits to expiration", func(t *testing.T) {
+		rt := setup(t)
+
+		otherTxn := expiringTxn
+		otherTxn.Expiration = expiration + 1
+		rt.SetCaller(bob, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.approve(rt, 0, makeProposalHash(t, &otherTxn), nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("proposal hash without expiration is unchanged", func(t *testing.T) {
+		txn := expiringTxn
+		txn.Expiration = 0
+		// Hash of the transaction as computed before expiration was introduced.
+		expected, err := hex.DecodeString("d4c6709e46487219df41f0422ce0981527dacbec748c78438d8259d6f0d1364c")
+		require.NoError(t, err)
+		assert.Equal(t, expected, makeProposalHash(t, &txn))
+		assert.NotEqual(t, expected, makeProposalHash(t, &expiringTxn))
+	})
+
+	t.Run("anyone may prune an expired transaction", func(t *testing.T) {
+		rt := setup(t)
+
+		// a second transaction without expiration is retained
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		actor.proposeOK(rt, chuck, sendValue, fakeMethod, fakeParams, nil)
+
+		rt.SetEpoch(expiration + 1)
+		rt.SetCaller(tutil.NewIDAddr(t, 999), builtin.AccountActorCodeID)
+		actor.pruneExpiredTransactions(rt, 0)
+
+		actor.assertTransactions(rt, multisig.Transaction{
+			To:       chuck,
+			Value:    sendValue,
+			Method:   fakeMethod,
+			Params:   fakeParams,
+			Approved: []addr.Address{anne},
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to prune unexpired transaction", func(t *testing.T) {
+		rt := setup(t)
+
+		rt.SetEpoch(expiration)
+		rt.SetCaller(tutil.NewIDAddr(t, 999), builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.pruneExpiredTransactions(rt, 0)
+		})
+
+		actor.assertTransactions(rt, expiringTxn)
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to prune transaction that does not exist", func(t *testing.T) {
+		rt := setup(t)
+
+		rt.SetEpoch(expiration + 1)
+		rt.SetCaller(tutil.NewIDAddr(t, 999), builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			actor.pruneExpiredTransactions(rt, 0, 1)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to propose with past expiration", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 2, noUnlockDuration, 0, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.proposeExpiring(rt, chuck, sendValue, fakeMethod, fakeParams, rt.Epoch()-1)
+		})
+		actor.checkState(rt)
+	})
+}
+
+func TestPendingTransactionLimit(t *testing.T) {
+	actor := msActorHarness{multisig.Actor{}, t}
+
+	receiver := tutil.NewIDAddr(t, 100)
+	anne := tutil.NewIDAddr(t, 101)
+	bob := tutil.NewIDAddr(t, 102)
+	chuck := tutil.NewIDAddr(t, 103)
+
+	const noUnlockDuration = int64(0)
+	var sendValue = abi.NewTokenAmount(10)
+
+	rt := mock.NewBuilder(context.Background(), receiver).
+		WithCaller(builtin.InitActorAddr, builtin.InitActorCodeID).
+		WithHasher(blake2b.Sum256).
+		Build(t)
+	actor.constructAndVerify(rt, 2, noUnlockDuration, 0, anne, bob)
+
+	rt.SetCaller(anne, builtin.AccountActorCodeID)
+	for i := 0; i < multisig.MaxPendingTxnsPerSigner; i++ {
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+	}
+
+	rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+	rt.ExpectAbort(exitcode.ErrForbidden, func() {
+		actor.propose(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+	})
+
+	// another signer may still propose
+	rt.SetCaller(bob, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+	actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+
+	// cancelling a transaction makes room for another
+	rt.SetCaller(anne, builtin.AccountActorCodeID)
+	rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+	actor.cancel(rt, 0, nil)
+	rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+	actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+
+	actor.checkState(rt)
+}
+
+func TestExecutedHistory(t *testing.T) {
+	actor := msActorHarness{multisig.Actor{}, t}
+
+	receiver := tutil.NewIDAddr(t, 100)
+	anne := tutil.NewIDAddr(t, 101)
+	bob := tutil.NewIDAddr(t, 102)
+	chuck := tutil.NewIDAddr(t, 103)
+
+	const fakeMethod = abi.MethodNum(42)
+	var sendValue = abi.NewTokenAmount(10)
+	var fakeParams = runtime.CBORBytes([]byte{1, 2, 3, 4})
+
+	builder := mock.NewBuilder(context.Background(), receiver).
+		WithCaller(builtin.InitActorAddr, builtin.InitActorCodeID).
+		WithEpoch(10).
+		WithBalance(big.Mul(sendValue, big.NewInt(10)), big.Zero()).
+		WithHasher(blake2b.Sum256)
+
+	t.Run("records outcome of executed transactions", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructWithHistory(rt, 2, 4, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		proposalHash := actor.proposeOK(rt, chuck, sendValue, fakeMethod, fakeParams, nil)
+
+		// the inner message fails
+		rt.SetEpoch(20)
+		rt.SetCaller(bob, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectSend(chuck, fakeMethod, fakeParams, sendValue, nil, exitcode.ErrIllegalArgument)
+		code := actor.approve(rt, 0, proposalHash, nil)
+		assert.Equal(t, exitcode.ErrIllegalArgument, code)
+
+		var st multisig.State
+		rt.GetState(&st)
+		executed, found, err := st.GetExecutedTransaction(rt.AdtStore(), 0)
+		require.NoError(t, err)
+		require.True(t, found)
+		assert.Equal(t, multisig.ExecutedTransaction{
+			TxnID:        0,
+			ProposalHash: proposalHash,
+			ExitCode:     exitcode.ErrIllegalArgument,
+			Epoch:        20,
+		}, *executed)
+
+		_, found, err = st.GetExecutedTransaction(rt.AdtStore(), 1)
+		require.NoError(t, err)
+		assert.False(t, found)
+		actor.checkState(rt)
+	})
+
+	t.Run("history retains only the most recent transactions", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructWithHistory(rt, 1, 2, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		for i := 0; i < 3; i++ {
+			rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+			rt.ExpectSend(chuck, builtin.MethodSend, nil, sendValue, nil, exitcode.Ok)
+			actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+		}
+
+		var st multisig.State
+		rt.GetState(&st)
+		assert.Equal(t, uint64(3), st.NumExecutedTxns)
+		executed, err := st.ExecutedTransactions(rt.AdtStore())
+		require.NoError(t, err)
+		require.Len(t, executed, 2)
+		assert.Equal(t, multisig.TxnID(1), executed[0].TxnID)
+		assert.Equal(t, multisig.TxnID(2), executed[1].TxnID)
+
+		_, found, err := st.GetExecutedTransaction(rt.AdtStore(), 0)
+		require.NoError(t, err)
+		assert.False(t, found)
+		actor.checkState(rt)
+	})
+
+	t.Run("no history is retained by default", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, 0, 0, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectSend(chuck, builtin.MethodSend, nil, sendValue, nil, exitcode.Ok)
+		actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+
+		var st multisig.State
+		rt.GetState(&st)
+		assert.Equal(t, uint64(0), st.NumExecutedTxns)
+		executed, err := st.ExecutedTransactions(rt.AdtStore())
+		require.NoError(t, err)
+		assert.Empty(t, executed)
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to construct with history longer than maximum", func(t *testing.T) {
+		rt := builder.Build(t)
+
+		rt.ExpectValidateCallerAddr(builtin.InitActorAddr)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.a.Constructor, &multisig.ConstructorParams{
+				Signers:               []addr.Address{anne, bob},
+				NumApprovalsThreshold: 1,
+				ExecutedHistoryLength: multisig.MaxExecutedHistoryLength + 1,
+			})
 		})
+		rt.Verify()
+	})
+
+	executeSends := func(rt *mock.Runtime, n int) {
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		for i := 0; i < n; i++ {
+			rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+			rt.ExpectSend(chuck, builtin.MethodSend, nil, sendValue, nil, exitcode.Ok)
+			actor.proposeOK(rt, chuck, sendValue, builtin.MethodSend, nil, nil)
+		}
 	}
+
+	executedIDs := func(rt *mock.Runtime) []multisig.TxnID {
+		var st multisig.State
+		rt.GetState(&st)
+		executed, err := st.ExecutedTransactions(rt.AdtStore())
+		require.NoError(t, err)
+		var ids []multisig.TxnID
+		for _, txn := range executed {
+			ids = append(ids, txn.TxnID)
+		}
+		return ids
+	}
+
+	t.Run("enables history on an existing wallet", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, 0, 0, anne, bob)
+		executeSends(rt, 1)
+
+		actor.setExecutedHistoryLength(rt, 2)
+		executeSends(rt, 3)
+		assert.Equal(t, []multisig.TxnID{2, 3}, executedIDs(rt))
+		actor.checkState(rt)
+	})
+
+	t.Run("shortening history retains the most recent transactions", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructWithHistory(rt, 1, 4, anne, bob)
+		executeSends(rt, 5)
+		assert.Equal(t, []multisig.TxnID{1, 2, 3, 4}, executedIDs(rt))
+
+		actor.setExecutedHistoryLength(rt, 2)
+		assert.Equal(t, []multisig.TxnID{3, 4}, executedIDs(rt))
+
+		executeSends(rt, 1)
+		assert.Equal(t, []multisig.TxnID{4, 5}, executedIDs(rt))
+		actor.checkState(rt)
+	})
+
+	t.Run("lengthening history retains existing transactions", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructWithHistory(rt, 1, 2, anne, bob)
+		executeSends(rt, 3)
+
+		actor.setExecutedHistoryLength(rt, 3)
+		assert.Equal(t, []multisig.TxnID{1, 2}, executedIDs(rt))
+
+		executeSends(rt, 2)
+		assert.Equal(t, []multisig.TxnID{2, 3, 4}, executedIDs(rt))
+		actor.checkState(rt)
+	})
+
+	t.Run("disabling history discards it", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructWithHistory(rt, 1, 2, anne, bob)
+		executeSends(rt, 1)
+
+		actor.setExecutedHistoryLength(rt, 0)
+		assert.Empty(t, executedIDs(rt))
+		var st multisig.State
+		rt.GetState(&st)
+		assert.Equal(t, uint64(0), st.NumExecutedTxns)
+
+		executeSends(rt, 1)
+		assert.Empty(t, executedIDs(rt))
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to set history length unless called by the wallet", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, 0, 0, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(receiver)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.a.SetExecutedHistoryLength, &multisig.SetExecutedHistoryLengthParams{Length: 2})
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fail to set history length longer than maximum", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, 0, 0, anne, bob)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.setExecutedHistoryLength(rt, multisig.MaxExecutedHistoryLength+1)
+		})
+		actor.checkState(rt)
+	})
+}
+
+func TestLockBalance(t *testing.T) {
+	actor := msActorHarness{multisig.Actor{}, t}
+
+	receiver := tutil.NewIDAddr(t, 100)
+	anne := tutil.NewIDAddr(t, 101)
+	bob := tutil.NewIDAddr(t, 102)
+
+	const noUnlockDuration = int64(0)
+	balance := abi.NewTokenAmount(100)
+
+	builder := mock.NewBuilder(context.Background(), receiver).
+		WithCaller(builtin.InitActorAddr, builtin.InitActorCodeID).
+		WithEpoch(0).
+		WithBalance(balance, big.Zero()).
+		WithHasher(blake2b.Sum256)
+
+	t.Run("locks balance of a wallet with no vesting schedule", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, noUnlockDuration, 0, anne, bob)
+
+		rt.SetEpoch(10)
+		actor.lockBalance(rt, 10, 10, balance)
+
+		var st multisig.State
+		rt.GetState(&st)
+		assert.Equal(t, abi.ChainEpoch(10), st.StartEpoch)
+		assert.Equal(t, abi.ChainEpoch(10), st.UnlockDuration)
+		assert.Equal(t, balance, st.InitialBalance)
+
+		// nothing may be spent at the start of the schedule
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrInsufficientFunds, func() {
+			actor.propose(rt, bob, abi.NewTokenAmount(1), builtin.MethodSend, nil, nil)
+		})
+
+		// half the balance is unlocked half way through
+		rt.SetEpoch(15)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectSend(bob, builtin.MethodSend, nil, abi.NewTokenAmount(50), nil, exitcode.Ok)
+		actor.proposeOK(rt, bob, abi.NewTokenAmount(50), builtin.MethodSend, nil, nil)
+
+		rt.SetBalance(big.Sub(balance, abi.NewTokenAmount(50)))
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrInsufficientFunds, func() {
+			actor.propose(rt, bob, abi.NewTokenAmount(1), builtin.MethodSend, nil, nil)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("locks balance from a future start epoch", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, noUnlockDuration, 0, anne, bob)
+
+		actor.lockBalance(rt, 10, 10, abi.NewTokenAmount(60))
+
+		// the locked amount is held before the schedule starts, and the rest of the balance may be spent
+		rt.SetEpoch(5)
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectAbort(exitcode.ErrInsufficientFunds, func() {
+			actor.propose(rt, bob, abi.NewTokenAmount(41), builtin.MethodSend, nil, nil)
+		})
+		rt.ExpectValidateCallerType(builtin.AccountActorCodeID, builtin.MultisigActorCodeID)
+		rt.ExpectSend(bob, builtin.MethodSend, nil, abi.NewTokenAmount(40), nil, exitcode.Ok)
+		actor.proposeOK(rt, bob, abi.NewTokenAmount(40), builtin.MethodSend, nil, nil)
+		actor.checkState(rt)
+	})
+
+	t.Run("may replace a schedule that has fully unlocked", func(t *testing.T) {
+		rt := builder.Build(t)
+		rt.SetReceived(balance)
+		actor.constructAndVerify(rt, 1, 10, 0, anne, bob)
+
+		rt.SetEpoch(9)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			actor.lockBalance(rt, 9, 10, balance)
+		})
+
+		rt.SetEpoch(10)
+		actor.lockBalance(rt, 10, 20, balance)
+
+		var st multisig.State
+		rt.GetState(&st)
+		assert.Equal(t, abi.ChainEpoch(10), st.StartEpoch)
+		assert.Equal(t, abi.ChainEpoch(20), st.UnlockDuration)
+		actor.checkState(rt)
+	})
+
+	t.Run("fails to lock with non-positive duration", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, noUnlockDuration, 0, anne, bob)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.lockBalance(rt, 0, 0, balance)
+		})
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.lockBalance(rt, 0, -1, balance)
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails to lock a negative amount", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, noUnlockDuration, 0, anne, bob)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			actor.lockBalance(rt, 0, 10, abi.NewTokenAmount(-1))
+		})
+		actor.checkState(rt)
+	})
+
+	t.Run("fails when caller is not the wallet", func(t *testing.T) {
+		rt := builder.Build(t)
+		actor.constructAndVerify(rt, 1, noUnlockDuration, 0, anne, bob)
+
+		rt.SetCaller(anne, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(receiver)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.a.LockBalance, &multisig.LockBalanceParams{StartEpoch: 0, UnlockDuration: 10, Amount: balance})
+		})
+		rt.Verify()
+		actor.checkState(rt)
+	})
 }
 
 //
@@ -1655,6 +2243,19 @@ func (h *msActorHarness) constructAndVerify(rt *mock.Runtime, numApprovalsThresh
 	rt.Verify()
 }
 
+func (h *msActorHarness) constructWithHistory(rt *mock.Runtime, numApprovalsThresh uint64, historyLength uint64, signers ...addr.Address) {
+	constructParams := multisig.ConstructorParams{
+		Signers:               signers,
+		NumApprovalsThreshold: numApprovalsThresh,
+		ExecutedHistoryLength: historyLength,
+	}
+
+	rt.ExpectValidateCallerAddr(builtin.InitActorAddr)
+	ret := rt.Call(h.a.Constructor, &constructParams)
+	assert.Nil(h.t, ret)
+	rt.Verify()
+}
+
 func (h *msActorHarness) propose(rt *mock.Runtime, to addr.Address, value abi.TokenAmount, method abi.MethodNum, params []byte, out runtime.CBORUnmarshaler) exitcode.ExitCode {
 	proposeParams := &multisig.ProposeParams{
 		To:     to,
@@ -1758,6 +2359,46 @@ func (h *msActorHarness) changeNumApprovalsThreshold(rt *mock.Runtime, newThresh
 	rt.Call(h.a.ChangeNumApprovalsThreshold, thrshParams)
 }
 
+func (h *msActorHarness) proposeExpiring(rt *mock.Runtime, to addr.Address, value abi.TokenAmount, method abi.MethodNum, params []byte,
+	expiration abi.ChainEpoch) {
+	ret := rt.Call(h.a.Propose, &multisig.ProposeParams{
+		To:         to,
+		Value:      value,
+		Method:     method,
+		Params:     params,
+		Expiration: expiration,
+	})
+	rt.Verify()
+	require.False(h.t, ret.(*multisig.ProposeReturn).Applied)
+}
+
+func (h *msActorHarness) pruneExpiredTransactions(rt *mock.Runtime, txnIDs ...multisig.TxnID) {
+	rt.ExpectValidateCallerAny()
+	ret := rt.Call(h.a.PruneExpiredTransactions, &multisig.PruneExpiredTransactionsParams{TxnIDs: txnIDs})
+	assert.Nil(h.t, ret)
+	rt.Verify()
+}
+
+func (h *msActorHarness) lockBalance(rt *mock.Runtime, start, duration abi.ChainEpoch, amount abi.TokenAmount) {
+	rt.SetCaller(rt.Receiver(), builtin.MultisigActorCodeID)
+	rt.ExpectValidateCallerAddr(rt.Receiver())
+	ret := rt.Call(h.a.LockBalance, &multisig.LockBalanceParams{
+		StartEpoch:     start,
+		UnlockDuration: duration,
+		Amount:         amount,
+	})
+	assert.Nil(h.t, ret)
+	rt.Verify()
+}
+
+func (h *msActorHarness) setExecutedHistoryLength(rt *mock.Runtime, length uint64) {
+	rt.SetCaller(rt.Receiver(), builtin.MultisigActorCodeID)
+	rt.ExpectValidateCallerAddr(rt.Receiver())
+	ret := rt.Call(h.a.SetExecutedHistoryLength, &multisig.SetExecutedHistoryLengthParams{Length: length})
+	assert.Nil(h.t, ret)
+	rt.Verify()
+}
+
 func (h *msActorHarness) assertTransactions(rt *mock.Runtime, expected ...multisig.Transaction) {
 	var st multisig.State
 	rt.GetState(&st)
@@ -1792,3 +2433,10 @@ func (s key) Key() string {
 func asKey(in string) adt.Keyer {
 	return key(in)
 }
+
+func (h *msActorHarness) checkState(rt *mock.Runtime) {
+	var st multisig.State
+	rt.GetState(&st)
+	_, msgs := multisig.CheckStateInvariants(&st, rt.AdtStore(), rt.Balance())
+	assert.True(h.t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
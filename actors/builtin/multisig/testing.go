@@ -0,0 +1,107 @@
+package multisig
+
+import (
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+)
+
+type StateSummary struct {
+	PendingTxnCount uint64
+	NumApprovals    uint64
+	SignerCount     int
+}
+
+// Checks internal invariants of multisig state.
+func CheckStateInvariants(st *State, store adt.Store, balance abi.TokenAmount) (*StateSummary, *builtin.MessageAccumulator) {
+	acc := &builtin.MessageAccumulator{}
+	acc.Require(balance.GreaterThanEqual(big.Zero()), "multisig balance is negative %v", balance)
+
+	// assert invariants involving signers
+	acc.Require(len(st.Signers) > 0, "multisig has no signers")
+	acc.Require(st.NumApprovalsThreshold >= 1, "multisig threshold %d is less than one", st.NumApprovalsThreshold)
+	acc.Require(st.NumApprovalsThreshold <= uint64(len(st.Signers)), "multisig threshold %d exceeds signer count %d",
+		st.NumApprovalsThreshold, len(st.Signers))
+	signers := make(map[string]bool, len(st.Signers))
+	for _, s := range st.Signers {
+		acc.Require(!signers[string(s.Bytes())], "duplicate signer %v", s)
+		signers[string(s.Bytes())] = true
+	}
+
+	// assert invariants involving the vesting schedule
+	acc.Require(st.UnlockDuration >= 0, "negative unlock duration %d", st.UnlockDuration)
+	acc.Require(st.InitialBalance.GreaterThanEqual(big.Zero()), "negative initial balance %v", st.InitialBalance)
+	if st.UnlockDuration == 0 {
+		acc.Require(st.InitialBalance.IsZero(), "non-zero initial balance %v without an unlock duration", st.InitialBalance)
+	}
+
+	pendingTxnCount := uint64(0)
+	proposedCounts := map[address.Address]int{}
+	maxTxnID := TxnID(-1)
+	if transactions, err := adt.AsMap(store, st.PendingTxns); err != nil {
+		acc.Addf("error loading transactions: %v", err)
+	} else {
+		var txn Transaction
+		err = transactions.ForEach(&txn, func(txnIDStr string) error {
+			txnID, err := parseTxnIDKey(txnIDStr)
+			if err != nil {
+				return err
+			}
+			if txnID > maxTxnID {
+				maxTxnID = txnID
+			}
+
+			acc.Require(len(txn.Approved) > 0, "transaction %d has no approvals", txnID)
+			approvers := make(map[string]bool, len(txn.Approved))
+			for _, approver := range txn.Approved {
+				acc.Require(!approvers[string(approver.Bytes())], "transaction %d has duplicate approval from %v", txnID, approver)
+				approvers[string(approver.Bytes())] = true
+			}
+			acc.Require(txn.Value.GreaterThanEqual(big.Zero()), "transaction %d has negative value %v", txnID, txn.Value)
+			acc.Require(txn.Expiration >= 0, "transaction %d has negative expiration %d", txnID, txn.Expiration)
+			if len(txn.Approved) > 0 {
+				proposedCounts[txn.Approved[0]]++
+			}
+
+			pendingTxnCount++
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating transactions")
+	}
+
+	for proposer, count := range proposedCounts {
+		acc.Require(count <= MaxPendingTxnsPerSigner, "proposer %v has %d pending transactions, exceeding maximum %d",
+			proposer, count, MaxPendingTxnsPerSigner)
+	}
+
+	// assert invariants involving the executed transaction history
+	acc.Require(st.ExecutedHistoryLength <= MaxExecutedHistoryLength, "executed history length %d exceeds maximum %d",
+		st.ExecutedHistoryLength, MaxExecutedHistoryLength)
+	if st.ExecutedHistoryLength == 0 {
+		acc.Require(st.NumExecutedTxns == 0, "%d executed transactions recorded without a history", st.NumExecutedTxns)
+	} else if executed, err := st.ExecutedTransactions(store); err != nil {
+		acc.Addf("error loading executed transactions: %v", err)
+	} else {
+		for _, txn := range executed {
+			acc.Require(txn.TxnID < st.NextTxnID, "executed transaction %d was never proposed (next id %d)", txn.TxnID, st.NextTxnID)
+			acc.Require(len(txn.ProposalHash) > 0, "executed transaction %d has no proposal hash", txn.TxnID)
+		}
+	}
+
+	acc.Require(st.NextTxnID > maxTxnID, "next transaction id %d should be greater than pending transaction ids (max %d)",
+		st.NextTxnID, maxTxnID)
+
+	return &StateSummary{
+		PendingTxnCount: pendingTxnCount,
+		NumApprovals:    st.NumApprovalsThreshold,
+		SignerCount:     len(st.Signers),
+	}, acc
+}
+
+func parseTxnIDKey(key string) (TxnID, error) {
+	id, err := adt.ParseIntKey(key)
+	return TxnID(id), err
+}
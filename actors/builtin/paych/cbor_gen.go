@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 
+	address "github.com/filecoin-project/go-address"
 	abi "github.com/filecoin-project/specs-actors/actors/abi"
 	crypto "github.com/filecoin-project/specs-actors/actors/crypto"
 	cbg "github.com/whyrusleeping/cbor-gen"
@@ -14,7 +15,7 @@ import (
 
 var _ = xerrors.Errorf
 
-var lengthBufState = []byte{134}
+var lengthBufState = []byte{135}
 
 func (t *State) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -70,6 +71,19 @@ func (t *State) MarshalCBOR(w io.Writer) error {
 		return xerrors.Errorf("failed to write cid field t.LaneStates: %w", err)
 	}
 
+	// t.Recipients ([]paych.ChannelRecipient) (slice)
+	if len(t.Recipients) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Recipients was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Recipients))); err != nil {
+		return err
+	}
+	for _, v := range t.Recipients {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -87,7 +101,7 @@ func (t *State) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 6 {
+	if extra != 7 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -180,10 +194,39 @@ func (t *State) UnmarshalCBOR(r io.Reader) error {
 		t.LaneStates = c
 
 	}
+	// t.Recipients ([]paych.ChannelRecipient) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Recipients: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Recipients = make([]ChannelRecipient, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v ChannelRecipient
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Recipients[i] = v
+	}
+
 	return nil
 }
 
-var lengthBufLaneState = []byte{130}
+var lengthBufLaneState = []byte{131}
 
 func (t *LaneState) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -207,6 +250,10 @@ func (t *LaneState) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
+	// t.Recipient (address.Address) (struct)
+	if err := t.Recipient.MarshalCBOR(w); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -224,7 +271,7 @@ func (t *LaneState) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 2 {
+	if extra != 3 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -250,6 +297,25 @@ func (t *LaneState) UnmarshalCBOR(r io.Reader) error {
 		}
 		t.Nonce = uint64(extra)
 
+	}
+	// t.Recipient (address.Address) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return err
+			}
+			t.Recipient = new(address.Address)
+			if err := t.Recipient.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("unmarshaling t.Recipient pointer: %w", err)
+			}
+		}
+
 	}
 	return nil
 }
@@ -331,7 +397,69 @@ func (t *Merge) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufConstructorParams = []byte{130}
+var lengthBufChannelRecipient = []byte{130}
+
+func (t *ChannelRecipient) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufChannelRecipient); err != nil {
+		return err
+	}
+
+	// t.Recipient (address.Address) (struct)
+	if err := t.Recipient.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.ToSend (big.Int) (struct)
+	if err := t.ToSend.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *ChannelRecipient) UnmarshalCBOR(r io.Reader) error {
+	*t = ChannelRecipient{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Recipient (address.Address) (struct)
+
+	{
+
+		if err := t.Recipient.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Recipient: %w", err)
+		}
+
+	}
+	// t.ToSend (big.Int) (struct)
+
+	{
+
+		if err := t.ToSend.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.ToSend: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufConstructorParams = []byte{131}
 
 func (t *ConstructorParams) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -342,6 +470,8 @@ func (t *ConstructorParams) MarshalCBOR(w io.Writer) error {
 		return err
 	}
 
+	scratch := make([]byte, 9)
+
 	// t.From (address.Address) (struct)
 	if err := t.From.MarshalCBOR(w); err != nil {
 		return err
@@ -351,6 +481,20 @@ func (t *ConstructorParams) MarshalCBOR(w io.Writer) error {
 	if err := t.To.MarshalCBOR(w); err != nil {
 		return err
 	}
+
+	// t.Recipients ([]address.Address) (slice)
+	if len(t.Recipients) > cbg.MaxLength {
+		return xerrors.Errorf("Slice value in field t.Recipients was too long")
+	}
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajArray, uint64(len(t.Recipients))); err != nil {
+		return err
+	}
+	for _, v := range t.Recipients {
+		if err := v.MarshalCBOR(w); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -368,7 +512,7 @@ func (t *ConstructorParams) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 2 {
+	if extra != 3 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -390,6 +534,35 @@ func (t *ConstructorParams) UnmarshalCBOR(r io.Reader) error {
 		}
 
 	}
+	// t.Recipients ([]address.Address) (slice)
+
+	maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+
+	if extra > cbg.MaxLength {
+		return fmt.Errorf("t.Recipients: array too large (%d)", extra)
+	}
+
+	if maj != cbg.MajArray {
+		return fmt.Errorf("expected cbor array")
+	}
+
+	if extra > 0 {
+		t.Recipients = make([]address.Address, extra)
+	}
+
+	for i := 0; i < int(extra); i++ {
+
+		var v address.Address
+		if err := v.UnmarshalCBOR(br); err != nil {
+			return err
+		}
+
+		t.Recipients[i] = v
+	}
+
 	return nil
 }
 
@@ -477,7 +650,7 @@ func (t *UpdateChannelStateParams) UnmarshalCBOR(r io.Reader) error {
 	return nil
 }
 
-var lengthBufSignedVoucher = []byte{139}
+var lengthBufSignedVoucher = []byte{140}
 
 func (t *SignedVoucher) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -577,6 +750,11 @@ func (t *SignedVoucher) MarshalCBOR(w io.Writer) error {
 		}
 	}
 
+	// t.Recipient (address.Address) (struct)
+	if err := t.Recipient.MarshalCBOR(w); err != nil {
+		return err
+	}
+
 	// t.Signature (crypto.Signature) (struct)
 	if err := t.Signature.MarshalCBOR(w); err != nil {
 		return err
@@ -598,7 +776,7 @@ func (t *SignedVoucher) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 11 {
+	if extra != 12 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -792,6 +970,25 @@ func (t *SignedVoucher) UnmarshalCBOR(r io.Reader) error {
 		t.Merges[i] = v
 	}
 
+	// t.Recipient (address.Address) (struct)
+
+	{
+
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != cbg.CborNull[0] {
+			if err := br.UnreadByte(); err != nil {
+				return err
+			}
+			t.Recipient = new(address.Address)
+			if err := t.Recipient.UnmarshalCBOR(br); err != nil {
+				return xerrors.Errorf("unmarshaling t.Recipient pointer: %w", err)
+			}
+		}
+
+	}
 	// t.Signature (crypto.Signature) (struct)
 
 	{
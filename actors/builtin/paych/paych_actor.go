@@ -31,6 +31,8 @@ var _ abi.Invokee = Actor{}
 type ConstructorParams struct {
 	From addr.Address // Payer
 	To   addr.Address // Payee
+	// (optional) Additional payees, to which lanes may be bound
+	Recipients []addr.Address
 }
 
 // Constructor creates a payment channel actor. See State for meaning of params.
@@ -45,10 +47,25 @@ func (pca *Actor) Constructor(rt vmr.Runtime, params *ConstructorParams) *adt.Em
 	from, err := pca.resolveAccount(rt, params.From)
 	builtin.RequireNoErr(rt, err, exitcode.Unwrap(err, exitcode.ErrIllegalState), "failed to resolve from address: %s", params.From)
 
+	if len(params.Recipients) > MaxRecipients {
+		rt.Abortf(exitcode.ErrIllegalArgument, "too many recipients %d, maximum %d", len(params.Recipients), MaxRecipients)
+	}
+	recipients := make([]addr.Address, 0, len(params.Recipients))
+	seen := map[addr.Address]struct{}{from: {}, to: {}}
+	for _, raw := range params.Recipients {
+		recipient, err := pca.resolveAccount(rt, raw)
+		builtin.RequireNoErr(rt, err, exitcode.Unwrap(err, exitcode.ErrIllegalState), "failed to resolve recipient address: %s", raw)
+		if _, ok := seen[recipient]; ok {
+			rt.Abortf(exitcode.ErrIllegalArgument, "recipient %v is already a party to the channel", raw)
+		}
+		seen[recipient] = struct{}{}
+		recipients = append(recipients, recipient)
+	}
+
 	emptyArrCid, err := adt.MakeEmptyArray(adt.AsStore(rt)).Root()
 	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to create empty array")
 
-	st := ConstructState(from, to, emptyArrCid)
+	st := ConstructState(from, to, recipients, emptyArrCid)
 	rt.State().Create(st)
 
 	return nil
@@ -107,6 +124,8 @@ type SignedVoucher struct {
 
 	// (optional) Set of lanes to be merged into `Lane`
 	Merges []Merge
+	// (optional) The additional recipient of the channel to which `Lane` is bound. If nil, the lane pays `To`.
+	Recipient *addr.Address
 
 	// Sender's signature over the voucher
 	Signature *crypto.Signature
@@ -125,16 +144,27 @@ type ModVerifyParams struct {
 func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStateParams) *adt.EmptyValue {
 	var st State
 	rt.State().Readonly(&st)
+	rt.ValidateImmediateCallerIs(st.parties()...)
+	sv := params.Sv
+
+	recipient := st.To
+	if sv.Recipient != nil {
+		resolved, ok := rt.ResolveAddress(*sv.Recipient)
+		if !ok || resolved == st.To || st.toSendTo(resolved) == nil {
+			rt.Abortf(exitcode.ErrIllegalArgument, "voucher recipient %v is not an additional recipient of the channel", *sv.Recipient)
+		}
+		recipient = resolved
+	}
 
 	// both parties must sign voucher: one who submits it, the other explicitly signs it
-	rt.ValidateImmediateCallerIs(st.From, st.To)
 	var signer addr.Address
 	if rt.Message().Caller() == st.From {
-		signer = st.To
-	} else {
+		signer = recipient
+	} else if rt.Message().Caller() == recipient {
 		signer = st.From
+	} else {
+		rt.Abortf(exitcode.ErrForbidden, "caller %v is neither payer nor voucher recipient %v", rt.Message().Caller(), recipient)
 	}
-	sv := params.Sv
 
 	if sv.Signature == nil {
 		rt.Abortf(exitcode.ErrIllegalArgument, "voucher has no signature")
@@ -200,6 +230,9 @@ func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStatePa
 				Redeemed: big.Zero(),
 				Nonce:    0,
 			}
+			if recipient != st.To {
+				laneState.Recipient = &recipient
+			}
 			laneFound = false
 		}
 
@@ -208,6 +241,10 @@ func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStatePa
 				rt.Abortf(exitcode.ErrIllegalArgument, "voucher has an outdated nonce, existing nonce: %d, voucher nonce: %d, cannot redeem",
 					laneState.Nonce, sv.Nonce)
 			}
+			if st.laneRecipient(laneState) != recipient {
+				rt.Abortf(exitcode.ErrIllegalArgument, "voucher recipient %v does not match lane %d recipient %v",
+					recipient, laneId, st.laneRecipient(laneState))
+			}
 		}
 
 		// The next section actually calculates the payment amounts to update the payment channel state
@@ -227,6 +264,9 @@ func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStatePa
 			if otherls.Nonce >= merge.Nonce {
 				rt.Abortf(exitcode.ErrIllegalArgument, "merged lane in voucher has outdated nonce, cannot redeem")
 			}
+			if st.laneRecipient(otherls) != recipient {
+				rt.Abortf(exitcode.ErrIllegalArgument, "voucher cannot merge lane %d with a different recipient", merge.Lane)
+			}
 
 			redeemedFromOthers = big.Add(redeemedFromOthers, otherls.Redeemed)
 			otherls.Nonce = merge.Nonce
@@ -241,18 +281,19 @@ func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStatePa
 		// 3. set new redeemed value for merged-into lane
 		laneState.Redeemed = sv.Amount
 
-		newSendBalance := big.Add(st.ToSend, balanceDelta)
+		toSend := st.toSendTo(recipient)
+		newSendBalance := big.Add(*toSend, balanceDelta)
 
 		// 4. check operation validity
 		if newSendBalance.LessThan(big.Zero()) {
 			rt.Abortf(exitcode.ErrIllegalArgument, "voucher would leave channel balance negative")
 		}
-		if newSendBalance.GreaterThan(rt.CurrentBalance()) {
+		if big.Add(st.totalToSend(), balanceDelta).GreaterThan(rt.CurrentBalance()) {
 			rt.Abortf(exitcode.ErrIllegalArgument, "not enough funds in channel to cover voucher")
 		}
 
 		// 5. add new redemption ToSend
-		st.ToSend = newSendBalance
+		*toSend = newSendBalance
 
 		// update channel settlingAt and MinSettleHeight if delayed by voucher
 		if sv.MinSettleHeight != 0 {
@@ -276,7 +317,7 @@ func (pca Actor) UpdateChannelState(rt vmr.Runtime, params *UpdateChannelStatePa
 func (pca Actor) Settle(rt vmr.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 	var st State
 	rt.State().Transaction(&st, func() {
-		rt.ValidateImmediateCallerIs(st.From, st.To)
+		rt.ValidateImmediateCallerIs(st.parties()...)
 
 		if st.SettlingAt != 0 {
 			rt.Abortf(exitcode.ErrIllegalState, "channel already settling")
@@ -293,7 +334,7 @@ func (pca Actor) Settle(rt vmr.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 func (pca Actor) Collect(rt vmr.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 	var st State
 	rt.State().Readonly(&st)
-	rt.ValidateImmediateCallerIs(st.From, st.To)
+	rt.ValidateImmediateCallerIs(st.parties()...)
 
 	if st.SettlingAt == 0 || rt.CurrEpoch() < st.SettlingAt {
 		rt.Abortf(exitcode.ErrForbidden, "payment channel not settling or settled")
@@ -308,6 +349,17 @@ func (pca Actor) Collect(rt vmr.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
 	)
 	builtin.RequireSuccess(rt, codeTo, "Failed to send funds to `To`")
 
+	// send each additional recipient its ToSend
+	for _, r := range st.Recipients {
+		_, code := rt.Send(
+			r.Recipient,
+			builtin.MethodSend,
+			nil,
+			r.ToSend,
+		)
+		builtin.RequireSuccess(rt, code, "failed to send funds to recipient %v", r.Recipient)
+	}
+
 	// the remaining balance will be returned to "From" upon deletion.
 	rt.DeleteActor(st.From)
 
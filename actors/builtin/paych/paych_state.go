@@ -27,6 +27,16 @@ type State struct {
 
 	// Collections of lane states for the channel, maintained in ID order.
 	LaneStates cid.Cid // AMT<LaneState>
+
+	// Additional recipients of payouts from the channel, to which lanes may be bound, in addition to To.
+	Recipients []ChannelRecipient
+}
+
+// An additional recipient of payouts from a channel.
+type ChannelRecipient struct {
+	Recipient addr.Address
+	// Amount successfully redeemed through lanes bound to this recipient, paid out on `Collect()`
+	ToSend abi.TokenAmount
 }
 
 // The Lane state tracks the latest (highest) voucher nonce used to merge the lane
@@ -34,6 +44,8 @@ type State struct {
 type LaneState struct {
 	Redeemed big.Int
 	Nonce    uint64
+	// The additional recipient to which the lane is bound, or nil if the lane pays To.
+	Recipient *addr.Address
 }
 
 // Specifies which `Lane`s to be merged with what `Nonce` on channelUpdate
@@ -42,7 +54,11 @@ type Merge struct {
 	Nonce uint64
 }
 
-func ConstructState(from addr.Address, to addr.Address, emptyArrCid cid.Cid) *State {
+func ConstructState(from addr.Address, to addr.Address, recipients []addr.Address, emptyArrCid cid.Cid) *State {
+	var channelRecipients []ChannelRecipient
+	for _, r := range recipients {
+		channelRecipients = append(channelRecipients, ChannelRecipient{Recipient: r, ToSend: big.Zero()})
+	}
 	return &State{
 		From:            from,
 		To:              to,
@@ -50,5 +66,45 @@ func ConstructState(from addr.Address, to addr.Address, emptyArrCid cid.Cid) *St
 		SettlingAt:      0,
 		MinSettleHeight: 0,
 		LaneStates:      emptyArrCid,
+		Recipients:      channelRecipients,
+	}
+}
+
+// Returns the addresses of the parties to the channel: the payer, and each recipient.
+func (st *State) parties() []addr.Address {
+	parties := []addr.Address{st.From, st.To}
+	for _, r := range st.Recipients {
+		parties = append(parties, r.Recipient)
+	}
+	return parties
+}
+
+// Returns the amount to be paid out to a recipient of the channel, or nil if the address is not a recipient.
+func (st *State) toSendTo(recipient addr.Address) *abi.TokenAmount {
+	if recipient == st.To {
+		return &st.ToSend
+	}
+	for i := range st.Recipients {
+		if st.Recipients[i].Recipient == recipient {
+			return &st.Recipients[i].ToSend
+		}
+	}
+	return nil
+}
+
+// Returns the total amount to be paid out to all recipients of the channel.
+func (st *State) totalToSend() abi.TokenAmount {
+	total := st.ToSend
+	for _, r := range st.Recipients {
+		total = big.Add(total, r.ToSend)
+	}
+	return total
+}
+
+// Returns the recipient to which a lane is bound.
+func (st *State) laneRecipient(ls *LaneState) addr.Address {
+	if ls.Recipient == nil {
+		return st.To
 	}
+	return *ls.Recipient
 }
@@ -821,6 +821,203 @@ func TestActor_Collect(t *testing.T) {
 	}
 }
 
+func TestActor_Recipients(t *testing.T) {
+	ctx := context.Background()
+	paychAddr := tutil.NewIDAddr(t, 100)
+	payerAddr := tutil.NewIDAddr(t, 101)
+	payeeAddr := tutil.NewIDAddr(t, 102)
+	aliceAddr := tutil.NewIDAddr(t, 103)
+	bobAddr := tutil.NewIDAddr(t, 104)
+	sig := &crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte{0, 1, 2, 3, 4, 5, 6, 7}}
+	balance := abi.NewTokenAmount(100)
+
+	builder := mock.NewBuilder(ctx, paychAddr).
+		WithBalance(balance, big.Zero()).
+		WithCaller(builtin.InitActorAddr, builtin.InitActorCodeID).
+		WithActorType(payerAddr, builtin.AccountActorCodeID).
+		WithActorType(payeeAddr, builtin.AccountActorCodeID).
+		WithActorType(aliceAddr, builtin.AccountActorCodeID).
+		WithActorType(bobAddr, builtin.AccountActorCodeID)
+
+	parties := []addr.Address{payerAddr, payeeAddr, aliceAddr, bobAddr}
+
+	setup := func(t *testing.T) (*mock.Runtime, *pcActorHarness) {
+		rt := builder.Build(t)
+		actor := pcActorHarness{Actor{}, t, paychAddr, payerAddr, payeeAddr}
+		rt.ExpectValidateCallerType(builtin.InitActorCodeID)
+		ret := rt.Call(actor.Constructor, &ConstructorParams{From: payerAddr, To: payeeAddr, Recipients: []addr.Address{aliceAddr, bobAddr}})
+		assert.Nil(t, ret)
+		rt.Verify()
+		return rt, &actor
+	}
+
+	voucher := func(lane, nonce uint64, amount int64, recipient *addr.Address) SignedVoucher {
+		return SignedVoucher{ChannelAddr: paychAddr, Lane: lane, Nonce: nonce, Amount: big.NewInt(amount), Recipient: recipient, Signature: sig}
+	}
+
+	redeem := func(rt *mock.Runtime, actor *pcActorHarness, caller, signer addr.Address, sv SignedVoucher) {
+		rt.SetCaller(caller, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectVerifySignature(*sv.Signature, signer, voucherBytes(t, &sv), nil)
+		ret := rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+		assert.Nil(t, ret)
+		rt.Verify()
+	}
+
+	t.Run("constructs channel with additional recipients", func(t *testing.T) {
+		rt, _ := setup(t)
+
+		var st State
+		rt.GetState(&st)
+		assert.Equal(t, []ChannelRecipient{
+			{Recipient: aliceAddr, ToSend: big.Zero()},
+			{Recipient: bobAddr, ToSend: big.Zero()},
+		}, st.Recipients)
+	})
+
+	t.Run("fails to construct with a recipient that is already a party", func(t *testing.T) {
+		for _, recipients := range [][]addr.Address{{aliceAddr, aliceAddr}, {payeeAddr}, {payerAddr}} {
+			rt := builder.Build(t)
+			rt.ExpectValidateCallerType(builtin.InitActorCodeID)
+			rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+				rt.Call((&Actor{}).Constructor, &ConstructorParams{From: payerAddr, To: payeeAddr, Recipients: recipients})
+			})
+		}
+	})
+
+	t.Run("fails to construct with too many recipients", func(t *testing.T) {
+		rt := builder.Build(t)
+		var recipients []addr.Address
+		for i := 0; i <= MaxRecipients; i++ {
+			recipient := tutil.NewIDAddr(t, uint64(200+i))
+			rt.SetAddressActorType(recipient, builtin.AccountActorCodeID)
+			recipients = append(recipients, recipient)
+		}
+
+		rt.ExpectValidateCallerType(builtin.InitActorCodeID)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call((&Actor{}).Constructor, &ConstructorParams{From: payerAddr, To: payeeAddr, Recipients: recipients})
+		})
+	})
+
+	t.Run("redeems vouchers into each recipient's lanes", func(t *testing.T) {
+		rt, actor := setup(t)
+
+		// a recipient submits a voucher signed by the payer
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 1, 10, &aliceAddr))
+		// the payer submits a voucher signed by the recipient
+		redeem(rt, actor, payerAddr, bobAddr, voucher(1, 1, 20, &bobAddr))
+		// the original payee is unaffected
+		redeem(rt, actor, payeeAddr, payerAddr, voucher(2, 1, 30, nil))
+		// a later voucher on a lane adds only the difference
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 2, 15, &aliceAddr))
+
+		var st State
+		rt.GetState(&st)
+		assert.Equal(t, big.NewInt(30), st.ToSend)
+		assert.Equal(t, []ChannelRecipient{
+			{Recipient: aliceAddr, ToSend: big.NewInt(15)},
+			{Recipient: bobAddr, ToSend: big.NewInt(20)},
+		}, st.Recipients)
+
+		lane0 := getLaneState(t, rt, st.LaneStates, 0)
+		require.NotNil(t, lane0.Recipient)
+		assert.Equal(t, aliceAddr, *lane0.Recipient)
+		assert.Nil(t, getLaneState(t, rt, st.LaneStates, 2).Recipient)
+	})
+
+	t.Run("voucher recipient must match lane recipient", func(t *testing.T) {
+		rt, actor := setup(t)
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 1, 10, &aliceAddr))
+
+		sv := voucher(0, 2, 20, &bobAddr)
+		rt.SetCaller(bobAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectVerifySignature(*sv.Signature, payerAddr, voucherBytes(t, &sv), nil)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+		})
+	})
+
+	t.Run("fails to merge lanes of different recipients", func(t *testing.T) {
+		rt, actor := setup(t)
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 1, 10, &aliceAddr))
+		redeem(rt, actor, bobAddr, payerAddr, voucher(1, 1, 10, &bobAddr))
+
+		sv := voucher(0, 2, 20, &aliceAddr)
+		sv.Merges = []Merge{{Lane: 1, Nonce: 2}}
+		rt.SetCaller(aliceAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectVerifySignature(*sv.Signature, payerAddr, voucherBytes(t, &sv), nil)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+		})
+	})
+
+	t.Run("recipient cannot redeem another recipient's voucher", func(t *testing.T) {
+		rt, actor := setup(t)
+
+		sv := voucher(0, 1, 10, nil)
+		rt.SetCaller(aliceAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+		})
+	})
+
+	t.Run("voucher recipient must be an additional recipient", func(t *testing.T) {
+		rt, actor := setup(t)
+
+		for _, recipient := range []addr.Address{payeeAddr, tutil.NewIDAddr(t, 999)} {
+			sv := voucher(0, 1, 10, &recipient)
+			rt.SetCaller(payerAddr, builtin.AccountActorCodeID)
+			rt.ExpectValidateCallerAddr(parties...)
+			rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+				rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+			})
+		}
+	})
+
+	t.Run("vouchers of all recipients are limited by the channel balance", func(t *testing.T) {
+		rt, actor := setup(t)
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 1, 60, &aliceAddr))
+
+		sv := voucher(1, 1, 41, &bobAddr)
+		rt.SetCaller(bobAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectVerifySignature(*sv.Signature, payerAddr, voucherBytes(t, &sv), nil)
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			rt.Call(actor.UpdateChannelState, &UpdateChannelStateParams{Sv: sv})
+		})
+	})
+
+	t.Run("collect pays each recipient", func(t *testing.T) {
+		rt, actor := setup(t)
+		redeem(rt, actor, aliceAddr, payerAddr, voucher(0, 1, 10, &aliceAddr))
+		redeem(rt, actor, bobAddr, payerAddr, voucher(1, 1, 20, &bobAddr))
+		redeem(rt, actor, payeeAddr, payerAddr, voucher(2, 1, 30, nil))
+
+		// any recipient may settle
+		rt.SetCaller(aliceAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.Call(actor.Settle, nil)
+		rt.Verify()
+
+		var st State
+		rt.GetState(&st)
+		rt.SetEpoch(st.SettlingAt)
+
+		rt.SetCaller(bobAddr, builtin.AccountActorCodeID)
+		rt.ExpectValidateCallerAddr(parties...)
+		rt.ExpectSend(payeeAddr, builtin.MethodSend, nil, big.NewInt(30), nil, exitcode.Ok)
+		rt.ExpectSend(aliceAddr, builtin.MethodSend, nil, big.NewInt(10), nil, exitcode.Ok)
+		rt.ExpectSend(bobAddr, builtin.MethodSend, nil, big.NewInt(20), nil, exitcode.Ok)
+		rt.ExpectDeleteActor(payerAddr)
+		rt.Call(actor.Collect, nil)
+		rt.Verify()
+	})
+}
+
 type pcActorHarness struct {
 	Actor
 	t testing.TB
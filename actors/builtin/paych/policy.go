@@ -13,3 +13,6 @@ const SettleDelay = builtin.EpochsInHour * 12
 
 // Maximum size of a secret that can be submitted with a payment channel update (in bytes).
 const MaxSecretSize = 256
+
+// Maximum number of additional recipients of a channel.
+const MaxRecipients = 16
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 
 	addr "github.com/filecoin-project/go-address"
@@ -42,6 +43,8 @@ func TestConstruction(t *testing.T) {
 		rt := builder.Build(t)
 
 		actor.constructAndVerify(rt)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("create miner", func(t *testing.T) {
@@ -69,6 +72,8 @@ func TestConstruction(t *testing.T) {
 		assert.Equal(t, power.Claim{big.Zero(), big.Zero()}, actualClaim) // miner has not proven anything
 
 		verifyEmptyMap(t, rt, st.CronEventQueue)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -87,6 +92,8 @@ func TestCreateMinerFailures(t *testing.T) {
 			rt.Call(ac.CreateMiner, &power.CreateMinerParams{})
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if send to Init Actor fails", func(t *testing.T) {
@@ -138,6 +145,8 @@ func TestUpdateClaimedPowerFailures(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if claim does not exist for caller", func(t *testing.T) {
@@ -154,6 +163,8 @@ func TestUpdateClaimedPowerFailures(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 }
 
@@ -195,6 +206,8 @@ func TestEnrollCronEpoch(t *testing.T) {
 		evt = events[0]
 		require.EqualValues(t, p3, evt.CallbackPayload)
 		require.EqualValues(t, miner2, evt.MinerAddr)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("enroll for an epoch before the current epoch", func(t *testing.T) {
@@ -224,6 +237,8 @@ func TestEnrollCronEpoch(t *testing.T) {
 		require.EqualValues(t, p2, evt.CallbackPayload)
 		require.EqualValues(t, miner, evt.MinerAddr)
 		require.EqualValues(t, abi.ChainEpoch(0), st.FirstCronEpoch)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if epoch is negative", func(t *testing.T) {
@@ -307,6 +322,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 		claim2 = actor.getClaim(rt, miner2)
 		require.Equal(t, big.Zero(), claim2.RawBytePower)
 		require.Equal(t, big.Zero(), claim2.QualityAdjPower)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("power accounting crossing threshold", func(t *testing.T) {
@@ -343,6 +360,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 
 		actor.updateClaimedPower(rt, miner3, div(delta.Neg(), 2), delta.Neg())
 		actor.expectTotalPowerEager(rt, div(expectedTotalBelow, 2), expectedTotalBelow)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("all of one miner's power disappears when that miner dips below min power threshold", func(t *testing.T) {
@@ -368,6 +387,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 
 		expectedTotal = mul(powerUnit, 3)
 		actor.expectTotalPowerEager(rt, expectedTotal, expectedTotal)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("threshold only depends on qa power, not raw byte", func(t *testing.T) {
@@ -389,6 +410,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 		actor.updateClaimedPower(rt, miner3, big.Zero(), powerUnit)
 		st = getState(rt)
 		assert.Equal(t, int64(3), st.MinerAboveMinPowerCount)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("qa power is above threshold before and after update", func(t *testing.T) {
@@ -407,6 +430,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 		st = getState(rt)
 		require.EqualValues(t, mul(powerUnit, 4), st.TotalQualityAdjPower)
 		require.EqualValues(t, mul(powerUnit, 4), st.TotalRawBytePower)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("claimed power is externally available", func(t *testing.T) {
@@ -424,6 +449,8 @@ func TestPowerAndPledgeAccounting(t *testing.T) {
 
 		assert.Equal(t, powerUnit, claim.RawBytePower)
 		assert.Equal(t, powerUnit, claim.QualityAdjPower)
+
+		actor.checkState(rt)
 	})
 }
 
@@ -448,6 +475,8 @@ func TestCron(t *testing.T) {
 		rt.ExpectBatchVerifySeals(nil, nil, nil)
 		rt.Call(actor.Actor.OnEpochTickEnd, nil)
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("test amount sent to reward actor and state change", func(t *testing.T) {
@@ -477,6 +506,8 @@ func TestCron(t *testing.T) {
 		require.EqualValues(t, delta, st.ThisEpochPledgeCollateral)
 		require.EqualValues(t, expectedPower, st.ThisEpochQualityAdjPower)
 		require.EqualValues(t, expectedPower, st.ThisEpochRawBytePower)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("event scheduled in null round called next round", func(t *testing.T) {
@@ -504,6 +535,8 @@ func TestCron(t *testing.T) {
 
 		rt.Call(actor.Actor.OnEpochTickEnd, nil)
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 
 	t.Run("event scheduled in past called next round", func(t *testing.T) {
@@ -548,6 +581,8 @@ func TestCron(t *testing.T) {
 			return nil
 		})
 		require.NoError(t, err)
+
+		actor.checkState(rt)
 	})
 
 	t.Run("fails to enroll if epoch is negative", func(t *testing.T) {
@@ -607,6 +642,8 @@ func TestCron(t *testing.T) {
 
 		rt.Call(actor.Actor.OnEpochTickEnd, nil)
 		rt.Verify()
+
+		actor.checkState(rt)
 	})
 }
 
@@ -695,6 +732,8 @@ func TestCronBatchProofVerifies(t *testing.T) {
 		cs := []confirmedSectorSend{{miner1, []abi.SectorNumber{info.Number}}}
 
 		ac.onEpochTickEnd(rt, 0, big.Zero(), cs, infos)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("success with one miner and multiple confirmed sectors", func(t *testing.T) {
@@ -708,6 +747,8 @@ func TestCronBatchProofVerifies(t *testing.T) {
 		cs := []confirmedSectorSend{{miner1, []abi.SectorNumber{info1.Number, info2.Number, info3.Number}}}
 
 		ac.onEpochTickEnd(rt, 0, big.Zero(), cs, infos)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("duplicate sector numbers are ignored for a miner", func(t *testing.T) {
@@ -724,6 +765,8 @@ func TestCronBatchProofVerifies(t *testing.T) {
 		cs := []confirmedSectorSend{{miner1, []abi.SectorNumber{info1.Number, info2.Number}}}
 
 		ac.onEpochTickEnd(rt, 0, big.Zero(), cs, infos)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("success with multiple miners and multiple confirmed sectors and assert expected power", func(t *testing.T) {
@@ -758,11 +801,15 @@ func TestCronBatchProofVerifies(t *testing.T) {
 			miner4: {*info7, *info8}}
 
 		ac.onEpochTickEnd(rt, 0, big.Zero(), cs, infos)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("success when no confirmed sector", func(t *testing.T) {
 		rt, ac := basicPowerSetup(t)
 		ac.onEpochTickEnd(rt, 0, big.Zero(), nil, nil)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("verification for one sector fails but others succeeds for a miner", func(t *testing.T) {
@@ -798,6 +845,8 @@ func TestCronBatchProofVerifies(t *testing.T) {
 
 		rt.Call(ac.OnEpochTickEnd, nil)
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if batch verify seals fails", func(t *testing.T) {
@@ -1114,3 +1163,10 @@ func batchVerifyDefaultOutput(vis map[addr.Address][]abi.SealVerifyInfo) map[add
 	}
 	return out
 }
+
+func (h *spActorHarness) checkState(rt *mock.Runtime) {
+	var st power.State
+	rt.GetState(&st)
+	_, msgs := power.CheckStateInvariants(&st, rt.AdtStore(), rt.Balance())
+	assert.True(h.t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
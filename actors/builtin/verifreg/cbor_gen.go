@@ -6,13 +6,14 @@ import (
 	"fmt"
 	"io"
 
+	abi "github.com/filecoin-project/specs-actors/actors/abi"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	xerrors "golang.org/x/xerrors"
 )
 
 var _ = xerrors.Errorf
 
-var lengthBufState = []byte{131}
+var lengthBufState = []byte{133}
 
 func (t *State) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -42,6 +43,18 @@ func (t *State) MarshalCBOR(w io.Writer) error {
 		return xerrors.Errorf("failed to write cid field t.VerifiedClients: %w", err)
 	}
 
+	// t.RemoveDataCapProposalIDs (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.RemoveDataCapProposalIDs); err != nil {
+		return xerrors.Errorf("failed to write cid field t.RemoveDataCapProposalIDs: %w", err)
+	}
+
+	// t.AllowanceGrants (cid.Cid) (struct)
+
+	if err := cbg.WriteCidBuf(scratch, w, t.AllowanceGrants); err != nil {
+		return xerrors.Errorf("failed to write cid field t.AllowanceGrants: %w", err)
+	}
+
 	return nil
 }
 
@@ -59,7 +72,7 @@ func (t *State) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 3 {
+	if extra != 5 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -95,6 +108,30 @@ func (t *State) UnmarshalCBOR(r io.Reader) error {
 
 		t.VerifiedClients = c
 
+	}
+	// t.RemoveDataCapProposalIDs (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.RemoveDataCapProposalIDs: %w", err)
+		}
+
+		t.RemoveDataCapProposalIDs = c
+
+	}
+	// t.AllowanceGrants (cid.Cid) (struct)
+
+	{
+
+		c, err := cbg.ReadCid(br)
+		if err != nil {
+			return xerrors.Errorf("failed to read cid field t.AllowanceGrants: %w", err)
+		}
+
+		t.AllowanceGrants = c
+
 	}
 	return nil
 }
@@ -346,3 +383,526 @@ func (t *RestoreBytesParams) UnmarshalCBOR(r io.Reader) error {
 	}
 	return nil
 }
+
+var lengthBufRemoveDataCapParams = []byte{132}
+
+func (t *RemoveDataCapParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRemoveDataCapParams); err != nil {
+		return err
+	}
+
+	// t.VerifiedClientToRemove (address.Address) (struct)
+	if err := t.VerifiedClientToRemove.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.DataCapAmountToRemove (big.Int) (struct)
+	if err := t.DataCapAmountToRemove.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.VerifierRequest1 (verifreg.RemoveDataCapRequest) (struct)
+	if err := t.VerifierRequest1.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.VerifierRequest2 (verifreg.RemoveDataCapRequest) (struct)
+	if err := t.VerifierRequest2.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RemoveDataCapParams) UnmarshalCBOR(r io.Reader) error {
+	*t = RemoveDataCapParams{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 4 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.VerifiedClientToRemove (address.Address) (struct)
+
+	{
+
+		if err := t.VerifiedClientToRemove.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifiedClientToRemove: %w", err)
+		}
+
+	}
+	// t.DataCapAmountToRemove (big.Int) (struct)
+
+	{
+
+		if err := t.DataCapAmountToRemove.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.DataCapAmountToRemove: %w", err)
+		}
+
+	}
+	// t.VerifierRequest1 (verifreg.RemoveDataCapRequest) (struct)
+
+	{
+
+		if err := t.VerifierRequest1.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifierRequest1: %w", err)
+		}
+
+	}
+	// t.VerifierRequest2 (verifreg.RemoveDataCapRequest) (struct)
+
+	{
+
+		if err := t.VerifierRequest2.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifierRequest2: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufUpdateVerifierAllowanceParams = []byte{130}
+
+func (t *UpdateVerifierAllowanceParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufUpdateVerifierAllowanceParams); err != nil {
+		return err
+	}
+
+	// t.Address (address.Address) (struct)
+	if err := t.Address.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Allowance (big.Int) (struct)
+	if err := t.Allowance.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *UpdateVerifierAllowanceParams) UnmarshalCBOR(r io.Reader) error {
+	*t = UpdateVerifierAllowanceParams{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Address (address.Address) (struct)
+
+	{
+
+		if err := t.Address.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Address: %w", err)
+		}
+
+	}
+	// t.Allowance (big.Int) (struct)
+
+	{
+
+		if err := t.Allowance.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Allowance: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufRemoveDataCapReturn = []byte{130}
+
+func (t *RemoveDataCapReturn) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRemoveDataCapReturn); err != nil {
+		return err
+	}
+
+	// t.VerifiedClient (address.Address) (struct)
+	if err := t.VerifiedClient.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.DataCapRemoved (big.Int) (struct)
+	if err := t.DataCapRemoved.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RemoveDataCapReturn) UnmarshalCBOR(r io.Reader) error {
+	*t = RemoveDataCapReturn{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.VerifiedClient (address.Address) (struct)
+
+	{
+
+		if err := t.VerifiedClient.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifiedClient: %w", err)
+		}
+
+	}
+	// t.DataCapRemoved (big.Int) (struct)
+
+	{
+
+		if err := t.DataCapRemoved.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.DataCapRemoved: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufRmDcProposalID = []byte{129}
+
+func (t *RmDcProposalID) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRmDcProposalID); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.ProposalID (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.ProposalID)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *RmDcProposalID) UnmarshalCBOR(r io.Reader) error {
+	*t = RmDcProposalID{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 1 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.ProposalID (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.ProposalID = uint64(extra)
+
+	}
+	return nil
+}
+
+var lengthBufRemoveDataCapRequest = []byte{130}
+
+func (t *RemoveDataCapRequest) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRemoveDataCapRequest); err != nil {
+		return err
+	}
+
+	// t.Verifier (address.Address) (struct)
+	if err := t.Verifier.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.VerifierSignature (crypto.Signature) (struct)
+	if err := t.VerifierSignature.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RemoveDataCapRequest) UnmarshalCBOR(r io.Reader) error {
+	*t = RemoveDataCapRequest{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Verifier (address.Address) (struct)
+
+	{
+
+		if err := t.Verifier.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Verifier: %w", err)
+		}
+
+	}
+	// t.VerifierSignature (crypto.Signature) (struct)
+
+	{
+
+		if err := t.VerifierSignature.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifierSignature: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufRemoveDataCapProposal = []byte{131}
+
+func (t *RemoveDataCapProposal) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufRemoveDataCapProposal); err != nil {
+		return err
+	}
+
+	// t.VerifiedClient (address.Address) (struct)
+	if err := t.VerifiedClient.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.DataCapAmount (big.Int) (struct)
+	if err := t.DataCapAmount.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.RemovalProposalID (verifreg.RmDcProposalID) (struct)
+	if err := t.RemovalProposalID.MarshalCBOR(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *RemoveDataCapProposal) UnmarshalCBOR(r io.Reader) error {
+	*t = RemoveDataCapProposal{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 3 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.VerifiedClient (address.Address) (struct)
+
+	{
+
+		if err := t.VerifiedClient.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.VerifiedClient: %w", err)
+		}
+
+	}
+	// t.DataCapAmount (big.Int) (struct)
+
+	{
+
+		if err := t.DataCapAmount.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.DataCapAmount: %w", err)
+		}
+
+	}
+	// t.RemovalProposalID (verifreg.RmDcProposalID) (struct)
+
+	{
+
+		if err := t.RemovalProposalID.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.RemovalProposalID: %w", err)
+		}
+
+	}
+	return nil
+}
+
+var lengthBufAllowanceGrant = []byte{132}
+
+func (t *AllowanceGrant) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write(lengthBufAllowanceGrant); err != nil {
+		return err
+	}
+
+	scratch := make([]byte, 9)
+
+	// t.Verifier (address.Address) (struct)
+	if err := t.Verifier.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Client (address.Address) (struct)
+	if err := t.Client.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Amount (big.Int) (struct)
+	if err := t.Amount.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.Epoch (abi.ChainEpoch) (int64)
+	if t.Epoch >= 0 {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.Epoch)); err != nil {
+			return err
+		}
+	} else {
+		if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajNegativeInt, uint64(-t.Epoch-1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *AllowanceGrant) UnmarshalCBOR(r io.Reader) error {
+	*t = AllowanceGrant{}
+
+	br := cbg.GetPeeker(r)
+	scratch := make([]byte, 8)
+
+	maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+
+	if extra != 4 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Verifier (address.Address) (struct)
+
+	{
+
+		if err := t.Verifier.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Verifier: %w", err)
+		}
+
+	}
+	// t.Client (address.Address) (struct)
+
+	{
+
+		if err := t.Client.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Client: %w", err)
+		}
+
+	}
+	// t.Amount (big.Int) (struct)
+
+	{
+
+		if err := t.Amount.UnmarshalCBOR(br); err != nil {
+			return xerrors.Errorf("unmarshaling t.Amount: %w", err)
+		}
+
+	}
+	// t.Epoch (abi.ChainEpoch) (int64)
+	{
+		maj, extra, err := cbg.CborReadHeaderBuf(br, scratch)
+		var extraI int64
+		if err != nil {
+			return err
+		}
+		switch maj {
+		case cbg.MajUnsignedInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 positive overflow")
+			}
+		case cbg.MajNegativeInt:
+			extraI = int64(extra)
+			if extraI < 0 {
+				return fmt.Errorf("int64 negative oveflow")
+			}
+			extraI = -1 - extraI
+		default:
+			return fmt.Errorf("wrong type for int64 field: %d", maj)
+		}
+
+		t.Epoch = abi.ChainEpoch(extraI)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package verifreg
+
+import (
+	addr "github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+)
+
+type StateSummary struct {
+	Verifiers map[addr.Address]DataCap
+	Clients   map[addr.Address]DataCap
+}
+
+// Checks internal invariants of verified registry state.
+func CheckStateInvariants(st *State, store adt.Store, balance abi.TokenAmount) (*StateSummary, *builtin.MessageAccumulator) {
+	acc := &builtin.MessageAccumulator{}
+	acc.Require(balance.GreaterThanEqual(big.Zero()), "verified registry balance is negative %v", balance)
+	acc.Require(st.RootKey.Protocol() == addr.ID, "root key %v should have ID protocol", st.RootKey)
+
+	// Check verifiers
+	allVerifiers := map[addr.Address]DataCap{}
+	if verifiers, err := adt.AsMap(store, st.Verifiers); err != nil {
+		acc.Addf("error loading verifiers: %v", err)
+	} else {
+		var vcap abi.StoragePower
+		err = verifiers.ForEach(&vcap, func(key string) error {
+			verifier, err := addr.NewFromBytes([]byte(key))
+			if err != nil {
+				return err
+			}
+
+			acc.Require(verifier.Protocol() == addr.ID, "verifier %v should have ID protocol", verifier)
+			acc.Require(verifier != st.RootKey, "root key %v is a verifier", verifier)
+			acc.Require(vcap.GreaterThanEqual(big.Zero()), "verifier %v cap %v is negative", verifier, vcap)
+			allVerifiers[verifier] = vcap.Copy()
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating verifiers")
+	}
+
+	// Check clients
+	allClients := map[addr.Address]DataCap{}
+	if clients, err := adt.AsMap(store, st.VerifiedClients); err != nil {
+		acc.Addf("error loading verified clients: %v", err)
+	} else {
+		var ccap abi.StoragePower
+		err = clients.ForEach(&ccap, func(key string) error {
+			client, err := addr.NewFromBytes([]byte(key))
+			if err != nil {
+				return err
+			}
+
+			acc.Require(client.Protocol() == addr.ID, "client %v should have ID protocol", client)
+			acc.Require(client != st.RootKey, "root key %v is a verified client", client)
+			acc.Require(ccap.GreaterThanEqual(MinVerifiedDealSize), "client %v cap %v is below minimum verified deal size %v",
+				client, ccap, MinVerifiedDealSize)
+			allClients[client] = ccap.Copy()
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating clients")
+	}
+
+	// Check remove DataCap proposal IDs
+	if proposalIDs, err := adt.AsMap(store, st.RemoveDataCapProposalIDs); err != nil {
+		acc.Addf("error loading remove DataCap proposal IDs: %v", err)
+	} else {
+		var proposalID RmDcProposalID
+		err = proposalIDs.ForEach(&proposalID, func(key string) error {
+			verifier, err := addr.NewFromBytes([]byte(key))
+			if err != nil {
+				return err
+			}
+
+			acc.Require(verifier.Protocol() == addr.ID, "remove DataCap proposal verifier %v should have ID protocol", verifier)
+			acc.Require(proposalID.ProposalID > 0, "remove DataCap proposal ID for verifier %v recorded without a proposal", verifier)
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating remove DataCap proposal IDs")
+	}
+
+	// Check allowance grants
+	if grants, err := adt.AsArray(store, st.AllowanceGrants); err != nil {
+		acc.Addf("error loading allowance grants: %v", err)
+	} else {
+		var grant AllowanceGrant
+		prevEpoch := abi.ChainEpoch(-1)
+		err = grants.ForEach(&grant, func(i int64) error {
+			acc.Require(grant.Verifier.Protocol() == addr.ID, "allowance grant %d verifier %v should have ID protocol", i, grant.Verifier)
+			acc.Require(grant.Client.Protocol() == addr.ID, "allowance grant %d client %v should have ID protocol", i, grant.Client)
+			acc.Require(grant.Amount.GreaterThanEqual(MinVerifiedDealSize), "allowance grant %d amount %v is below minimum verified deal size %v",
+				i, grant.Amount, MinVerifiedDealSize)
+			acc.Require(grant.Epoch >= prevEpoch, "allowance grant %d at epoch %d precedes previous grant at epoch %d", i, grant.Epoch, prevEpoch)
+			prevEpoch = grant.Epoch
+			return nil
+		})
+		acc.RequireNoError(err, "error iterating allowance grants")
+	}
+
+	// Verifiers and clients are disjoint.
+	for v := range allVerifiers {
+		_, found := allClients[v]
+		acc.Require(!found, "verifier %v is also a verified client", v)
+	}
+
+	return &StateSummary{
+		Verifiers: allVerifiers,
+		Clients:   allClients,
+	}, acc
+}
@@ -1,11 +1,14 @@
 package verifreg
 
 import (
+	"bytes"
+
 	addr "github.com/filecoin-project/go-address"
 
 	abi "github.com/filecoin-project/specs-actors/actors/abi"
 	big "github.com/filecoin-project/specs-actors/actors/abi/big"
 	builtin "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
 	vmr "github.com/filecoin-project/specs-actors/actors/runtime"
 	exitcode "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
 	. "github.com/filecoin-project/specs-actors/actors/util"
@@ -22,6 +25,8 @@ func (a Actor) Exports() []interface{} {
 		4:                         a.AddVerifiedClient,
 		5:                         a.UseBytes,
 		6:                         a.RestoreBytes,
+		7:                         a.RemoveVerifiedClientDataCap,
+		8:                         a.UpdateVerifierAllowance,
 	}
 }
 
@@ -41,7 +46,10 @@ func (a Actor) Constructor(rt vmr.Runtime, rootKey *addr.Address) *adt.EmptyValu
 	emptyMap, err := adt.MakeEmptyMap(adt.AsStore(rt)).Root()
 	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to create state")
 
-	st := ConstructState(emptyMap, idAddr)
+	emptyArray, err := adt.MakeEmptyArray(adt.AsStore(rt)).Root()
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to create state")
+
+	st := ConstructState(emptyMap, emptyArray, idAddr)
 	rt.State().Create(st)
 	return nil
 }
@@ -114,6 +122,45 @@ func (a Actor) RemoveVerifier(rt vmr.Runtime, verifierAddr *addr.Address) *adt.E
 	return nil
 }
 
+type UpdateVerifierAllowanceParams struct {
+	Address   addr.Address
+	Allowance DataCap // The verifier's new allowance, replacing any remaining allowance.
+}
+
+// Sets the allowance of an existing verifier, which may be more or less than its remaining allowance.
+// Only the root key may invoke this method.
+func (a Actor) UpdateVerifierAllowance(rt vmr.Runtime, params *UpdateVerifierAllowanceParams) *adt.EmptyValue {
+	if params.Allowance.LessThan(MinVerifiedDealSize) {
+		rt.Abortf(exitcode.ErrIllegalArgument, "allowance %d below MinVerifiedDealSize for update verifier %v", params.Allowance, params.Address)
+	}
+
+	verifier, err := builtin.ResolveToIDAddr(rt, params.Address)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to resolve verifier address %v to ID address", params.Address)
+
+	var st State
+	rt.State().Readonly(&st)
+	rt.ValidateImmediateCallerIs(st.RootKey)
+
+	rt.State().Transaction(&st, func() {
+		verifiers, err := adt.AsMap(adt.AsStore(rt), st.Verifiers)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load verifiers")
+
+		found, err := verifiers.Get(AddrKey(verifier), nil)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get verifier %v", verifier)
+		if !found {
+			rt.Abortf(exitcode.ErrNotFound, "no such verifier %v", verifier)
+		}
+
+		err = verifiers.Put(AddrKey(verifier), &params.Allowance)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update verifier %v with allowance %d", verifier, params.Allowance)
+
+		st.Verifiers, err = verifiers.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush verifiers")
+	})
+
+	return nil
+}
+
 type AddVerifiedClientParams struct {
 	Address   addr.Address
 	Allowance DataCap
@@ -181,11 +228,25 @@ func (a Actor) AddVerifiedClient(rt vmr.Runtime, params *AddVerifiedClientParams
 		err = verifiedClients.Put(AddrKey(client), &params.Allowance)
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to add verified client %v with cap %d", client, params.Allowance)
 
+		grants, err := adt.AsArray(adt.AsStore(rt), st.AllowanceGrants)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load allowance grants")
+
+		err = grants.AppendContinuous(&AllowanceGrant{
+			Verifier: verifier,
+			Client:   client,
+			Amount:   params.Allowance,
+			Epoch:    rt.CurrEpoch(),
+		})
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to record allowance grant to %v", client)
+
 		st.Verifiers, err = verifiers.Root()
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush verifiers")
 
 		st.VerifiedClients, err = verifiedClients.Root()
 		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush verified clients")
+
+		st.AllowanceGrants, err = grants.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush allowance grants")
 	})
 
 	return nil
@@ -301,3 +362,136 @@ func (a Actor) RestoreBytes(rt vmr.Runtime, params *RestoreBytesParams) *adt.Emp
 
 	return nil
 }
+
+type RemoveDataCapParams struct {
+	VerifiedClientToRemove addr.Address
+	DataCapAmountToRemove  DataCap
+	VerifierRequest1       RemoveDataCapRequest
+	VerifierRequest2       RemoveDataCapRequest
+}
+
+// A verifier's signed agreement to a removal of DataCap.
+type RemoveDataCapRequest struct {
+	Verifier          addr.Address
+	VerifierSignature crypto.Signature
+}
+
+// The message signed by a verifier to agree to a removal of DataCap.
+// The proposal ID must match the verifier's next removal proposal ID, so that a signed request cannot be replayed.
+type RemoveDataCapProposal struct {
+	VerifiedClient    addr.Address
+	DataCapAmount     DataCap
+	RemovalProposalID RmDcProposalID
+}
+
+// Prefixes the serialized proposal in the bytes a verifier signs, so that the signature cannot be valid
+// for data the same key signs for another purpose.
+const SignatureDomainSeparation_RemoveDataCap = "fil_removedatacap:"
+
+// Returns the bytes a verifier signs to agree to the proposal.
+func (p *RemoveDataCapProposal) SigningBytes() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(SignatureDomainSeparation_RemoveDataCap)
+	if err := p.MarshalCBOR(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type RemoveDataCapReturn struct {
+	VerifiedClient addr.Address
+	DataCapRemoved DataCap
+}
+
+// Removes DataCap from a verified client, with the signed agreement of two distinct verifiers.
+// The client's entry is deleted if its remaining DataCap would be less than MinVerifiedDealSize.
+// Only the root key may invoke this method.
+func (a Actor) RemoveVerifiedClientDataCap(rt vmr.Runtime, params *RemoveDataCapParams) *RemoveDataCapReturn {
+	var st State
+	rt.State().Readonly(&st)
+	rt.ValidateImmediateCallerIs(st.RootKey)
+
+	if params.DataCapAmountToRemove.LessThanEqual(big.Zero()) {
+		rt.Abortf(exitcode.ErrIllegalArgument, "non-positive DataCap to remove %v", params.DataCapAmountToRemove)
+	}
+
+	client, err := builtin.ResolveToIDAddr(rt, params.VerifiedClientToRemove)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to resolve verified client address %v", params.VerifiedClientToRemove)
+
+	verifier1, err := builtin.ResolveToIDAddr(rt, params.VerifierRequest1.Verifier)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to resolve verifier address %v", params.VerifierRequest1.Verifier)
+	verifier2, err := builtin.ResolveToIDAddr(rt, params.VerifierRequest2.Verifier)
+	builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to resolve verifier address %v", params.VerifierRequest2.Verifier)
+	if verifier1 == verifier2 {
+		rt.Abortf(exitcode.ErrIllegalArgument, "removal requires two distinct verifiers, got %v twice", verifier1)
+	}
+
+	var removed DataCap
+	rt.State().Transaction(&st, func() {
+		verifiers, err := adt.AsMap(adt.AsStore(rt), st.Verifiers)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load verifiers")
+
+		verifiedClients, err := adt.AsMap(adt.AsStore(rt), st.VerifiedClients)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load verified clients")
+
+		proposalIDs, err := adt.AsMap(adt.AsStore(rt), st.RemoveDataCapProposalIDs)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to load remove DataCap proposal IDs")
+
+		var vcCap DataCap
+		found, err := verifiedClients.Get(AddrKey(client), &vcCap)
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get verified client %v", client)
+		if !found {
+			rt.Abortf(exitcode.ErrNotFound, "no such verified client %v", client)
+		}
+
+		for _, req := range []struct {
+			verifier  addr.Address
+			signature crypto.Signature
+		}{
+			{verifier1, params.VerifierRequest1.VerifierSignature},
+			{verifier2, params.VerifierRequest2.VerifierSignature},
+		} {
+			found, err := verifiers.Get(AddrKey(req.verifier), nil)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get verifier %v", req.verifier)
+			if !found {
+				rt.Abortf(exitcode.ErrNotFound, "no such verifier %v", req.verifier)
+			}
+
+			var proposalID RmDcProposalID
+			_, err = proposalIDs.Get(AddrKey(req.verifier), &proposalID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to get remove DataCap proposal ID for %v", req.verifier)
+
+			signed, err := (&RemoveDataCapProposal{
+				VerifiedClient:    client,
+				DataCapAmount:     params.DataCapAmountToRemove,
+				RemovalProposalID: proposalID,
+			}).SigningBytes()
+			builtin.RequireNoErr(rt, err, exitcode.ErrSerialization, "failed to serialize remove DataCap proposal")
+			err = rt.Syscalls().VerifySignature(req.signature, req.verifier, signed)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalArgument, "invalid signature from verifier %v", req.verifier)
+
+			proposalID.ProposalID++
+			err = proposalIDs.Put(AddrKey(req.verifier), &proposalID)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update remove DataCap proposal ID for %v", req.verifier)
+		}
+
+		removed = big.Min(params.DataCapAmountToRemove, vcCap)
+		newVcCap := big.Sub(vcCap, removed)
+		if newVcCap.LessThan(MinVerifiedDealSize) {
+			removed = vcCap
+			err = verifiedClients.Delete(AddrKey(client))
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to delete verified client %v", client)
+		} else {
+			err = verifiedClients.Put(AddrKey(client), &newVcCap)
+			builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to update verified client %v with %v", client, newVcCap)
+		}
+
+		st.VerifiedClients, err = verifiedClients.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush verified clients")
+
+		st.RemoveDataCapProposalIDs, err = proposalIDs.Root()
+		builtin.RequireNoErr(rt, err, exitcode.ErrIllegalState, "failed to flush remove DataCap proposal IDs")
+	})
+
+	return &RemoveDataCapReturn{VerifiedClient: client, DataCapRemoved: removed}
+}
@@ -24,15 +24,48 @@ type State struct {
 
 	// VerifiedClients can add VerifiedClientData, up to DataCap.
 	VerifiedClients cid.Cid // HAMT[addr.Address]DataCap
+
+	// The next ID of a proposal to remove DataCap that each verifier may sign, protecting against replay.
+	// A verifier with no entry has not yet agreed to any removal.
+	RemoveDataCapProposalIDs cid.Cid // HAMT[addr.Address]RmDcProposalID
+
+	// Append-only history of DataCap granted by verifiers to verified clients, in the order granted.
+	AllowanceGrants cid.Cid // AMT[AllowanceGrant]
+}
+
+type RmDcProposalID struct {
+	ProposalID uint64
+}
+
+// A record of DataCap granted to a verified client by a verifier.
+type AllowanceGrant struct {
+	Verifier addr.Address
+	Client   addr.Address
+	Amount   DataCap
+	Epoch    abi.ChainEpoch
 }
 
 var MinVerifiedDealSize abi.StoragePower = big.NewInt(1 << 20) // PARAM_FINISH
 
 // rootKeyAddress comes from genesis.
-func ConstructState(emptyMapCid cid.Cid, rootKeyAddress addr.Address) *State {
+func ConstructState(emptyMapCid, emptyArrayCid cid.Cid, rootKeyAddress addr.Address) *State {
 	return &State{
-		RootKey:         rootKeyAddress,
-		Verifiers:       emptyMapCid,
-		VerifiedClients: emptyMapCid,
+		RootKey:                  rootKeyAddress,
+		Verifiers:                emptyMapCid,
+		VerifiedClients:          emptyMapCid,
+		RemoveDataCapProposalIDs: emptyMapCid,
+		AllowanceGrants:          emptyArrayCid,
+	}
+}
+
+// Iterates the history of allowance grants, in the order granted.
+func (st *State) ForEachAllowanceGrant(store adt.Store, fn func(i int64, grant *AllowanceGrant) error) error {
+	grants, err := adt.AsArray(store, st.AllowanceGrants)
+	if err != nil {
+		return err
 	}
+	var grant AllowanceGrant
+	return grants.ForEach(&grant, func(i int64) error {
+		return fn(i, &grant)
+	})
 }
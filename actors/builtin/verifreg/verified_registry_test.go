@@ -1,7 +1,10 @@
 package verifreg_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/filecoin-project/go-address"
@@ -9,10 +12,12 @@ import (
 	"github.com/filecoin-project/specs-actors/actors/abi/big"
 	"github.com/filecoin-project/specs-actors/actors/builtin"
 	"github.com/filecoin-project/specs-actors/actors/builtin/verifreg"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
 	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
 	"github.com/filecoin-project/specs-actors/actors/util/adt"
 	"github.com/filecoin-project/specs-actors/support/mock"
 	tutil "github.com/filecoin-project/specs-actors/support/testing"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,11 +50,16 @@ func TestConstruction(t *testing.T) {
 		emptyMap, err := adt.MakeEmptyMap(store).Root()
 		require.NoError(t, err)
 
+		emptyArray, err := adt.MakeEmptyArray(store).Root()
+		require.NoError(t, err)
+
 		var state verifreg.State
 		rt.GetState(&state)
 
 		require.Equal(t, emptyMap, state.VerifiedClients)
 		require.Equal(t, emptyMap, state.Verifiers)
+		require.Equal(t, emptyMap, state.RemoveDataCapProposalIDs)
+		require.Equal(t, emptyArray, state.AllowanceGrants)
 		require.Equal(t, raddr, state.RootKey)
 	})
 
@@ -108,6 +118,7 @@ func TestAddVerifier(t *testing.T) {
 		})
 		rt.Verify()
 
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when allowance less than MinVerifiedDealSize", func(t *testing.T) {
@@ -139,6 +150,8 @@ func TestAddVerifier(t *testing.T) {
 			ac.addVerifier(rt, clientAddr, allowance)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails to add verifier with non-ID address if not resolvable to ID address", func(t *testing.T) {
@@ -156,6 +169,8 @@ func TestAddVerifier(t *testing.T) {
 	t.Run("successfully add a verifier", func(t *testing.T) {
 		rt, ac := basicVerifRegSetup(t, root)
 		ac.addNewVerifier(rt, va, allowance)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully add a verifier after resolving to ID address", func(t *testing.T) {
@@ -170,6 +185,8 @@ func TestAddVerifier(t *testing.T) {
 
 		dc := ac.getVerifierCap(rt, verifierIdAddr)
 		require.EqualValues(t, allowance, dc)
+
+		ac.checkState(rt)
 	})
 }
 
@@ -190,6 +207,8 @@ func TestRemoveVerifier(t *testing.T) {
 			rt.Call(ac.RemoveVerifier, &v.Address)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when verifier does not exist", func(t *testing.T) {
@@ -203,6 +222,8 @@ func TestRemoveVerifier(t *testing.T) {
 			rt.Call(ac.RemoveVerifier, &v)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully remove a verifier", func(t *testing.T) {
@@ -210,6 +231,8 @@ func TestRemoveVerifier(t *testing.T) {
 		ac.addNewVerifier(rt, va, allowance)
 
 		ac.removeVerifier(rt, va)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("add verifier with non ID address and then remove with its ID address", func(t *testing.T) {
@@ -225,6 +248,83 @@ func TestRemoveVerifier(t *testing.T) {
 		// remove using ID address
 		ac.removeVerifier(rt, verifierIdAddr)
 		ac.assertVerifierRemoved(rt, verifierIdAddr)
+
+		ac.checkState(rt)
+	})
+}
+
+func TestUpdateVerifierAllowance(t *testing.T) {
+	root := tutil.NewIDAddr(t, 101)
+	va := tutil.NewIDAddr(t, 201)
+	allowance := big.Mul(verifreg.MinVerifiedDealSize, big.NewInt(4))
+
+	t.Run("increases a verifier's allowance", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+		ac.addNewVerifier(rt, va, allowance)
+
+		newAllowance := big.Mul(allowance, big.NewInt(2))
+		ac.updateVerifierAllowance(rt, va, newAllowance)
+		assert.Equal(t, newAllowance, ac.getVerifierCap(rt, va))
+		ac.checkState(rt)
+	})
+
+	t.Run("reduces a verifier's remaining allowance", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+		clientAddr := tutil.NewIDAddr(t, 301)
+		ac.addNewVerifier(rt, va, allowance)
+		ac.addVerifiedClient(rt, va, clientAddr, verifreg.MinVerifiedDealSize)
+
+		ac.updateVerifierAllowance(rt, va, verifreg.MinVerifiedDealSize)
+		assert.Equal(t, verifreg.MinVerifiedDealSize, ac.getVerifierCap(rt, va))
+
+		// clients already granted DataCap are unaffected
+		assert.Equal(t, verifreg.MinVerifiedDealSize, ac.getClientCap(rt, clientAddr))
+		ac.checkState(rt)
+	})
+
+	t.Run("updates a verifier after resolving to ID address", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+		verifierNonIdAddr := tutil.NewBLSAddr(t, 1)
+		rt.AddIDAddress(verifierNonIdAddr, va)
+		ac.addNewVerifier(rt, va, allowance)
+
+		ac.updateVerifierAllowance(rt, verifierNonIdAddr, verifreg.MinVerifiedDealSize)
+		assert.Equal(t, verifreg.MinVerifiedDealSize, ac.getVerifierCap(rt, va))
+		ac.checkState(rt)
+	})
+
+	t.Run("fails when verifier does not exist", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			ac.updateVerifierAllowance(rt, va, allowance)
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("fails when allowance less than MinVerifiedDealSize", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+		ac.addNewVerifier(rt, va, allowance)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			ac.updateVerifierAllowance(rt, va, big.Sub(verifreg.MinVerifiedDealSize, big.NewInt(1)))
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("fails when caller is not the root key", func(t *testing.T) {
+		rt, ac := basicVerifRegSetup(t, root)
+		ac.addNewVerifier(rt, va, allowance)
+
+		rt.ExpectValidateCallerAddr(ac.rootkey)
+		rt.SetCaller(va, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(ac.UpdateVerifierAllowance, &verifreg.UpdateVerifierAllowanceParams{Address: va, Allowance: big.Mul(allowance, big.NewInt(2))})
+		})
+		rt.Verify()
+
+		assert.Equal(t, allowance, ac.getVerifierCap(rt, va))
+		ac.checkState(rt)
 	})
 }
 
@@ -262,7 +362,9 @@ func TestAddVerifiedClient(t *testing.T) {
 		ac.addVerifiedClient(rt, verifier.Address, c1.Address, c1.Allowance)
 		ac.addVerifiedClient(rt, verifier.Address, c2.Address, c2.Allowance)
 
-		// add clients 3 & 4
+		// add clients 3 & 4 at a later epoch
+		grantEpoch := abi.ChainEpoch(100)
+		rt.SetEpoch(grantEpoch)
 		ac.addVerifiedClient(rt, verifier2.Address, c3.Address, c3.Allowance)
 		ac.addVerifiedClient(rt, verifier2.Address, c4.Address, c4.Allowance)
 
@@ -274,6 +376,16 @@ func TestAddVerifiedClient(t *testing.T) {
 
 		require.EqualValues(t, big.Zero(), ac.getVerifierCap(rt, verifierAddr))
 		require.EqualValues(t, big.Zero(), ac.getVerifierCap(rt, verifierAddr2))
+
+		// each grant is recorded in order
+		grants := ac.getAllowanceGrants(rt)
+		require.Len(t, grants, 4)
+		assert.Equal(t, verifreg.AllowanceGrant{Verifier: verifierAddr, Client: clientAddr, Amount: clientAllowance, Epoch: 0}, grants[0])
+		assert.Equal(t, verifreg.AllowanceGrant{Verifier: verifierAddr, Client: clientAddr2, Amount: clientAllowance, Epoch: 0}, grants[1])
+		assert.Equal(t, verifreg.AllowanceGrant{Verifier: verifierAddr2, Client: clientAddr3, Amount: clientAllowance, Epoch: grantEpoch}, grants[2])
+		assert.Equal(t, verifreg.AllowanceGrant{Verifier: verifierAddr2, Client: clientAddr4, Amount: clientAllowance, Epoch: grantEpoch}, grants[3])
+
+		ac.checkState(rt)
 	})
 
 	t.Run("verifier successfully adds a verified client and then fails on adding another verified client because of low allowance", func(t *testing.T) {
@@ -301,6 +413,8 @@ func TestAddVerifiedClient(t *testing.T) {
 		// one client should exist and verifier should have no more allowance left
 		require.EqualValues(t, c1.Allowance, ac.getClientCap(rt, c1.Address))
 		require.EqualValues(t, big.Zero(), ac.getVerifierCap(rt, verifierAddr))
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully add a verified client after resolving it's given non ID address to it's ID address", func(t *testing.T) {
@@ -339,6 +453,8 @@ func TestAddVerifiedClient(t *testing.T) {
 
 		// add client works
 		ac.addVerifiedClient(rt, verifier.Address, c1.Address, c1.Allowance)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails to add verified client if address is not resolvable to ID address", func(t *testing.T) {
@@ -368,6 +484,8 @@ func TestAddVerifiedClient(t *testing.T) {
 			rt.Call(ac.AddVerifiedClient, p)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when caller is not a verifier", func(t *testing.T) {
@@ -383,6 +501,8 @@ func TestAddVerifiedClient(t *testing.T) {
 			rt.Call(ac.AddVerifiedClient, client)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when verifier cap is less than client allowance", func(t *testing.T) {
@@ -398,6 +518,8 @@ func TestAddVerifiedClient(t *testing.T) {
 			rt.Call(ac.AddVerifiedClient, client)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when verified client already exists", func(t *testing.T) {
@@ -416,6 +538,8 @@ func TestAddVerifiedClient(t *testing.T) {
 			rt.Call(ac.AddVerifiedClient, client)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails when root is added as a verified client", func(t *testing.T) {
@@ -441,6 +565,8 @@ func TestAddVerifiedClient(t *testing.T) {
 			rt.Call(ac.AddVerifiedClient, client)
 		})
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 }
 
@@ -489,6 +615,8 @@ func TestUseBytes(t *testing.T) {
 		// verify
 		require.EqualValues(t, bal1, ac.getClientCap(rt, clientAddr))
 		ac.assertClientRemoved(rt, clientAddr2)
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully consume deal bytes for verified client and then fail on next attempt because it does NOT have enough allowance", func(t *testing.T) {
@@ -511,6 +639,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully consume deal bytes after resolving verified client address", func(t *testing.T) {
@@ -527,6 +657,8 @@ func TestUseBytes(t *testing.T) {
 		// use bytes
 		dSize1 := verifreg.MinVerifiedDealSize
 		ac.useBytes(rt, clientNonIdAddr, dSize1, &capExpectation{expectedCap: big.Sub(clientAllowance, dSize1)})
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully consume deal for verified client and then fail on next attempt because it has been removed", func(t *testing.T) {
@@ -550,6 +682,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if caller is not storage market actor", func(t *testing.T) {
@@ -563,6 +697,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if deal size is less than min verified deal size", func(t *testing.T) {
@@ -575,6 +711,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if verified client does not exist", func(t *testing.T) {
@@ -588,6 +726,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if deal size is greater than verified client cap", func(t *testing.T) {
@@ -606,6 +746,8 @@ func TestUseBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 }
 
@@ -666,6 +808,8 @@ func TestRestoreBytes(t *testing.T) {
 		require.EqualValues(t, bal1, ac.getClientCap(rt, clientAddr))
 		require.EqualValues(t, bal2, ac.getClientCap(rt, clientAddr2))
 		require.EqualValues(t, bal3, ac.getClientCap(rt, clientAddr3))
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully restore bytes after using bytes reduces a client's cap", func(t *testing.T) {
@@ -680,6 +824,8 @@ func TestRestoreBytes(t *testing.T) {
 
 		sz := verifreg.MinVerifiedDealSize
 		ac.restoreBytes(rt, clientAddr, sz, &capExpectation{expectedCap: big.Add(bal, sz)})
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully restore deal bytes after resolving client address", func(t *testing.T) {
@@ -701,6 +847,8 @@ func TestRestoreBytes(t *testing.T) {
 		ac.restoreBytes(rt, clientNonIdAddr, sz, &capExpectation{expectedCap: big.Add(bal, sz)})
 
 		require.EqualValues(t, big.Add(bal, sz), ac.getClientCap(rt, clientIdAddr))
+
+		ac.checkState(rt)
 	})
 
 	t.Run("successfully restore bytes after using bytes removes a client", func(t *testing.T) {
@@ -714,6 +862,8 @@ func TestRestoreBytes(t *testing.T) {
 
 		sz := verifreg.MinVerifiedDealSize
 		ac.restoreBytes(rt, clientAddr, sz, &capExpectation{expectedCap: sz})
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if caller is not storage market actor", func(t *testing.T) {
@@ -727,6 +877,8 @@ func TestRestoreBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fail if deal size is less than min verified deal size", func(t *testing.T) {
@@ -741,6 +893,8 @@ func TestRestoreBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if attempt to restore bytes for root", func(t *testing.T) {
@@ -754,6 +908,8 @@ func TestRestoreBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
 	})
 
 	t.Run("fails if attempt to restore bytes for verifier", func(t *testing.T) {
@@ -770,6 +926,150 @@ func TestRestoreBytes(t *testing.T) {
 		})
 
 		rt.Verify()
+
+		ac.checkState(rt)
+	})
+}
+
+func TestRemoveVerifiedClientDataCap(t *testing.T) {
+	root := tutil.NewIDAddr(t, 101)
+	clientAddr := tutil.NewIDAddr(t, 201)
+	verifierAddr := tutil.NewIDAddr(t, 301)
+	verifierAddr2 := tutil.NewIDAddr(t, 302)
+	verifierAddr3 := tutil.NewIDAddr(t, 303)
+	vallow := big.Mul(verifreg.MinVerifiedDealSize, big.NewInt(10))
+	clientCap := big.Mul(verifreg.MinVerifiedDealSize, big.NewInt(5))
+
+	setup := func(t *testing.T) (*mock.Runtime, *verifRegActorTestHarness) {
+		rt, ac := basicVerifRegSetup(t, root)
+		ac.generateAndAddVerifierAndVerifiedClient(rt, verifierAddr, clientAddr, vallow, clientCap)
+		ac.addNewVerifier(rt, verifierAddr2, vallow)
+		return rt, ac
+	}
+
+	t.Run("removes part of a client's DataCap", func(t *testing.T) {
+		rt, ac := setup(t)
+		amount := big.Mul(verifreg.MinVerifiedDealSize, big.NewInt(2))
+
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 0, nil)
+		ac.expectRemovalSignature(rt, verifierAddr2, clientAddr, amount, 0, nil)
+		ret := ac.removeDataCap(rt, clientAddr, amount, verifierAddr, verifierAddr2)
+
+		assert.Equal(t, clientAddr, ret.VerifiedClient)
+		assert.Equal(t, amount, ret.DataCapRemoved)
+		assert.Equal(t, big.Sub(clientCap, amount), ac.getClientCap(rt, clientAddr))
+		assert.Equal(t, uint64(1), ac.getRemovalProposalID(rt, verifierAddr))
+		assert.Equal(t, uint64(1), ac.getRemovalProposalID(rt, verifierAddr2))
+		ac.checkState(rt)
+
+		// a further removal is signed with the next proposal IDs
+		ac.addNewVerifier(rt, verifierAddr3, vallow)
+		ac.expectRemovalSignature(rt, verifierAddr3, clientAddr, amount, 0, nil)
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 1, nil)
+		ac.removeDataCap(rt, clientAddr, amount, verifierAddr3, verifierAddr)
+		assert.Equal(t, big.Sub(clientCap, big.Mul(amount, big.NewInt(2))), ac.getClientCap(rt, clientAddr))
+		assert.Equal(t, uint64(2), ac.getRemovalProposalID(rt, verifierAddr))
+		ac.checkState(rt)
+	})
+
+	t.Run("deletes client when remaining DataCap is below minimum", func(t *testing.T) {
+		rt, ac := setup(t)
+		amount := big.Sub(clientCap, big.NewInt(1))
+
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 0, nil)
+		ac.expectRemovalSignature(rt, verifierAddr2, clientAddr, amount, 0, nil)
+		ret := ac.removeDataCap(rt, clientAddr, amount, verifierAddr, verifierAddr2)
+
+		assert.Equal(t, clientCap, ret.DataCapRemoved)
+		ac.assertClientRemoved(rt, clientAddr)
+		ac.checkState(rt)
+	})
+
+	t.Run("removal exceeding client DataCap removes all of it", func(t *testing.T) {
+		rt, ac := setup(t)
+		amount := big.Mul(clientCap, big.NewInt(2))
+
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 0, nil)
+		ac.expectRemovalSignature(rt, verifierAddr2, clientAddr, amount, 0, nil)
+		ret := ac.removeDataCap(rt, clientAddr, amount, verifierAddr, verifierAddr2)
+
+		assert.Equal(t, clientCap, ret.DataCapRemoved)
+		ac.assertClientRemoved(rt, clientAddr)
+		ac.checkState(rt)
+	})
+
+	t.Run("signed request cannot be replayed", func(t *testing.T) {
+		rt, ac := setup(t)
+		amount := verifreg.MinVerifiedDealSize
+
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 0, nil)
+		ac.expectRemovalSignature(rt, verifierAddr2, clientAddr, amount, 0, nil)
+		ac.removeDataCap(rt, clientAddr, amount, verifierAddr, verifierAddr2)
+
+		// the same signatures are now checked against the next proposal ID, and rejected
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, amount, 1, errors.New("signature over wrong proposal"))
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			ac.removeDataCap(rt, clientAddr, amount, verifierAddr, verifierAddr2)
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("signed bytes are domain separated", func(t *testing.T) {
+		proposal := verifreg.RemoveDataCapProposal{
+			VerifiedClient:    clientAddr,
+			DataCapAmount:     verifreg.MinVerifiedDealSize,
+			RemovalProposalID: verifreg.RmDcProposalID{ProposalID: 3},
+		}
+		signed, err := proposal.SigningBytes()
+		require.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		require.NoError(t, proposal.MarshalCBOR(&buf))
+		assert.Equal(t, append([]byte("fil_removedatacap:"), buf.Bytes()...), signed)
+	})
+
+	t.Run("requires two distinct verifiers", func(t *testing.T) {
+		rt, ac := setup(t)
+
+		rt.ExpectAbort(exitcode.ErrIllegalArgument, func() {
+			ac.removeDataCap(rt, clientAddr, verifreg.MinVerifiedDealSize, verifierAddr, verifierAddr)
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("fails when a signer is not a verifier", func(t *testing.T) {
+		rt, ac := setup(t)
+
+		ac.expectRemovalSignature(rt, verifierAddr, clientAddr, verifreg.MinVerifiedDealSize, 0, nil)
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			ac.removeDataCap(rt, clientAddr, verifreg.MinVerifiedDealSize, verifierAddr, verifierAddr3)
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("fails for unknown client", func(t *testing.T) {
+		rt, ac := setup(t)
+
+		rt.ExpectAbort(exitcode.ErrNotFound, func() {
+			ac.removeDataCap(rt, tutil.NewIDAddr(t, 299), verifreg.MinVerifiedDealSize, verifierAddr, verifierAddr2)
+		})
+		ac.checkState(rt)
+	})
+
+	t.Run("fails when caller is not the root key", func(t *testing.T) {
+		rt, ac := setup(t)
+
+		rt.ExpectValidateCallerAddr(root)
+		rt.SetCaller(verifierAddr, builtin.AccountActorCodeID)
+		rt.ExpectAbort(exitcode.ErrForbidden, func() {
+			rt.Call(ac.RemoveVerifiedClientDataCap, &verifreg.RemoveDataCapParams{
+				VerifiedClientToRemove: clientAddr,
+				DataCapAmountToRemove:  verifreg.MinVerifiedDealSize,
+				VerifierRequest1:       verifreg.RemoveDataCapRequest{Verifier: verifierAddr},
+				VerifierRequest2:       verifreg.RemoveDataCapRequest{Verifier: verifierAddr2},
+			})
+		})
+		ac.checkState(rt)
 	})
 }
 
@@ -857,6 +1157,15 @@ func (h *verifRegActorTestHarness) addVerifier(rt *mock.Runtime, verifier addres
 	require.EqualValues(h.t, datacap, h.getVerifierCap(rt, verifierIdAddr))
 }
 
+func (h *verifRegActorTestHarness) updateVerifierAllowance(rt *mock.Runtime, verifier address.Address, allowance verifreg.DataCap) {
+	rt.ExpectValidateCallerAddr(h.rootkey)
+
+	rt.SetCaller(h.rootkey, builtin.VerifiedRegistryActorCodeID)
+	ret := rt.Call(h.UpdateVerifierAllowance, &verifreg.UpdateVerifierAllowanceParams{Address: verifier, Allowance: allowance})
+	require.Nil(h.t, ret)
+	rt.Verify()
+}
+
 func (h *verifRegActorTestHarness) removeVerifier(rt *mock.Runtime, verifier address.Address) {
 	rt.ExpectValidateCallerAddr(h.rootkey)
 
@@ -868,6 +1177,62 @@ func (h *verifRegActorTestHarness) removeVerifier(rt *mock.Runtime, verifier add
 	h.assertVerifierRemoved(rt, verifier)
 }
 
+func (h *verifRegActorTestHarness) expectRemovalSignature(rt *mock.Runtime, verifier, client address.Address, amount verifreg.DataCap,
+	proposalID uint64, result error) {
+	signed, err := (&verifreg.RemoveDataCapProposal{
+		VerifiedClient:    client,
+		DataCapAmount:     amount,
+		RemovalProposalID: verifreg.RmDcProposalID{ProposalID: proposalID},
+	}).SigningBytes()
+	require.NoError(h.t, err)
+	rt.ExpectVerifySignature(removalSignature(verifier), verifier, signed, result)
+}
+
+func (h *verifRegActorTestHarness) removeDataCap(rt *mock.Runtime, client address.Address, amount verifreg.DataCap,
+	verifier1, verifier2 address.Address) *verifreg.RemoveDataCapReturn {
+	rt.ExpectValidateCallerAddr(h.rootkey)
+	rt.SetCaller(h.rootkey, builtin.MultisigActorCodeID)
+
+	ret := rt.Call(h.RemoveVerifiedClientDataCap, &verifreg.RemoveDataCapParams{
+		VerifiedClientToRemove: client,
+		DataCapAmountToRemove:  amount,
+		VerifierRequest1:       verifreg.RemoveDataCapRequest{Verifier: verifier1, VerifierSignature: removalSignature(verifier1)},
+		VerifierRequest2:       verifreg.RemoveDataCapRequest{Verifier: verifier2, VerifierSignature: removalSignature(verifier2)},
+	})
+	rt.Verify()
+	return ret.(*verifreg.RemoveDataCapReturn)
+}
+
+func (h *verifRegActorTestHarness) getAllowanceGrants(rt *mock.Runtime) []verifreg.AllowanceGrant {
+	var st verifreg.State
+	rt.GetState(&st)
+
+	var grants []verifreg.AllowanceGrant
+	err := st.ForEachAllowanceGrant(adt.AsStore(rt), func(_ int64, grant *verifreg.AllowanceGrant) error {
+		grants = append(grants, *grant)
+		return nil
+	})
+	require.NoError(h.t, err)
+	return grants
+}
+
+func (h *verifRegActorTestHarness) getRemovalProposalID(rt *mock.Runtime, verifier address.Address) uint64 {
+	var st verifreg.State
+	rt.GetState(&st)
+
+	ids, err := adt.AsMap(adt.AsStore(rt), st.RemoveDataCapProposalIDs)
+	require.NoError(h.t, err)
+
+	var id verifreg.RmDcProposalID
+	_, err = ids.Get(verifreg.AddrKey(verifier), &id)
+	require.NoError(h.t, err)
+	return id.ProposalID
+}
+
+func removalSignature(verifier address.Address) crypto.Signature {
+	return crypto.Signature{Type: crypto.SigTypeBLS, Data: []byte(verifier.String())}
+}
+
 type capExpectation struct {
 	expectedCap verifreg.DataCap
 	removed     bool
@@ -964,3 +1329,10 @@ func (h *verifRegActorTestHarness) assertClientRemoved(rt *mock.Runtime, a addre
 	require.NoError(h.t, err)
 	require.False(h.t, found)
 }
+
+func (h *verifRegActorTestHarness) checkState(rt *mock.Runtime) {
+	var st verifreg.State
+	rt.GetState(&st)
+	_, msgs := verifreg.CheckStateInvariants(&st, rt.AdtStore(), rt.Balance())
+	assert.True(h.t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
@@ -0,0 +1,225 @@
+package migration
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	addr "github.com/filecoin-project/go-address"
+	cid "github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+)
+
+// An actor entry in a state tree, as seen by a migration.
+type Actor struct {
+	Code    cid.Cid
+	Head    cid.Cid
+	Balance abi.TokenAmount
+}
+
+// StateTree abstracts the state tree being migrated, so that the migration can run against any
+// implementation (e.g. a node's state tree or the test VM).
+type StateTree interface {
+	// Iterates all actors in the tree. The actor passed to f may be retained.
+	ForEachActor(f func(a addr.Address, act *Actor) error) error
+	GetActor(a addr.Address) (*Actor, bool, error)
+	SetActor(a addr.Address, act *Actor) error
+}
+
+// Input to the migration of a single actor's state.
+type ActorMigrationInput struct {
+	Address    addr.Address
+	Balance    abi.TokenAmount
+	Head       cid.Cid
+	PriorEpoch abi.ChainEpoch // Epoch of the last state transition prior to migration.
+}
+
+// Result of the migration of a single actor's state.
+type ActorMigrationResult struct {
+	NewCode cid.Cid // New actor code CID, or cid.Undef to retain the existing code.
+	NewHead cid.Cid
+	// Funds to be debited from the actor's balance and burnt as part of the migration.
+	// Must be non-negative and no more than the actor's balance.
+	Transfer abi.TokenAmount
+}
+
+// An ActorMigration computes new state for a single actor.
+// Migrations for different actors run concurrently, so a migration must only write new state to the store
+// and must not depend on the migrated state of any other actor.
+type ActorMigration func(ctx context.Context, store adt.Store, in ActorMigrationInput) (*ActorMigrationResult, error)
+
+// Parameters for a state tree migration.
+type Config struct {
+	// Maximum number of actor migrations to run concurrently. Defaults to the number of CPUs if not positive.
+	MaxWorkers int
+	// Epoch of the last state transition prior to migration.
+	PriorEpoch abi.ChainEpoch
+}
+
+type migrationJob struct {
+	address   addr.Address
+	actor     *Actor
+	migration ActorMigration
+}
+
+// MigrateStateTree applies a migration to each actor in the tree whose code CID has an entry in migrations.
+// Actors with code that has no migration are carried over unchanged.
+// Per-actor migrations run in parallel, bounded by the configured number of workers. Their results are then
+// written to the tree sequentially, in iteration order, so the resulting tree is independent of scheduling.
+// Funds transferred out of actors are credited to the burnt funds actor, and the migration fails if the total
+// balance of all actors is not conserved.
+func MigrateStateTree(ctx context.Context, store adt.Store, tree StateTree, migrations map[cid.Cid]ActorMigration, cfg Config) error {
+	// Collect the migration jobs and the total balance prior to migration.
+	var jobs []*migrationJob
+	totalBefore := big.Zero()
+	if err := tree.ForEachActor(func(a addr.Address, act *Actor) error {
+		totalBefore = big.Add(totalBefore, act.Balance)
+		if m, ok := migrations[act.Code]; ok {
+			jobs = append(jobs, &migrationJob{address: a, actor: act, migration: m})
+		}
+		return nil
+	}); err != nil {
+		return xerrors.Errorf("failed to iterate actors: %w", err)
+	}
+
+	results, err := runJobs(ctx, store, jobs, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Write results to the tree.
+	burnt := big.Zero()
+	for i, job := range jobs {
+		res := results[i]
+		if !res.NewHead.Defined() {
+			return xerrors.Errorf("migration of actor %v returned undefined head", job.address)
+		}
+		transfer := big.Zero()
+		if !res.Transfer.Nil() {
+			transfer = res.Transfer
+		}
+		if transfer.LessThan(big.Zero()) {
+			return xerrors.Errorf("migration of actor %v returned negative transfer %v", job.address, transfer)
+		}
+		if transfer.GreaterThan(job.actor.Balance) {
+			return xerrors.Errorf("migration of actor %v transfer %v exceeds balance %v", job.address, transfer, job.actor.Balance)
+		}
+
+		migrated := &Actor{
+			Code:    job.actor.Code,
+			Head:    res.NewHead,
+			Balance: big.Sub(job.actor.Balance, transfer),
+		}
+		if res.NewCode.Defined() {
+			migrated.Code = res.NewCode
+		}
+		if err := tree.SetActor(job.address, migrated); err != nil {
+			return xerrors.Errorf("failed to set migrated actor %v: %w", job.address, err)
+		}
+		burnt = big.Add(burnt, transfer)
+	}
+
+	if !burnt.IsZero() {
+		burntFunds, found, err := tree.GetActor(builtin.BurntFundsActorAddr)
+		if err != nil {
+			return xerrors.Errorf("failed to load burnt funds actor: %w", err)
+		}
+		if !found {
+			return xerrors.Errorf("burnt funds actor not found")
+		}
+		burntFunds.Balance = big.Add(burntFunds.Balance, burnt)
+		if err := tree.SetActor(builtin.BurntFundsActorAddr, burntFunds); err != nil {
+			return xerrors.Errorf("failed to set burnt funds actor: %w", err)
+		}
+	}
+
+	return CheckBalanceConserved(tree, totalBefore)
+}
+
+// CheckBalanceConserved returns an error if the total balance of all actors in the tree differs from expected.
+func CheckBalanceConserved(tree StateTree, expected abi.TokenAmount) error {
+	total := big.Zero()
+	if err := tree.ForEachActor(func(a addr.Address, act *Actor) error {
+		if act.Balance.LessThan(big.Zero()) {
+			return xerrors.Errorf("actor %v has negative balance %v", a, act.Balance)
+		}
+		total = big.Add(total, act.Balance)
+		return nil
+	}); err != nil {
+		return xerrors.Errorf("failed to sum balances: %w", err)
+	}
+	if !total.Equals(expected) {
+		return xerrors.Errorf("total balance %v after migration does not match %v before", total, expected)
+	}
+	return nil
+}
+
+// Runs the migration jobs on a bounded pool of workers, returning results in job order.
+// The first error cancels outstanding jobs and is returned.
+func runJobs(ctx context.Context, store adt.Store, jobs []*migrationJob, cfg Config) ([]*ActorMigrationResult, error) {
+	workers := cfg.MaxWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*ActorMigrationResult, len(jobs))
+	indexes := make(chan int)
+	var errOnce sync.Once
+	var firstErr error
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				job := jobs[i]
+				res, err := job.migration(ctx, store, ActorMigrationInput{
+					Address:    job.address,
+					Balance:    job.actor.Balance,
+					Head:       job.actor.Head,
+					PriorEpoch: cfg.PriorEpoch,
+				})
+				if err == nil && res == nil {
+					err = xerrors.New("nil result")
+				}
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = xerrors.Errorf("failed to migrate actor %v: %w", job.address, err)
+						cancel()
+					})
+					continue
+				}
+				results[i] = res
+			}
+		}()
+	}
+
+	// Feed jobs to workers until all are dispatched or the migration is cancelled.
+dispatch:
+	for i := range jobs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("migration cancelled: %w", err)
+	}
+	return results, nil
+}
@@ -189,6 +189,10 @@ type Syscalls interface {
 	VerifySeal(vi abi.SealVerifyInfo) error
 
 	BatchVerifySeals(vis map[address.Address][]abi.SealVerifyInfo) (map[address.Address][]bool, error)
+	// Verifies an aggregate proof of the seals of many sectors of a single miner.
+	VerifyAggregateSeals(aggregate abi.AggregateSealVerifyProofAndInfos) error
+	// Verifies a proof that a sector's replica was updated to encode new data.
+	VerifyReplicaUpdate(update abi.ReplicaUpdateInfo) error
 
 	// Verifies a proof of spacetime.
 	VerifyPoSt(vi abi.WindowPoStVerifyInfo) error
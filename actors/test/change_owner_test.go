@@ -0,0 +1,89 @@
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeOwner(t *testing.T) {
+	ctx := context.Background()
+	v := vm.NewVMWithSingletons(ctx, t)
+	addrs := vm.CreateAccounts(ctx, t, v, 3, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+	initialOwner, newOwner, otherAccount := addrs[0], addrs[1], addrs[2]
+
+	newOwnerID, found := v.NormalizeAddress(newOwner)
+	require.True(t, found)
+	otherAccountID, found := v.NormalizeAddress(otherAccount)
+	require.True(t, found)
+
+	// create miner
+	params := power.CreateMinerParams{
+		Owner:         initialOwner,
+		Worker:        initialOwner,
+		SealProofType: abi.RegisteredSealProof_StackedDrg32GiBV1,
+		Peer:          abi.PeerID("not really a peer id"),
+	}
+	ret, code := v.ApplyMessage(initialOwner, builtin.StoragePowerActorAddr, big.Mul(big.NewInt(1_000), vm.FIL), builtin.MethodsPower.CreateMiner, &params)
+	require.Equal(t, exitcode.Ok, code)
+	minerAddrs, ok := ret.(*power.CreateMinerReturn)
+	require.True(t, ok)
+
+	// propose a new owner
+	_, code = v.ApplyMessage(initialOwner, minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.ChangeOwnerAddress, &newOwnerID)
+	require.Equal(t, exitcode.Ok, code)
+	info := getMinerInfo(t, v, minerAddrs.IDAddress)
+	initialOwnerID, found := v.NormalizeAddress(initialOwner)
+	require.True(t, found)
+	assert.Equal(t, initialOwnerID, info.Owner)
+	require.NotNil(t, info.PendingOwnerAddress)
+	assert.Equal(t, newOwnerID, *info.PendingOwnerAddress)
+
+	// a third party cannot confirm the proposal
+	_, code = v.ApplyMessage(otherAccount, minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.ChangeOwnerAddress, &otherAccountID)
+	assert.Equal(t, exitcode.ErrForbidden, code)
+
+	// the proposed owner confirms
+	_, code = v.ApplyMessage(newOwner, minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.ChangeOwnerAddress, &newOwnerID)
+	require.Equal(t, exitcode.Ok, code)
+	info = getMinerInfo(t, v, minerAddrs.IDAddress)
+	assert.Equal(t, newOwnerID, info.Owner)
+	assert.Nil(t, info.PendingOwnerAddress)
+
+	// the old owner may no longer withdraw funds, the new one may
+	withdrawParams := miner.WithdrawBalanceParams{AmountRequested: vm.FIL}
+	_, code = v.ApplyMessage(initialOwner, minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.WithdrawBalance, &withdrawParams)
+	assert.Equal(t, exitcode.ErrForbidden, code)
+
+	_, code = v.ApplyMessage(newOwner, minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.WithdrawBalance, &withdrawParams)
+	require.Equal(t, exitcode.Ok, code)
+	vm.ExpectInvocation{
+		To:     minerAddrs.IDAddress,
+		Method: builtin.MethodsMiner.WithdrawBalance,
+		SubInvocations: []vm.ExpectInvocation{
+			{To: newOwnerID, Method: builtin.MethodSend, Value: vm.ExpectAttoFil(vm.FIL)},
+		},
+	}.Matches(t, v.LastInvocation())
+
+	vm.AssertStateInvariants(t, v)
+}
+
+func getMinerInfo(t *testing.T, v *vm.VM, minerAddr addr.Address) *miner.MinerInfo {
+	var st miner.State
+	err := v.GetState(minerAddr, &st)
+	require.NoError(t, err)
+	info, err := st.GetInfo(v.Store())
+	require.NoError(t, err)
+	return info
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/filecoin-project/specs-actors/actors/builtin"
 	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
 	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
 	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
 	tutil "github.com/filecoin-project/specs-actors/support/testing"
 	vm "github.com/filecoin-project/specs-actors/support/vm"
@@ -218,7 +219,7 @@ func TestCommitPoStFlow(t *testing.T) {
 			Proofs: []abi.PoStProof{{
 				PoStProof: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
 			}},
-			ChainCommitRand: []byte("not really random"),
+			ChainCommitRand: v.Randomness().GetRandomnessFromTickets(crypto.DomainSeparationTag_PoStChainCommit, dlInfo.Challenge, nil),
 		}
 		_, code = tv.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
 		require.Equal(t, exitcode.Ok, code)
@@ -253,6 +254,8 @@ func TestCommitPoStFlow(t *testing.T) {
 		networkStats := vm.GetNetworkStats(t, tv)
 		assert.Equal(t, big.NewInt(int64(sectorSize)), networkStats.TotalBytesCommitted)
 		assert.True(t, networkStats.TotalPledgeCollateral.GreaterThan(big.Zero()))
+
+		vm.AssertStateInvariants(t, tv)
 	})
 
 	t.Run("skip sector", func(t *testing.T) {
@@ -269,7 +272,7 @@ func TestCommitPoStFlow(t *testing.T) {
 			Proofs: []abi.PoStProof{{
 				PoStProof: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
 			}},
-			ChainCommitRand: []byte("not really random"),
+			ChainCommitRand: v.Randomness().GetRandomnessFromTickets(crypto.DomainSeparationTag_PoStChainCommit, dlInfo.Challenge, nil),
 		}
 		_, code = tv.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
 		require.Equal(t, exitcode.Ok, code)
@@ -294,6 +297,36 @@ func TestCommitPoStFlow(t *testing.T) {
 		networkStats := vm.GetNetworkStats(t, tv)
 		assert.Equal(t, big.Zero(), networkStats.TotalBytesCommitted)
 		assert.True(t, networkStats.TotalPledgeCollateral.GreaterThan(big.Zero()))
+
+		vm.AssertStateInvariants(t, tv)
+	})
+
+	t.Run("PoSt must commit to chain randomness", func(t *testing.T) {
+		tv, err := v.WithEpoch(v.GetEpoch())
+		require.NoError(t, err)
+		randomness := vm.NewRandomness(42)
+		randomness.SetTicketRandomness(dlInfo.Challenge, []byte("scripted"))
+		tv.SetRandomness(randomness)
+
+		submitParams := miner.SubmitWindowedPoStParams{
+			Deadline: dlInfo.Index,
+			Partitions: []miner.PoStPartition{{
+				Index:   pIdx,
+				Skipped: bitfield.New(),
+			}},
+			Proofs: []abi.PoStProof{{
+				PoStProof: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
+			}},
+			ChainCommitRand: v.Randomness().GetRandomnessFromTickets(crypto.DomainSeparationTag_PoStChainCommit, dlInfo.Challenge, nil),
+		}
+		_, code = tv.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
+		require.Equal(t, exitcode.ErrIllegalArgument, code)
+
+		submitParams.ChainCommitRand = []byte("scripted")
+		_, code = tv.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
+		require.Equal(t, exitcode.Ok, code)
+
+		vm.AssertStateInvariants(t, tv)
 	})
 
 	t.Run("missed first PoSt deadline", func(t *testing.T) {
@@ -329,5 +362,7 @@ func TestCommitPoStFlow(t *testing.T) {
 		networkStats := vm.GetNetworkStats(t, tv)
 		assert.Equal(t, big.Zero(), networkStats.TotalBytesCommitted)
 		assert.True(t, networkStats.TotalPledgeCollateral.GreaterThan(big.Zero()))
+
+		vm.AssertStateInvariants(t, tv)
 	})
 }
@@ -91,7 +91,7 @@ func TestReplaceCommittedCapacitySectorWithDealLadenSector(t *testing.T) {
 		Proofs: []abi.PoStProof{{
 			PoStProof: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
 		}},
-		ChainCommitRand: []byte("not really random"),
+		ChainCommitRand: v.Randomness().GetRandomnessFromTickets(crypto.DomainSeparationTag_PoStChainCommit, dlInfo.Challenge, nil),
 	}
 
 	_, code = v.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
@@ -251,7 +251,7 @@ func TestReplaceCommittedCapacitySectorWithDealLadenSector(t *testing.T) {
 		Proofs: []abi.PoStProof{{
 			PoStProof: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
 		}},
-		ChainCommitRand: []byte("not really random"),
+		ChainCommitRand: v.Randomness().GetRandomnessFromTickets(crypto.DomainSeparationTag_PoStChainCommit, dlInfo.Challenge, nil),
 	}
 	_, code = v.ApplyMessage(addrs[0], minerAddrs.RobustAddress, big.Zero(), builtin.MethodsMiner.SubmitWindowedPoSt, &submitParams)
 	require.Equal(t, exitcode.Ok, code)
@@ -296,6 +296,8 @@ func TestReplaceCommittedCapacitySectorWithDealLadenSector(t *testing.T) {
 	assert.Equal(t, ccSectorPower.QA, minerPower.QA)
 	assert.Equal(t, ccSectorPower.Raw, networkStats.TotalBytesCommitted)
 	assert.Equal(t, ccSectorPower.QA, networkStats.TotalQABytesCommitted)
+
+	vm.AssertStateInvariants(t, v)
 }
 
 func publishDeal(t *testing.T, v *vm.VM, provider, dealClient, minerID addr.Address, dealLabel string,
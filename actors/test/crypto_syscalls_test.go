@@ -0,0 +1,278 @@
+package test_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	initactor "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+	"github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	"github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/filecoin-project/specs-actors/actors/runtime"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+	"github.com/minio/blake2b-simd"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaychVoucherSignatures(t *testing.T) {
+	ctx := context.Background()
+	fromKey, toKey, otherKey := vm.NewSecpKey(1), vm.NewSecpKey(2), vm.NewSecpKey(3)
+	from, to := fromKey.Address(), toKey.Address()
+
+	setup := func(t *testing.T) (*vm.VM, addr.Address) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		v.SetSyscalls(vm.CryptoSyscalls)
+		vm.CreateAccountsForKeys(ctx, t, v, big.Mul(big.NewInt(10_000), vm.FIL), from, to)
+		ret := execActor(t, v, from, builtin.PaymentChannelActorCodeID, big.Mul(big.NewInt(100), vm.FIL),
+			&paych.ConstructorParams{From: from, To: to})
+		return v, ret.IDAddress
+	}
+
+	signedVoucher := func(t *testing.T, key *vm.SecpKey, sv paych.SignedVoucher) *paych.SignedVoucher {
+		vb, err := sv.SigningBytes()
+		require.NoError(t, err)
+		sig := key.Sign(vb)
+		sv.Signature = &sig
+		return &sv
+	}
+
+	t.Run("redeem voucher signed by payer", func(t *testing.T) {
+		v, chAddr := setup(t)
+		sv := signedVoucher(t, fromKey, paych.SignedVoucher{ChannelAddr: chAddr, Lane: 1, Nonce: 1, Amount: vm.FIL})
+
+		_, code := v.ApplyMessage(to, chAddr, big.Zero(), builtin.MethodsPaych.UpdateChannelState, &paych.UpdateChannelStateParams{Sv: *sv})
+		require.Equal(t, exitcode.Ok, code)
+
+		var st paych.State
+		require.NoError(t, v.GetState(chAddr, &st))
+		assert.Equal(t, vm.FIL, st.ToSend)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("reject voucher signed by wrong key", func(t *testing.T) {
+		v, chAddr := setup(t)
+		sv := signedVoucher(t, otherKey, paych.SignedVoucher{ChannelAddr: chAddr, Lane: 1, Nonce: 1, Amount: vm.FIL})
+
+		_, code := v.ApplyMessage(to, chAddr, big.Zero(), builtin.MethodsPaych.UpdateChannelState, &paych.UpdateChannelStateParams{Sv: *sv})
+		assert.Equal(t, exitcode.ErrIllegalArgument, code)
+	})
+
+	t.Run("reject voucher altered after signing", func(t *testing.T) {
+		v, chAddr := setup(t)
+		sv := signedVoucher(t, fromKey, paych.SignedVoucher{ChannelAddr: chAddr, Lane: 1, Nonce: 1, Amount: vm.FIL})
+		sv.Amount = big.Mul(big.NewInt(2), vm.FIL)
+
+		_, code := v.ApplyMessage(to, chAddr, big.Zero(), builtin.MethodsPaych.UpdateChannelState, &paych.UpdateChannelStateParams{Sv: *sv})
+		assert.Equal(t, exitcode.ErrIllegalArgument, code)
+	})
+
+	t.Run("voucher secret must hash to preimage", func(t *testing.T) {
+		v, chAddr := setup(t)
+		secret := []byte("open sesame")
+		hashed := blake2b.Sum256(secret)
+		sv := signedVoucher(t, fromKey, paych.SignedVoucher{ChannelAddr: chAddr, Lane: 1, Nonce: 1, Amount: vm.FIL, SecretPreimage: hashed[:]})
+
+		_, code := v.ApplyMessage(to, chAddr, big.Zero(), builtin.MethodsPaych.UpdateChannelState, &paych.UpdateChannelStateParams{Sv: *sv, Secret: []byte("wrong")})
+		assert.Equal(t, exitcode.ErrIllegalArgument, code)
+
+		_, code = v.ApplyMessage(to, chAddr, big.Zero(), builtin.MethodsPaych.UpdateChannelState, &paych.UpdateChannelStateParams{Sv: *sv, Secret: secret})
+		assert.Equal(t, exitcode.Ok, code)
+	})
+}
+
+// Keys and signatures produced by libsecp256k1 (via github.com/filecoin-project/go-crypto, as used by lotus)
+// for the private keys of vm.NewSecpKey. Test keys must derive the same public keys and sign identically.
+func TestSecpSignatureVectors(t *testing.T) {
+	ctx := context.Background()
+	v := vm.NewVMWithSingletons(ctx, t)
+	syscalls := vm.CryptoSyscalls(v, builtin.SystemActorAddr)
+
+	for _, tc := range []struct {
+		desc        string
+		seed        int64
+		pubKey, sig string
+		msg         string
+	}{{
+		desc: "recovery id 0",
+		seed: 1,
+		pubKey: "040ff01e42d3e91295be89e1f6fbb3c048523614713e5fd4d3f1dc5e03d9261dd1" +
+			"10612ca59674f696bcb9849026bbe890f72b7f121258b211a7b44ba809537920",
+		msg: "hello filecoin",
+		sig: "ac7e854b6acc0b38f2fd899c87e33dcb3d6e3e143c3259f13ebf6766d32869cc" +
+			"6400b7cfe051d632a42df81f96ede7ac561cb165408958545e92781d90ad3ed800",
+	}, {
+		desc: "recovery id 1",
+		seed: 2,
+		pubKey: "04506da925d65bf9029ccc9362d465e7411c6e1ffea213fd3cd23675985fb0f7bf" +
+			"6fb7bf4f48984c7b9085cb68a9486dee0a2e592462c754dee2c92b4257397d1c",
+		msg: "",
+		sig: "d06ab9bf3ded5a47b7a7847d4938f2a750ee1380340595dc60da936011a412cc" +
+			"5dfaf43e0bb9749fbc1177bdc00158b04086629bb731a86fa67522349faf733201",
+	}} {
+		t.Run(tc.desc, func(t *testing.T) {
+			key := vm.NewSecpKey(tc.seed)
+			pubKey, err := hex.DecodeString(tc.pubKey)
+			require.NoError(t, err)
+			signer, err := addr.NewSecp256k1Address(pubKey)
+			require.NoError(t, err)
+			assert.Equal(t, pubKey, key.PublicKey())
+			assert.Equal(t, signer, key.Address())
+
+			sigBytes, err := hex.DecodeString(tc.sig)
+			require.NoError(t, err)
+			expected := crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: sigBytes}
+			assert.Equal(t, expected, key.Sign([]byte(tc.msg)))
+			assert.NoError(t, syscalls.VerifySignature(expected, signer, []byte(tc.msg)))
+			assert.Error(t, syscalls.VerifySignature(expected, signer, []byte(tc.msg+"x")))
+		})
+	}
+}
+
+func TestMultisigProposalHash(t *testing.T) {
+	ctx := context.Background()
+	v := vm.NewVMWithSingletons(ctx, t)
+	v.SetSyscalls(vm.CryptoSyscalls)
+	addrs := vm.CreateAccounts(ctx, t, v, 3, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+	proposer, approver, recipient := addrs[0], addrs[1], addrs[2]
+
+	msigBalance := big.Mul(big.NewInt(10), vm.FIL)
+	ret := execActor(t, v, proposer, builtin.MultisigActorCodeID, msigBalance, &multisig.ConstructorParams{
+		Signers:               []addr.Address{proposer, approver},
+		NumApprovalsThreshold: 2,
+	})
+	msigAddr := ret.IDAddress
+
+	proposal := multisig.ProposeParams{To: recipient, Value: vm.FIL, Method: builtin.MethodSend}
+	pret, code := v.ApplyMessage(proposer, msigAddr, big.Zero(), builtin.MethodsMultisig.Propose, &proposal)
+	require.Equal(t, exitcode.Ok, code)
+	txnID := pret.(*multisig.ProposeReturn).TxnID
+
+	proposerID, found := v.NormalizeAddress(proposer)
+	require.True(t, found)
+	proposalHash, err := multisig.ComputeProposalHash(&multisig.Transaction{
+		To:       proposal.To,
+		Value:    proposal.Value,
+		Method:   proposal.Method,
+		Approved: []addr.Address{proposerID},
+	}, blake2b.Sum256)
+	require.NoError(t, err)
+
+	// an approval referencing a different proposal is rejected
+	wrongHash := blake2b.Sum256([]byte("some other proposal"))
+	_, code = v.ApplyMessage(approver, msigAddr, big.Zero(), builtin.MethodsMultisig.Approve,
+		&multisig.TxnIDParams{ID: txnID, ProposalHash: wrongHash[:]})
+	assert.Equal(t, exitcode.ErrIllegalArgument, code)
+
+	// approval with the real hash executes the transaction
+	aret, code := v.ApplyMessage(approver, msigAddr, big.Zero(), builtin.MethodsMultisig.Approve,
+		&multisig.TxnIDParams{ID: txnID, ProposalHash: proposalHash})
+	require.Equal(t, exitcode.Ok, code)
+	assert.True(t, aret.(*multisig.ApproveReturn).Applied)
+
+	act, found, err := v.GetActor(msigAddr)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, big.Sub(msigBalance, vm.FIL), act.Balance)
+	vm.AssertStateInvariants(t, v)
+}
+
+func TestComputeUnsealedSectorCID(t *testing.T) {
+	ctx := context.Background()
+	v := vm.NewVMWithSingletons(ctx, t)
+	syscalls := vm.CryptoSyscalls(v, builtin.StorageMarketActorAddr)
+
+	// Unsealed CIDs of empty sectors, as computed by the proofs library.
+	emptyCommD2KiB, err := cid.Decode("baga6ea4seaqpy7usqklokfx2vxuynmupslkeutzexe2uqurdg5vhtebhxqmpqmy")
+	require.NoError(t, err)
+	emptyCommD32GiB, err := cid.Decode("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	require.NoError(t, err)
+
+	// Hashes two tree nodes as the proofs library does: sha256, truncated to a BLS12-381 field element.
+	nodeHash := func(left, right []byte) []byte {
+		h := sha256.Sum256(append(append([]byte{}, left...), right...))
+		h[31] &= 0x3f
+		return h[:]
+	}
+	zeroComm := func(size abi.PaddedPieceSize) []byte {
+		comm := make([]byte, 32)
+		for s := abi.PaddedPieceSize(32); s < size; s *= 2 {
+			comm = nodeHash(comm, comm)
+		}
+		return comm
+	}
+	pieceCID := func(comm []byte) cid.Cid {
+		digest, err := mh.Encode(comm, market.PieceCIDPrefix.MhType)
+		require.NoError(t, err)
+		return cid.NewCidV1(market.PieceCIDPrefix.Codec, digest)
+	}
+
+	t.Run("empty sectors", func(t *testing.T) {
+		commD, err := syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg2KiBV1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, emptyCommD2KiB, commD)
+
+		commD, err = syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg32GiBV1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, emptyCommD32GiB, commD)
+	})
+
+	t.Run("sector of zero pieces matches empty sector", func(t *testing.T) {
+		// A 256 byte piece is padded with 256 and 512 byte zero pieces before the 1024 byte piece.
+		commD, err := syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg2KiBV1, []abi.PieceInfo{
+			{Size: 256, PieceCID: pieceCID(zeroComm(256))},
+			{Size: 1024, PieceCID: pieceCID(zeroComm(1024))},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, emptyCommD2KiB, commD)
+	})
+
+	t.Run("pieces are padded to alignment", func(t *testing.T) {
+		a := bytes.Repeat([]byte{0x0a}, 32)
+		b := bytes.Repeat([]byte{0x0b}, 32)
+		commD, err := syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg2KiBV1, []abi.PieceInfo{
+			{Size: 512, PieceCID: pieceCID(a)},
+			{Size: 1024, PieceCID: pieceCID(b)},
+		})
+		require.NoError(t, err)
+
+		// [a(512) | zero(512)] | b(1024)
+		expected := nodeHash(nodeHash(a, zeroComm(512)), b)
+		assert.Equal(t, pieceCID(expected), commD)
+	})
+
+	t.Run("rejects pieces overflowing the sector", func(t *testing.T) {
+		_, err := syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg2KiBV1, []abi.PieceInfo{
+			{Size: 1024, PieceCID: pieceCID(zeroComm(1024))},
+			{Size: 2048, PieceCID: pieceCID(zeroComm(2048))},
+		})
+		assert.Error(t, err)
+
+		_, err = syscalls.ComputeUnsealedSectorCID(abi.RegisteredSealProof_StackedDrg2KiBV1, []abi.PieceInfo{
+			{Size: 4096, PieceCID: pieceCID(zeroComm(4096))},
+		})
+		assert.Error(t, err)
+	})
+}
+
+// Creates an actor through the init actor, returning its addresses.
+func execActor(t *testing.T, v *vm.VM, from addr.Address, code cid.Cid, value abi.TokenAmount, params runtime.CBORMarshaler) *initactor.ExecReturn {
+	buf := new(bytes.Buffer)
+	require.NoError(t, params.MarshalCBOR(buf))
+	ret, exit := v.ApplyMessage(from, builtin.InitActorAddr, value, builtin.MethodsInit.Exec,
+		&initactor.ExecParams{CodeCID: code, ConstructorParams: buf.Bytes()})
+	require.Equal(t, exitcode.Ok, exit)
+	return ret.(*initactor.ExecReturn)
+}
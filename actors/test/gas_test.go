@@ -0,0 +1,99 @@
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasAccounting(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("value transfer is charged for method invocation", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 2, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+
+		_, code := v.ApplyMessage(addrs[0], addrs[1], vm.FIL, builtin.MethodSend, nil)
+		require.Equal(t, exitcode.Ok, code)
+
+		expected := vm.DefaultPricelist.OnMethodInvocation(vm.FIL, builtin.MethodSend).Total()
+		assert.Equal(t, expected, v.LastInvocation().GasUsed)
+	})
+
+	t.Run("gas of sub-invocations is included in caller", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+
+		createMiner(t, v, addrs[0])
+
+		var checkInvocation func(inv *vm.Invocation)
+		checkInvocation = func(inv *vm.Invocation) {
+			assert.True(t, inv.GasUsed > 0)
+			subTotal := int64(0)
+			for _, sub := range inv.SubInvocations {
+				subTotal += sub.GasUsed
+				checkInvocation(sub)
+			}
+			assert.True(t, inv.GasUsed > subTotal)
+		}
+		checkInvocation(v.LastInvocation())
+	})
+
+	t.Run("pricelist is pluggable", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+		v.SetPricelist(vm.ZeroPricelist)
+
+		createMiner(t, v, addrs[0])
+		assert.Equal(t, int64(0), v.LastInvocation().GasUsed)
+	})
+
+	t.Run("message exceeding gas limit fails and is rolled back", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+		v.SetGasLimit(1_000_000)
+
+		params := power.CreateMinerParams{
+			Owner:         addrs[0],
+			Worker:        addrs[0],
+			SealProofType: abi.RegisteredSealProof_StackedDrg32GiBV1,
+			Peer:          abi.PeerID("not really a peer id"),
+		}
+		_, code := v.ApplyMessage(addrs[0], builtin.StoragePowerActorAddr, big.Zero(), builtin.MethodsPower.CreateMiner, &params)
+		assert.Equal(t, exitcode.SysErrOutOfGas, code)
+		assert.Equal(t, int64(1_000_000), v.LastInvocation().GasUsed)
+
+		// no miner was created
+		networkStats := vm.GetNetworkStats(t, v)
+		assert.Equal(t, int64(0), networkStats.MinerCount)
+
+		// with enough gas the same message succeeds
+		v.SetGasLimit(vm.DefaultGasLimit)
+		_, code = v.ApplyMessage(addrs[0], builtin.StoragePowerActorAddr, big.Zero(), builtin.MethodsPower.CreateMiner, &params)
+		assert.Equal(t, exitcode.Ok, code)
+	})
+}
+
+func createMiner(t *testing.T, v *vm.VM, owner addr.Address) *power.CreateMinerReturn {
+	params := power.CreateMinerParams{
+		Owner:         owner,
+		Worker:        owner,
+		SealProofType: abi.RegisteredSealProof_StackedDrg32GiBV1,
+		Peer:          abi.PeerID("not really a peer id"),
+	}
+	ret, code := v.ApplyMessage(owner, builtin.StoragePowerActorAddr, big.Zero(), builtin.MethodsPower.CreateMiner, &params)
+	require.Equal(t, exitcode.Ok, code)
+	minerAddrs, ok := ret.(*power.CreateMinerReturn)
+	require.True(t, ok)
+	return minerAddrs
+}
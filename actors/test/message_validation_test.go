@@ -0,0 +1,159 @@
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	tutil "github.com/filecoin-project/specs-actors/support/testing"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageValidation(t *testing.T) {
+	ctx := context.Background()
+	balance := big.Mul(big.NewInt(10_000), vm.FIL)
+
+	t.Run("call sequence number increments with each message", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 2, balance, 93837778)
+		assert.Equal(t, uint64(0), getActor(t, v, addrs[0]).CallSeqNum)
+
+		_, code := v.ApplyMessage(addrs[0], addrs[1], vm.FIL, builtin.MethodSend, nil)
+		require.Equal(t, exitcode.Ok, code)
+		assert.Equal(t, uint64(1), getActor(t, v, addrs[0]).CallSeqNum)
+		assert.Equal(t, uint64(0), getActor(t, v, addrs[1]).CallSeqNum)
+
+		// a failed message still consumes the sequence number
+		_, code = v.ApplyMessage(addrs[0], addrs[1], big.Mul(balance, big.NewInt(2)), builtin.MethodSend, nil)
+		require.Equal(t, exitcode.SysErrInsufficientFunds, code)
+		assert.Equal(t, uint64(2), getActor(t, v, addrs[0]).CallSeqNum)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("message with wrong call sequence number is rejected", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 2, balance, 93837778)
+		send := func(callSeqNum uint64) vm.MessageReceipt {
+			return v.ApplyChainMessage(&vm.Message{
+				From:       addrs[0],
+				To:         addrs[1],
+				CallSeqNum: callSeqNum,
+				Value:      vm.FIL,
+				Method:     builtin.MethodSend,
+				GasLimit:   vm.DefaultGasLimit,
+			})
+		}
+
+		assert.Equal(t, exitcode.SysErrSenderStateInvalid, send(1).ExitCode)
+		assert.Equal(t, exitcode.Ok, send(0).ExitCode)
+		// replay
+		assert.Equal(t, exitcode.SysErrSenderStateInvalid, send(0).ExitCode)
+		assert.Equal(t, exitcode.Ok, send(1).ExitCode)
+
+		sender := getActor(t, v, addrs[0])
+		assert.Equal(t, uint64(2), sender.CallSeqNum)
+		assert.Equal(t, big.Sub(balance, big.Mul(big.NewInt(2), vm.FIL)), sender.Balance)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("message from unknown sender is rejected", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, balance, 93837778)
+
+		_, code := v.ApplyMessage(tutil.NewBLSAddr(t, 1234), addrs[0], big.Zero(), builtin.MethodSend, nil)
+		assert.Equal(t, exitcode.SysErrSenderInvalid, code)
+		_, code = v.ApplyMessage(tutil.NewIDAddr(t, 1234), addrs[0], big.Zero(), builtin.MethodSend, nil)
+		assert.Equal(t, exitcode.SysErrSenderInvalid, code)
+	})
+
+	t.Run("sender pays for gas used", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 2, balance, 93837778)
+		gasPrice := abi.NewTokenAmount(100)
+		v.SetGasPrice(gasPrice)
+		rewardBalance := getActor(t, v, builtin.RewardActorAddr).Balance
+
+		receipt := v.ApplyChainMessage(&vm.Message{
+			From:       addrs[0],
+			To:         addrs[1],
+			CallSeqNum: 0,
+			Value:      vm.FIL,
+			Method:     builtin.MethodSend,
+			GasLimit:   vm.DefaultGasLimit,
+			GasPrice:   gasPrice,
+		})
+		require.Equal(t, exitcode.Ok, receipt.ExitCode)
+		assert.Equal(t, vm.DefaultPricelist.OnMethodInvocation(vm.FIL, builtin.MethodSend).Total(), receipt.GasUsed)
+
+		gasCost := big.Mul(big.NewInt(receipt.GasUsed), gasPrice)
+		assert.Equal(t, big.Sub(big.Sub(balance, vm.FIL), gasCost), getActor(t, v, addrs[0]).Balance)
+		assert.Equal(t, big.Add(balance, vm.FIL), getActor(t, v, addrs[1]).Balance)
+		assert.Equal(t, big.Add(rewardBalance, gasCost), getActor(t, v, builtin.RewardActorAddr).Balance)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("sender pays for gas used by failed message", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, balance, 93837778)
+		gasPrice := abi.NewTokenAmount(100)
+		v.SetGasPrice(gasPrice)
+		v.SetGasLimit(1_000_000)
+
+		createMinerParams := power.CreateMinerParams{
+			Owner:         addrs[0],
+			Worker:        addrs[0],
+			SealProofType: abi.RegisteredSealProof_StackedDrg32GiBV1,
+			Peer:          abi.PeerID("not really a peer id"),
+		}
+		_, code := v.ApplyMessage(addrs[0], builtin.StoragePowerActorAddr, big.Zero(), builtin.MethodsPower.CreateMiner, &createMinerParams)
+		require.Equal(t, exitcode.SysErrOutOfGas, code)
+
+		sender := getActor(t, v, addrs[0])
+		assert.Equal(t, uint64(1), sender.CallSeqNum)
+		assert.Equal(t, big.Sub(balance, big.Mul(big.NewInt(1_000_000), gasPrice)), sender.Balance)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("sender must cover the gas limit", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 2, balance, 93837778)
+		v.SetGasPrice(big.Div(balance, big.NewInt(vm.DefaultGasLimit-1)))
+
+		_, code := v.ApplyMessage(addrs[0], addrs[1], big.Zero(), builtin.MethodSend, nil)
+		assert.Equal(t, exitcode.SysErrSenderStateInvalid, code)
+
+		sender := getActor(t, v, addrs[0])
+		assert.Equal(t, uint64(0), sender.CallSeqNum)
+		assert.Equal(t, balance, sender.Balance)
+	})
+
+	t.Run("actors created by successive messages have distinct addresses", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, balance, 93837778)
+		params := multisig.ConstructorParams{Signers: []addr.Address{addrs[0]}, NumApprovalsThreshold: 1}
+
+		first := execActor(t, v, addrs[0], builtin.MultisigActorCodeID, big.Zero(), &params)
+		second := execActor(t, v, addrs[0], builtin.MultisigActorCodeID, big.Zero(), &params)
+		assert.NotEqual(t, first.RobustAddress, second.RobustAddress)
+		assert.NotEqual(t, first.IDAddress, second.IDAddress)
+		vm.AssertStateInvariants(t, v)
+	})
+}
+
+func getActor(t *testing.T, v *vm.VM, a addr.Address) *vm.TestActor {
+	idAddr, found := v.NormalizeAddress(a)
+	require.True(t, found)
+	act, found, err := v.GetActor(idAddr)
+	require.NoError(t, err)
+	require.True(t, found)
+	return act
+}
@@ -0,0 +1,141 @@
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/migration"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/filecoin-project/specs-actors/actors/util/adt"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	cid "github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+)
+
+func TestMigrateState(t *testing.T) {
+	ctx := context.Background()
+	minerBalance := big.Mul(big.NewInt(10), vm.FIL)
+
+	// Sets up a VM with several funded miners.
+	setup := func(t *testing.T) (*vm.VM, []addr.Address) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 1, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+
+		var miners []addr.Address
+		for i := 0; i < 5; i++ {
+			params := power.CreateMinerParams{
+				Owner:         addrs[0],
+				Worker:        addrs[0],
+				SealProofType: abi.RegisteredSealProof_StackedDrg32GiBV1,
+				Peer:          abi.PeerID("not really a peer id"),
+			}
+			ret, code := v.ApplyMessage(addrs[0], builtin.StoragePowerActorAddr, minerBalance, builtin.MethodsPower.CreateMiner, &params)
+			require.Equal(t, exitcode.Ok, code)
+			miners = append(miners, ret.(*power.CreateMinerReturn).IDAddress)
+		}
+		return v, miners
+	}
+
+	// Rewrites each miner's peer ID and burns a fraction of its balance.
+	migrateMiner := func(burnDivisor int64) migration.ActorMigration {
+		return func(ctx context.Context, store adt.Store, in migration.ActorMigrationInput) (*migration.ActorMigrationResult, error) {
+			var st miner.State
+			if err := store.Get(ctx, in.Head, &st); err != nil {
+				return nil, err
+			}
+			info, err := st.GetInfo(store)
+			if err != nil {
+				return nil, err
+			}
+			info.PeerId = abi.PeerID("migrated")
+			if err := st.SaveInfo(store, info); err != nil {
+				return nil, err
+			}
+			newHead, err := store.Put(ctx, &st)
+			if err != nil {
+				return nil, err
+			}
+			return &migration.ActorMigrationResult{
+				NewHead:  newHead,
+				Transfer: big.Div(in.Balance, big.NewInt(burnDivisor)),
+			}, nil
+		}
+	}
+
+	t.Run("migrates actors in parallel and conserves balance", func(t *testing.T) {
+		v, miners := setup(t)
+		burntBefore, _, err := v.GetActor(builtin.BurntFundsActorAddr)
+		require.NoError(t, err)
+
+		err = v.MigrateState(ctx, map[cid.Cid]migration.ActorMigration{
+			builtin.StorageMinerActorCodeID: migrateMiner(2),
+		}, migration.Config{MaxWorkers: 3, PriorEpoch: v.GetEpoch()})
+		require.NoError(t, err)
+
+		for _, minerAddr := range miners {
+			assert.Equal(t, abi.PeerID("migrated"), getMinerInfo(t, v, minerAddr).PeerId)
+
+			act, found, err := v.GetActor(minerAddr)
+			require.NoError(t, err)
+			require.True(t, found)
+			assert.Equal(t, builtin.StorageMinerActorCodeID, act.Code)
+			assert.Equal(t, big.Div(minerBalance, big.NewInt(2)), act.Balance)
+		}
+
+		burntAfter, _, err := v.GetActor(builtin.BurntFundsActorAddr)
+		require.NoError(t, err)
+		expectedBurn := big.Mul(big.Div(minerBalance, big.NewInt(2)), big.NewInt(int64(len(miners))))
+		assert.Equal(t, big.Add(burntBefore.Balance, expectedBurn), burntAfter.Balance)
+
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("failed migration leaves state unchanged", func(t *testing.T) {
+		v, miners := setup(t)
+		before, _, err := v.GetActor(miners[0])
+		require.NoError(t, err)
+
+		err = v.MigrateState(ctx, map[cid.Cid]migration.ActorMigration{
+			builtin.StorageMinerActorCodeID: func(ctx context.Context, store adt.Store, in migration.ActorMigrationInput) (*migration.ActorMigrationResult, error) {
+				if in.Address == miners[3] {
+					return nil, xerrors.New("boom")
+				}
+				return migrateMiner(2)(ctx, store, in)
+			},
+		}, migration.Config{MaxWorkers: 2})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+
+		after, _, err := v.GetActor(miners[0])
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("transfer exceeding balance fails", func(t *testing.T) {
+		v, miners := setup(t)
+
+		err := v.MigrateState(ctx, map[cid.Cid]migration.ActorMigration{
+			builtin.StorageMinerActorCodeID: func(ctx context.Context, store adt.Store, in migration.ActorMigrationInput) (*migration.ActorMigrationResult, error) {
+				return &migration.ActorMigrationResult{
+					NewHead:  in.Head,
+					Transfer: big.Add(in.Balance, big.NewInt(1)),
+				}, nil
+			},
+		}, migration.Config{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds balance")
+
+		act, _, err := v.GetActor(miners[0])
+		require.NoError(t, err)
+		assert.Equal(t, minerBalance, act.Balance)
+	})
+}
@@ -0,0 +1,76 @@
+package test_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultisigLockBalance(t *testing.T) {
+	ctx := context.Background()
+	v := vm.NewVMWithSingletons(ctx, t)
+	addrs := vm.CreateAccounts(ctx, t, v, 3, big.Mul(big.NewInt(10_000), vm.FIL), 93837778)
+	proposer, approver, recipient := addrs[0], addrs[1], addrs[2]
+
+	msigBalance := big.Mul(big.NewInt(100), vm.FIL)
+	ret := execActor(t, v, proposer, builtin.MultisigActorCodeID, msigBalance, &multisig.ConstructorParams{
+		Signers:               []addr.Address{proposer, approver},
+		NumApprovalsThreshold: 2,
+	})
+	msigAddr := ret.IDAddress
+
+	// the signers agree to lock the wallet's balance, unlocking over 100 epochs
+	lockParams := multisig.LockBalanceParams{StartEpoch: v.GetEpoch(), UnlockDuration: 100, Amount: msigBalance}
+	buf := new(bytes.Buffer)
+	require.NoError(t, lockParams.MarshalCBOR(buf))
+	proposeAndApprove(t, v, msigAddr, proposer, approver, multisig.ProposeParams{
+		To:     msigAddr,
+		Value:  big.Zero(),
+		Method: builtin.MethodsMultisig.LockBalance,
+		Params: buf.Bytes(),
+	})
+
+	var st multisig.State
+	require.NoError(t, v.GetState(msigAddr, &st))
+	assert.Equal(t, msigBalance, st.InitialBalance)
+	assert.Equal(t, abi.ChainEpoch(100), st.UnlockDuration)
+
+	// a spend of locked funds fails on approval
+	v, err := v.WithEpoch(v.GetEpoch() + 50)
+	require.NoError(t, err)
+	spend := multisig.ProposeParams{To: recipient, Value: big.Add(big.Div(msigBalance, big.NewInt(2)), vm.FIL), Method: builtin.MethodSend}
+	pret, code := v.ApplyMessage(proposer, msigAddr, big.Zero(), builtin.MethodsMultisig.Propose, &spend)
+	require.Equal(t, exitcode.Ok, code)
+	_, code = v.ApplyMessage(approver, msigAddr, big.Zero(), builtin.MethodsMultisig.Approve,
+		&multisig.TxnIDParams{ID: pret.(*multisig.ProposeReturn).TxnID})
+	assert.Equal(t, exitcode.ErrInsufficientFunds, code)
+
+	// a spend of unlocked funds succeeds
+	spend.Value = big.Div(msigBalance, big.NewInt(2))
+	proposeAndApprove(t, v, msigAddr, proposer, approver, spend)
+	assert.Equal(t, big.Div(msigBalance, big.NewInt(2)), getActor(t, v, msigAddr).Balance)
+	vm.AssertStateInvariants(t, v)
+}
+
+// Proposes a transaction from one signer and approves it with another, requiring that it executes successfully.
+func proposeAndApprove(t *testing.T, v *vm.VM, msigAddr, proposer, approver addr.Address, params multisig.ProposeParams) {
+	pret, code := v.ApplyMessage(proposer, msigAddr, big.Zero(), builtin.MethodsMultisig.Propose, &params)
+	require.Equal(t, exitcode.Ok, code)
+
+	aret, code := v.ApplyMessage(approver, msigAddr, big.Zero(), builtin.MethodsMultisig.Approve,
+		&multisig.TxnIDParams{ID: pret.(*multisig.ProposeReturn).TxnID})
+	require.Equal(t, exitcode.Ok, code)
+	require.True(t, aret.(*multisig.ApproveReturn).Applied)
+	require.Equal(t, exitcode.Ok, aret.(*multisig.ApproveReturn).Code)
+}
@@ -0,0 +1,90 @@
+package test_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	vm "github.com/filecoin-project/specs-actors/support/vm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTipset(t *testing.T) {
+	ctx := context.Background()
+	balance := big.Mul(big.NewInt(10_000), vm.FIL)
+
+	t.Run("applies messages and pays block rewards", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+		addrs := vm.CreateAccounts(ctx, t, v, 3, balance, 93837778)
+		sender, recipient, owner := addrs[0], addrs[1], addrs[2]
+		minerA := createMiner(t, v, owner).IDAddress
+		minerB := createMiner(t, v, owner).IDAddress
+		gasPrice := abi.NewTokenAmount(100)
+
+		// fund the reward actor to pay full block rewards
+		_, code := v.ApplyMessage(owner, builtin.RewardActorAddr, big.Mul(big.NewInt(1000), vm.FIL), builtin.MethodSend, nil)
+		require.Equal(t, exitcode.Ok, code)
+
+		var rewardSt reward.State
+		require.NoError(t, v.GetState(builtin.RewardActorAddr, &rewardSt))
+		blockReward := big.Div(rewardSt.ThisEpochReward, big.NewInt(builtin.ExpectedLeadersPerEpoch))
+		burntBalance := getActor(t, v, builtin.BurntFundsActorAddr).Balance
+
+		transfer := &vm.Message{From: sender, To: recipient, CallSeqNum: 0, Value: vm.FIL, Method: builtin.MethodSend,
+			GasLimit: 1_000_000, GasPrice: gasPrice}
+		replay := &vm.Message{From: sender, To: recipient, CallSeqNum: 0, Value: vm.FIL, Method: builtin.MethodSend,
+			GasLimit: 2_000_000, GasPrice: gasPrice}
+
+		v, receipts, err := v.ApplyTipset(10, []vm.BlockMessages{
+			{Miner: minerA, WinCount: 1, Messages: []*vm.Message{transfer}},
+			// the transfer is included again, along with a message re-using its sequence number
+			{Miner: minerB, WinCount: 1, Messages: []*vm.Message{transfer, replay}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, abi.ChainEpoch(10), v.GetEpoch())
+
+		require.Equal(t, 2, len(receipts))
+		assert.Equal(t, exitcode.Ok, receipts[0].ExitCode)
+		assert.Equal(t, exitcode.SysErrSenderStateInvalid, receipts[1].ExitCode)
+
+		// the transfer was applied once
+		gasCost := big.Mul(big.NewInt(receipts[0].GasUsed), gasPrice)
+		assert.Equal(t, big.Sub(big.Sub(balance, vm.FIL), gasCost), getActor(t, v, sender).Balance)
+		assert.Equal(t, big.Add(balance, vm.FIL), getActor(t, v, recipient).Balance)
+
+		// the first miner earns the gas, the second is penalized for including an invalid message
+		penalty := big.Mul(big.NewInt(replay.GasLimit), gasPrice)
+		assert.Equal(t, big.Add(blockReward, gasCost), getActor(t, v, minerA).Balance)
+		assert.Equal(t, big.Sub(blockReward, penalty), getActor(t, v, minerB).Balance)
+		assert.Equal(t, big.Add(burntBalance, penalty), getActor(t, v, builtin.BurntFundsActorAddr).Balance)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("ends with cron tick", func(t *testing.T) {
+		v := vm.NewVMWithSingletons(ctx, t)
+
+		v, receipts, err := v.ApplyTipset(10, nil)
+		require.NoError(t, err)
+		assert.Empty(t, receipts)
+
+		// cron updates the reward actor via the power actor, computing the reward for the following epoch
+		var rewardSt reward.State
+		require.NoError(t, v.GetState(builtin.RewardActorAddr, &rewardSt))
+		assert.Equal(t, abi.ChainEpoch(11), rewardSt.Epoch)
+		vm.AssertStateInvariants(t, v)
+	})
+
+	t.Run("tipset may not precede the current epoch", func(t *testing.T) {
+		v, err := vm.NewVMWithSingletons(ctx, t).WithEpoch(10)
+		require.NoError(t, err)
+
+		_, _, err = v.ApplyTipset(9, nil)
+		assert.Error(t, err)
+	})
+}
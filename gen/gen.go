@@ -28,6 +28,9 @@ func main() {
 		abi.SectorID{},
 		abi.SectorInfo{},
 		abi.SealVerifyInfo{},
+		abi.AggregateSealVerifyInfo{},
+		abi.AggregateSealVerifyProofAndInfos{},
+		abi.ReplicaUpdateInfo{},
 		abi.PoStProof{},
 		abi.WindowPoStVerifyInfo{},
 		abi.WinningPoStVerifyInfo{},
@@ -94,6 +97,8 @@ func main() {
 		multisig.State{},
 		multisig.Transaction{},
 		multisig.ProposalHashData{},
+		multisig.ExpiringProposalHashData{},
+		multisig.ExecutedTransaction{},
 		// method params
 		multisig.ConstructorParams{},
 		multisig.ProposeParams{},
@@ -102,6 +107,9 @@ func main() {
 		multisig.TxnIDParams{},
 		multisig.ChangeNumApprovalsThresholdParams{},
 		multisig.SwapSignerParams{},
+		multisig.LockBalanceParams{},
+		multisig.PruneExpiredTransactionsParams{},
+		multisig.SetExecutedHistoryLengthParams{},
 		// method returns
 		multisig.ApproveReturn{},
 		multisig.ProposeReturn{},
@@ -114,6 +122,7 @@ func main() {
 		paych.State{},
 		paych.LaneState{},
 		paych.Merge{},
+		paych.ChannelRecipient{},
 		// method params
 		paych.ConstructorParams{},
 		paych.UpdateChannelStateParams{},
@@ -150,16 +159,26 @@ func main() {
 		market.WithdrawBalanceParams{},
 		market.PublishStorageDealsParams{},
 		market.ActivateDealsParams{},
+		market.SectorDeals{},
 		market.VerifyDealsForActivationParams{},
+		market.SectorWeights{},
 		market.VerifyDealsForActivationReturn{},
 		market.ComputeDataCommitmentParams{},
 		market.OnMinerSectorsTerminateParams{},
+		market.CancelDealParams{},
+		market.ExtendDealsParams{},
+		market.SectorDealExtensions{},
 		// method returns
 		market.PublishStorageDealsReturn{},
+		market.DealRejection{},
+		market.ExtendDealsReturn{},
 		// other types
 		market.DealProposal{},
 		market.ClientDealProposal{},
 		market.DealState{},
+		market.DealCancellation{},
+		market.ClientDealExtension{},
+		market.DealExtension{},
 	); err != nil {
 		panic(err)
 	}
@@ -177,6 +196,8 @@ func main() {
 		miner.SectorPreCommitInfo{},
 		miner.SectorOnChainInfo{},
 		miner.WorkerKeyChange{},
+		miner.BeneficiaryTerm{},
+		miner.PendingBeneficiaryChange{},
 		miner.VestingFunds{},
 		miner.VestingFund{},
 		// method params
@@ -187,6 +208,8 @@ func main() {
 		miner.ChangePeerIDParams{},
 		miner.ChangeMultiaddrsParams{},
 		miner.ProveCommitSectorParams{},
+		miner.ProveCommitAggregateParams{},
+		miner.PreCommitSectorBatchParams{},
 		miner.ChangeWorkerAddressParams{},
 		miner.ExtendSectorExpirationParams{},
 		miner.DeclareFaultsParams{},
@@ -197,13 +220,19 @@ func main() {
 		miner.WithdrawBalanceParams{},
 		miner.CompactPartitionsParams{},
 		miner.CompactSectorNumbersParams{},
+		miner.ChangeBeneficiaryParams{},
+		miner.DisputeWindowedPoStParams{},
+		miner.ProveReplicaUpdatesParams{},
+		miner.RepayDebtReturn{},
 		// other types
 		miner.CronEventPayload{},
 		miner.FaultDeclaration{},
 		miner.RecoveryDeclaration{},
+		miner.ReplicaUpdate{},
 		miner.ExpirationExtension{},
 		miner.TerminationDeclaration{},
 		miner.PoStPartition{},
+		miner.WindowedPoSt{},
 	); err != nil {
 		panic(err)
 	}
@@ -216,7 +245,15 @@ func main() {
 		verifreg.AddVerifiedClientParams{},
 		verifreg.UseBytesParams{},
 		verifreg.RestoreBytesParams{},
+		verifreg.RemoveDataCapParams{},
+		verifreg.UpdateVerifierAllowanceParams{},
+		// method returns
+		verifreg.RemoveDataCapReturn{},
 		// other types
+		verifreg.RmDcProposalID{},
+		verifreg.RemoveDataCapRequest{},
+		verifreg.RemoveDataCapProposal{},
+		verifreg.AllowanceGrant{},
 	); err != nil {
 		panic(err)
 	}
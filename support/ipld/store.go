@@ -3,6 +3,7 @@ package ipld
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	block "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
@@ -11,15 +12,19 @@ import (
 	"github.com/filecoin-project/specs-actors/actors/util/adt"
 )
 
+// An in-memory block store, safe for concurrent use (e.g. by parallel state migrations).
 type BlockStoreInMemory struct {
+	lk   sync.RWMutex
 	data map[cid.Cid]block.Block
 }
 
 func NewBlockStoreInMemory() *BlockStoreInMemory {
-	return &BlockStoreInMemory{make(map[cid.Cid]block.Block)}
+	return &BlockStoreInMemory{data: make(map[cid.Cid]block.Block)}
 }
 
 func (mb *BlockStoreInMemory) Get(c cid.Cid) (block.Block, error) {
+	mb.lk.RLock()
+	defer mb.lk.RUnlock()
 	d, ok := mb.data[c]
 	if ok {
 		return d, nil
@@ -28,6 +33,8 @@ func (mb *BlockStoreInMemory) Get(c cid.Cid) (block.Block, error) {
 }
 
 func (mb *BlockStoreInMemory) Put(b block.Block) error {
+	mb.lk.Lock()
+	defer mb.lk.Unlock()
 	mb.data[b.Cid()] = b
 	return nil
 }
@@ -68,6 +68,8 @@ type Runtime struct {
 	expectVerifyConsensusFault     *expectVerifyConsensusFault
 	expectDeleteActor              *addr.Address
 	expectBatchVerifySeals         *expectBatchVerifySeals
+	expectAggregateVerifySeals     *expectAggregateVerifySeals
+	expectVerifyReplicaUpdates     []*expectVerifyReplicaUpdate
 
 	logs []string
 	// Gas charged explicitly through rt.ChargeGas. Note: most charges are implicit
@@ -80,6 +82,16 @@ type expectBatchVerifySeals struct {
 	err error
 }
 
+type expectAggregateVerifySeals struct {
+	in  abi.AggregateSealVerifyProofAndInfos
+	err error
+}
+
+type expectVerifyReplicaUpdate struct {
+	in  abi.ReplicaUpdateInfo
+	err error
+}
+
 type expectRandomness struct {
 	// Expected parameters.
 	tag     crypto.DomainSeparationTag
@@ -654,6 +666,40 @@ func (rt *Runtime) BatchVerifySeals(vis map[addr.Address][]abi.SealVerifyInfo) (
 	return nil, nil
 }
 
+func (rt *Runtime) VerifyAggregateSeals(agg abi.AggregateSealVerifyProofAndInfos) error {
+	exp := rt.expectAggregateVerifySeals
+	if exp != nil {
+		if !reflect.DeepEqual(exp.in, agg) {
+			rt.failTest("unexpected aggregate seal verification\n"+
+				"        : %v\n"+
+				"expected: %v",
+				agg, exp.in)
+		}
+		defer func() {
+			rt.expectAggregateVerifySeals = nil
+		}()
+		return exp.err
+	}
+	rt.failTestNow("unexpected syscall to verify aggregate seals with %v", agg)
+	return nil
+}
+
+func (rt *Runtime) VerifyReplicaUpdate(update abi.ReplicaUpdateInfo) error {
+	if len(rt.expectVerifyReplicaUpdates) == 0 {
+		rt.failTestNow("unexpected syscall to verify replica update with %v", update)
+	}
+
+	exp := rt.expectVerifyReplicaUpdates[0]
+	if !reflect.DeepEqual(exp.in, update) {
+		rt.failTest("unexpected replica update verification\n"+
+			"        : %v\n"+
+			"expected: %v",
+			update, exp.in)
+	}
+	rt.expectVerifyReplicaUpdates = rt.expectVerifyReplicaUpdates[1:]
+	return exp.err
+}
+
 func (rt *Runtime) VerifyPoSt(vi abi.WindowPoStVerifyInfo) error {
 	exp := rt.expectVerifyPoSt
 	if exp != nil {
@@ -873,6 +919,20 @@ func (rt *Runtime) ExpectComputeUnsealedSectorCID(reg abi.RegisteredSealProof, p
 	}
 }
 
+func (rt *Runtime) ExpectAggregateVerifySeals(agg abi.AggregateSealVerifyProofAndInfos, err error) {
+	rt.expectAggregateVerifySeals = &expectAggregateVerifySeals{
+		in:  agg,
+		err: err,
+	}
+}
+
+func (rt *Runtime) ExpectVerifyReplicaUpdate(update abi.ReplicaUpdateInfo, err error) {
+	rt.expectVerifyReplicaUpdates = append(rt.expectVerifyReplicaUpdates, &expectVerifyReplicaUpdate{
+		in:  update,
+		err: err,
+	})
+}
+
 func (rt *Runtime) ExpectVerifyPoSt(post abi.WindowPoStVerifyInfo, result error) {
 	rt.expectVerifyPoSt = &expectVerifyPoSt{
 		post:   post,
@@ -927,6 +987,12 @@ func (rt *Runtime) Verify() {
 	if rt.expectBatchVerifySeals != nil {
 		rt.failTest("missing expected batch verify seals with %v", rt.expectBatchVerifySeals)
 	}
+	if rt.expectAggregateVerifySeals != nil {
+		rt.failTest("missing expected aggregate verify seals with %v", rt.expectAggregateVerifySeals)
+	}
+	if len(rt.expectVerifyReplicaUpdates) > 0 {
+		rt.failTest("missing expected verify replica update with %v", rt.expectVerifyReplicaUpdates[0].in)
+	}
 
 	if rt.expectComputeUnsealedSectorCID != nil {
 		rt.failTest("missing expected ComputeUnsealedSectorCID with %v", rt.expectComputeUnsealedSectorCID)
@@ -954,6 +1020,8 @@ func (rt *Runtime) Reset() {
 	rt.expectVerifySigs = nil
 	rt.expectVerifySeal = nil
 	rt.expectBatchVerifySeals = nil
+	rt.expectAggregateVerifySeals = nil
+	rt.expectVerifyReplicaUpdates = nil
 	rt.expectComputeUnsealedSectorCID = nil
 }
 
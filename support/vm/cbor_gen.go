@@ -12,7 +12,7 @@ import (
 
 var _ = xerrors.Errorf
 
-var lengthBufTestActor = []byte{131}
+var lengthBufTestActor = []byte{132}
 
 func (t *TestActor) MarshalCBOR(w io.Writer) error {
 	if t == nil {
@@ -37,6 +37,12 @@ func (t *TestActor) MarshalCBOR(w io.Writer) error {
 		return xerrors.Errorf("failed to write cid field t.Code: %w", err)
 	}
 
+	// t.CallSeqNum (uint64) (uint64)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, w, cbg.MajUnsignedInt, uint64(t.CallSeqNum)); err != nil {
+		return err
+	}
+
 	// t.Balance (big.Int) (struct)
 	if err := t.Balance.MarshalCBOR(w); err != nil {
 		return err
@@ -58,7 +64,7 @@ func (t *TestActor) UnmarshalCBOR(r io.Reader) error {
 		return fmt.Errorf("cbor input should be of type array")
 	}
 
-	if extra != 3 {
+	if extra != 4 {
 		return fmt.Errorf("cbor input had wrong number of fields")
 	}
 
@@ -85,6 +91,20 @@ func (t *TestActor) UnmarshalCBOR(r io.Reader) error {
 
 		t.Code = c
 
+	}
+	// t.CallSeqNum (uint64) (uint64)
+
+	{
+
+		maj, extra, err = cbg.CborReadHeaderBuf(br, scratch)
+		if err != nil {
+			return err
+		}
+		if maj != cbg.MajUnsignedInt {
+			return fmt.Errorf("wrong type for uint64 field")
+		}
+		t.CallSeqNum = uint64(extra)
+
 	}
 	// t.Balance (big.Int) (struct)
 
@@ -0,0 +1,202 @@
+package vm_test
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+)
+
+// Default limit on the gas that may be consumed by a single top-level message.
+// This matches the block gas limit, so any message that would fit in a block will not run out of gas.
+const DefaultGasLimit = int64(10000000000)
+
+// A GasCharge is an amount of gas charged for some operation.
+// Compute gas accounts for execution, storage gas for bytes added to the state tree.
+type GasCharge struct {
+	Name    string
+	Compute int64
+	Storage int64
+}
+
+// Total gas of the charge.
+func (g GasCharge) Total() int64 {
+	return g.Compute + g.Storage
+}
+
+func (g GasCharge) String() string {
+	return fmt.Sprintf("%s(compute: %d, storage: %d)", g.Name, g.Compute, g.Storage)
+}
+
+func newGasCharge(name string, compute int64, storage int64) GasCharge {
+	return GasCharge{Name: name, Compute: compute, Storage: storage}
+}
+
+// Pricelist provides prices for operations in the VM.
+// Tests may install an alternative implementation to explore costs under a different schedule.
+type Pricelist interface {
+	// OnMethodInvocation returns the gas used when invoking a method, including for bare value transfers.
+	OnMethodInvocation(value abi.TokenAmount, methodNum abi.MethodNum) GasCharge
+
+	// OnIpldGet returns the gas used for loading an object of the given size from the store.
+	OnIpldGet(dataSize int) GasCharge
+	// OnIpldPut returns the gas used for storing an object of the given size.
+	OnIpldPut(dataSize int) GasCharge
+
+	// OnCreateActor returns the gas used for creating an actor.
+	OnCreateActor() GasCharge
+	// OnDeleteActor returns the gas used for deleting an actor.
+	OnDeleteActor() GasCharge
+
+	OnVerifySignature(sigType crypto.SigType, plainTextSize int) GasCharge
+	OnHashing(dataSize int) GasCharge
+	OnComputeUnsealedSectorCid(proofType abi.RegisteredSealProof, pieces []abi.PieceInfo) GasCharge
+	OnVerifySeal(info abi.SealVerifyInfo) GasCharge
+	OnBatchVerifySeals(infoCount int) GasCharge
+	OnVerifyAggregateSeals(aggregate abi.AggregateSealVerifyProofAndInfos) GasCharge
+	OnVerifyReplicaUpdate(update abi.ReplicaUpdateInfo) GasCharge
+	OnVerifyPost(info abi.WindowPoStVerifyInfo) GasCharge
+	OnVerifyConsensusFault() GasCharge
+}
+
+// The default price list.
+// Values are taken from the schedule of the reference node implementation at network version 0.
+var DefaultPricelist Pricelist = &pricelistV0{
+	storagePerByte: 1000,
+
+	sendBase:                29233,
+	sendTransferFunds:       27500,
+	sendTransferOnlyPremium: 159672,
+	sendInvokeMethod:        -5377,
+
+	ipldGetBase:    75242,
+	ipldPutBase:    84070,
+	ipldPutPerByte: 1,
+
+	createActorCompute: 1108454,
+	createActorStorage: 36 + 40,
+	deleteActor:        -(36 + 40),
+
+	verifySignature: map[crypto.SigType]int64{
+		crypto.SigTypeBLS:       16598605,
+		crypto.SigTypeSecp256k1: 1637292,
+	},
+
+	hashingBase:                  31355,
+	computeUnsealedSectorCidBase: 98647,
+	verifySealBase:               2000,
+	verifyAggregateSealBase:      449900,
+	verifyAggregateSealPer:       206000,
+	verifyReplicaUpdate:          36316136,
+	verifyPostBase:               123861062,
+	verifyPostPerSector:          9226981,
+	verifyConsensusFault:         495422,
+}
+
+// A price list that charges nothing, for tests that should be unaffected by gas.
+var ZeroPricelist Pricelist = &pricelistV0{
+	verifySignature: map[crypto.SigType]int64{},
+}
+
+type pricelistV0 struct {
+	// Multiplier converting bytes of state into storage gas.
+	storagePerByte int64
+
+	sendBase                int64
+	sendTransferFunds       int64
+	sendTransferOnlyPremium int64
+	sendInvokeMethod        int64
+
+	ipldGetBase    int64
+	ipldPutBase    int64
+	ipldPutPerByte int64
+
+	createActorCompute int64
+	createActorStorage int64
+	deleteActor        int64
+
+	verifySignature map[crypto.SigType]int64
+
+	hashingBase                  int64
+	computeUnsealedSectorCidBase int64
+	verifySealBase               int64
+	verifyAggregateSealBase      int64
+	verifyAggregateSealPer       int64
+	verifyReplicaUpdate          int64
+	verifyPostBase               int64
+	verifyPostPerSector          int64
+	verifyConsensusFault         int64
+}
+
+var _ Pricelist = (*pricelistV0)(nil)
+
+func (pl *pricelistV0) OnMethodInvocation(value abi.TokenAmount, methodNum abi.MethodNum) GasCharge {
+	ret := pl.sendBase
+	if !value.Nil() && value.GreaterThan(big.Zero()) {
+		ret += pl.sendTransferFunds
+		if methodNum == builtin.MethodSend {
+			// transfer only
+			ret += pl.sendTransferOnlyPremium
+		}
+	}
+	if methodNum != builtin.MethodSend {
+		ret += pl.sendInvokeMethod
+	}
+	return newGasCharge("OnMethodInvocation", ret, 0)
+}
+
+func (pl *pricelistV0) OnIpldGet(dataSize int) GasCharge {
+	return newGasCharge("OnIpldGet", pl.ipldGetBase, 0)
+}
+
+func (pl *pricelistV0) OnIpldPut(dataSize int) GasCharge {
+	return newGasCharge("OnIpldPut", pl.ipldPutBase, int64(dataSize)*pl.ipldPutPerByte*pl.storagePerByte)
+}
+
+func (pl *pricelistV0) OnCreateActor() GasCharge {
+	return newGasCharge("OnCreateActor", pl.createActorCompute, pl.createActorStorage*pl.storagePerByte)
+}
+
+func (pl *pricelistV0) OnDeleteActor() GasCharge {
+	return newGasCharge("OnDeleteActor", 0, pl.deleteActor*pl.storagePerByte)
+}
+
+func (pl *pricelistV0) OnVerifySignature(sigType crypto.SigType, plainTextSize int) GasCharge {
+	return newGasCharge("OnVerifySignature", pl.verifySignature[sigType], 0)
+}
+
+func (pl *pricelistV0) OnHashing(dataSize int) GasCharge {
+	return newGasCharge("OnHashing", pl.hashingBase, 0)
+}
+
+func (pl *pricelistV0) OnComputeUnsealedSectorCid(proofType abi.RegisteredSealProof, pieces []abi.PieceInfo) GasCharge {
+	return newGasCharge("OnComputeUnsealedSectorCid", pl.computeUnsealedSectorCidBase, 0)
+}
+
+func (pl *pricelistV0) OnVerifySeal(info abi.SealVerifyInfo) GasCharge {
+	// this is not used by the builtin actors, which verify seals in batches
+	return newGasCharge("OnVerifySeal", pl.verifySealBase, 0)
+}
+
+func (pl *pricelistV0) OnBatchVerifySeals(infoCount int) GasCharge {
+	// batch verification is invoked by the power actor's cron handler and is not charged per-seal
+	return newGasCharge("OnBatchVerifySeals", 0, 0)
+}
+
+func (pl *pricelistV0) OnVerifyAggregateSeals(aggregate abi.AggregateSealVerifyProofAndInfos) GasCharge {
+	return newGasCharge("OnVerifyAggregateSeals", pl.verifyAggregateSealBase+pl.verifyAggregateSealPer*int64(len(aggregate.Infos)), 0)
+}
+
+func (pl *pricelistV0) OnVerifyReplicaUpdate(update abi.ReplicaUpdateInfo) GasCharge {
+	return newGasCharge("OnVerifyReplicaUpdate", pl.verifyReplicaUpdate, 0)
+}
+
+func (pl *pricelistV0) OnVerifyPost(info abi.WindowPoStVerifyInfo) GasCharge {
+	return newGasCharge("OnVerifyPost", pl.verifyPostBase+pl.verifyPostPerSector*int64(len(info.ChallengedSectors)), 0)
+}
+
+func (pl *pricelistV0) OnVerifyConsensusFault() GasCharge {
+	return newGasCharge("OnVerifyConsensusFault", pl.verifyConsensusFault, 0)
+}
@@ -0,0 +1,160 @@
+package vm_test
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	"github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/builtin/verifreg"
+)
+
+// Within this code, Go errors are not expected, but are often converted to messages so that execution
+// can continue to find more errors rather than fail with no insight.
+// Only errors that are particularly troublesome to recover from should propagate as Go errors.
+
+// Checks the state invariants of every builtin actor with a checker in the VM's state tree,
+// then checks invariants that span actors.
+// Returns the accumulated invariant violations and the total balance of all actors.
+func CheckStateInvariants(v *VM) (*builtin.MessageAccumulator, abi.TokenAmount, error) {
+	acc := &builtin.MessageAccumulator{}
+	totalFIL := big.Zero()
+
+	var powerSummary *power.StateSummary
+	var marketSummary *market.StateSummary
+	minerSummaries := make(map[address.Address]*miner.StateSummary)
+
+	if err := v.ForEachActor(func(key address.Address, actor *TestActor) error {
+		acc := acc.WithPrefix("%v ", key) // Intentional shadow
+		if key.Protocol() != address.ID {
+			acc.Addf("unexpected address protocol in state tree root: %v", key)
+		}
+		totalFIL = big.Add(totalFIL, actor.Balance)
+
+		switch actor.Code {
+		case builtin.StoragePowerActorCodeID:
+			var st power.State
+			if err := v.store.Get(v.ctx, actor.Head, &st); err != nil {
+				return err
+			}
+			summary, msgs := power.CheckStateInvariants(&st, v.store, actor.Balance)
+			acc.WithPrefix("power: ").AddAll(msgs)
+			powerSummary = summary
+		case builtin.StorageMinerActorCodeID:
+			var st miner.State
+			if err := v.store.Get(v.ctx, actor.Head, &st); err != nil {
+				return err
+			}
+			summary, msgs := miner.CheckStateInvariants(&st, v.store, actor.Balance)
+			acc.WithPrefix("miner: ").AddAll(msgs)
+			minerSummaries[key] = summary
+		case builtin.StorageMarketActorCodeID:
+			var st market.State
+			if err := v.store.Get(v.ctx, actor.Head, &st); err != nil {
+				return err
+			}
+			summary, msgs := market.CheckStateInvariants(&st, v.store, actor.Balance)
+			acc.WithPrefix("market: ").AddAll(msgs)
+			marketSummary = summary
+		case builtin.MultisigActorCodeID:
+			var st multisig.State
+			if err := v.store.Get(v.ctx, actor.Head, &st); err != nil {
+				return err
+			}
+			_, msgs := multisig.CheckStateInvariants(&st, v.store, actor.Balance)
+			acc.WithPrefix("multisig: ").AddAll(msgs)
+		case builtin.VerifiedRegistryActorCodeID:
+			var st verifreg.State
+			if err := v.store.Get(v.ctx, actor.Head, &st); err != nil {
+				return err
+			}
+			_, msgs := verifreg.CheckStateInvariants(&st, v.store, actor.Balance)
+			acc.WithPrefix("verifreg: ").AddAll(msgs)
+		}
+		return nil
+	}); err != nil {
+		return nil, big.Zero(), err
+	}
+
+	//
+	// Perform cross-actor checks from state summaries here.
+	//
+
+	if powerSummary == nil {
+		acc.Add("power actor state not found")
+	} else {
+		checkMinersAgainstPower(acc, minerSummaries, powerSummary)
+	}
+	if marketSummary == nil {
+		acc.Add("market actor state not found")
+	} else {
+		checkDealsAgainstMiners(acc, v.GetEpoch(), minerSummaries, marketSummary)
+	}
+
+	return acc, totalFIL, nil
+}
+
+func checkMinersAgainstPower(acc *builtin.MessageAccumulator, minerSummaries map[address.Address]*miner.StateSummary, powerSummary *power.StateSummary) {
+	for addr, minerSummary := range minerSummaries { // nolint:nomaprange
+		// check claim
+		claim, ok := powerSummary.Claims[addr]
+		acc.Require(ok, "miner %v has no power claim", addr)
+		if ok {
+			claimPower := miner.NewPowerPair(claim.RawBytePower, claim.QualityAdjPower)
+			acc.Require(minerSummary.ActivePower.Equals(claimPower),
+				"miner %v computed active power %v does not match claim %v", addr, minerSummary.ActivePower, claimPower)
+		}
+	}
+
+	for addr := range powerSummary.Claims { // nolint:nomaprange
+		_, ok := minerSummaries[addr]
+		acc.Require(ok, "power claim for %v has no miner actor", addr)
+	}
+
+	for addr := range powerSummary.Crons { // nolint:nomaprange
+		_, ok := minerSummaries[addr]
+		acc.Require(ok, "power cron event for %v has no miner actor", addr)
+	}
+
+	for addr := range powerSummary.Proofs { // nolint:nomaprange
+		_, ok := minerSummaries[addr]
+		acc.Require(ok, "power proof validation for %v has no miner actor", addr)
+	}
+}
+
+func checkDealsAgainstMiners(acc *builtin.MessageAccumulator, currEpoch abi.ChainEpoch, minerSummaries map[address.Address]*miner.StateSummary, marketSummary *market.StateSummary) {
+	for dealID, deal := range marketSummary.Deals { // nolint:nomaprange
+		minerSummary, found := minerSummaries[deal.Provider]
+		acc.Require(found, "provider %v for deal %d not found among miners", deal.Provider, dealID)
+		if !found {
+			continue
+		}
+
+		// Deals that have started and not yet ended or been slashed must be in a sector of the provider.
+		if deal.SectorStartEpoch == -1 || deal.SlashEpoch != -1 || deal.EndEpoch <= currEpoch {
+			continue
+		}
+		sectorDeal, found := minerSummary.Deals[dealID]
+		acc.Require(found, "un-slashed active deal %d not referenced in a sector of provider %v", dealID, deal.Provider)
+		if !found {
+			continue
+		}
+		acc.Require(deal.SectorStartEpoch == sectorDeal.SectorStart,
+			"deal state start %d does not match sector start %d for deal %d", deal.SectorStartEpoch, sectorDeal.SectorStart, dealID)
+		acc.Require(deal.EndEpoch <= sectorDeal.SectorExpiration,
+			"deal end epoch %d is after sector expiration %d for deal %d", deal.EndEpoch, sectorDeal.SectorExpiration, dealID)
+	}
+}
+
+// Returns an error listing all accumulated messages, or nil if there are none.
+func InvariantErrors(acc *builtin.MessageAccumulator) error {
+	if acc.IsEmpty() {
+		return nil
+	}
+	return errors.Errorf("%d state invariants broken:\n%v", len(acc.Messages()), acc.Messages())
+}
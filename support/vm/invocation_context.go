@@ -43,6 +43,9 @@ type topLevelContext struct {
 	originatorStableAddress address.Address // Stable (public key) address of the top-level message sender.
 	originatorCallSeq       uint64          // Call sequence number of the top-level message.
 	newActorAddressCount    uint64          // Count of calls to NewActorAddress (mutable).
+	gasLimit                int64           // Gas limit of the top-level message.
+	gasUsed                 int64           // Gas charged so far (mutable).
+	outOfGas                bool            // Whether the gas limit has been exceeded (mutable).
 }
 
 func newInvocationContext(rt *VM, topLevel *topLevelContext, msg InternalMessage, fromActor *TestActor, emptyObject cid.Cid) invocationContext {
@@ -66,6 +69,7 @@ func (ic *invocationContext) Create(obj runtime.CBORMarshaler) {
 	if actr.Head.Defined() && !ic.emptyObject.Equals(actr.Head) {
 		ic.Abortf(exitcode.SysErrorIllegalActor, "failed to construct actor state: already initialized")
 	}
+	ic.chargeIpldPut(obj)
 	c, err := ic.rt.store.Put(ic.rt.ctx, obj)
 	if err != nil {
 		ic.Abortf(exitcode.ErrIllegalState, "failed to create actor state")
@@ -109,6 +113,7 @@ func (ic *invocationContext) loadState(obj runtime.CBORUnmarshaler) cid.Cid {
 	if err != nil {
 		panic(errors.Wrapf(err, "failed to load state for actor %s, CID %s", ic.msg.to, c))
 	}
+	ic.chargeIpldGet(obj)
 	return c
 }
 
@@ -138,7 +143,7 @@ var _ runtime.Runtime = (*invocationContext)(nil)
 
 // Store implements runtime.Runtime.
 func (ic *invocationContext) Store() runtime.Store {
-	return &storeWrapper{s: ic.rt.store, rt: ic.rt}
+	return &storeWrapper{s: ic.rt.store, ic: ic}
 }
 
 // Message implements runtime.InvocationContext.
@@ -170,12 +175,12 @@ func (ic *invocationContext) GetActorCodeCID(a address.Address) (cid.Cid, bool)
 	return entry.Code, true
 }
 
-func (ic *invocationContext) GetRandomnessFromBeacon(_ crypto.DomainSeparationTag, _ abi.ChainEpoch, _ []byte) abi.Randomness {
-	return []byte("not really random")
+func (ic *invocationContext) GetRandomnessFromBeacon(tag crypto.DomainSeparationTag, epoch abi.ChainEpoch, entropy []byte) abi.Randomness {
+	return ic.rt.randomness.GetRandomnessFromBeacon(tag, epoch, entropy)
 }
 
-func (ic *invocationContext) GetRandomnessFromTickets(_ crypto.DomainSeparationTag, _ abi.ChainEpoch, _ []byte) abi.Randomness {
-	return []byte("not really random")
+func (ic *invocationContext) GetRandomnessFromTickets(tag crypto.DomainSeparationTag, epoch abi.ChainEpoch, entropy []byte) abi.Randomness {
+	return ic.rt.randomness.GetRandomnessFromTickets(tag, epoch, entropy)
 }
 
 func (ic *invocationContext) ValidateImmediateCallerAcceptAny() {
@@ -279,6 +284,8 @@ func (ic *invocationContext) CreateActor(codeID cid.Cid, addr address.Address) {
 		ic.Abortf(exitcode.SysErrorIllegalArgument, "Actor address already exists")
 	}
 
+	ic.chargeGas(ic.rt.pricelist.OnCreateActor())
+
 	newActor := &TestActor{
 		Head:    ic.emptyObject,
 		Code:    codeID,
@@ -297,9 +304,11 @@ func (ic *invocationContext) DeleteActor(beneficiary address.Address) {
 		panic(err)
 	}
 	if !found {
-		ic.Abortf(exitcode.SysErrorIllegalActor, "delete non-existent actor %s", receiverActor)
+		ic.Abortf(exitcode.SysErrorIllegalActor, "delete non-existent actor %s", receiver)
 	}
 
+	ic.chargeGas(ic.rt.pricelist.OnDeleteActor())
+
 	// Transfer any remaining balance to the beneficiary.
 	// This looks like it could cause a problem with gas refund going to a non-existent actor, but the gas payer
 	// is always an account actor, which cannot be the receiver of this message.
@@ -320,8 +329,9 @@ func (ic *invocationContext) Context() context.Context {
 	return ic.rt.ctx
 }
 
-func (ic *invocationContext) ChargeGas(_ string, _ int64, _ int64) {
-	// no-op
+// ChargeGas charges the compute gas. Virtual gas is tracked by real VMs for pricing research only and is ignored.
+func (ic *invocationContext) ChargeGas(name string, compute int64, _ int64) {
+	ic.chargeGas(newGasCharge(name, compute, 0))
 }
 
 // Starts a new tracing span. The span must be End()ed explicitly, typically with a deferred invocation.
@@ -331,7 +341,10 @@ func (ic *invocationContext) StartSpan(_ string) runtime.TraceSpan {
 
 // Provides the system call interface.
 func (ic *invocationContext) Syscalls() runtime.Syscalls {
-	return fakeSyscalls{receiver: ic.msg.to, epoch: ic.rt.currentEpoch}
+	return &syscallsWrapper{
+		inner: ic.rt.syscalls(ic.rt, ic.msg.to),
+		ic:    ic,
+	}
 }
 
 // Note events that may make debugging easier
@@ -354,6 +367,67 @@ func (r returnWrapper) Into(o runtime.CBORUnmarshaler) error {
 	return o.UnmarshalCBOR(&b)
 }
 
+/////////////////////////////////////////////
+//          Gas charging syscalls
+/////////////////////////////////////////////
+
+// Charges gas for each syscall before delegating to an underlying implementation.
+type syscallsWrapper struct {
+	inner runtime.Syscalls
+	ic    *invocationContext
+}
+
+var _ runtime.Syscalls = (*syscallsWrapper)(nil)
+
+func (s *syscallsWrapper) VerifySignature(signature crypto.Signature, signer address.Address, plaintext []byte) error {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifySignature(signature.Type, len(plaintext)))
+	return s.inner.VerifySignature(signature, signer, plaintext)
+}
+
+func (s *syscallsWrapper) HashBlake2b(data []byte) [32]byte {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnHashing(len(data)))
+	return s.inner.HashBlake2b(data)
+}
+
+func (s *syscallsWrapper) ComputeUnsealedSectorCID(reg abi.RegisteredSealProof, pieces []abi.PieceInfo) (cid.Cid, error) {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnComputeUnsealedSectorCid(reg, pieces))
+	return s.inner.ComputeUnsealedSectorCID(reg, pieces)
+}
+
+func (s *syscallsWrapper) VerifySeal(vi abi.SealVerifyInfo) error {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifySeal(vi))
+	return s.inner.VerifySeal(vi)
+}
+
+func (s *syscallsWrapper) BatchVerifySeals(vis map[address.Address][]abi.SealVerifyInfo) (map[address.Address][]bool, error) {
+	count := 0
+	for _, infos := range vis { //nolint:nomaprange
+		count += len(infos)
+	}
+	s.ic.chargeGas(s.ic.rt.pricelist.OnBatchVerifySeals(count))
+	return s.inner.BatchVerifySeals(vis)
+}
+
+func (s *syscallsWrapper) VerifyAggregateSeals(aggregate abi.AggregateSealVerifyProofAndInfos) error {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifyAggregateSeals(aggregate))
+	return s.inner.VerifyAggregateSeals(aggregate)
+}
+
+func (s *syscallsWrapper) VerifyReplicaUpdate(update abi.ReplicaUpdateInfo) error {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifyReplicaUpdate(update))
+	return s.inner.VerifyReplicaUpdate(update)
+}
+
+func (s *syscallsWrapper) VerifyPoSt(vi abi.WindowPoStVerifyInfo) error {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifyPost(vi))
+	return s.inner.VerifyPoSt(vi)
+}
+
+func (s *syscallsWrapper) VerifyConsensusFault(h1, h2, extra []byte) (*runtime.ConsensusFault, error) {
+	s.ic.chargeGas(s.ic.rt.pricelist.OnVerifyConsensusFault())
+	return s.inner.VerifyConsensusFault(h1, h2, extra)
+}
+
 /////////////////////////////////////////////
 //          Fake syscalls
 /////////////////////////////////////////////
@@ -392,6 +466,14 @@ func (s fakeSyscalls) BatchVerifySeals(vi map[address.Address][]abi.SealVerifyIn
 	return res, nil
 }
 
+func (s fakeSyscalls) VerifyAggregateSeals(_ abi.AggregateSealVerifyProofAndInfos) error {
+	return nil
+}
+
+func (s fakeSyscalls) VerifyReplicaUpdate(_ abi.ReplicaUpdateInfo) error {
+	return nil
+}
+
 func (s fakeSyscalls) VerifyPoSt(_ abi.WindowPoStVerifyInfo) error {
 	return nil
 }
@@ -419,23 +501,64 @@ func (fts *fakeTraceSpan) End() {
 
 type storeWrapper struct {
 	s  adt.Store
-	rt *VM
+	ic *invocationContext
 }
 
 func (s storeWrapper) Get(c cid.Cid, o runtime.CBORUnmarshaler) bool {
-	err := s.s.Get(s.rt.ctx, c, o)
+	err := s.s.Get(s.ic.rt.ctx, c, o)
 	// assume all errors are not found errors (bad assumption, but ok for testing)
-	return err == nil
+	if err != nil {
+		return false
+	}
+	s.ic.chargeIpldGet(o)
+	return true
 }
 
 func (s storeWrapper) Put(x runtime.CBORMarshaler) cid.Cid {
-	c, err := s.s.Put(s.rt.ctx, x)
+	s.ic.chargeIpldPut(x)
+	c, err := s.s.Put(s.ic.rt.ctx, x)
 	if err != nil {
-		s.rt.Abortf(exitcode.ErrIllegalState, "could not put object in store")
+		s.ic.rt.Abortf(exitcode.ErrIllegalState, "could not put object in store")
 	}
 	return c
 }
 
+/////////////////////////////////////////////
+//          gas
+/////////////////////////////////////////////
+
+// Charges gas to the top-level message, aborting with SysErrOutOfGas if the gas limit is exceeded.
+func (ic *invocationContext) chargeGas(charge GasCharge) {
+	ic.topLevel.gasUsed += charge.Total()
+	if ic.topLevel.gasUsed > ic.topLevel.gasLimit {
+		ic.topLevel.gasUsed = ic.topLevel.gasLimit
+		ic.topLevel.outOfGas = true
+		ic.Abortf(exitcode.SysErrOutOfGas, "not enough gas for %s: limit %d", charge, ic.topLevel.gasLimit)
+	}
+}
+
+func (ic *invocationContext) chargeIpldGet(obj runtime.CBORUnmarshaler) {
+	ic.chargeGas(ic.rt.pricelist.OnIpldGet(serializedSize(obj)))
+}
+
+func (ic *invocationContext) chargeIpldPut(obj runtime.CBORMarshaler) {
+	ic.chargeGas(ic.rt.pricelist.OnIpldPut(serializedSize(obj)))
+}
+
+// Computes the size of an object's serialization, or zero if it cannot be serialized.
+// Objects loaded from the store are re-serialized since the raw block is not available.
+func serializedSize(obj interface{}) int {
+	m, ok := obj.(runtime.CBORMarshaler)
+	if !ok {
+		return 0
+	}
+	var buf bytes.Buffer
+	if err := m.MarshalCBOR(&buf); err != nil {
+		return 0
+	}
+	return buf.Len()
+}
+
 /////////////////////////////////////////////
 //          invocation
 /////////////////////////////////////////////
@@ -450,6 +573,7 @@ func (ic *invocationContext) invoke() (ret returnWrapper, errcode exitcode.ExitC
 	}
 
 	ic.rt.startInvocation(&ic.msg)
+	gasStart := ic.topLevel.gasUsed
 
 	// Install handler for abort, which rolls back all state changes from this and any nested invocations.
 	// This is the only path by which a non-OK exit code may be returned.
@@ -462,7 +586,7 @@ func (ic *invocationContext) invoke() (ret returnWrapper, errcode exitcode.ExitC
 			case abort:
 				ic.rt.Log(runtime.WARN, "Abort during actor execution. errMsg: %v exitCode: %d sender: %v receiver; %v method: %d value %v",
 					r, r.code, ic.msg.from, ic.msg.to, ic.msg.method, ic.msg.value)
-				ic.rt.endInvocation(r.code, adt.Empty)
+				ic.rt.endInvocation(r.code, adt.Empty, ic.topLevel.gasUsed-gasStart)
 				ret = returnWrapper{adt.Empty} // The Empty here should never be used, but slightly safer than zero value.
 				errcode = r.code
 				return
@@ -474,6 +598,8 @@ func (ic *invocationContext) invoke() (ret returnWrapper, errcode exitcode.ExitC
 		}
 	}()
 
+	ic.chargeGas(ic.rt.pricelist.OnMethodInvocation(ic.msg.value, ic.msg.method))
+
 	// pre-dispatch
 	// 1. load target actor
 	// 2. transfer optional funds
@@ -504,7 +630,7 @@ func (ic *invocationContext) invoke() (ret returnWrapper, errcode exitcode.ExitC
 
 	// 4. if we are just sending funds, there is nothing else to do.
 	if ic.msg.method == builtin.MethodSend {
-		ic.rt.endInvocation(exitcode.Ok, adt.Empty)
+		ic.rt.endInvocation(exitcode.Ok, adt.Empty, ic.topLevel.gasUsed-gasStart)
 		return returnWrapper{adt.Empty}, exitcode.Ok
 	}
 
@@ -529,7 +655,7 @@ func (ic *invocationContext) invoke() (ret returnWrapper, errcode exitcode.ExitC
 	ret = returnWrapper{inner: marsh}
 
 	// 3. success!
-	ic.rt.endInvocation(exitcode.Ok, marsh)
+	ic.rt.endInvocation(exitcode.Ok, marsh, ic.topLevel.gasUsed-gasStart)
 	return ret, exitcode.Ok
 }
 
@@ -689,6 +815,7 @@ func (ic *invocationContext) replace(obj runtime.CBORMarshaler) cid.Cid {
 	if !found {
 		ic.rt.Abortf(exitcode.ErrIllegalState, "failed to find actor %s for state", ic.msg.to)
 	}
+	ic.chargeIpldPut(obj)
 	c, err := ic.rt.store.Put(ic.rt.ctx, obj)
 	if err != nil {
 		ic.rt.Abortf(exitcode.ErrIllegalState, "could not save new state")
@@ -0,0 +1,62 @@
+package vm_test
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+
+	"github.com/filecoin-project/specs-actors/actors/migration"
+)
+
+// MigrateState applies state migrations to the VM's state tree and commits the result.
+// If the migration fails, the state tree is left unchanged.
+func (vm *VM) MigrateState(ctx context.Context, migrations map[cid.Cid]migration.ActorMigration, cfg migration.Config) error {
+	priorRoot, err := vm.checkpoint()
+	if err != nil {
+		return err
+	}
+
+	if err := migration.MigrateStateTree(ctx, vm.store, vmStateTree{vm}, migrations, cfg); err != nil {
+		if rbErr := vm.rollback(priorRoot); rbErr != nil {
+			panic(rbErr)
+		}
+		return err
+	}
+
+	_, err = vm.checkpoint()
+	return err
+}
+
+// Adapts the VM's actors to the state tree interface used by migrations.
+type vmStateTree struct {
+	vm *VM
+}
+
+var _ migration.StateTree = vmStateTree{}
+
+func (t vmStateTree) ForEachActor(f func(a address.Address, act *migration.Actor) error) error {
+	return t.vm.ForEachActor(func(a address.Address, act *TestActor) error {
+		return f(a, &migration.Actor{Code: act.Code, Head: act.Head, Balance: act.Balance})
+	})
+}
+
+func (t vmStateTree) GetActor(a address.Address) (*migration.Actor, bool, error) {
+	act, found, err := t.vm.GetActor(a)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	return &migration.Actor{Code: act.Code, Head: act.Head, Balance: act.Balance}, true, nil
+}
+
+func (t vmStateTree) SetActor(a address.Address, act *migration.Actor) error {
+	// Retain any fields of an existing actor that migrations don't see.
+	testActor, _, err := t.vm.GetActor(a)
+	if err != nil {
+		return err
+	}
+	testActor.Code = act.Code
+	testActor.Head = act.Head
+	testActor.Balance = act.Balance
+	return t.vm.setActor(t.vm.ctx, a, testActor)
+}
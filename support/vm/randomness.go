@@ -0,0 +1,69 @@
+package vm_test
+
+import (
+	"encoding/binary"
+
+	"github.com/minio/blake2b-simd"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+)
+
+// Randomness is a deterministic source of ticket and beacon randomness for the VM.
+// Values are derived from a seed together with the domain separation tag, epoch and entropy of each request,
+// unless a specific value has been scripted for the requested epoch.
+type Randomness struct {
+	seed    int64
+	tickets map[abi.ChainEpoch]abi.Randomness
+	beacon  map[abi.ChainEpoch]abi.Randomness
+}
+
+// Distinguishes ticket from beacon randomness in derived values.
+const (
+	randomnessKindTickets = byte(iota)
+	randomnessKindBeacon
+)
+
+func NewRandomness(seed int64) *Randomness {
+	return &Randomness{
+		seed:    seed,
+		tickets: map[abi.ChainEpoch]abi.Randomness{},
+		beacon:  map[abi.ChainEpoch]abi.Randomness{},
+	}
+}
+
+// Scripts the ticket randomness returned for an epoch, regardless of tag or entropy.
+func (r *Randomness) SetTicketRandomness(epoch abi.ChainEpoch, value abi.Randomness) {
+	r.tickets[epoch] = value
+}
+
+// Scripts the beacon randomness returned for an epoch, regardless of tag or entropy.
+func (r *Randomness) SetBeaconRandomness(epoch abi.ChainEpoch, value abi.Randomness) {
+	r.beacon[epoch] = value
+}
+
+func (r *Randomness) GetRandomnessFromTickets(tag crypto.DomainSeparationTag, epoch abi.ChainEpoch, entropy []byte) abi.Randomness {
+	if value, ok := r.tickets[epoch]; ok {
+		return value
+	}
+	return r.derive(randomnessKindTickets, tag, epoch, entropy)
+}
+
+func (r *Randomness) GetRandomnessFromBeacon(tag crypto.DomainSeparationTag, epoch abi.ChainEpoch, entropy []byte) abi.Randomness {
+	if value, ok := r.beacon[epoch]; ok {
+		return value
+	}
+	return r.derive(randomnessKindBeacon, tag, epoch, entropy)
+}
+
+func (r *Randomness) derive(kind byte, tag crypto.DomainSeparationTag, epoch abi.ChainEpoch, entropy []byte) abi.Randomness {
+	buf := make([]byte, 1+3*8, 1+3*8+len(entropy))
+	buf[0] = kind
+	binary.BigEndian.PutUint64(buf[1:], uint64(r.seed))
+	binary.BigEndian.PutUint64(buf[9:], uint64(tag))
+	binary.BigEndian.PutUint64(buf[17:], uint64(epoch))
+	buf = append(buf, entropy...)
+
+	digest := blake2b.Sum256(buf)
+	return digest[:]
+}
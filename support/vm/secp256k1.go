@@ -0,0 +1,267 @@
+package vm_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/minio/blake2b-simd"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+)
+
+// A minimal, pure-Go secp256k1 implementation sufficient to sign and verify messages the way Filecoin does:
+// recoverable ECDSA signatures over the blake2b-256 digest of the message.
+// It is neither constant-time nor fast, and must only be used for testing.
+
+type secpPoint struct {
+	x, y *big.Int
+}
+
+var secpP = hexInt("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f")
+var secpN = hexInt("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+var secpG = &secpPoint{
+	x: hexInt("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+	y: hexInt("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+}
+
+const secpSignatureLength = 65
+
+func hexInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex integer " + s)
+	}
+	return i
+}
+
+// Writes the big-endian bytes of a non-negative integer into buf, zero-padding on the left.
+func fillBytes(i *big.Int, buf []byte) {
+	b := i.Bytes()
+	copy(buf[len(buf)-len(b):], b)
+}
+
+// Adds two points. A nil point is the point at infinity.
+func secpAdd(a, b *secpPoint) *secpPoint {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return nil
+		}
+		// lambda = 3x^2 / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	} else {
+		// lambda = (by - ay) / (bx - ax)
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, secpP)
+		lambda = num.Mul(num, den.ModInverse(den, secpP))
+	}
+	lambda.Mod(lambda, secpP)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x)
+	x.Sub(x, b.x)
+	x.Mod(x, secpP)
+
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda)
+	y.Sub(y, a.y)
+	y.Mod(y, secpP)
+	return &secpPoint{x: x, y: y}
+}
+
+func secpMul(k *big.Int, pt *secpPoint) *secpPoint {
+	var acc *secpPoint
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		acc = secpAdd(acc, acc)
+		if k.Bit(i) == 1 {
+			acc = secpAdd(acc, pt)
+		}
+	}
+	return acc
+}
+
+// Serializes a point as an uncompressed public key.
+func (pt *secpPoint) bytes() []byte {
+	out := make([]byte, 65)
+	out[0] = 4
+	fillBytes(pt.x, out[1:33])
+	fillBytes(pt.y, out[33:])
+	return out
+}
+
+// A secp256k1 private key for signing messages in tests.
+type SecpKey struct {
+	d   *big.Int
+	pub *secpPoint
+}
+
+// Derives a private key deterministically from a seed.
+func NewSecpKey(seed int64) *SecpKey {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(seed))
+	for ctr := byte(0); ; ctr++ {
+		h := blake2b.Sum256(append(buf[:], ctr))
+		d := new(big.Int).SetBytes(h[:])
+		if d.Sign() > 0 && d.Cmp(secpN) < 0 {
+			return &SecpKey{d: d, pub: secpMul(d, secpG)}
+		}
+	}
+}
+
+// The uncompressed public key.
+func (k *SecpKey) PublicKey() []byte {
+	return k.pub.bytes()
+}
+
+// The secp256k1 address of the key.
+func (k *SecpKey) Address() address.Address {
+	addr, err := address.NewSecp256k1Address(k.PublicKey())
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+// Generates successive RFC 6979 nonce candidates for a key and digest, as libsecp256k1 does.
+type rfc6979 struct {
+	k, v []byte
+}
+
+func newRFC6979(key, digest []byte) *rfc6979 {
+	g := &rfc6979{k: make([]byte, 32), v: make([]byte, 32)}
+	for i := range g.v {
+		g.v[i] = 1
+	}
+	for _, sep := range []byte{0, 1} {
+		g.k = g.mac(g.v, []byte{sep}, key, digest)
+		g.v = g.mac(g.v)
+	}
+	return g
+}
+
+func (g *rfc6979) mac(data ...[]byte) []byte {
+	h := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func (g *rfc6979) next(retry bool) *big.Int {
+	if retry {
+		g.k = g.mac(g.v, []byte{0})
+		g.v = g.mac(g.v)
+	}
+	g.v = g.mac(g.v)
+	return new(big.Int).SetBytes(g.v)
+}
+
+// Signs the blake2b-256 digest of a message, producing a recoverable signature.
+// Signatures are identical to those of libsecp256k1, as used by Filecoin nodes.
+func (k *SecpKey) Sign(msg []byte) crypto.Signature {
+	digest := blake2b.Sum256(msg)
+	z := new(big.Int).SetBytes(digest[:])
+
+	var dBytes [32]byte
+	fillBytes(k.d, dBytes[:])
+	nonces := newRFC6979(dBytes[:], digest[:])
+	for retry := false; ; retry = true {
+		nonce := nonces.next(retry)
+		if nonce.Sign() == 0 || nonce.Cmp(secpN) >= 0 {
+			continue
+		}
+
+		R := secpMul(nonce, secpG)
+		r := new(big.Int).Mod(R.x, secpN)
+		if r.Sign() == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, k.d)
+		s.Add(s, z)
+		s.Mul(s, new(big.Int).ModInverse(nonce, secpN))
+		s.Mod(s, secpN)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		v := byte(R.y.Bit(0))
+		if R.x.Cmp(secpN) >= 0 {
+			v |= 2
+		}
+		// Use the low-s form, flipping the parity of R to match.
+		if s.Cmp(new(big.Int).Rsh(secpN, 1)) > 0 {
+			s.Sub(secpN, s)
+			v ^= 1
+		}
+
+		data := make([]byte, secpSignatureLength)
+		fillBytes(r, data[:32])
+		fillBytes(s, data[32:64])
+		data[64] = v
+		return crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: data}
+	}
+}
+
+// Recovers the uncompressed public key which produced a signature over the blake2b-256 digest of a message.
+func secpRecover(sig []byte, msg []byte) ([]byte, error) {
+	if len(sig) != secpSignatureLength {
+		return nil, xerrors.Errorf("invalid signature length %d", len(sig))
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	v := sig[64]
+	if r.Sign() == 0 || r.Cmp(secpN) >= 0 || s.Sign() == 0 || s.Cmp(secpN) >= 0 || v > 3 {
+		return nil, xerrors.New("invalid signature values")
+	}
+
+	// Reconstruct R from its x coordinate and the parity of its y coordinate.
+	x := new(big.Int).Set(r)
+	if v&2 != 0 {
+		x.Add(x, secpN)
+		if x.Cmp(secpP) >= 0 {
+			return nil, xerrors.New("invalid signature point")
+		}
+	}
+	ySq := new(big.Int).Exp(x, big.NewInt(3), secpP)
+	ySq.Add(ySq, big.NewInt(7))
+	ySq.Mod(ySq, secpP)
+	exp := new(big.Int).Add(secpP, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(ySq, exp, secpP)
+	if new(big.Int).Exp(y, big.NewInt(2), secpP).Cmp(ySq) != 0 {
+		return nil, xerrors.New("signature point not on curve")
+	}
+	if y.Bit(0) != uint(v&1) {
+		y.Sub(secpP, y)
+	}
+	R := &secpPoint{x: x, y: y}
+
+	// Q = r^-1 (sR - zG)
+	digest := blake2b.Sum256(msg)
+	z := new(big.Int).SetBytes(digest[:])
+	negZ := new(big.Int).Sub(secpN, z.Mod(z, secpN))
+	rInv := new(big.Int).ModInverse(r, secpN)
+	u1 := new(big.Int).Mul(negZ, rInv)
+	u1.Mod(u1, secpN)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, secpN)
+	Q := secpAdd(secpMul(u1, secpG), secpMul(u2, R))
+	if Q == nil {
+		return nil, xerrors.New("recovered point at infinity")
+	}
+	return Q.bytes(), nil
+}
@@ -0,0 +1,195 @@
+package vm_test
+
+import (
+	"math/bits"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+	"github.com/minio/blake2b-simd"
+	"github.com/minio/sha256-simd"
+	mh "github.com/multiformats/go-multihash"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/account"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/filecoin-project/specs-actors/actors/runtime"
+)
+
+// SyscallsFactory constructs the syscalls provided to the actor receiving a message.
+type SyscallsFactory func(vm *VM, receiver address.Address) runtime.Syscalls
+
+// FakeSyscalls provides syscalls that succeed without doing any work. This is the default for a new VM.
+func FakeSyscalls(vm *VM, receiver address.Address) runtime.Syscalls {
+	return fakeSyscalls{receiver: receiver, epoch: vm.currentEpoch}
+}
+
+// CryptoSyscalls provides syscalls that really compute blake2b hashes and unsealed sector CIDs,
+// and verify secp256k1 signatures. BLS signatures are not supported.
+// Proof and consensus fault verification is faked, as for FakeSyscalls.
+func CryptoSyscalls(vm *VM, receiver address.Address) runtime.Syscalls {
+	return cryptoSyscalls{
+		fakeSyscalls: fakeSyscalls{receiver: receiver, epoch: vm.currentEpoch},
+		vm:           vm,
+	}
+}
+
+type cryptoSyscalls struct {
+	fakeSyscalls
+	vm *VM
+}
+
+func (s cryptoSyscalls) VerifySignature(signature crypto.Signature, signer address.Address, plaintext []byte) error {
+	signerKey, err := s.resolveKeyAddress(signer)
+	if err != nil {
+		return err
+	}
+
+	switch signature.Type {
+	case crypto.SigTypeSecp256k1:
+		if signerKey.Protocol() != address.SECP256K1 {
+			return xerrors.Errorf("secp256k1 signature for %v with %v key address", signer, signerKey)
+		}
+		pubkey, err := secpRecover(signature.Data, plaintext)
+		if err != nil {
+			return xerrors.Errorf("failed to recover secp256k1 public key: %w", err)
+		}
+		recovered, err := address.NewSecp256k1Address(pubkey)
+		if err != nil {
+			return err
+		}
+		if recovered != signerKey {
+			return xerrors.Errorf("signature signed by %v, not %v", recovered, signerKey)
+		}
+		return nil
+	case crypto.SigTypeBLS:
+		return xerrors.Errorf("BLS signature verification is not supported")
+	default:
+		return xerrors.Errorf("unknown signature type %d", signature.Type)
+	}
+}
+
+// Resolves an ID address to the key address of the account actor it identifies.
+func (s cryptoSyscalls) resolveKeyAddress(a address.Address) (address.Address, error) {
+	if a.Protocol() != address.ID {
+		return a, nil
+	}
+	act, found, err := s.vm.GetActor(a)
+	if err != nil {
+		return address.Undef, err
+	}
+	if !found {
+		return address.Undef, xerrors.Errorf("signer %v not found", a)
+	}
+	if act.Code != builtin.AccountActorCodeID {
+		return address.Undef, xerrors.Errorf("signer %v is not an account actor", a)
+	}
+	var st account.State
+	if err := s.vm.store.Get(s.vm.ctx, act.Head, &st); err != nil {
+		return address.Undef, err
+	}
+	return st.Address, nil
+}
+
+func (s cryptoSyscalls) HashBlake2b(data []byte) [32]byte {
+	return blake2b.Sum256(data)
+}
+
+// Computes the root of the binary merkle tree of the pieces, padded with zero pieces to fill the sector.
+func (s cryptoSyscalls) ComputeUnsealedSectorCID(proof abi.RegisteredSealProof, pieces []abi.PieceInfo) (cid.Cid, error) {
+	ssize, err := proof.SectorSize()
+	if err != nil {
+		return cid.Undef, err
+	}
+	sectorSize := abi.PaddedPieceSize(ssize)
+
+	type node struct {
+		size abi.PaddedPieceSize
+		comm []byte
+	}
+	var stack []node
+	var offset abi.PaddedPieceSize
+	push := func(size abi.PaddedPieceSize, comm []byte) {
+		stack = append(stack, node{size, comm})
+		offset += size
+		// Combine sibling subtrees as soon as both are complete.
+		for len(stack) >= 2 && stack[len(stack)-1].size == stack[len(stack)-2].size {
+			left, right := stack[len(stack)-2], stack[len(stack)-1]
+			stack = append(stack[:len(stack)-2], node{left.size * 2, pieceNodeHash(left.comm, right.comm)})
+		}
+	}
+	// Pads with zero pieces, smallest first, until the offset is aligned to a boundary of the given size.
+	padTo := func(size abi.PaddedPieceSize) {
+		toFill := uint64(-offset % size)
+		for toFill > 0 {
+			padSize := abi.PaddedPieceSize(1) << uint(bits.TrailingZeros64(toFill))
+			toFill ^= uint64(padSize)
+			push(padSize, zeroPieceCommitment(padSize))
+		}
+	}
+
+	for _, p := range pieces {
+		if err := p.Size.Validate(); err != nil {
+			return cid.Undef, xerrors.Errorf("invalid piece size: %w", err)
+		}
+		comm, err := pieceCommitment(p.PieceCID)
+		if err != nil {
+			return cid.Undef, err
+		}
+		padTo(p.Size)
+		push(p.Size, comm)
+		if offset > sectorSize {
+			return cid.Undef, xerrors.Errorf("pieces of total size %d exceed sector size %d", offset, sectorSize)
+		}
+	}
+	if len(pieces) == 0 {
+		push(sectorSize, zeroPieceCommitment(sectorSize))
+	}
+	padTo(sectorSize)
+
+	if len(stack) != 1 || stack[0].size != sectorSize {
+		return cid.Undef, xerrors.Errorf("pieces do not form a complete sector tree")
+	}
+	digest, err := mh.Encode(stack[0].comm, market.PieceCIDPrefix.MhType)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return cid.NewCidV1(market.PieceCIDPrefix.Codec, digest), nil
+}
+
+// Extracts the raw commitment from a piece CID.
+func pieceCommitment(c cid.Cid) ([]byte, error) {
+	prefix := c.Prefix()
+	if prefix.Codec != market.PieceCIDPrefix.Codec || prefix.MhType != market.PieceCIDPrefix.MhType {
+		return nil, xerrors.Errorf("invalid piece CID prefix %v", prefix)
+	}
+	decoded, err := mh.Decode(c.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded.Digest) != 32 {
+		return nil, xerrors.Errorf("invalid piece commitment length %d", len(decoded.Digest))
+	}
+	return decoded.Digest, nil
+}
+
+// The commitment to a padded piece of all zeros of the given size.
+func zeroPieceCommitment(size abi.PaddedPieceSize) []byte {
+	comm := make([]byte, 32)
+	for s := abi.PaddedPieceSize(32); s < size; s *= 2 {
+		comm = pieceNodeHash(comm, comm)
+	}
+	return comm
+}
+
+// Hashes two child nodes with sha256, truncated to fit a BLS12-381 field element.
+func pieceNodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write(left)
+	_, _ = h.Write(right)
+	out := h.Sum(nil)
+	out[31] &= 0x3f
+	return out
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/filecoin-project/specs-actors/actors/abi"
@@ -84,7 +85,7 @@ func NewVMWithSingletons(ctx context.Context, t *testing.T) *VM {
 
 	// this will need to be replaced with the address of a multisig actor for the verified registry to be tested accurately
 	initializeActor(ctx, t, vm, &account.State{Address: VerifregRoot}, builtin.AccountActorCodeID, VerifregRoot, big.Zero())
-	vrState := verifreg.ConstructState(emptyMapCID, VerifregRoot)
+	vrState := verifreg.ConstructState(emptyMapCID, emptyArrayCID, VerifregRoot)
 	initializeActor(ctx, t, vm, vrState, builtin.VerifiedRegistryActorCodeID, builtin.VerifiedRegistryActorAddr, big.Zero())
 
 	// burnt funds
@@ -98,13 +99,23 @@ func NewVMWithSingletons(ctx context.Context, t *testing.T) *VM {
 
 // Creates n account actors in the VM with the given balance
 func CreateAccounts(ctx context.Context, t *testing.T, vm *VM, n int, balance abi.TokenAmount, seed int64) []address.Address {
+	keyAddrs := make([]address.Address, n)
+	for i := range keyAddrs {
+		keyAddrs[i] = actor_testing.NewBLSAddr(t, seed+int64(i))
+	}
+	CreateAccountsForKeys(ctx, t, vm, balance, keyAddrs...)
+	return keyAddrs
+}
+
+// Creates an account actor in the VM for each key address, with the given balance.
+// Use with secp256k1 key addresses from SecpKey to sign messages which CryptoSyscalls will verify.
+func CreateAccountsForKeys(ctx context.Context, t *testing.T, vm *VM, balance abi.TokenAmount, keyAddrs ...address.Address) {
 	var initState initactor.State
 	err := vm.GetState(builtin.InitActorAddr, &initState)
 	require.NoError(t, err)
 
-	addrPairs := make([]addrPair, n)
-	for i := range addrPairs {
-		addr := actor_testing.NewBLSAddr(t, seed+int64(i))
+	addrPairs := make([]addrPair, len(keyAddrs))
+	for i, addr := range keyAddrs {
 		idAddr, err := initState.MapAddressToNewID(vm.store, addr)
 		require.NoError(t, err)
 
@@ -116,13 +127,10 @@ func CreateAccounts(ctx context.Context, t *testing.T, vm *VM, n int, balance ab
 	err = vm.setActorState(ctx, builtin.InitActorAddr, &initState)
 	require.NoError(t, err)
 
-	pubAddrs := make([]address.Address, len(addrPairs))
-	for i, addrPair := range addrPairs {
+	for _, addrPair := range addrPairs {
 		st := &account.State{Address: addrPair.pubAddr}
 		initializeActor(ctx, t, vm, st, builtin.AccountActorCodeID, addrPair.idAddr, balance)
-		pubAddrs[i] = addrPair.pubAddr
 	}
-	return pubAddrs
 }
 
 //
@@ -282,12 +290,9 @@ func AdvanceByDeadline(t *testing.T, v *VM, minerIDAddr address.Address, predica
 	dlInfo := MinerDLInfo(t, v, minerIDAddr)
 	var err error
 	for predicate(dlInfo) {
-		v, err = v.WithEpoch(dlInfo.Last())
+		v, _, err = v.ApplyTipset(dlInfo.Last(), nil)
 		require.NoError(t, err)
 
-		_, code := v.ApplyMessage(builtin.SystemActorAddr, builtin.CronActorAddr, big.Zero(), builtin.MethodsCron.EpochTick, nil)
-		require.Equal(t, exitcode.Ok, code)
-
 		dlInfo = MinerDLInfo(t, v, minerIDAddr)
 	}
 	return v, dlInfo
@@ -331,6 +336,13 @@ func AdvanceTillProvingDeadline(t *testing.T, v *VM, minerIDAddress address.Addr
 // state abstraction
 //
 
+// Checks all state invariants of the VM's state tree and fails the test if any are broken.
+func AssertStateInvariants(t *testing.T, v *VM) {
+	msgs, _, err := CheckStateInvariants(v)
+	require.NoError(t, err)
+	assert.True(t, msgs.IsEmpty(), strings.Join(msgs.Messages(), "\n"))
+}
+
 type MinerBalances struct {
 	AvailableBalance abi.TokenAmount
 	VestingBalance   abi.TokenAmount
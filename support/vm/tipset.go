@@ -0,0 +1,123 @@
+package vm_test
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/pkg/errors"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	"github.com/filecoin-project/specs-actors/actors/runtime"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+)
+
+// Gas limit for messages sent implicitly by the system actor, which are not constrained by the block gas limit.
+const implicitMessageGasLimit = DefaultGasLimit * 10000
+
+// BlockMessages are the messages included in a single block of a tipset, together with the block's miner.
+type BlockMessages struct {
+	Miner    address.Address
+	WinCount int64 // Number of reward units won by the block, which must be positive.
+	Messages []*Message
+}
+
+// ApplyTipset creates a new VM at the given epoch and applies the blocks of a tipset to it, as a node would.
+// Messages are applied in block order. A message included in more than one block is applied only once, and
+// accounted to the first block that includes it.
+// After each block's messages, the block's miner is awarded the block reward together with the gas paid by its
+// messages, less a penalty for each message that failed sender validation.
+// Finally, the cron actor's epoch tick is invoked.
+// Returns the new VM and a receipt for each distinct message, in the order applied.
+// Failure of the implicit reward or cron messages is an error, since a node could not proceed.
+func (vm *VM) ApplyTipset(epoch abi.ChainEpoch, blocks []BlockMessages) (*VM, []MessageReceipt, error) {
+	if epoch < vm.currentEpoch {
+		return nil, nil, errors.Errorf("tipset epoch %d before current epoch %d", epoch, vm.currentEpoch)
+	}
+	next, err := vm.WithEpoch(epoch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var receipts []MessageReceipt
+	applied := map[string]struct{}{}
+	for _, block := range blocks {
+		penalty := big.Zero()
+		gasReward := big.Zero()
+		for _, msg := range block.Messages {
+			id, err := msg.identity()
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, ok := applied[id]; ok {
+				continue
+			}
+			applied[id] = struct{}{}
+
+			receipt := next.ApplyChainMessage(msg)
+			receipts = append(receipts, receipt)
+			if receipt.ExitCode == exitcode.SysErrSenderInvalid || receipt.ExitCode == exitcode.SysErrSenderStateInvalid {
+				// The miner pays for including a message that could not be applied.
+				penalty = big.Add(penalty, big.Mul(big.NewInt(msg.GasLimit), msg.gasPrice()))
+			} else {
+				gasReward = big.Add(gasReward, big.Mul(big.NewInt(receipt.GasUsed), msg.gasPrice()))
+			}
+		}
+
+		receipt := next.applyImplicitMessage(builtin.RewardActorAddr, builtin.MethodsReward.AwardBlockReward, &reward.AwardBlockRewardParams{
+			Miner:     block.Miner,
+			Penalty:   penalty,
+			GasReward: gasReward,
+			WinCount:  block.WinCount,
+		})
+		if receipt.ExitCode != exitcode.Ok {
+			return nil, nil, errors.Errorf("failed to award block reward to %s: exit code %d", block.Miner, receipt.ExitCode)
+		}
+	}
+
+	receipt := next.applyImplicitMessage(builtin.CronActorAddr, builtin.MethodsCron.EpochTick, nil)
+	if receipt.ExitCode != exitcode.Ok {
+		return nil, nil, errors.Errorf("failed to apply cron tick at epoch %d: exit code %d", epoch, receipt.ExitCode)
+	}
+	return next, receipts, nil
+}
+
+// Applies a message from the system actor, which neither checks nor increments its call sequence number,
+// nor charges for gas.
+func (vm *VM) applyImplicitMessage(to address.Address, method abi.MethodNum, params interface{}) MessageReceipt {
+	fromActor, found, err := vm.GetActor(builtin.SystemActorAddr)
+	if err != nil {
+		panic(err)
+	}
+	if !found {
+		panic("unreachable: system actor not found")
+	}
+	return vm.applyMessage(builtin.SystemActorAddr, fromActor, builtin.SystemActorAddr, &Message{
+		From:       builtin.SystemActorAddr,
+		To:         to,
+		CallSeqNum: fromActor.CallSeqNum,
+		Value:      big.Zero(),
+		Method:     method,
+		Params:     params,
+		GasLimit:   implicitMessageGasLimit,
+	})
+}
+
+// Identifies a message by its content, so that the same message included in several blocks is applied only once.
+func (m *Message) identity() (string, error) {
+	var params bytes.Buffer
+	if m.Params != nil {
+		marshaler, ok := m.Params.(runtime.CBORMarshaler)
+		if !ok {
+			return "", errors.Errorf("params of type %T are not CBOR marshalable", m.Params)
+		}
+		if err := marshaler.MarshalCBOR(&params); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%s/%s/%d/%v/%d/%x/%d/%v", m.From, m.To, m.CallSeqNum, m.Value, m.Method, params.Bytes(),
+		m.GasLimit, m.GasPrice), nil
+}
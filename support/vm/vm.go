@@ -7,6 +7,7 @@ import (
 	"github.com/filecoin-project/specs-actors/actors/abi"
 	"github.com/filecoin-project/specs-actors/actors/abi/big"
 	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/account"
 	"github.com/filecoin-project/specs-actors/actors/builtin/exported"
 	init_ "github.com/filecoin-project/specs-actors/actors/builtin/init"
 	"github.com/filecoin-project/specs-actors/actors/runtime"
@@ -19,14 +20,21 @@ import (
 
 // VM is a simplified message execution framework for the purposes of testing inter-actor communication.
 // The VM maintains actor state and can be used to simulate message validation for a single block or tipset.
-// The VM does not track gas charges, provide working syscalls, validate message nonces and many other things
-// that a compliant VM needs to do.
+// The VM charges gas according to a pluggable price list, and validates the sender and call sequence number of
+// each top-level message. By default it does not provide working syscalls, and it does not validate
+// many other things that a compliant VM needs to do.
 type VM struct {
 	ctx   context.Context
 	store adt.Store
 
 	currentEpoch abi.ChainEpoch
 
+	pricelist  Pricelist
+	gasLimit   int64           // Gas limit applied to each message sent with ApplyMessage.
+	gasPrice   abi.TokenAmount // Gas price applied to each message sent with ApplyMessage.
+	syscalls   SyscallsFactory
+	randomness *Randomness
+
 	actorImpls  ActorImplLookup
 	stateRoot   cid.Cid  // The last committed root.
 	actors      *adt.Map // The current (not necessarily committed) root node.
@@ -41,11 +49,12 @@ type VM struct {
 
 // VM types
 
-// Simplifed actor implementation that does not contain message nonce.
+// Simplifed actor implementation.
 type TestActor struct {
-	Head    cid.Cid
-	Code    cid.Cid
-	Balance abi.TokenAmount
+	Head       cid.Cid
+	Code       cid.Cid
+	CallSeqNum uint64 // Sequence number of the next top-level message sent by this actor.
+	Balance    abi.TokenAmount
 }
 
 type ActorImplLookup map[cid.Cid]exported.BuiltinActor
@@ -58,10 +67,38 @@ type InternalMessage struct {
 	params interface{}
 }
 
+// Message is a top-level message, as it would be included on chain.
+type Message struct {
+	From       address.Address
+	To         address.Address
+	CallSeqNum uint64
+	Value      abi.TokenAmount
+	Method     abi.MethodNum
+	Params     interface{}
+	GasLimit   int64
+	GasPrice   abi.TokenAmount // Price per unit of gas, paid by the sender to the reward actor.
+}
+
+// The gas price of the message, treating an unset price as zero.
+func (m *Message) gasPrice() abi.TokenAmount {
+	if m.GasPrice.Nil() {
+		return big.Zero()
+	}
+	return m.GasPrice
+}
+
+// MessageReceipt is the result of applying a top-level message.
+type MessageReceipt struct {
+	ExitCode exitcode.ExitCode
+	Ret      runtime.CBORMarshaler
+	GasUsed  int64
+}
+
 type Invocation struct {
 	Msg            *InternalMessage
 	Exitcode       exitcode.ExitCode
 	Ret            runtime.CBORMarshaler
+	GasUsed        int64 // Gas charged during this invocation, including sub-invocations.
 	SubInvocations []*Invocation
 }
 
@@ -89,6 +126,11 @@ func NewVM(ctx context.Context, actorImpls ActorImplLookup, store adt.Store) *VM
 		stateRoot:   actorRoot,
 		actorsDirty: false,
 		emptyObject: emptyObject,
+		pricelist:   DefaultPricelist,
+		gasLimit:    DefaultGasLimit,
+		gasPrice:    big.Zero(),
+		syscalls:    FakeSyscalls,
+		randomness:  NewRandomness(0),
 	}
 }
 
@@ -112,9 +154,45 @@ func (vm *VM) WithEpoch(epoch abi.ChainEpoch) (*VM, error) {
 		actorsDirty:  false,
 		emptyObject:  vm.emptyObject,
 		currentEpoch: epoch,
+		pricelist:    vm.pricelist,
+		gasLimit:     vm.gasLimit,
+		gasPrice:     vm.gasPrice,
+		syscalls:     vm.syscalls,
+		randomness:   vm.randomness,
 	}, nil
 }
 
+// SetPricelist installs the price list used to charge gas for subsequent messages.
+func (vm *VM) SetPricelist(pricelist Pricelist) {
+	vm.pricelist = pricelist
+}
+
+// SetSyscalls installs the syscalls provided to actors for subsequent messages.
+func (vm *VM) SetSyscalls(syscalls SyscallsFactory) {
+	vm.syscalls = syscalls
+}
+
+// SetRandomness installs the source of ticket and beacon randomness for subsequent messages.
+func (vm *VM) SetRandomness(randomness *Randomness) {
+	vm.randomness = randomness
+}
+
+// Randomness returns the VM's source of ticket and beacon randomness.
+func (vm *VM) Randomness() *Randomness {
+	return vm.randomness
+}
+
+// SetGasLimit sets the gas limit applied to each subsequent message sent with ApplyMessage.
+func (vm *VM) SetGasLimit(limit int64) {
+	vm.gasLimit = limit
+}
+
+// SetGasPrice sets the gas price applied to each subsequent message sent with ApplyMessage.
+// The default price is zero, so messages do not affect the sender's balance beyond the value they transfer.
+func (vm *VM) SetGasPrice(price abi.TokenAmount) {
+	vm.gasPrice = price
+}
+
 func (vm *VM) rollback(root cid.Cid) error {
 	var err error
 	vm.actors, err = adt.AsMap(vm.store, root)
@@ -217,15 +295,47 @@ func (vm *VM) NormalizeAddress(addr address.Address) (address.Address, bool) {
 }
 
 // ApplyMessage applies the message to the current state.
+// The message carries the sender's next call sequence number, and the VM's gas limit and gas price.
 func (vm *VM) ApplyMessage(from, to address.Address, value abi.TokenAmount, method abi.MethodNum, params interface{}) (runtime.CBORMarshaler, exitcode.ExitCode) {
+	var callSeqNum uint64
+	if fromID, ok := vm.NormalizeAddress(from); ok {
+		fromActor, found, err := vm.GetActor(fromID)
+		if err != nil {
+			panic(err)
+		}
+		if found {
+			callSeqNum = fromActor.CallSeqNum
+		}
+	}
+
+	receipt := vm.ApplyChainMessage(&Message{
+		From:       from,
+		To:         to,
+		CallSeqNum: callSeqNum,
+		Value:      value,
+		Method:     method,
+		Params:     params,
+		GasLimit:   vm.gasLimit,
+		GasPrice:   vm.gasPrice,
+	})
+	return receipt.Ret, receipt.ExitCode
+}
+
+// ApplyChainMessage applies a top-level message to the current state, validating it as a node would.
+// A message whose sender does not exist fails with SysErrSenderInvalid, and a message with the wrong call sequence
+// number or from a sender unable to cover the gas limit fails with SysErrSenderStateInvalid. In these cases the state
+// is unchanged.
+// Otherwise, the sender's call sequence number is incremented and the sender pays for the gas used, even if
+// execution fails.
+func (vm *VM) ApplyChainMessage(msg *Message) MessageReceipt {
 	// This method does not actually execute the message itself,
 	// but rather deals with the pre/post processing of a message.
 	// (see: `invocationContext.invoke()` for the dispatch and execution)
 
 	// load actor from global state
-	var ok bool
-	if from, ok = vm.NormalizeAddress(from); !ok {
-		return nil, exitcode.SysErrSenderInvalid
+	from, ok := vm.NormalizeAddress(msg.From)
+	if !ok {
+		return MessageReceipt{ExitCode: exitcode.SysErrSenderInvalid}
 	}
 
 	fromActor, found, err := vm.GetActor(from)
@@ -234,9 +344,39 @@ func (vm *VM) ApplyMessage(from, to address.Address, value abi.TokenAmount, meth
 	}
 	if !found {
 		// Execution error; sender does not exist at time of message execution.
-		return nil, exitcode.SysErrSenderInvalid
+		return MessageReceipt{ExitCode: exitcode.SysErrSenderInvalid}
+	}
+
+	if msg.CallSeqNum != fromActor.CallSeqNum {
+		vm.Log(runtime.WARN, "message from %s has call sequence number %d, expected %d", from, msg.CallSeqNum, fromActor.CallSeqNum)
+		return MessageReceipt{ExitCode: exitcode.SysErrSenderStateInvalid}
+	}
+
+	gasPrice := msg.gasPrice()
+	gasPrepay := big.Mul(big.NewInt(msg.GasLimit), gasPrice)
+	if fromActor.Balance.LessThan(gasPrepay) {
+		vm.Log(runtime.WARN, "sender %s balance %v insufficient for gas prepayment %v", from, fromActor.Balance, gasPrepay)
+		return MessageReceipt{ExitCode: exitcode.SysErrSenderStateInvalid}
+	}
+
+	// Increment the sequence number and withhold the gas prepayment.
+	fromActor.CallSeqNum++
+	fromActor.Balance = big.Sub(fromActor.Balance, gasPrepay)
+	if err := vm.setActor(vm.ctx, from, fromActor); err != nil {
+		panic(err)
 	}
 
+	receipt := vm.applyMessage(from, fromActor, vm.stableAddress(from, fromActor), msg)
+
+	// Refund unused gas to the sender and pay for the gas used.
+	gasCost := big.Mul(big.NewInt(receipt.GasUsed), gasPrice)
+	vm.creditActor(from, big.Sub(gasPrepay, gasCost))
+	vm.creditActor(builtin.RewardActorAddr, gasCost)
+	return receipt
+}
+
+// Executes a message from a validated sender, rolling back its effects if it fails.
+func (vm *VM) applyMessage(from address.Address, fromActor *TestActor, stableFrom address.Address, msg *Message) MessageReceipt {
 	// checkpoint state
 	// Even if the message fails, the following accumulated changes will be applied:
 	// - CallSeqNumber increment
@@ -252,16 +392,19 @@ func (vm *VM) ApplyMessage(from, to address.Address, value abi.TokenAmount, meth
 	// 3. process the msg
 
 	topLevel := topLevelContext{
-		newActorAddressCount: 0,
+		originatorStableAddress: stableFrom,
+		originatorCallSeq:       msg.CallSeqNum,
+		newActorAddressCount:    0,
+		gasLimit:                msg.GasLimit,
 	}
 
 	// build internal msg
 	imsg := InternalMessage{
 		from:   from,
-		to:     to,
-		value:  value,
-		method: method,
-		params: params,
+		to:     msg.To,
+		value:  msg.Value,
+		method: msg.Method,
+		params: msg.Params,
 	}
 
 	// build invocation context
@@ -270,6 +413,11 @@ func (vm *VM) ApplyMessage(from, to address.Address, value abi.TokenAmount, meth
 	// 3. invoke
 	ret, exitCode := ctx.invoke()
 
+	// An out of gas abort might be caught by a calling actor, but still fails the message as a whole.
+	if topLevel.outOfGas {
+		exitCode = exitcode.SysErrOutOfGas
+	}
+
 	// Roll back all state if the receipt's exit code is not ok.
 	// This is required in addition to rollback within the invocation context since top level messages can fail for
 	// more reasons than internal ones. Invocation context still needs its own rollback so actors can recover and
@@ -280,7 +428,46 @@ func (vm *VM) ApplyMessage(from, to address.Address, value abi.TokenAmount, meth
 		}
 	}
 
-	return ret.inner, exitCode
+	gasUsed := topLevel.gasUsed
+	if gasUsed > msg.GasLimit {
+		gasUsed = msg.GasLimit
+	}
+	return MessageReceipt{
+		ExitCode: exitCode,
+		Ret:      ret.inner,
+		GasUsed:  gasUsed,
+	}
+}
+
+// The stable address of a message sender, from which new actor addresses are derived.
+// This is the key address of an account actor, or the ID address of any other actor.
+func (vm *VM) stableAddress(idAddr address.Address, act *TestActor) address.Address {
+	if act.Code != builtin.AccountActorCodeID {
+		return idAddr
+	}
+	var st account.State
+	if err := vm.store.Get(vm.ctx, act.Head, &st); err != nil {
+		panic(err)
+	}
+	return st.Address
+}
+
+// Adds funds to an actor's balance outside of any message execution.
+func (vm *VM) creditActor(addr address.Address, amount abi.TokenAmount) {
+	if amount.IsZero() {
+		return
+	}
+	act, found, err := vm.GetActor(addr)
+	if err != nil {
+		panic(err)
+	}
+	if !found {
+		panic(fmt.Errorf("unreachable: credit account not found. %s", addr))
+	}
+	act.Balance = big.Add(act.Balance, amount)
+	if err := vm.setActor(vm.ctx, addr, act); err != nil {
+		panic(err)
+	}
 }
 
 func (vm *VM) GetState(addr address.Address, out runtime.CBORUnmarshaler) error {
@@ -294,6 +481,19 @@ func (vm *VM) GetState(addr address.Address, out runtime.CBORUnmarshaler) error
 	return vm.store.Get(vm.ctx, act.Head, out)
 }
 
+// ForEachActor iterates all actors in the current (not necessarily committed) state tree.
+func (vm *VM) ForEachActor(f func(addr address.Address, act *TestActor) error) error {
+	var act TestActor
+	return vm.actors.ForEach(&act, func(k string) error {
+		addr, err := address.NewFromBytes([]byte(k))
+		if err != nil {
+			return err
+		}
+		cpy := act
+		return f(addr, &cpy)
+	})
+}
+
 func (vm *VM) Store() adt.Store {
 	return vm.store
 }
@@ -377,11 +577,12 @@ func (vm *VM) startInvocation(msg *InternalMessage) {
 	vm.invocationStack = append(vm.invocationStack, &invocation)
 }
 
-func (vm *VM) endInvocation(code exitcode.ExitCode, ret runtime.CBORMarshaler) {
+func (vm *VM) endInvocation(code exitcode.ExitCode, ret runtime.CBORMarshaler, gasUsed int64) {
 	curIndex := len(vm.invocationStack) - 1
 	current := vm.invocationStack[curIndex]
 	current.Exitcode = code
 	current.Ret = ret
+	current.GasUsed = gasUsed
 
 	vm.invocationStack = vm.invocationStack[:curIndex]
 }